@@ -0,0 +1,49 @@
+package suffix
+
+// The adapters below take a function walking another collection, instead of the collection
+// itself, so they work with any package without depending on it. For example, with
+// github.com/armon/go-radix:
+//
+//	tree := suffix.FromRadix(func(fn func(string, interface{}) bool) {
+//		radixTree.Walk(fn)
+//	})
+//
+// and with github.com/hashicorp/go-immutable-radix:
+//
+//	tree := suffix.FromBytesRadix(func(fn func([]byte, interface{}) bool) {
+//		iradixTree.Root().Walk(fn)
+//	})
+//
+// Returning true from the callback stops the walking in both packages, and the adapters
+// never do that.
+
+// FromRadix builds a tree from the key/value pairs visited by walk, which calls fn for
+// each pair. It fits the Walk method of the tries keyed by string.
+func FromRadix(walk func(fn func(key string, value interface{}) bool)) *Tree {
+	tree := NewTree()
+	walk(func(key string, value interface{}) bool {
+		tree.Insert([]byte(key), value)
+		return false
+	})
+	return tree
+}
+
+// FromBytesRadix is like FromRadix, but for the tries keyed by []byte. The keys are copied,
+// so the trie can reuse them.
+func FromBytesRadix(walk func(fn func(key []byte, value interface{}) bool)) *Tree {
+	tree := NewTree()
+	walk(func(key []byte, value interface{}) bool {
+		tree.Insert(append([]byte{}, key...), value)
+		return false
+	})
+	return tree
+}
+
+// FromMap builds a tree from the keys and values of m.
+func FromMap(m map[string]interface{}) *Tree {
+	tree := NewTree()
+	for key, value := range m {
+		tree.Insert([]byte(key), value)
+	}
+	return tree
+}
@@ -0,0 +1,76 @@
+package suffix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tries usually declare their own callback types, like radix.WalkFn.
+type stringWalkFn func(key string, value interface{}) bool
+type bytesWalkFn func(key []byte, value interface{}) bool
+
+type stringTrie map[string]interface{}
+
+func (trie stringTrie) Walk(fn stringWalkFn) {
+	for key, value := range trie {
+		if fn(key, value) {
+			return
+		}
+	}
+}
+
+type bytesTrie struct {
+	keys   [][]byte
+	values []interface{}
+}
+
+func (trie *bytesTrie) Walk(fn bytesWalkFn) {
+	for i, key := range trie.keys {
+		if fn(key, trie.values[i]) {
+			return
+		}
+	}
+}
+
+func TestFromRadix(t *testing.T) {
+	lists, expected := getFixtures()
+	trie := stringTrie{}
+	for _, s := range lists {
+		trie[s] = s
+	}
+	tree := FromRadix(func(fn func(string, interface{}) bool) {
+		trie.Walk(fn)
+	})
+	assertSameContent(t, expected, tree)
+	assertSameContent(t, expected, FromMap(trie))
+}
+
+func TestFromBytesRadix(t *testing.T) {
+	lists, expected := getFixtures()
+	trie := &bytesTrie{}
+	for _, s := range lists {
+		trie.keys = append(trie.keys, []byte(s))
+		trie.values = append(trie.values, s)
+	}
+	var buf []byte
+	tree := FromBytesRadix(func(fn func([]byte, interface{}) bool) {
+		for i, key := range trie.keys {
+			// Pass the keys in a reused buffer
+			buf = append(buf[:0], key...)
+			if fn(buf, trie.values[i]) {
+				return
+			}
+		}
+	})
+	assertSameContent(t, expected, tree)
+
+	trie = &bytesTrie{keys: [][]byte{[]byte("sth"), {}}, values: []interface{}{1, 2}}
+	tree = FromBytesRadix(func(fn func([]byte, interface{}) bool) {
+		trie.Walk(fn)
+	})
+	value, found := tree.Get([]byte{})
+	assert.True(t, found)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, 2, tree.Len())
+}
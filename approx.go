@@ -0,0 +1,55 @@
+package suffix
+
+import "sort"
+
+// ApproxFind returns the starts of the substrings of the text which have the length of
+// pattern and differ from it in at most k bytes, in ascending order. Only substitutions are
+// allowed, a byte can't be inserted or deleted. The empty pattern matches at each position
+// from 0 to the length of the text.
+//
+// It descends the edges while fewer than k+1 bytes mismatch, so each branch of the tree is
+// abandoned at its (k+1)-th mismatch. The time grows with the size of the alphabet raised to
+// k, rather than with the length of the text, if k is small.
+func (idx *TextIndex) ApproxFind(pattern []byte, k int) []int {
+	if k < 0 {
+		return nil
+	}
+	var starts []int
+	if len(pattern) == 0 {
+		starts = make([]int, len(idx.text)+1)
+		for i := range starts {
+			starts[i] = i
+		}
+		return starts
+	}
+	text := idx.text
+	var visit func(node *_IndexNode, depth, mismatches int)
+	visit = func(node *_IndexNode, depth, mismatches int) {
+		for _, child := range node.children {
+			if child.start == child.end {
+				// The suffix ends before the pattern
+				continue
+			}
+			d, m := depth, mismatches
+			for i := child.start; i < child.end && d < len(pattern) && m <= k; i++ {
+				if text[i] != pattern[d] {
+					m++
+				}
+				d++
+			}
+			if m > k {
+				continue
+			}
+			if d == len(pattern) {
+				child.eachLeaf(func(suffix int) {
+					starts = append(starts, suffix)
+				})
+			} else if !child.isLeaf() {
+				visit(child, d, m)
+			}
+		}
+	}
+	visit(idx.root, 0, 0)
+	sort.Ints(starts)
+	return starts
+}
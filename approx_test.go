@@ -0,0 +1,49 @@
+package suffix
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func naiveApproxFind(text, pattern []byte, k int) []int {
+	var starts []int
+	for i := 0; i+len(pattern) <= len(text); i++ {
+		mismatches := 0
+		for j := range pattern {
+			if text[i+j] != pattern[j] {
+				mismatches++
+			}
+		}
+		if mismatches <= k {
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
+
+func TestTextIndex_ApproxFind(t *testing.T) {
+	idx := NewTextIndex([]byte("ACGTTGCAACGA"))
+	assert.Equal(t, []int{0, 8}, idx.ApproxFind([]byte("ACG"), 0))
+	assert.Equal(t, []int{6, 9}, idx.ApproxFind([]byte("CAA"), 1))
+	assert.Equal(t, []int{0, 8}, idx.ApproxFind([]byte("ACGT"), 1))
+	assert.Equal(t, []int(nil), idx.ApproxFind([]byte("TTTT"), 1))
+	assert.Equal(t, []int{0, 1}, idx.ApproxFind([]byte("ACGTTGCAACGA"[:11]), 11))
+	assert.Equal(t, []int(nil), idx.ApproxFind([]byte("ACGTTGCAACGAA"), 13))
+	assert.Equal(t, []int{0, 1, 2}, NewTextIndex([]byte("ab")).ApproxFind(nil, 0))
+	assert.Equal(t, []int(nil), idx.ApproxFind([]byte("A"), -1))
+}
+
+func TestTextIndex_ApproxFind_Random(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		text := randomText("ACGT", rand.Intn(40))
+		idx := NewTextIndex(text)
+		for j := 0; j < 20; j++ {
+			pattern := randomText("ACGT", 1+rand.Intn(6))
+			k := rand.Intn(3)
+			assert.Equal(t, naiveApproxFind(text, pattern, k), idx.ApproxFind(pattern, k),
+				"%q %q %d", text, pattern, k)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package suffix
+
+import (
+	"sort"
+)
+
+// Automaton is the suffix automaton of a text or a set of keys: the smallest automaton
+// accepting their substrings. It answers Contains and CountOccurrences in O(len(pattern)),
+// like TextIndex, but has at most 2n states with no labels to keep, so it is much smaller
+// for repetitive data.
+type Automaton struct {
+	states []automatonState
+	// The number of the occurrences of the empty string
+	size int
+}
+
+type automatonState struct {
+	// The length of the longest string reaching this state
+	len int
+	// The state of the longest suffix which reaches another state, -1 for the initial state
+	link int
+	// The number of end positions of the strings reaching this state
+	count int
+	// Sorted by the byte
+	next []automatonEdge
+}
+
+type automatonEdge struct {
+	c  byte
+	to int
+}
+
+func (a *Automaton) trans(state int, c byte) (int, bool) {
+	next := a.states[state].next
+	i := sort.Search(len(next), func(i int) bool { return next[i].c >= c })
+	if i < len(next) && next[i].c == c {
+		return next[i].to, true
+	}
+	return 0, false
+}
+
+func (a *Automaton) setTrans(state int, c byte, to int) {
+	next := a.states[state].next
+	i := sort.Search(len(next), func(i int) bool { return next[i].c >= c })
+	if i < len(next) && next[i].c == c {
+		next[i].to = to
+		return
+	}
+	next = append(next, automatonEdge{})
+	copy(next[i+1:], next[i:])
+	next[i] = automatonEdge{c, to}
+	a.states[state].next = next
+}
+
+// clone copies state q as the state of the strings of length n reaching it, for the states
+// from p following its links which go to q with c.
+func (a *Automaton) clone(p int, c byte, q, n int) int {
+	clone := len(a.states)
+	a.states = append(a.states, automatonState{
+		len:  n,
+		link: a.states[q].link,
+		next: append([]automatonEdge{}, a.states[q].next...),
+	})
+	for ; p >= 0; p = a.states[p].link {
+		if to, ok := a.trans(p, c); !ok || to != q {
+			break
+		}
+		a.setTrans(p, c, clone)
+	}
+	a.states[q].link = clone
+	return clone
+}
+
+// extend appends c to the string reaching last, and returns the state it reaches.
+func (a *Automaton) extend(last int, c byte) int {
+	n := a.states[last].len + 1
+	if q, ok := a.trans(last, c); ok {
+		// The string is already known, from another key
+		if a.states[q].len != n {
+			q = a.clone(last, c, q, n)
+		}
+		a.states[q].count++
+		return q
+	}
+	cur := len(a.states)
+	a.states = append(a.states, automatonState{len: n, count: 1})
+	p := last
+	for ; p >= 0; p = a.states[p].link {
+		if _, ok := a.trans(p, c); ok {
+			break
+		}
+		a.setTrans(p, c, cur)
+	}
+	if p < 0 {
+		a.states[cur].link = 0
+		return cur
+	}
+	q, _ := a.trans(p, c)
+	if a.states[q].len == a.states[p].len+1 {
+		a.states[cur].link = q
+	} else {
+		a.states[cur].link = a.clone(p, c, q, a.states[p].len+1)
+	}
+	return cur
+}
+
+// newAutomaton builds the automaton of the strings.
+func newAutomaton(each func(f func(s []byte))) *Automaton {
+	a := &Automaton{states: []automatonState{{link: -1}}}
+	each(func(s []byte) {
+		a.size += len(s) + 1
+		last := 0
+		for _, c := range s {
+			last = a.extend(last, c)
+		}
+	})
+	// The end positions of a state are also the end positions of its link
+	order := make([]int, len(a.states))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return a.states[order[i]].len > a.states[order[j]].len
+	})
+	for _, state := range order {
+		if link := a.states[state].link; link > 0 {
+			a.states[link].count += a.states[state].count
+		}
+	}
+	return a
+}
+
+// BuildAutomaton builds the suffix automaton of the indexed text.
+func (idx *TextIndex) BuildAutomaton() *Automaton {
+	return newAutomaton(func(f func(s []byte)) {
+		f(idx.text)
+	})
+}
+
+// BuildAutomaton builds the suffix automaton of the keys in the tree, which accepts the
+// substrings of any key.
+func (tree *Tree) BuildAutomaton() *Automaton {
+	return newAutomaton(func(f func(s []byte)) {
+		tree.Walk(func(key []byte, value interface{}) bool {
+			f(key)
+			return false
+		})
+	})
+}
+
+// walk returns the state reached by pattern, or -1.
+func (a *Automaton) walk(pattern []byte) int {
+	state := 0
+	for _, c := range pattern {
+		next, ok := a.trans(state, c)
+		if !ok {
+			return -1
+		}
+		state = next
+	}
+	return state
+}
+
+// Contains returns whether pattern is a substring of the text, or of a key.
+func (a *Automaton) Contains(pattern []byte) bool {
+	return a.walk(pattern) >= 0
+}
+
+// CountOccurrences returns the number of occurrences of pattern in the text, or the total
+// number in the keys. Overlapping occurrences are counted, and the empty pattern occurs
+// len(text)+1 times like bytes.Count.
+func (a *Automaton) CountOccurrences(pattern []byte) int {
+	state := a.walk(pattern)
+	if state < 0 {
+		return 0
+	}
+	if state == 0 {
+		return a.size
+	}
+	return a.states[state].count
+}
+
+// States returns the number of states, which is at most twice the length of the text.
+func (a *Automaton) States() int {
+	return len(a.states)
+}
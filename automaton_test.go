@@ -0,0 +1,82 @@
+package suffix
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func naiveCount(texts [][]byte, pattern []byte) int {
+	n := 0
+	for _, text := range texts {
+		for i := 0; i+len(pattern) <= len(text); i++ {
+			if bytes.HasPrefix(text[i:], pattern) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestAutomaton(t *testing.T) {
+	a := NewTextIndex([]byte("mississippi")).BuildAutomaton()
+	for pattern, count := range map[string]int{
+		"i": 4, "s": 4, "ss": 2, "issi": 2, "mississippi": 1, "pi": 1, "x": 0, "ipi": 0,
+		"": 12,
+	} {
+		assert.Equal(t, count, a.CountOccurrences([]byte(pattern)), pattern)
+		assert.Equal(t, count > 0, a.Contains([]byte(pattern)), pattern)
+	}
+	assert.True(t, a.States() <= 2*len("mississippi"))
+
+	a = NewTextIndex(nil).BuildAutomaton()
+	assert.Equal(t, 1, a.States())
+	assert.True(t, a.Contains(nil))
+	assert.False(t, a.Contains([]byte("a")))
+
+	// Repetitive text makes few states
+	a = NewTextIndex(bytes.Repeat([]byte("abc"), 1000)).BuildAutomaton()
+	assert.Equal(t, 3000+1, a.States())
+	assert.Equal(t, 999, a.CountOccurrences([]byte("abca")))
+}
+
+func TestAutomaton_Random(t *testing.T) {
+	for _, letters := range []string{"a", "ab", "acgt"} {
+		for i := 0; i < 50; i++ {
+			text := randomText(letters, rand.Intn(64))
+			a := NewTextIndex(text).BuildAutomaton()
+			for j := 0; j < 20; j++ {
+				pattern := randomText(letters, rand.Intn(6))
+				assert.Equal(t, naiveCount([][]byte{text}, pattern),
+					a.CountOccurrences(pattern), "%s %s", text, pattern)
+				assert.Equal(t, bytes.Contains(text, pattern), a.Contains(pattern))
+			}
+		}
+	}
+}
+
+func TestTree_BuildAutomaton(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		tree := NewTree()
+		var keys [][]byte
+		for j := rand.Intn(10); j > 0; j-- {
+			key := randomText("ab.", rand.Intn(16))
+			if _, found := tree.Get(key); !found {
+				keys = append(keys, key)
+			}
+			tree.Insert(key, nil)
+		}
+		a := tree.BuildAutomaton()
+		for j := 0; j < 20; j++ {
+			pattern := randomText("ab.", rand.Intn(5))
+			assert.Equal(t, naiveCount(keys, pattern), a.CountOccurrences(pattern),
+				"%q %s", keys, pattern)
+			if len(pattern) > 0 {
+				assert.Equal(t, tree.HasSequence(pattern), a.Contains(pattern), "%q %s", keys,
+					pattern)
+			}
+		}
+	}
+}
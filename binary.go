@@ -0,0 +1,69 @@
+package suffix
+
+import (
+	"fmt"
+)
+
+const binaryVersion = 1
+
+// MarshalBinary implements encoding.BinaryMarshaler. The keys are encoded with their values,
+// which should be nil, booleans, numbers, strings or []byte. Use GobEncode for other values.
+func (tree *Tree) MarshalBinary() ([]byte, error) {
+	buf := []byte{binaryVersion}
+	buf = appendUvarint(buf, uint64(tree.Len()))
+	var err error
+	tree.Walk(func(key []byte, value interface{}) bool {
+		buf = appendUvarint(buf, uint64(len(key)))
+		buf = append(buf, key...)
+		buf, err = appendValue(buf, value)
+		return err != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces the content of the tree
+// with the data encoded by MarshalBinary. The keys are inserted through the options of the
+// tree, and an error is returned if one of them is rejected.
+func (tree *Tree) UnmarshalBinary(data []byte) error {
+	if tree.frozen {
+		return ErrReadOnly
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("suffix: empty binary data")
+	}
+	if data[0] != binaryVersion {
+		return fmt.Errorf("suffix: unsupported binary version %d", data[0])
+	}
+	// The keys are referred by the tree, so they can't share the memory with the caller
+	d := decodeBuffer{data: append([]byte(nil), data[1:]...)}
+	n, err := d.uvarint()
+	if err != nil {
+		return err
+	}
+	newTree := tree.emptyLike()
+	for i := uint64(0); i < n; i++ {
+		key, err := d.lenBytes()
+		if err != nil {
+			return err
+		}
+		value, err := d.value()
+		if err != nil {
+			return err
+		}
+		if err := newTree.insertDecoded(key, value); err != nil {
+			return err
+		}
+	}
+	if len(d.data) != 0 {
+		return fmt.Errorf("%w: %d bytes of trailing data", ErrCorrupted, len(d.data))
+	}
+
+	tree.guard.acquire()
+	defer tree.guard.release(tree)
+	tree.root = newTree.root
+	tree.leavesNum = newTree.leavesNum
+	return nil
+}
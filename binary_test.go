@@ -0,0 +1,125 @@
+package suffix
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*Tree)(nil)
+	_ encoding.BinaryUnmarshaler = (*Tree)(nil)
+)
+
+func assertSameContent(t *testing.T, expected, actual *Tree) {
+	assert.Equal(t, expected.Len(), actual.Len())
+	expected.Walk(func(key []byte, value interface{}) bool {
+		actualValue, found := actual.Get(key)
+		assert.True(t, found, "key %q not found", key)
+		assert.Equal(t, value, actualValue)
+		return false
+	})
+}
+
+func TestMarshalBinary(t *testing.T) {
+	_, tree := getFixtures()
+	tree.Insert([]byte{}, nil)
+	data, err := tree.MarshalBinary()
+	assert.Nil(t, err)
+
+	newTree := NewTree()
+	newTree.Insert([]byte("sth"), "sth")
+	assert.Nil(t, newTree.UnmarshalBinary(data))
+	assertSameContent(t, tree, newTree)
+	assertGet(t, newTree, "sth", false)
+
+	// The tree doesn't share memory with data
+	for i := range data {
+		data[i] = 0
+	}
+	assertSameContent(t, tree, newTree)
+}
+
+func TestMarshalBinary_Values(t *testing.T) {
+	values := []interface{}{
+		nil, true, false, -1, int8(-8), int16(-16), int32(-32), int64(-64),
+		uint(1), uint8(8), uint16(16), uint32(32), uint64(64),
+		float32(3.2), 6.4, "", "sth", []byte{}, []byte("sth"),
+	}
+	tree := NewTree()
+	for i, value := range values {
+		tree.Insert([]byte{byte(i)}, value)
+	}
+	data, err := tree.MarshalBinary()
+	assert.Nil(t, err)
+	newTree := NewTree()
+	assert.Nil(t, newTree.UnmarshalBinary(data))
+	assertSameContent(t, tree, newTree)
+
+	tree.Insert([]byte("struct"), struct{}{})
+	_, err = tree.MarshalBinary()
+	assert.EqualError(t, err, "suffix: can't encode value of type struct {}")
+}
+
+func TestUnmarshalBinary_Invalid(t *testing.T) {
+	_, tree := getFixtures()
+	data, _ := tree.MarshalBinary()
+
+	newTree := NewTree()
+	assert.NotNil(t, newTree.UnmarshalBinary(nil))
+	assert.NotNil(t, newTree.UnmarshalBinary([]byte{2}))
+	assert.Equal(t, io.ErrUnexpectedEOF, newTree.UnmarshalBinary(data[:len(data)-1]))
+	assert.NotNil(t, newTree.UnmarshalBinary(append(data, 0)))
+	assert.Equal(t, 0, newTree.Len())
+
+	// Unknown value tag
+	assert.EqualError(t, newTree.UnmarshalBinary([]byte{binaryVersion, 1, 0, 255}),
+		"suffix: corrupted data: unknown value tag 255")
+}
+
+func TestUnmarshal_Options(t *testing.T) {
+	plain := NewTree()
+	plain.Insert([]byte("Example.COM"), 1)
+	plain.Insert([]byte("www.example.org"), 2)
+	binaryData, _ := plain.MarshalBinary()
+	gobData, _ := plain.GobEncode()
+	jsonData, _ := plain.MarshalJSON()
+	var formatData bytes.Buffer
+	plain.WriteTo(&formatData)
+
+	for name, decode := range map[string]func(tree *Tree) error{
+		"binary": func(tree *Tree) error { return tree.UnmarshalBinary(binaryData) },
+		"gob":    func(tree *Tree) error { return tree.GobDecode(gobData) },
+		"json":   func(tree *Tree) error { return tree.UnmarshalJSON(jsonData) },
+		"format": func(tree *Tree) error {
+			_, err := tree.ReadFrom(bytes.NewReader(formatData.Bytes()))
+			return err
+		},
+	} {
+		tree := NewTree(WithCaseFolding())
+		assert.Nil(t, decode(tree), name)
+		value, found := tree.Get([]byte("example.com"))
+		assert.True(t, found, name)
+		assert.Equal(t, "1", fmt.Sprint(value), name)
+		assert.Equal(t, 2, tree.Len(), name)
+
+		tree = NewTree(WithMaxKeyLen(len("Example.COM")))
+		assert.NotNil(t, decode(tree), name)
+		assert.Equal(t, 0, tree.Len(), name)
+	}
+
+	tree := NewTree(WithIDNA())
+	tree.Insert([]byte("bücher.example"), 1)
+	data, err := tree.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := NewTree(WithIDNA())
+	assert.Nil(t, decoded.UnmarshalBinary(data))
+	_, found := decoded.Get([]byte("bücher.example"))
+	assert.True(t, found)
+	_, found = decoded.Get([]byte("xn--bcher-kva.example"))
+	assert.True(t, found)
+}
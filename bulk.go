@@ -0,0 +1,72 @@
+package suffix
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// Merge inserts all keys of other into the tree, overwriting the values of existing keys.
+// It stops once ctx is done, and returns the number of keys merged so far with ctx.Err().
+// The keys rejected by the tree are not counted.
+func (tree *Tree) Merge(ctx context.Context, other *Tree) (merged int, err error) {
+	if other == tree {
+		return 0, nil
+	}
+	done := ctx.Done()
+	other.Walk(func(key []byte, value interface{}) bool {
+		select {
+		case <-done:
+			err = ctx.Err()
+			return true
+		default:
+		}
+		if _, ok := tree.Insert(key, value); ok {
+			merged++
+		}
+		return false
+	})
+	return merged, err
+}
+
+// BuildFromReader creates a tree from r, which contains one key per line. The values are nil.
+// It stops once ctx is done or r fails, and returns the tree built so far with the number of
+// keys read.
+func BuildFromReader(ctx context.Context, r io.Reader) (tree *Tree, n int, err error) {
+	tree = NewTree()
+	done := ctx.Done()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		select {
+		case <-done:
+			return tree, n, ctx.Err()
+		default:
+		}
+		// The scanner reuses its buffer, so we need to copy the key
+		line := scanner.Bytes()
+		key := make([]byte, len(line))
+		copy(key, line)
+		tree.Insert(key, nil)
+		n++
+	}
+	return tree, n, scanner.Err()
+}
+
+// WalkContext is like Walk, but stops once ctx is done. It returns the number of keys walked
+// with ctx.Err().
+func (tree *Tree) WalkContext(ctx context.Context,
+	f func(key []byte, value interface{}) (stop bool)) (walked int, err error) {
+
+	done := ctx.Done()
+	tree.Walk(func(key []byte, value interface{}) bool {
+		select {
+		case <-done:
+			err = ctx.Err()
+			return true
+		default:
+		}
+		walked++
+		return f(key, value)
+	})
+	return walked, err
+}
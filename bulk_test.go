@@ -0,0 +1,110 @@
+package suffix
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// errReader returns an error after the content is read.
+type errReader struct {
+	r   *strings.Reader
+	err error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	n, _ := r.r.Read(p)
+	if n == 0 {
+		return 0, r.err
+	}
+	return n, nil
+}
+
+func TestMerge(t *testing.T) {
+	lists, other := getFixtures()
+	tree := NewTree()
+	tree.Insert([]byte("table"), "chair")
+	tree.Insert([]byte("else"), "else")
+
+	merged, err := tree.Merge(context.Background(), other)
+	assert.Nil(t, err)
+	assert.Equal(t, len(lists), merged)
+	assert.Equal(t, len(lists)+1, tree.Len())
+	assertGet(t, tree, "table", true)
+	assertGet(t, tree, "else", true)
+
+	merged, err = tree.Merge(context.Background(), tree)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, merged)
+
+	// The rejected keys are not counted
+	tree = NewTree(WithMaxKeyLen(5))
+	merged, err = tree.Merge(context.Background(), other)
+	assert.Nil(t, err)
+	assert.Equal(t, tree.Len(), merged)
+	assert.True(t, merged < len(lists))
+	tree.Freeze()
+	merged, err = tree.Merge(context.Background(), other)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, merged)
+}
+
+func TestMerge_Canceled(t *testing.T) {
+	_, other := getFixtures()
+	tree := NewTree()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	merged, err := tree.Merge(ctx, other)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 0, merged)
+	assert.Equal(t, 0, tree.Len())
+}
+
+func TestBuildFromReader(t *testing.T) {
+	lists, _ := getFixtures()
+	tree, n, err := BuildFromReader(context.Background(),
+		strings.NewReader(strings.Join(lists, "\n")))
+	assert.Nil(t, err)
+	assert.Equal(t, len(lists), n)
+	for _, s := range lists {
+		_, found := tree.Get([]byte(s))
+		assert.True(t, found)
+	}
+
+	readErr := errors.New("broken")
+	tree, n, err = BuildFromReader(context.Background(),
+		&errReader{r: strings.NewReader("sth\nelse\n"), err: readErr})
+	assert.Equal(t, readErr, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, tree.Len())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tree, n, err = BuildFromReader(ctx, strings.NewReader("sth\nelse\n"))
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 0, tree.Len())
+}
+
+func TestWalkContext(t *testing.T) {
+	lists, tree := getFixtures()
+	walked, err := tree.WalkContext(context.Background(), func(key []byte, value interface{}) bool {
+		return false
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, len(lists), walked)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	walked, err = tree.WalkContext(ctx, func(key []byte, value interface{}) bool {
+		if string(key) == "believable" {
+			cancel()
+		}
+		return false
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 4, walked)
+}
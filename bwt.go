@@ -0,0 +1,82 @@
+package suffix
+
+import "fmt"
+
+// BWT returns the Burrows-Wheeler transform of the text, the last column of the sorted
+// rotations of the text followed by a terminator smaller than any byte. The terminator can't
+// be told from the bytes of the text, so it is left out of bwt, and primary is the row it
+// would be in, like the original pointer of bzip2. The rows are the suffixes in the order of
+// the leaves, so no suffix array is built.
+func (idx *TextIndex) BWT() (bwt []byte, primary int) {
+	text := idx.text
+	n := len(text)
+	bwt = make([]byte, 0, n)
+	if n > 0 {
+		// The first row starts with the terminator, which is the empty suffix
+		bwt = append(bwt, text[n-1])
+	}
+	row := 1
+	var visit func(node *_IndexNode)
+	visit = func(node *_IndexNode) {
+		if node.isLeaf() {
+			if node.suffix == 0 {
+				primary = row
+			} else {
+				bwt = append(bwt, text[node.suffix-1])
+			}
+			row++
+			return
+		}
+		for _, child := range node.children {
+			visit(child)
+		}
+	}
+	if n > 0 {
+		visit(idx.root)
+	}
+	return bwt, primary
+}
+
+// InverseBWT returns the text whose Burrows-Wheeler transform is bwt and primary, see
+// TextIndex.BWT.
+func InverseBWT(bwt []byte, primary int) ([]byte, error) {
+	n := len(bwt)
+	if primary < 0 || primary > n || (primary == 0) != (n == 0) {
+		return nil, fmt.Errorf("suffix: invalid primary index %d of BWT", primary)
+	}
+	// last returns the last byte of a row other than primary
+	last := func(row int) byte {
+		if row > primary {
+			row--
+		}
+		return bwt[row]
+	}
+	var counts [256]int
+	for _, c := range bwt {
+		counts[c]++
+	}
+	// starts[c] is the first row starting with c. Row 0 starts with the terminator.
+	var starts [256]int
+	row := 1
+	for c := range starts {
+		starts[c] = row
+		row += counts[c]
+	}
+	// lf[row] is the row of the rotation which moves the last byte of row to the front
+	lf := make([]int, n+1)
+	for row := 0; row <= n; row++ {
+		if row == primary {
+			continue
+		}
+		c := last(row)
+		lf[row] = starts[c]
+		starts[c]++
+	}
+	text := make([]byte, n)
+	row = 0
+	for i := n - 1; i >= 0; i-- {
+		text[i] = last(row)
+		row = lf[row]
+	}
+	return text, nil
+}
@@ -0,0 +1,77 @@
+package suffix
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// naiveBWT sorts the rotations of text with the terminator as -1.
+func naiveBWT(text []byte) ([]byte, int) {
+	n := len(text)
+	symbols := make([]int, n+1)
+	for i, c := range text {
+		symbols[i] = int(c)
+	}
+	symbols[n] = -1
+	rotations := make([]int, n+1)
+	for i := range rotations {
+		rotations[i] = i
+	}
+	sort.Slice(rotations, func(i, j int) bool {
+		for k := 0; k <= n; k++ {
+			a, b := symbols[(rotations[i]+k)%(n+1)], symbols[(rotations[j]+k)%(n+1)]
+			if a != b {
+				return a < b
+			}
+		}
+		return false
+	})
+	var bwt []byte
+	primary := 0
+	for row, start := range rotations {
+		if start == 0 {
+			primary = row
+			continue
+		}
+		bwt = append(bwt, text[start-1])
+	}
+	return bwt, primary
+}
+
+func TestTextIndex_BWT(t *testing.T) {
+	bwt, primary := NewTextIndex([]byte("banana")).BWT()
+	assert.Equal(t, "annbaa", string(bwt))
+	assert.Equal(t, 4, primary)
+	text, err := InverseBWT(bwt, primary)
+	assert.Nil(t, err)
+	assert.Equal(t, "banana", string(text))
+
+	bwt, primary = NewTextIndex(nil).BWT()
+	assert.Empty(t, bwt)
+	assert.Equal(t, 0, primary)
+	text, err = InverseBWT(bwt, primary)
+	assert.Nil(t, err)
+	assert.Empty(t, text)
+
+	_, err = InverseBWT([]byte("ab"), 3)
+	assert.NotNil(t, err)
+	_, err = InverseBWT([]byte("ab"), 0)
+	assert.NotNil(t, err)
+}
+
+func TestTextIndex_BWT_Random(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		text := randomText("abc", rand.Intn(30))
+		bwt, primary := NewTextIndex(text).BWT()
+		expectedBWT, expectedPrimary := naiveBWT(text)
+		assert.True(t, bytes.Equal(expectedBWT, bwt), "%q", text)
+		assert.Equal(t, expectedPrimary, primary, "%q", text)
+		inverse, err := InverseBWT(bwt, primary)
+		assert.Nil(t, err)
+		assert.Equal(t, string(text), string(inverse))
+	}
+}
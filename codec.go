@@ -0,0 +1,96 @@
+package suffix
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// Codec compresses the tree written by WriteCompressed, like gzip or zstd.
+type Codec interface {
+	// ID identifies the codec in the written data, so ReadFrom can find the codec to
+	// decompress it. 0 is reserved.
+	ID() byte
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) ID() byte {
+	return 1
+}
+
+func (gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// GzipCodec compresses with gzip. It is registered by default.
+var GzipCodec Codec = gzipCodec{}
+
+var (
+	codecsLock sync.RWMutex
+	codecs     = map[byte]Codec{
+		GzipCodec.ID(): GzipCodec,
+	}
+)
+
+// RegisterCodec makes a codec available to ReadFrom. It panics if the ID is 0 or is used by
+// another codec.
+func RegisterCodec(codec Codec) {
+	id := codec.ID()
+	if id == 0 {
+		panic("suffix: codec ID 0 is reserved")
+	}
+	codecsLock.Lock()
+	defer codecsLock.Unlock()
+	if registered, ok := codecs[id]; ok && registered != codec {
+		panic(fmt.Sprintf("suffix: codec ID %d is already registered", id))
+	}
+	codecs[id] = codec
+}
+
+func decompress(id byte, data []byte) ([]byte, error) {
+	codecsLock.RLock()
+	codec, ok := codecs[id]
+	codecsLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("suffix: unknown codec %d", id)
+	}
+	r, err := codec.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// WriteCompressed is like WriteTo, but compresses the tree with the codec. The codec needs to
+// be registered with RegisterCodec before ReadFrom can read it.
+func (tree *Tree) WriteCompressed(w io.Writer, codec Codec) (n int64, err error) {
+	sections, err := tree.encodeSections()
+	if err != nil {
+		return 0, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(formatMagic)
+	buf.Write([]byte{formatVersion, formatFlagCompressed, codec.ID()})
+	cw, err := codec.NewWriter(&buf)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = cw.Write(sections); err != nil {
+		return 0, err
+	}
+	if err = cw.Close(); err != nil {
+		return 0, err
+	}
+	return buf.WriteTo(w)
+}
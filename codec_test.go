@@ -0,0 +1,104 @@
+package suffix
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type flateCodec struct{}
+
+func (flateCodec) ID() byte {
+	return 100
+}
+
+func (flateCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(w, flate.BestCompression)
+}
+
+func (flateCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
+
+func getDomainTree() *Tree {
+	tree := NewTree()
+	for i := 0; i < 1000; i++ {
+		tree.Insert([]byte(fmt.Sprintf("host-%d.region-%d.example.com", i, i%10)), i)
+	}
+	return tree
+}
+
+func TestWriteCompressed(t *testing.T) {
+	tree := getDomainTree()
+	var plain bytes.Buffer
+	tree.WriteTo(&plain)
+
+	RegisterCodec(flateCodec{})
+	for _, codec := range []Codec{GzipCodec, flateCodec{}} {
+		var buf bytes.Buffer
+		n, err := tree.WriteCompressed(&buf, codec)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(buf.Len()), n)
+		assert.Less(t, buf.Len(), plain.Len()/2)
+
+		newTree := NewTree()
+		_, err = newTree.ReadFrom(&buf)
+		assert.Nil(t, err)
+		assertSameContent(t, tree, newTree)
+		assertSameStructure(t, tree, newTree)
+	}
+}
+
+func TestWriteCompressed_Invalid(t *testing.T) {
+	tree := getDomainTree()
+	var buf bytes.Buffer
+	tree.WriteCompressed(&buf, GzipCodec)
+	data := buf.Bytes()
+
+	newTree := NewTree()
+	corrupted := append([]byte{}, data...)
+	corrupted[len(formatMagic)+2] = 200
+	_, err := newTree.ReadFrom(bytes.NewReader(corrupted))
+	assert.EqualError(t, err, "suffix: unknown codec 200")
+	_, err = newTree.ReadFrom(bytes.NewReader(data[:len(data)-10]))
+	assert.NotNil(t, err)
+	_, err = newTree.ReadFrom(bytes.NewReader(data[:len(formatMagic)+2]))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.Equal(t, 0, newTree.Len())
+
+	tree.Insert([]byte("sth"), struct{}{})
+	_, err = tree.WriteCompressed(&buf, GzipCodec)
+	assert.NotNil(t, err)
+}
+
+func TestRegisterCodec(t *testing.T) {
+	assert.NotPanics(t, func() {
+		RegisterCodec(GzipCodec)
+	})
+	assert.Panics(t, func() {
+		RegisterCodec(gzipIDCodec{})
+	})
+	assert.Panics(t, func() {
+		RegisterCodec(zeroIDCodec{})
+	})
+}
+
+type gzipIDCodec struct {
+	flateCodec
+}
+
+func (gzipIDCodec) ID() byte {
+	return GzipCodec.ID()
+}
+
+type zeroIDCodec struct {
+	flateCodec
+}
+
+func (zeroIDCodec) ID() byte {
+	return 0
+}
@@ -0,0 +1,51 @@
+package suffix
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Collator compares keys in the order of a language. *collate.Collator in
+// golang.org/x/text/collate implements it.
+type Collator interface {
+	Compare(a, b []byte) int
+}
+
+// CollatorLess returns a less function for WalkSorted, which orders keys with c.
+func CollatorLess(c Collator) func(a, b []byte) bool {
+	return func(a, b []byte) bool {
+		return c.Compare(a, b) < 0
+	}
+}
+
+// WalkSorted is like Walk, but calls f with keys in the order of less, like the order of a
+// language given by CollatorLess. If less is nil, the keys are in the byte order.
+// Unlike Walk, all keys are collected and sorted before f is called.
+func (tree *Tree) WalkSorted(less func(a, b []byte) bool,
+	f func(key []byte, value interface{}) (stop bool)) {
+
+	if less == nil {
+		less = func(a, b []byte) bool {
+			return bytes.Compare(a, b) < 0
+		}
+	}
+	keys := make([][]byte, 0, tree.Len())
+	values := make([]interface{}, 0, tree.Len())
+	tree.Walk(func(key []byte, value interface{}) bool {
+		keys = append(keys, key)
+		values = append(values, value)
+		return false
+	})
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return less(keys[order[i]], keys[order[j]])
+	})
+	for _, i := range order {
+		if f(keys[i], values[i]) {
+			return
+		}
+	}
+}
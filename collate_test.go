@@ -0,0 +1,43 @@
+package suffix
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// caseInsensitiveCollator orders ASCII keys ignoring their case, with the lower case first.
+type caseInsensitiveCollator struct{}
+
+func (caseInsensitiveCollator) Compare(a, b []byte) int {
+	if c := bytes.Compare(bytes.ToLower(a), bytes.ToLower(b)); c != 0 {
+		return c
+	}
+	return -bytes.Compare(a, b)
+}
+
+func TestWalkSorted(t *testing.T) {
+	tree := NewTree()
+	for _, s := range []string{"banana", "Apple", "apple", "Cherry", "", "band"} {
+		tree.Insert([]byte(s), s)
+	}
+	walk := func(less func(a, b []byte) bool, limit int) []string {
+		var keys []string
+		tree.WalkSorted(less, func(key []byte, value interface{}) bool {
+			assert.Equal(t, string(key), value)
+			keys = append(keys, string(key))
+			return len(keys) == limit
+		})
+		return keys
+	}
+	assert.Equal(t, []string{"", "Apple", "Cherry", "apple", "banana", "band"}, walk(nil, -1))
+	assert.Equal(t, []string{"", "apple", "Apple", "banana", "band", "Cherry"},
+		walk(CollatorLess(caseInsensitiveCollator{}), -1))
+	assert.Equal(t, []string{"", "apple"}, walk(CollatorLess(caseInsensitiveCollator{}), 2))
+
+	NewTree().WalkSorted(nil, func(key []byte, value interface{}) bool {
+		t.Error("empty tree is walked")
+		return false
+	})
+}
@@ -0,0 +1,339 @@
+package suffix
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// ConcurrentTree is a suffix tree which is safe for concurrent use. Each node has its own
+// RWMutex, and the operations lock the nodes hand over hand down the match path: the lock of a
+// child is taken before the lock of its parent is released. So a writer only holds the node
+// it is passing or modifying, the writers in unrelated subtrees run concurrently even if their
+// keys end with the same bytes, and a reader only waits for the writers ahead of it on its
+// path. Every operation passes the root, but only holds it while the edges are searched.
+//
+// Remove may merge the node the key is removed from into its parent, so it holds two nodes at a
+// time. The locks are always taken from the root downward, so they can't deadlock.
+//
+// A snapshot is published behind an atomic pointer for the readers, and each writer clears the
+// pointer before changing the tree, like a seqlock validating the reads. So as long as no write
+// happened since the snapshot, Get and LongestSuffix read it without any lock, since the nodes
+// shared with a snapshot are never modified. Otherwise they publish a new snapshot if no writer
+// is running, or lock the nodes down the path. The writers copy the nodes shared with the
+// published snapshot before modifying them, so in write-heavy workloads each snapshot costs
+// the following writes a copy of their paths.
+//
+// HasSequence and Walk visit the whole tree, so they run on the published snapshot, which is
+// consistent and doesn't block the writers while they run.
+type ConcurrentTree struct {
+	// Held for reading by the writers and for writing by Snapshot, so a snapshot is taken
+	// between the writes
+	gate sync.RWMutex
+	// Guards root, which is replaced when it is copied after a snapshot
+	rootMu sync.RWMutex
+	root   *_Node
+	// Like Tree.owner, changed by Snapshot
+	owner     *cowOwner
+	leavesNum atomic.Int64
+	// The frozen snapshot serving the readers, nil once a write changed the tree after it
+	published atomic.Pointer[Tree]
+}
+
+// NewConcurrentTree creates a ConcurrentTree for future usage.
+func NewConcurrentTree() *ConcurrentTree {
+	return &ConcurrentTree{
+		root: &_Node{
+			edges: []*_Edge{},
+		},
+	}
+}
+
+// invalidate stops the readers from using the published snapshot. The writers call it before
+// changing the tree.
+func (tree *ConcurrentTree) invalidate() {
+	if tree.published.Load() != nil {
+		tree.published.Store(nil)
+	}
+}
+
+// snapshot returns the published snapshot, or publishes a new one if a write changed the tree
+// after it. If wait is false, it returns nil instead of waiting for the running writers.
+func (tree *ConcurrentTree) snapshot(wait bool) *Tree {
+	if published := tree.published.Load(); published != nil {
+		return published
+	}
+	if wait {
+		tree.gate.Lock()
+	} else if !tree.gate.TryLock() {
+		return nil
+	}
+	defer tree.gate.Unlock()
+	if published := tree.published.Load(); published != nil {
+		return published
+	}
+	// The writers copy the nodes shared with the snapshot from now on
+	tree.owner = &cowOwner{}
+	published := &Tree{
+		root:      tree.root,
+		leavesNum: int(tree.leavesNum.Load()),
+		frozen:    true,
+	}
+	tree.published.Store(published)
+	return published
+}
+
+// lockRoot locks the root for writing, copying it first if it is shared with a snapshot. The
+// caller must hold gate for reading.
+func (tree *ConcurrentTree) lockRoot() *_Node {
+	tree.rootMu.Lock()
+	defer tree.rootMu.Unlock()
+	tree.root = tree.root.writable(tree.owner)
+	tree.root.mu.Lock()
+	return tree.root
+}
+
+// rlockRoot locks the root for reading.
+func (tree *ConcurrentTree) rlockRoot() *_Node {
+	tree.rootMu.RLock()
+	defer tree.rootMu.RUnlock()
+	root := tree.root
+	root.mu.RLock()
+	return root
+}
+
+// matchEdge returns the index of the edge on the path of key, and the rest of key below it. The
+// index is -1 if key is not in the tree.
+func (node *_Node) matchEdge(key []byte) (idx int, subKey []byte) {
+	for i, edge := range node.edges {
+		if !bytes.HasSuffix(key, edge.label) {
+			continue
+		}
+		subKey = key[:len(key)-len(edge.label)]
+		if _, ok := edge.point.(*_Leaf); ok && len(subKey) > 0 {
+			continue
+		}
+		return i, subKey
+	}
+	return -1, nil
+}
+
+// Insert is like Tree.Insert.
+func (tree *ConcurrentTree) Insert(key []byte, value interface{}) (oldValue interface{}, ok bool) {
+	if key == nil {
+		return nil, false
+	}
+	tree.gate.RLock()
+	defer tree.gate.RUnlock()
+	tree.invalidate()
+	node, subKey := tree.lockRoot(), key
+	for {
+		next, nextKey, oldValue, replaced := node.insertStep(key, subKey, value)
+		if next == nil {
+			node.mu.Unlock()
+			if !replaced {
+				tree.leavesNum.Add(1)
+			}
+			return oldValue, true
+		}
+		next.mu.Lock()
+		node.mu.Unlock()
+		node, subKey = next, nextKey
+	}
+}
+
+// Get is like Tree.Get.
+func (tree *ConcurrentTree) Get(key []byte) (value interface{}, found bool) {
+	if key == nil {
+		return nil, false
+	}
+	if published := tree.snapshot(false); published != nil {
+		return published.Get(key)
+	}
+	node := tree.rlockRoot()
+	for {
+		i, subKey := node.matchEdge(key)
+		if i < 0 {
+			break
+		}
+		if leaf, ok := node.edges[i].point.(*_Leaf); ok {
+			value, found = leaf.value, true
+			break
+		}
+		child := node.edges[i].point.(*_Node)
+		child.mu.RLock()
+		node.mu.RUnlock()
+		node, key = child, subKey
+	}
+	node.mu.RUnlock()
+	return value, found
+}
+
+// LongestSuffix is like Tree.LongestSuffix.
+func (tree *ConcurrentTree) LongestSuffix(key []byte) (matchedKey []byte, value interface{},
+	found bool) {
+
+	if key == nil {
+		return nil, nil, false
+	}
+	if published := tree.snapshot(false); published != nil {
+		return published.LongestSuffix(key)
+	}
+	for node := tree.rlockRoot(); node != nil; {
+		var child *_Node
+		for _, edge := range node.edges {
+			if !bytes.HasSuffix(key, edge.label) {
+				continue
+			}
+			switch point := edge.point.(type) {
+			case *_Leaf:
+				// The keys matched later are longer
+				matchedKey, value, found = point.originKey, point.value, true
+			case *_Node:
+				child = point
+				key = key[:len(key)-len(edge.label)]
+			}
+			if len(edge.label) > 0 {
+				// Labels in the same Node don't share common suffix
+				break
+			}
+		}
+		if child != nil {
+			child.mu.RLock()
+		}
+		node.mu.RUnlock()
+		node = child
+	}
+	return matchedKey, value, found
+}
+
+// lockedLeaf is a leaf found by updateLeaf, with the nodes above it locked for writing.
+type lockedLeaf struct {
+	*_Leaf
+	// The node holding the leaf, and the index of its edge to the leaf
+	node *_Node
+	idx  int
+	// The parent of node, and the index of its edge to node. It is nil if node is the root.
+	parent    *_Node
+	parentIdx int
+}
+
+// updateLeaf locks the nodes hand over hand down the path of key for writing, and calls update
+// with the leaf of key if it is found. The node holding the leaf and its parent are both
+// locked, so update can remove the leaf and merge the node into the parent.
+func (tree *ConcurrentTree) updateLeaf(key []byte, update func(leaf *lockedLeaf)) {
+	tree.gate.RLock()
+	defer tree.gate.RUnlock()
+	path := lockedLeaf{node: tree.lockRoot()}
+	for {
+		i, subKey := path.node.matchEdge(key)
+		if i < 0 {
+			break
+		}
+		edge := path.node.edges[i]
+		if leaf, ok := edge.point.(*_Leaf); ok {
+			path._Leaf, path.idx = leaf, i
+			update(&path)
+			break
+		}
+		child := path.node.writableChild(edge, edge.point.(*_Node))
+		child.mu.Lock()
+		if path.parent != nil {
+			path.parent.mu.Unlock()
+		}
+		path.parent, path.parentIdx, path.node, key = path.node, i, child, subKey
+	}
+	path.node.mu.Unlock()
+	if path.parent != nil {
+		path.parent.mu.Unlock()
+	}
+}
+
+// remove removes the leaf from its node, and merges the node into the parent if it has only
+// one edge left.
+func (tree *ConcurrentTree) remove(leaf *lockedLeaf) {
+	tree.invalidate()
+	leaf.node.removeEdge(leaf.idx)
+	if leaf.parent != nil {
+		leaf.parent.mergeChildNode(leaf.parentIdx, leaf.node)
+	}
+	tree.leavesNum.Add(-1)
+}
+
+// Remove is like Tree.Remove.
+func (tree *ConcurrentTree) Remove(key []byte) (oldValue interface{}, found bool) {
+	if key == nil {
+		return nil, false
+	}
+	tree.updateLeaf(key, func(leaf *lockedLeaf) {
+		oldValue, found = leaf.value, true
+		tree.remove(leaf)
+	})
+	return oldValue, found
+}
+
+// CompareAndSwap is like Tree.CompareAndSwap.
+func (tree *ConcurrentTree) CompareAndSwap(key []byte, oldValue, newValue interface{}) (swapped bool) {
+	if key == nil {
+		return false
+	}
+	tree.updateLeaf(key, func(leaf *lockedLeaf) {
+		if leaf.value == oldValue {
+			tree.invalidate()
+			leaf.value = newValue
+			swapped = true
+		}
+	})
+	return swapped
+}
+
+// CompareAndDelete is like Tree.CompareAndDelete.
+func (tree *ConcurrentTree) CompareAndDelete(key []byte, oldValue interface{}) (deleted bool) {
+	if key == nil {
+		return false
+	}
+	tree.updateLeaf(key, func(leaf *lockedLeaf) {
+		if leaf.value == oldValue {
+			tree.remove(leaf)
+			deleted = true
+		}
+	})
+	return deleted
+}
+
+// Len is like Tree.Len. The writers running concurrently may be counted or not.
+func (tree *ConcurrentTree) Len() int {
+	return int(tree.leavesNum.Load())
+}
+
+// HasSequence is like Tree.HasSequence. It searches the published snapshot of the tree.
+func (tree *ConcurrentTree) HasSequence(key []byte) bool {
+	return tree.snapshot(true).HasSequence(key)
+}
+
+// Walk is like Tree.Walk. It walks the published snapshot of the tree, so the writers are not
+// blocked by f, and the keys written after Walk starts are not seen.
+func (tree *ConcurrentTree) Walk(f func(key []byte, value interface{}) (stop bool)) {
+	tree.snapshot(true).Walk(f)
+}
+
+// WalkSuffix is like Tree.WalkSuffix, on the published snapshot of the tree like Walk.
+func (tree *ConcurrentTree) WalkSuffix(suffix []byte, f func(key []byte, value interface{}) (stop bool)) {
+	tree.snapshot(true).WalkSuffix(suffix, f)
+}
+
+// Snapshot returns a point-in-time copy of the tree in O(1) time. If a write changed the tree
+// after the snapshot published for the readers, it waits for the running writers and blocks
+// the new ones until a new snapshot is published. Then the writers copy the nodes shared with
+// the snapshot before modifying them, like after Tree.Snapshot.
+func (tree *ConcurrentTree) Snapshot() *Tree {
+	return tree.snapshot(true).fork()
+}
+
+// SnapshotTo writes a consistent point-in-time image of the tree in the format of
+// Tree.WriteTo. The writers are only blocked while the snapshot is taken, and the image is
+// written without blocking the readers and writers.
+func (tree *ConcurrentTree) SnapshotTo(w io.Writer) (n int64, err error) {
+	return tree.Snapshot().WriteTo(w)
+}
@@ -0,0 +1,319 @@
+package suffix
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getConcurrentFixtures() ([]string, *ConcurrentTree) {
+	lists, _ := getFixtures()
+	tree := NewConcurrentTree()
+	for _, s := range lists {
+		tree.Insert([]byte(s), s)
+	}
+	return lists, tree
+}
+
+func TestConcurrentTree(t *testing.T) {
+	lists, tree := getConcurrentFixtures()
+	assert.Equal(t, len(lists), tree.Len())
+	for _, s := range lists {
+		value, found := tree.Get([]byte(s))
+		assert.True(t, found)
+		assert.Equal(t, s, value.(string))
+	}
+
+	oldValue, ok := tree.Insert([]byte("table"), "chair")
+	assert.True(t, ok)
+	assert.Equal(t, "table", oldValue.(string))
+	_, ok = tree.Insert(nil, "any")
+	assert.False(t, ok)
+
+	key, _, found := tree.LongestSuffix([]byte("vegetable"))
+	assert.True(t, found)
+	assert.Equal(t, "table", string(key))
+	_, _, found = tree.LongestSuffix([]byte("banana"))
+	assert.False(t, found)
+	tree.Insert([]byte{}, "")
+	key, _, found = tree.LongestSuffix([]byte("banana"))
+	assert.True(t, found)
+	assert.Equal(t, "", string(key))
+
+	assert.True(t, tree.HasSequence([]byte("bl")))
+	assert.False(t, tree.HasSequence([]byte("xyz")))
+
+	oldValue, found = tree.Remove([]byte("table"))
+	assert.True(t, found)
+	assert.Equal(t, "chair", oldValue.(string))
+	_, found = tree.Get([]byte("table"))
+	assert.False(t, found)
+	_, found = tree.Remove(nil)
+	assert.False(t, found)
+}
+
+func TestConcurrentTree_CompareAndSwap(t *testing.T) {
+	tree := NewConcurrentTree()
+	tree.Insert([]byte("counter"), 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for {
+					value, _ := tree.Get([]byte("counter"))
+					if tree.CompareAndSwap([]byte("counter"), value, value.(int)+1) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	value, _ := tree.Get([]byte("counter"))
+	assert.Equal(t, 400, value.(int))
+
+	assert.False(t, tree.CompareAndSwap(nil, nil, nil))
+	assert.False(t, tree.CompareAndDelete(nil, nil))
+	assert.False(t, tree.CompareAndDelete([]byte("counter"), 0))
+	assert.True(t, tree.CompareAndDelete([]byte("counter"), 400))
+	assert.Equal(t, 0, tree.Len())
+}
+
+func TestConcurrentTree_Walk(t *testing.T) {
+	lists, tree := getConcurrentFixtures()
+	tree.Insert([]byte{}, "")
+	result := map[string]bool{}
+	var first []byte
+	tree.Walk(func(key []byte, value interface{}) bool {
+		if first == nil {
+			first = key
+		}
+		result[string(key)] = true
+		return false
+	})
+	assert.Equal(t, len(lists)+1, len(result))
+	assert.Equal(t, []byte{}, first)
+
+	count := 0
+	tree.Walk(func(key []byte, value interface{}) bool {
+		count++
+		return count == 2
+	})
+	assert.Equal(t, 2, count)
+
+	count = 0
+	suffix := []byte("able")
+	tree.WalkSuffix(suffix, func(key []byte, value interface{}) bool {
+		assert.True(t, bytes.HasSuffix(key, suffix))
+		count++
+		return false
+	})
+	assert.Equal(t, 5, count)
+
+	count = 0
+	tree.WalkSuffix(nil, func(key []byte, value interface{}) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, len(lists)+1, count)
+}
+
+func TestConcurrentTree_Concurrent(t *testing.T) {
+	tree := NewConcurrentTree()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				key := []byte(strconv.Itoa(i*1000 + j))
+				tree.Insert(key, j)
+				tree.LongestSuffix(key)
+				tree.HasSequence([]byte("99"))
+				if j%2 == 0 {
+					tree.Remove(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 800, tree.Len())
+}
+
+func TestConcurrentTree_SameLastByte(t *testing.T) {
+	tree := NewConcurrentTree()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				key := []byte("host" + strconv.Itoa(j) + ".zone" + strconv.Itoa(i) + ".com")
+				tree.Insert(key, j)
+				if j%2 == 0 {
+					assert.True(t, tree.CompareAndDelete(key, j))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 800, tree.Len())
+	snapshot := tree.Snapshot()
+	assert.Nil(t, snapshot.Validate())
+	assert.Equal(t, 800, snapshot.Len())
+	value, found := tree.Get([]byte("host199.zone7.com"))
+	assert.True(t, found)
+	assert.Equal(t, 199, value)
+}
+
+func TestConcurrentTree_LockCoupling(t *testing.T) {
+	tree := NewConcurrentTree()
+	for _, s := range []string{"x.a.com", "y.a.com", "x.b.com", "y.b.com"} {
+		tree.Insert([]byte(s), s)
+	}
+	// root - ".com" -> Node - ".a" -> Node - "x" / "y"
+	//                      |- ".b" -> Node - "x" / "y"
+	com := tree.root.edges[0].point.(*_Node)
+	a := com.edges[0].point.(*_Node)
+	a.mu.Lock()
+
+	done := make(chan struct{})
+	go func() {
+		// Get would publish a snapshot, and the writers would copy the locked node after it
+		tree.Insert([]byte("z.b.com"), nil)
+		tree.Remove([]byte("y.b.com"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the writers in another subtree are blocked")
+	}
+
+	blocked := make(chan struct{})
+	go func() {
+		tree.Insert([]byte("z.a.com"), nil)
+		close(blocked)
+	}()
+	select {
+	case <-blocked:
+		t.Fatal("the writer in the locked subtree is not blocked")
+	case <-time.After(10 * time.Millisecond):
+	}
+	a.mu.Unlock()
+	<-blocked
+	_, found := tree.Get([]byte("z.a.com"))
+	assert.True(t, found)
+	assert.Equal(t, 5, tree.Len())
+}
+
+func TestConcurrentTree_Published(t *testing.T) {
+	_, tree := getConcurrentFixtures()
+	_, found := tree.Get([]byte("table"))
+	assert.True(t, found)
+	published := tree.published.Load()
+	assert.NotNil(t, published)
+
+	// The readers don't take the locks until the next write
+	tree.root.mu.Lock()
+	_, found = tree.Get([]byte("table"))
+	assert.True(t, found)
+	key, _, found := tree.LongestSuffix([]byte("vegetable"))
+	assert.True(t, found)
+	assert.Equal(t, "table", string(key))
+	assert.True(t, tree.HasSequence([]byte("bl")))
+	tree.root.mu.Unlock()
+
+	tree.Remove([]byte("table"))
+	assert.Nil(t, tree.published.Load())
+	// The published snapshot is not modified by the writers
+	_, found = published.Get([]byte("table"))
+	assert.True(t, found)
+
+	// A reader overlapping a writer locks the nodes instead of publishing a snapshot
+	tree.gate.RLock()
+	_, found = tree.Get([]byte("table"))
+	assert.False(t, found)
+	key, _, found = tree.LongestSuffix([]byte("a word"))
+	assert.True(t, found)
+	assert.Equal(t, "word", string(key))
+	assert.Nil(t, tree.published.Load())
+	tree.gate.RUnlock()
+
+	// Otherwise it publishes a new one, which is shared by Snapshot
+	_, found = tree.Get([]byte("table"))
+	assert.False(t, found)
+	published = tree.published.Load()
+	assert.NotNil(t, published)
+	assert.Equal(t, published.root, tree.Snapshot().root)
+	assert.False(t, tree.CompareAndSwap([]byte("table"), nil, nil))
+	assert.Equal(t, published, tree.published.Load())
+	assert.True(t, tree.CompareAndSwap([]byte("word"), "word", "WORD"))
+	assert.Nil(t, tree.published.Load())
+}
+
+func TestConcurrentTree_SnapshotTo(t *testing.T) {
+	lists, tree := getConcurrentFixtures()
+	tree.Insert([]byte{}, "empty")
+	expected := NewTree()
+	for _, s := range lists {
+		expected.Insert([]byte(s), s)
+	}
+	expected.Insert([]byte{}, "empty")
+
+	var buf bytes.Buffer
+	_, err := tree.SnapshotTo(&buf)
+	assert.Nil(t, err)
+	newTree := NewTree()
+	_, err = newTree.ReadFrom(&buf)
+	assert.Nil(t, err)
+	assertSameContent(t, expected, newTree)
+	msg, inOrder := checkLabelOrder(newTree)
+	assert.True(t, inOrder, msg)
+
+	// Changing the snapshot doesn't affect the tree
+	snapshot := tree.Snapshot()
+	snapshot.Remove([]byte("table"))
+	snapshot.Insert([]byte("sth"), nil)
+	_, found := tree.Get([]byte("table"))
+	assert.True(t, found)
+	_, found = tree.Get([]byte("sth"))
+	assert.False(t, found)
+}
+
+func TestConcurrentTree_SnapshotToConcurrent(t *testing.T) {
+	tree := NewConcurrentTree()
+	const keyNum = 2000
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Keys are inserted in order, so a consistent image holds a prefix of them
+		for i := 0; i < keyNum; i++ {
+			tree.Insert([]byte(strconv.Itoa(i)), i)
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		var buf bytes.Buffer
+		_, err := tree.SnapshotTo(&buf)
+		assert.Nil(t, err)
+		image := NewTree()
+		_, err = image.ReadFrom(&buf)
+		assert.Nil(t, err)
+		for i := 0; i < image.Len(); i++ {
+			value, found := image.Get([]byte(strconv.Itoa(i)))
+			assert.True(t, found)
+			assert.Equal(t, i, value)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package suffix
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// quoteKey quotes key for the debugging outputs and errors. Printable characters are kept,
+// and the other bytes, including the invalid UTF-8 of binary keys, are escaped as \xNN, so
+// the result is readable and can be pasted back into Go code.
+func quoteKey(key []byte) string {
+	var buf strings.Builder
+	buf.WriteByte('"')
+	for i := 0; i < len(key); {
+		r, size := utf8.DecodeRune(key[i:])
+		switch {
+		case r == '"' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(byte(r))
+		case (r != utf8.RuneError || size > 1) && strconv.IsPrint(r):
+			buf.Write(key[i : i+size])
+		default:
+			for _, c := range key[i : i+size] {
+				fmt.Fprintf(&buf, `\x%02x`, c)
+			}
+		}
+		i += size
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// quoteValue formats value for the debugging outputs. Strings and []byte are quoted like
+// quoteKey, and the other values are printed with fmt.Sprint, with newlines escaped so each
+// value stays on its line.
+func quoteValue(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		return quoteKey(v)
+	case string:
+		return quoteKey([]byte(v))
+	default:
+		return strings.ReplaceAll(fmt.Sprint(v), "\n", `\n`)
+	}
+}
+
+// QuoteKey quotes key like Dump, escaping non-printable bytes as \xNN, so other debugging
+// outputs of a tree can render the keys the same way.
+func QuoteKey(key []byte) string {
+	return quoteKey(key)
+}
+
+// QuoteValue formats value like Dump: strings and []byte are quoted like QuoteKey, and the
+// other values are printed with fmt.Sprint on a single line.
+func QuoteValue(value interface{}) string {
+	return quoteValue(value)
+}
+
+// Dump writes the structure of the tree as indented text for debugging. Each line is an
+// edge with its label, and the edges pointing to a key end with the key and its value:
+//
+//	"com"
+//	    "" -> "com"
+//	    "example." -> "example.com" = 1
+//
+// The labels, keys, and the values of strings and []byte are quoted, with non-printable bytes
+// escaped as \xNN.
+func (tree *Tree) Dump(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	dumpNode(bw, tree.root, 0)
+	return bw.Flush()
+}
+
+// dumpNode writes the edges under node for Dump, indented by depth levels.
+func dumpNode(bw *bufio.Writer, node *_Node, depth int) {
+	for _, edge := range node.edges {
+		bw.WriteString(strings.Repeat("    ", depth))
+		bw.WriteString(quoteKey(edge.label))
+		switch point := edge.point.(type) {
+		case *_Leaf:
+			bw.WriteString(" -> ")
+			bw.WriteString(quoteKey(point.originKey))
+			if point.value != nil {
+				bw.WriteString(" = ")
+				bw.WriteString(quoteValue(point.value))
+			}
+			bw.WriteByte('\n')
+		case *_Node:
+			bw.WriteByte('\n')
+			dumpNode(bw, point, depth+1)
+		}
+	}
+}
+
+// String returns the structure of the tree written by Dump, so a tree can be printed with
+// fmt. An empty tree is printed as "(empty)".
+func (tree *Tree) String() string {
+	var buf strings.Builder
+	tree.Dump(&buf)
+	if buf.Len() == 0 {
+		return "(empty)"
+	}
+	return buf.String()
+}
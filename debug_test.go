@@ -0,0 +1,66 @@
+package suffix
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteKey(t *testing.T) {
+	for key, expected := range map[string]string{
+		"":                 `""`,
+		"example.com":      `"example.com"`,
+		"caf\u00e9":        "\"caf\u00e9\"",
+		"\"\\":             `"\"\\"`,
+		"\x00\n\x7f":       `"\x00\x0a\x7f"`,
+		"\xff\xfe\xfd\xfc": `"\xff\xfe\xfd\xfc"`,
+		// Zero width space
+		"\u200b":     `"\xe2\x80\x8b"`,
+		"\ufffd\xff": "\"\ufffd\\xff\"",
+	} {
+		assert.Equal(t, expected, quoteKey([]byte(key)), key)
+	}
+}
+
+func TestQuoteValue(t *testing.T) {
+	assert.Equal(t, `"a\x00"`, QuoteValue("a\x00"))
+	assert.Equal(t, `"\xff\x0a"`, QuoteValue([]byte("\xff\n")))
+	assert.Equal(t, "1", QuoteValue(1))
+	assert.Equal(t, "<nil>", QuoteValue(nil))
+	assert.Equal(t, QuoteKey([]byte("\x01")), QuoteValue("\x01"))
+}
+
+func TestDump(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("example.com"), 1)
+	tree.Insert([]byte("a.example.com"), "a")
+	tree.Insert([]byte("\x01\xff"), []byte{0, '\n'})
+
+	var buf bytes.Buffer
+	assert.Nil(t, tree.Dump(&buf))
+	assert.Equal(t, `"\x01\xff" -> "\x01\xff" = "\x00\x0a"
+"com"
+    "" -> "com"
+    "example."
+        "" -> "example.com" = 1
+        "a." -> "a.example.com" = "a"
+`, buf.String())
+
+	buf.Reset()
+	assert.Nil(t, NewTree().Dump(&buf))
+	assert.Equal(t, "", buf.String())
+}
+
+func TestString(t *testing.T) {
+	tree := NewTree()
+	assert.Equal(t, "(empty)", tree.String())
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("example.com"), 1)
+	assert.Equal(t, `"com"
+    "" -> "com"
+    "example." -> "example.com" = 1
+`, fmt.Sprint(tree))
+}
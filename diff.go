@@ -0,0 +1,177 @@
+package suffix
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+const (
+	// KeyAdded means the key is only in the other tree.
+	KeyAdded ChangeKind = iota
+	// KeyRemoved means the key is only in the tree.
+	KeyRemoved
+	// KeyChanged means the key has different values in the two trees.
+	KeyChanged
+)
+
+var changeKindNames = []string{"added", "removed", "changed"}
+
+func (kind ChangeKind) String() string {
+	if int(kind) < len(changeKindNames) {
+		return changeKindNames[kind]
+	}
+	return "unknown"
+}
+
+// Change is a difference between two trees found by Diff.
+type Change struct {
+	Kind ChangeKind
+	Key  []byte
+	// The value in the tree, nil for KeyAdded
+	OldValue interface{}
+	// The value in the other tree, nil for KeyRemoved
+	NewValue interface{}
+}
+
+// valuesEqual compares two values with ==, or with reflect.DeepEqual if they are not
+// comparable, so Diff doesn't panic on values like slices.
+func valuesEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb {
+		return false
+	}
+	if ta.Comparable() {
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(kind ChangeKind, key []byte, oldValue, newValue interface{}) {
+	d.changes = append(d.changes, Change{kind, key, oldValue, newValue})
+}
+
+func (d *differ) addAll(point interface{}, kind ChangeKind) {
+	walkPoint(point, func(key []byte, value interface{}) bool {
+		if kind == KeyAdded {
+			d.add(kind, key, nil, value)
+		} else {
+			d.add(kind, key, value, nil)
+		}
+		return false
+	})
+}
+
+// walkPoint walks the keys under point, which is a node or a leaf.
+func walkPoint(point interface{}, f func(key []byte, value interface{}) bool) {
+	switch point := point.(type) {
+	case *_Leaf:
+		f(point.originKey, point.value)
+	case *_Node:
+		point.walk(f)
+	}
+}
+
+// diffNodes compares two nodes at the same path. Nodes shared by copy-on-write are the same,
+// so they are skipped.
+func (d *differ) diffNodes(a, b *_Node) {
+	if a == b {
+		return
+	}
+	matched := make([]bool, len(b.edges))
+	for _, edgeA := range a.edges {
+		// The labels in a node don't share the last byte, so the keys under edgeA can only
+		// be under the edge of b ending with the same byte
+		j := -1
+		for i, edgeB := range b.edges {
+			if len(edgeA.label) == 0 && len(edgeB.label) == 0 ||
+				len(edgeA.label) > 0 && len(edgeB.label) > 0 &&
+					edgeA.label[len(edgeA.label)-1] == edgeB.label[len(edgeB.label)-1] {
+				j = i
+				break
+			}
+		}
+		if j < 0 {
+			d.addAll(edgeA.point, KeyRemoved)
+			continue
+		}
+		matched[j] = true
+		d.diffEdges(edgeA, b.edges[j])
+	}
+	for j, edgeB := range b.edges {
+		if !matched[j] {
+			d.addAll(edgeB.point, KeyAdded)
+		}
+	}
+}
+
+func (d *differ) diffEdges(a, b *_Edge) {
+	if bytes.Equal(a.label, b.label) {
+		switch pointA := a.point.(type) {
+		case *_Leaf:
+			if pointB, ok := b.point.(*_Leaf); ok {
+				if !valuesEqual(pointA.value, pointB.value) {
+					d.add(KeyChanged, pointA.originKey, pointA.value, pointB.value)
+				}
+				return
+			}
+		case *_Node:
+			if pointB, ok := b.point.(*_Node); ok {
+				d.diffNodes(pointA, pointB)
+				return
+			}
+		}
+	}
+	// The subtrees have different shapes, compare their keys
+	values := map[string]interface{}{}
+	walkPoint(a.point, func(key []byte, value interface{}) bool {
+		values[string(key)] = value
+		return false
+	})
+	walkPoint(b.point, func(key []byte, value interface{}) bool {
+		oldValue, ok := values[string(key)]
+		if !ok {
+			d.add(KeyAdded, key, nil, value)
+			return false
+		}
+		delete(values, string(key))
+		if !valuesEqual(oldValue, value) {
+			d.add(KeyChanged, key, oldValue, value)
+		}
+		return false
+	})
+	walkPoint(a.point, func(key []byte, value interface{}) bool {
+		if _, ok := values[string(key)]; ok {
+			d.add(KeyRemoved, key, value, nil)
+		}
+		return false
+	})
+}
+
+// Diff returns the changes turning the tree into other, sorted by the keys, to audit what a
+// new version of a rule set changes. The values are compared with ==, or reflect.DeepEqual if
+// they are not comparable.
+//
+// The subtrees shared by a tree and its Snapshot are skipped, so comparing a snapshot with
+// the tree it is taken from, after a few changes, only visits the changed paths.
+func (tree *Tree) Diff(other *Tree) []Change {
+	d := differ{}
+	d.diffNodes(tree.root, other.root)
+	sort.Slice(d.changes, func(i, j int) bool {
+		return bytes.Compare(d.changes[i].Key, d.changes[j].Key) < 0
+	})
+	for i := range d.changes {
+		d.changes[i].Key = tree.output(d.changes[i].Key)
+	}
+	return d.changes
+}
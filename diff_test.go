@@ -0,0 +1,114 @@
+package suffix
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/spacewander/go-suffix-tree/suffixtest"
+)
+
+func TestDiff(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("com"), 1)
+	tree.Insert([]byte("example.com"), 2)
+	tree.Insert([]byte("a.example.com"), []string{"a"})
+	tree.Insert([]byte("org"), nil)
+
+	other := NewTree()
+	other.Insert([]byte("com"), 1)
+	other.Insert([]byte("example.com"), 3)
+	other.Insert([]byte("a.example.com"), []string{"a"})
+	other.Insert([]byte("b.example.com"), nil)
+	other.Insert([]byte("net"), nil)
+
+	assert.Equal(t, []Change{
+		{Kind: KeyAdded, Key: []byte("b.example.com")},
+		{Kind: KeyChanged, Key: []byte("example.com"), OldValue: 2, NewValue: 3},
+		{Kind: KeyAdded, Key: []byte("net")},
+		{Kind: KeyRemoved, Key: []byte("org")},
+	}, tree.Diff(other))
+	assert.Equal(t, []Change{
+		{Kind: KeyRemoved, Key: []byte("b.example.com")},
+		{Kind: KeyChanged, Key: []byte("example.com"), OldValue: 3, NewValue: 2},
+		{Kind: KeyRemoved, Key: []byte("net")},
+		{Kind: KeyAdded, Key: []byte("org")},
+	}, other.Diff(tree))
+	assert.Empty(t, tree.Diff(tree))
+	assert.Empty(t, NewTree().Diff(NewTree()))
+	assert.Equal(t, 4, len(tree.Diff(NewTree())))
+
+	assert.Equal(t, "added", KeyAdded.String())
+	assert.Equal(t, "changed", KeyChanged.String())
+	assert.Equal(t, "unknown", ChangeKind(10).String())
+}
+
+func TestDiff_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		keys := suffixtest.RandomKeys(r, 20)
+		tree, other := NewTree(), NewTree()
+		treeValues, otherValues := map[string]int{}, map[string]int{}
+		for _, key := range keys {
+			if r.Intn(4) > 0 {
+				v := r.Intn(2)
+				tree.Insert(key, v)
+				treeValues[string(key)] = v
+			}
+			if r.Intn(4) > 0 {
+				v := r.Intn(2)
+				other.Insert(key, v)
+				otherValues[string(key)] = v
+			}
+		}
+
+		var expected []Change
+		for key, v := range treeValues {
+			if w, ok := otherValues[key]; !ok {
+				expected = append(expected, Change{KeyRemoved, []byte(key), v, nil})
+			} else if v != w {
+				expected = append(expected, Change{KeyChanged, []byte(key), v, w})
+			}
+		}
+		for key, w := range otherValues {
+			if _, ok := treeValues[key]; !ok {
+				expected = append(expected, Change{KeyAdded, []byte(key), nil, w})
+			}
+		}
+		sort.Slice(expected, func(i, j int) bool {
+			return bytes.Compare(expected[i].Key, expected[j].Key) < 0
+		})
+		changes := tree.Diff(other)
+		if len(expected) == 0 {
+			assert.Empty(t, changes)
+		} else {
+			assert.Equal(t, expected, changes, "%q", keys)
+		}
+	}
+}
+
+func TestDiff_Snapshot(t *testing.T) {
+	tree := NewTree()
+	for i := 0; i < 1000; i++ {
+		tree.Insert([]byte(string(rune('a'+i%26))+".example"+string(rune('a'+i/26))), i)
+	}
+	snapshot := tree.Snapshot()
+	tree.Insert([]byte("new.org"), nil)
+	tree.Remove([]byte("a.examplea"))
+
+	// Only the changed paths are visited: the other subtrees are shared
+	assert.Equal(t, []Change{
+		{Kind: KeyAdded, Key: []byte("a.examplea"), NewValue: 0},
+		{Kind: KeyRemoved, Key: []byte("new.org")},
+	}, tree.Diff(snapshot))
+	shared := 0
+	for i, edge := range tree.root.edges {
+		if edge.point == snapshot.root.edges[i].point {
+			shared++
+		}
+	}
+	assert.True(t, shared > 0)
+}
@@ -0,0 +1,64 @@
+package suffix
+
+import "sort"
+
+// DocumentIndex is a suffix tree over several documents, the generalized suffix tree, to find
+// which documents contain a pattern. The documents are identified by their positions in the
+// slice given to NewDocumentIndex.
+type DocumentIndex struct {
+	idx *TextIndex
+	// starts[i] is the start of the i-th document in the text of idx, with the end of the
+	// text appended
+	starts []int
+}
+
+// NewDocumentIndex indexes the documents. Unlike NewTextIndex, it copies the documents. The
+// occurrences across two documents are never reported.
+func NewDocumentIndex(docs [][]byte) *DocumentIndex {
+	size := 0
+	for _, doc := range docs {
+		size += len(doc)
+	}
+	text := make([]byte, 0, size)
+	starts := make([]int, 0, len(docs)+1)
+	for _, doc := range docs {
+		starts = append(starts, len(text))
+		text = append(text, doc...)
+	}
+	starts = append(starts, len(text))
+	return &DocumentIndex{idx: NewTextIndex(text), starts: starts}
+}
+
+// Len returns the number of documents.
+func (d *DocumentIndex) Len() int {
+	return len(d.starts) - 1
+}
+
+// WhichDocuments returns the documents containing pattern, with the number of occurrences of
+// pattern in each of them. Overlapping occurrences are counted, and the empty pattern occurs
+// len(doc)+1 times like bytes.Count. It takes the time of walking pattern and the occurrences
+// of pattern.
+func (d *DocumentIndex) WhichDocuments(pattern []byte) map[int]int {
+	docs := map[int]int{}
+	if len(pattern) == 0 {
+		for i := 0; i < d.Len(); i++ {
+			docs[i] = d.starts[i+1] - d.starts[i] + 1
+		}
+		return docs
+	}
+	node := d.idx.locus(pattern)
+	if node == nil {
+		return docs
+	}
+	node.eachLeaf(func(suffix int) {
+		// The last document starting at or before the suffix. Empty documents share their
+		// start with the next one, so take the last.
+		doc := sort.Search(d.Len(), func(i int) bool {
+			return d.starts[i] > suffix
+		}) - 1
+		if suffix+len(pattern) <= d.starts[doc+1] {
+			docs[doc]++
+		}
+	})
+	return docs
+}
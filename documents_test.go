@@ -0,0 +1,50 @@
+package suffix
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentIndex(t *testing.T) {
+	d := NewDocumentIndex([][]byte{
+		[]byte("banana"), []byte(""), []byte("ananas"), []byte("nab"),
+	})
+	assert.Equal(t, 4, d.Len())
+	assert.Equal(t, map[int]int{0: 2, 2: 2}, d.WhichDocuments([]byte("ana")))
+	assert.Equal(t, map[int]int{0: 1, 3: 1}, d.WhichDocuments([]byte("b")))
+	// "anaa" and "sna" only exist across the documents
+	assert.Equal(t, map[int]int{}, d.WhichDocuments([]byte("aan")))
+	assert.Equal(t, map[int]int{}, d.WhichDocuments([]byte("sna")))
+	assert.Equal(t, map[int]int{}, d.WhichDocuments([]byte("x")))
+	assert.Equal(t, map[int]int{0: 7, 1: 1, 2: 7, 3: 4}, d.WhichDocuments(nil))
+
+	d = NewDocumentIndex(nil)
+	assert.Equal(t, 0, d.Len())
+	assert.Equal(t, map[int]int{}, d.WhichDocuments([]byte("a")))
+}
+
+func TestDocumentIndex_Random(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		docs := make([][]byte, rand.Intn(6))
+		for j := range docs {
+			docs[j] = randomText("ab", rand.Intn(16))
+		}
+		d := NewDocumentIndex(docs)
+		for j := 0; j < 20; j++ {
+			pattern := randomText("ab", rand.Intn(4))
+			expected := map[int]int{}
+			for k, doc := range docs {
+				if n := naiveCount([][]byte{doc}, pattern); n > 0 {
+					expected[k] = n
+				}
+				if len(pattern) > 0 {
+					assert.Equal(t, bytes.Contains(doc, pattern), expected[k] > 0)
+				}
+			}
+			assert.Equal(t, expected, d.WhichDocuments(pattern), "%q %q", docs, pattern)
+		}
+	}
+}
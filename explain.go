@@ -0,0 +1,94 @@
+package suffix
+
+// Explanation tells how a query is matched by LongestSuffix, see Explain.
+type Explanation struct {
+	// The query as matched, after the options transform it
+	Query []byte
+	// The edges considered by LongestSuffix, like the steps reported by WithTracer. Each
+	// matched label consumes its length of bytes from the end of the query.
+	Steps []TraceStep
+	// The number of bytes at the end of the query matched by the labels on the path, and
+	// the part of the query left where the matching stopped
+	Consumed int
+	Rest     []byte
+	// The result of LongestSuffix, nil if there is none
+	Matched []byte
+	// Whether the query itself is a key
+	Exact bool
+	// The key sharing the longest common suffix with the query, which may not be a suffix of
+	// it, with the length of the common suffix. Nearest is nil if the tree is empty.
+	Nearest       []byte
+	NearestShared int
+}
+
+// Explain explains how the query is matched, for debugging a query which doesn't match the
+// expected key, like a misrouted host name. It returns the error if the options of the tree
+// reject the query.
+func (tree *Tree) Explain(query []byte) (*Explanation, error) {
+	key, err := tree.prepareKey(query)
+	if err != nil {
+		return nil, err
+	}
+	e := &Explanation{Query: key, Rest: key}
+	leaf := tree.root.traceLookup(OpLongestSuffix, key, tree.separator(), false, 0,
+		func(step TraceStep) {
+			e.Steps = append(e.Steps, step)
+			if step.Action != TraceSkip && len(step.Rest)-len(step.Label) < len(e.Rest) {
+				e.Rest = step.Rest[:len(step.Rest)-len(step.Label)]
+			}
+		})
+	e.Consumed = len(key) - len(e.Rest)
+	if leaf != nil {
+		e.Matched = tree.output(leaf.originKey)
+		e.Exact = len(leaf.originKey) == len(key)
+	}
+	if nearest, shared := tree.root.nearest(key, 0); nearest != nil {
+		e.Nearest, e.NearestShared = tree.output(nearest.originKey), shared
+	}
+	return e, nil
+}
+
+// output converts a stored key with outputKey.
+func (tree *Tree) output(key []byte) []byte {
+	if tree.outputKey != nil {
+		return tree.outputKey(key)
+	}
+	return key
+}
+
+// nearest finds a key sharing the longest common suffix with key, and the length of the
+// common suffix plus shared.
+func (node *_Node) nearest(key []byte, shared int) (*_Leaf, int) {
+	for _, edge := range node.edges {
+		n := seqSuffixLen(key, edge.label)
+		if n == 0 {
+			continue
+		}
+		// Labels in the same Node don't share common suffix, so only this edge shares a
+		// common suffix with key
+		switch point := edge.point.(type) {
+		case *_Leaf:
+			return point, shared + n
+		case *_Node:
+			if n == len(edge.label) {
+				return point.nearest(key[:len(key)-n], shared+n)
+			}
+			return point.firstLeaf(), shared + n
+		}
+	}
+	return node.firstLeaf(), shared
+}
+
+// firstLeaf returns the first leaf under node in the order of Walk, or nil for an empty node.
+func (node *_Node) firstLeaf() *_Leaf {
+	if len(node.edges) == 0 {
+		return nil
+	}
+	switch point := node.edges[0].point.(type) {
+	case *_Leaf:
+		return point
+	case *_Node:
+		return point.firstLeaf()
+	}
+	return nil
+}
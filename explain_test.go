@@ -0,0 +1,47 @@
+package suffix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	tree := NewTree(WithSeparator('.'))
+	tree.Insert([]byte("com"), 1)
+	tree.Insert([]byte("example.com"), 2)
+	tree.Insert([]byte("org"), 3)
+
+	e, err := tree.Explain([]byte("www.ample.com"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("www.ample.com"), e.Query)
+	assert.Equal(t, []byte("com"), e.Matched)
+	assert.False(t, e.Exact)
+	assert.Equal(t, 3, e.Consumed)
+	assert.Equal(t, []byte("www.ample."), e.Rest)
+	assert.Equal(t, 3, len(e.Steps))
+	assert.Equal(t, TraceSkip, e.Steps[2].Action)
+	// "example.com" shares "ample.com" with the query, but it isn't a suffix
+	assert.Equal(t, []byte("example.com"), e.Nearest)
+	assert.Equal(t, len("ample.com"), e.NearestShared)
+
+	e, _ = tree.Explain([]byte("example.com"))
+	assert.True(t, e.Exact)
+	assert.Equal(t, []byte("example.com"), e.Matched)
+	assert.Equal(t, []byte{}, e.Rest)
+	assert.Equal(t, len("example.com"), e.NearestShared)
+
+	e, _ = tree.Explain([]byte("net"))
+	assert.Nil(t, e.Matched)
+	assert.Equal(t, 0, e.Consumed)
+	assert.Equal(t, []byte("net"), e.Rest)
+	assert.Equal(t, []byte("com"), e.Nearest)
+	assert.Equal(t, 0, e.NearestShared)
+
+	e, _ = NewTree().Explain([]byte("com"))
+	assert.Nil(t, e.Nearest)
+	assert.Empty(t, e.Steps)
+
+	_, err = tree.Explain(nil)
+	assert.NotNil(t, err)
+}
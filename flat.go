@@ -0,0 +1,617 @@
+package suffix
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The flat layout written by WriteFlat. All integers are little-endian, whatever the byte
+// order and word size of the host, so a layout written on amd64 can be mapped on arm64,
+// 32-bit platforms or wasm as is.
+//
+//	header: magic "SFXF", version, leaf count, and the offsets of labels, nodes and values
+//	labels: all edge labels concatenated, padded with zeros to a multiple of the integer size
+//	nodes:  node records in BFS order, starting from the root. Each record is the edge count,
+//		followed by three integers per edge: the label offset in labels, the label length
+//		and the target. If the highest bit of the target is set, the rest bits are the
+//		index of a leaf, otherwise the target is the offset of the child node in nodes.
+//	values: leaf count + 1 offsets into the value data, followed by the value data.
+//		The value of leaf i is encoded like MarshalBinary in data[offset[i]:offset[i+1]].
+//
+// The version is always an uint32 after the magic. Versions 1 and 2 use uint32 for the other
+// integers, so the header, labels and nodes together can be at most 4GiB, and so can the value
+// data. Version 3 uses uint64 for them, and WriteFlat only writes it for the trees too large
+// for version 2.
+//
+// Since version 2, every integer is aligned to its size from the start of the layout, so it
+// can be read with a single aligned load when the layout is mapped. Version 1 has no padding
+// after the labels, and it is still readable.
+const (
+	flatMagic = "SFXF"
+	// Version 1 doesn't align the nodes
+	flatVersionUnaligned = 1
+	flatVersion          = 2
+	// Version 3 uses 64-bit integers
+	flatVersionWide = 3
+	// The header size of versions 1 and 2, which is also the minimum size of a layout
+	flatHeaderSize     = 24
+	flatWideHeaderSize = 40
+)
+
+// flatWidth is the size of the integers in the flat layout, 4 or 8 bytes.
+type flatWidth uint64
+
+func (w flatWidth) leafFlag() uint64 {
+	return 1 << (8*w - 1)
+}
+
+func (w flatWidth) edgeSize() uint64 {
+	return 3 * uint64(w)
+}
+
+// WriteFlat writes the tree in the flat layout, which can be queried by FlatTree without
+// decoding. The values should be nil, booleans, numbers, strings or []byte.
+func (tree *Tree) WriteFlat(w io.Writer) (n int64, err error) {
+	return tree.writeFlat(w, false)
+}
+
+// writeFlat writes the flat layout in version 2, or in version 3 if wide is set or the tree
+// is too large for version 2. The sizes and offsets are computed first, and then the sections
+// are streamed to w, so the layout is never held in memory.
+func (tree *Tree) writeFlat(w io.Writer, wide bool) (n int64, err error) {
+	var nodeList []*_Node
+	queue := []*_Node{tree.root}
+	edgesNum, labelsSize := uint64(0), uint64(0)
+	// The values are encoded twice, for their offsets here and for writing them later, so
+	// only the offsets are kept
+	var valueOffsets []uint64
+	var valueBuf []byte
+	valuesSize := uint64(0)
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		edgesNum += uint64(len(node.edges))
+		nodeList = append(nodeList, node)
+		for _, edge := range node.edges {
+			labelsSize += uint64(len(edge.label))
+			switch point := edge.point.(type) {
+			case *_Leaf:
+				valueOffsets = append(valueOffsets, valuesSize)
+				valueBuf, err = appendValue(valueBuf[:0], point.value)
+				if err != nil {
+					return 0, err
+				}
+				valuesSize += uint64(len(valueBuf))
+			case *_Node:
+				queue = append(queue, point)
+			}
+		}
+	}
+	valueOffsets = append(valueOffsets, valuesSize)
+
+	version, width, headerSize := uint32(flatVersion), flatWidth(4), uint64(flatHeaderSize)
+	// The offsets of the sections, written in the header
+	var nodesOff, valuesOff uint64
+	layout := func() {
+		nodesOff = headerSize + alignFlat(labelsSize, width)
+		valuesOff = nodesOff + uint64(width)*uint64(len(nodeList)) + width.edgeSize()*edgesNum
+	}
+	layout()
+	if wide || valuesOff > math.MaxUint32 || valuesSize > math.MaxUint32 ||
+		uint64(len(valueOffsets)) > width.leafFlag() {
+		version, width, headerSize = flatVersionWide, 8, flatWideHeaderSize
+		layout()
+	}
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	var tmp [8]byte
+	putInt := func(x uint64) {
+		if width == 8 {
+			binary.LittleEndian.PutUint64(tmp[:], x)
+		} else {
+			binary.LittleEndian.PutUint32(tmp[:], uint32(x))
+		}
+		bw.Write(tmp[:width])
+	}
+	bw.WriteString(flatMagic)
+	binary.LittleEndian.PutUint32(tmp[:], version)
+	bw.Write(tmp[:4])
+	putInt(uint64(len(valueOffsets) - 1))
+	putInt(headerSize)
+	putInt(nodesOff)
+	putInt(valuesOff)
+	for _, node := range nodeList {
+		for _, edge := range node.edges {
+			bw.Write(edge.label)
+		}
+	}
+	for i := headerSize + labelsSize; i < nodesOff; i++ {
+		bw.WriteByte(0)
+	}
+
+	nodeOffsets := make(map[*_Node]uint64, len(nodeList))
+	nodesSize := uint64(0)
+	for _, node := range nodeList {
+		nodeOffsets[node] = nodesSize
+		nodesSize += uint64(width) + width.edgeSize()*uint64(len(node.edges))
+	}
+	labelOff, leaf := uint64(0), uint64(0)
+	for _, node := range nodeList {
+		putInt(uint64(len(node.edges)))
+		for _, edge := range node.edges {
+			putInt(labelOff)
+			putInt(uint64(len(edge.label)))
+			labelOff += uint64(len(edge.label))
+			switch point := edge.point.(type) {
+			case *_Leaf:
+				putInt(width.leafFlag() | leaf)
+				leaf++
+			case *_Node:
+				putInt(nodeOffsets[point])
+			}
+		}
+	}
+	for _, off := range valueOffsets {
+		putInt(off)
+	}
+	for _, node := range nodeList {
+		for _, edge := range node.edges {
+			if leaf, ok := edge.point.(*_Leaf); ok {
+				// The values have been encoded once, so they can't fail
+				valueBuf, _ = appendValue(valueBuf[:0], leaf.value)
+				bw.Write(valueBuf)
+			}
+		}
+	}
+	err = bw.Flush()
+	return cw.n, err
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// alignFlat rounds n up to a multiple of width.
+func alignFlat(n uint64, width flatWidth) uint64 {
+	return (n + uint64(width) - 1) &^ (uint64(width) - 1)
+}
+
+// FlatTree is a read-only suffix tree, which reads the nodes directly from the flat layout
+// written by WriteFlat. Loading a FlatTree costs nothing no matter how large it is, but each
+// query is a bit slower than Tree. For layouts larger than the memory, use OpenPaged.
+//
+// The data is checked while it is read. The queries treat a corrupted layout or a failed read
+// as not found, and their Try variants, like TryGet, return the error.
+type FlatTree struct {
+	// Either data or pages is set
+	data         []byte
+	pages        *pageCache
+	width        flatWidth
+	labels       flatSection
+	nodes        flatSection
+	valueOffsets flatSection
+	values       flatSection
+	leavesNum    int
+	// Set if the layout is mapped or opened from a file
+	closer func() error
+}
+
+// flatSection is a range of the flat layout.
+type flatSection struct {
+	off, size uint64
+}
+
+// newFlatTree checks the header of a flat layout of the given size, and creates a FlatTree
+// without data. The header should be flatWideHeaderSize bytes unless the layout is shorter.
+func newFlatTree(header []byte, size uint64) (*FlatTree, error) {
+	if len(header) < flatHeaderSize || !bytes.HasPrefix(header, []byte(flatMagic)) {
+		return nil, fmt.Errorf("suffix: not a tree in flat layout")
+	}
+	header = header[len(flatMagic):]
+	version := binary.LittleEndian.Uint32(header)
+	header = header[4:]
+	width := flatWidth(4)
+	switch version {
+	case flatVersionUnaligned, flatVersion:
+	case flatVersionWide:
+		if len(header) < flatWideHeaderSize-8 {
+			return nil, fmt.Errorf("suffix: invalid flat layout header")
+		}
+		width = 8
+	default:
+		return nil, fmt.Errorf("suffix: unsupported flat layout version %d", version)
+	}
+	readInt := func() uint64 {
+		var x uint64
+		if width == 8 {
+			x = binary.LittleEndian.Uint64(header)
+		} else {
+			x = uint64(binary.LittleEndian.Uint32(header))
+		}
+		header = header[width:]
+		return x
+	}
+	leavesNum := readInt()
+	labelsOff := readInt()
+	nodesOff := readInt()
+	valuesOff := readInt()
+	headerSize := uint64(len(flatMagic)) + 4 + 4*uint64(width)
+	aligned := nodesOff%uint64(width) == 0 && valuesOff%uint64(width) == 0
+	// Check the leaf count first, so the offset of the value data can't overflow
+	if leavesNum >= width.leafFlag() || leavesNum > size ||
+		uint64(int(leavesNum)) != leavesNum {
+		return nil, fmt.Errorf("suffix: invalid flat layout header")
+	}
+	valueDataOff := valuesOff + uint64(width)*(leavesNum+1)
+	if labelsOff != headerSize || nodesOff < labelsOff || nodesOff > size ||
+		valuesOff < nodesOff+uint64(width) || valuesOff > size || valueDataOff > size ||
+		(version != flatVersionUnaligned && !aligned) {
+		return nil, fmt.Errorf("suffix: invalid flat layout header")
+	}
+	return &FlatTree{
+		width:        width,
+		labels:       flatSection{labelsOff, nodesOff - labelsOff},
+		nodes:        flatSection{nodesOff, valuesOff - nodesOff},
+		valueOffsets: flatSection{valuesOff, valueDataOff - valuesOff},
+		values:       flatSection{valueDataOff, size - valueDataOff},
+		leavesNum:    int(leavesNum),
+	}, nil
+}
+
+// NewFlatTree creates a FlatTree over data written by WriteFlat. The data is referred by
+// the FlatTree, so don't modify it.
+func NewFlatTree(data []byte) (*FlatTree, error) {
+	tree, err := newFlatTree(data, uint64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	tree.data = data
+	return tree, nil
+}
+
+// ErrClosed is returned by the queries of a FlatTree after Close.
+var ErrClosed = errors.New("suffix: flat tree is closed")
+
+// Close releases the file opened by OpenMapped or OpenPaged. The queries return ErrClosed
+// after Close, or nothing if they don't return an error.
+func (tree *FlatTree) Close() error {
+	var err error
+	if tree.closer != nil {
+		err = tree.closer()
+		tree.closer = nil
+	}
+	tree.data, tree.pages = nil, nil
+	return err
+}
+
+// read returns n bytes at off of the section. The range should have been checked.
+func (tree *FlatTree) read(section flatSection, off, n uint64) ([]byte, error) {
+	start := section.off + off
+	if tree.pages != nil {
+		return tree.pages.read(start, n)
+	}
+	if tree.data == nil {
+		return nil, ErrClosed
+	}
+	return tree.data[start : start+n : start+n], nil
+}
+
+func (tree *FlatTree) intAt(section flatSection, off uint64) (uint64, error) {
+	width := uint64(tree.width)
+	if off > section.size || section.size-off < width {
+		return 0, fmt.Errorf("%w: flat tree offset %d out of range", ErrCorrupted, off)
+	}
+	b, err := tree.read(section, off, width)
+	if err != nil {
+		return 0, err
+	}
+	if width == 8 {
+		return binary.LittleEndian.Uint64(b), nil
+	}
+	return uint64(binary.LittleEndian.Uint32(b)), nil
+}
+
+func (tree *FlatTree) edgeNum(node uint64) (int, error) {
+	n, err := tree.intAt(tree.nodes, node)
+	if err != nil {
+		return 0, err
+	}
+	if n > tree.nodes.size {
+		return 0, fmt.Errorf("%w: flat tree node %d has %d edges", ErrCorrupted, node, n)
+	}
+	return int(n), nil
+}
+
+// isLeaf reports whether the target of an edge is a leaf.
+func (tree *FlatTree) isLeaf(target uint64) bool {
+	return target&tree.width.leafFlag() != 0
+}
+
+// edge returns the label and the target of the i-th edge of node.
+func (tree *FlatTree) edge(node uint64, i int) (label []byte, target uint64, err error) {
+	base := node + uint64(tree.width) + uint64(i)*tree.width.edgeSize()
+	off, err := tree.intAt(tree.nodes, base)
+	if err != nil {
+		return nil, 0, err
+	}
+	size, err := tree.intAt(tree.nodes, base+uint64(tree.width))
+	if err != nil {
+		return nil, 0, err
+	}
+	target, err = tree.intAt(tree.nodes, base+2*uint64(tree.width))
+	if err != nil {
+		return nil, 0, err
+	}
+	if off > tree.labels.size || size > tree.labels.size-off {
+		return nil, 0, fmt.Errorf("%w: flat tree label at %d out of range", ErrCorrupted, off)
+	}
+	if !tree.isLeaf(target) && target <= node {
+		// Nodes are in BFS order, so this also prevents loops
+		return nil, 0, fmt.Errorf("%w: flat tree node %d points back to %d",
+			ErrCorrupted, node, target)
+	}
+	label, err = tree.read(tree.labels, off, size)
+	return label, target, err
+}
+
+func (tree *FlatTree) value(target uint64) (interface{}, error) {
+	i := target &^ tree.width.leafFlag()
+	if i >= uint64(tree.leavesNum) {
+		return nil, fmt.Errorf("%w: flat tree value %d out of range", ErrCorrupted, i)
+	}
+	width := uint64(tree.width)
+	start, err := tree.intAt(tree.valueOffsets, width*i)
+	if err != nil {
+		return nil, err
+	}
+	end, err := tree.intAt(tree.valueOffsets, width*(i+1))
+	if err != nil {
+		return nil, err
+	}
+	if start > end || end > tree.values.size {
+		return nil, fmt.Errorf("%w: flat tree value %d out of range", ErrCorrupted, i)
+	}
+	data, err := tree.read(tree.values, start, end-start)
+	if err != nil {
+		return nil, err
+	}
+	d := decodeBuffer{data: data}
+	value, err := d.value()
+	if err != nil {
+		return nil, fmt.Errorf("%w: flat tree value %d: %v", ErrCorrupted, i, err)
+	}
+	return value, nil
+}
+
+// Len returns the number of keys in the tree.
+func (tree *FlatTree) Len() int {
+	return tree.leavesNum
+}
+
+// Get is like Tree.Get. The []byte values share memory with the FlatTree and must not
+// be modified. If the layout can't be read, it returns not found, see TryGet.
+func (tree *FlatTree) Get(key []byte) (value interface{}, found bool) {
+	value, found, _ = tree.TryGet(key)
+	return value, found
+}
+
+// TryGet is like Get, but returns the error if the layout can't be read: ErrClosed after
+// Close, an error wrapping ErrCorrupted for a corrupted layout, or the error of the reader
+// of a paged FlatTree.
+func (tree *FlatTree) TryGet(key []byte) (value interface{}, found bool, err error) {
+	if key == nil {
+		return nil, false, nil
+	}
+	node := uint64(0)
+	for {
+		n, err := tree.edgeNum(node)
+		if err != nil {
+			return nil, false, err
+		}
+		next := false
+		for i := 0; i < n; i++ {
+			label, target, err := tree.edge(node, i)
+			if err != nil {
+				return nil, false, err
+			}
+			if !bytes.HasSuffix(key, label) {
+				continue
+			}
+			subKey := key[:len(key)-len(label)]
+			if tree.isLeaf(target) {
+				if len(subKey) == 0 {
+					value, err = tree.value(target)
+					if err != nil {
+						return nil, false, err
+					}
+					return value, true, nil
+				}
+				continue
+			}
+			node, key, next = target, subKey, true
+			break
+		}
+		if !next {
+			return nil, false, nil
+		}
+	}
+}
+
+// LongestSuffix is like Tree.LongestSuffix. The matchedKey is a slice of the given key.
+// If the layout can't be read, it returns not found, see TryLongestSuffix.
+func (tree *FlatTree) LongestSuffix(key []byte) (matchedKey []byte, value interface{}, found bool) {
+	matchedKey, value, found, _ = tree.TryLongestSuffix(key)
+	return matchedKey, value, found
+}
+
+// TryLongestSuffix is like LongestSuffix, but returns the error if the layout can't be read
+// like TryGet.
+func (tree *FlatTree) TryLongestSuffix(key []byte) (matchedKey []byte, value interface{},
+	found bool, err error) {
+
+	if key == nil {
+		return nil, nil, false, nil
+	}
+	node := uint64(0)
+	rest := key
+	for {
+		n, err := tree.edgeNum(node)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		next := false
+		for i := 0; i < n; i++ {
+			label, target, err := tree.edge(node, i)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			if !bytes.HasSuffix(rest, label) {
+				continue
+			}
+			subKey := rest[:len(rest)-len(label)]
+			if tree.isLeaf(target) {
+				value, err = tree.value(target)
+				if err != nil {
+					return nil, nil, false, err
+				}
+				matchedKey, found = key[len(subKey):], true
+				if len(label) == 0 {
+					// Look for a longer one
+					continue
+				}
+				return matchedKey, value, found, nil
+			}
+			node, rest, next = target, subKey, true
+			break
+		}
+		if !next {
+			return matchedKey, value, found, nil
+		}
+	}
+}
+
+func (tree *FlatTree) matchSequence(label []byte, target uint64, key []byte) (bool, error) {
+	if len(key) <= len(label) {
+		return bytes.HasSuffix(label, key), nil
+	}
+	if !bytes.HasSuffix(key, label) || tree.isLeaf(target) {
+		return false, nil
+	}
+	subKey := key[:len(key)-len(label)]
+	n, err := tree.edgeNum(target)
+	if err != nil {
+		return false, err
+	}
+	for i := 0; i < n; i++ {
+		childLabel, childTarget, err := tree.edge(target, i)
+		if err != nil {
+			return false, err
+		}
+		matched, err := tree.matchSequence(childLabel, childTarget, subKey)
+		if matched || err != nil {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+func (tree *FlatTree) hasSequence(node uint64, key []byte) (bool, error) {
+	n, err := tree.edgeNum(node)
+	if err != nil {
+		return false, err
+	}
+	for i := 0; i < n; i++ {
+		label, target, err := tree.edge(node, i)
+		if err != nil {
+			return false, err
+		}
+		for end := len(label); end > 0; end-- {
+			matched, err := tree.matchSequence(label[:end], target, key)
+			if matched || err != nil {
+				return matched, err
+			}
+		}
+		if !tree.isLeaf(target) {
+			if found, err := tree.hasSequence(target, key); found || err != nil {
+				return found, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// HasSequence is like Tree.HasSequence. If the layout can't be read, it returns false, see
+// TryHasSequence.
+func (tree *FlatTree) HasSequence(key []byte) bool {
+	found, _ := tree.TryHasSequence(key)
+	return found
+}
+
+// TryHasSequence is like HasSequence, but returns the error if the layout can't be read like
+// TryGet.
+func (tree *FlatTree) TryHasSequence(key []byte) (bool, error) {
+	if key == nil || tree.leavesNum == 0 {
+		return false, nil
+	}
+	if len(key) == 0 {
+		return true, nil
+	}
+	return tree.hasSequence(0, key)
+}
+
+func (tree *FlatTree) walk(node uint64, labels [][]byte,
+	f func(key []byte, value interface{}) bool) (stop bool, err error) {
+
+	n, err := tree.edgeNum(node)
+	if err != nil {
+		return true, err
+	}
+	for i := 0; i < n; i++ {
+		label, target, err := tree.edge(node, i)
+		if err != nil {
+			return true, err
+		}
+		if tree.isLeaf(target) {
+			value, err := tree.value(target)
+			if err != nil {
+				return true, err
+			}
+			key := append([]byte{}, label...)
+			for j := len(labels) - 1; j >= 0; j-- {
+				key = append(key, labels[j]...)
+			}
+			if f(key, value) {
+				return true, nil
+			}
+		} else if stop, err := tree.walk(target, append(labels, label), f); stop {
+			return true, err
+		}
+	}
+	return false, nil
+}
+
+// Walk is like Tree.Walk. If the layout can't be read, it stops walking, see TryWalk.
+func (tree *FlatTree) Walk(f func(key []byte, value interface{}) (stop bool)) {
+	tree.TryWalk(f)
+}
+
+// TryWalk is like Walk, but returns the error if the layout can't be read like TryGet. The
+// keys walked before the error have been passed to f.
+func (tree *FlatTree) TryWalk(f func(key []byte, value interface{}) (stop bool)) error {
+	_, err := tree.walk(0, nil, f)
+	return err
+}
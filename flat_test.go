@@ -0,0 +1,304 @@
+package suffix
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var updateGolden = flag.Bool("update", false, "Update the golden files in testdata")
+
+func flatten(t *testing.T, tree *Tree) *FlatTree {
+	var buf bytes.Buffer
+	n, err := tree.WriteFlat(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	flat, err := NewFlatTree(buf.Bytes())
+	assert.Nil(t, err)
+	return flat
+}
+
+func assertSameAsFlat(t *testing.T, tree *Tree, flat *FlatTree, queries []string) {
+	assert.Equal(t, tree.Len(), flat.Len())
+	for _, q := range queries {
+		key := []byte(q)
+		expectedValue, expectedFound := tree.Get(key)
+		value, found := flat.Get(key)
+		assert.Equal(t, expectedFound, found, "Get %q", q)
+		assert.Equal(t, expectedValue, value, "Get %q", q)
+
+		expectedKey, expectedValue, expectedFound := tree.LongestSuffix(key)
+		matchedKey, value, found := flat.LongestSuffix(key)
+		assert.Equal(t, expectedFound, found, "LongestSuffix %q", q)
+		assert.Equal(t, string(expectedKey), string(matchedKey), "LongestSuffix %q", q)
+		assert.Equal(t, expectedValue, value, "LongestSuffix %q", q)
+
+		assert.Equal(t, tree.HasSequence(key), flat.HasSequence(key), "HasSequence %q", q)
+	}
+
+	var expectedKeys, keys []string
+	tree.Walk(func(key []byte, value interface{}) bool {
+		expectedKeys = append(expectedKeys, string(key))
+		return false
+	})
+	flat.Walk(func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return false
+	})
+	assert.Equal(t, expectedKeys, keys)
+}
+
+func TestFlatTree(t *testing.T) {
+	lists, tree := getFixtures()
+	flat := flatten(t, tree)
+	queries := append([]string{
+		"", "vegetable", "ble", "bl", "xyz", "something else", "words", "sword",
+	}, lists...)
+	assertSameAsFlat(t, tree, flat, queries)
+
+	tree.Insert([]byte{}, 1)
+	tree.Insert([]byte("able"), []byte("able"))
+	assertSameAsFlat(t, tree, flatten(t, tree), queries)
+
+	_, found := flat.Get(nil)
+	assert.False(t, found)
+	_, _, found = flat.LongestSuffix(nil)
+	assert.False(t, found)
+	assert.False(t, flat.HasSequence(nil))
+	assert.False(t, flatten(t, NewTree()).HasSequence([]byte{}))
+
+	count := 0
+	flat.Walk(func(key []byte, value interface{}) bool {
+		count++
+		return string(key) == "believable"
+	})
+	assert.Equal(t, 4, count)
+}
+
+func TestFlatTree_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	randomKey := func() string {
+		b := make([]byte, r.Intn(8))
+		for i := range b {
+			b[i] = "abc"[r.Intn(3)]
+		}
+		return string(b)
+	}
+	for i := 0; i < 20; i++ {
+		tree := NewTree()
+		var queries []string
+		for j := 0; j < 50; j++ {
+			key := randomKey()
+			tree.Insert([]byte(key), j)
+			queries = append(queries, key, randomKey())
+		}
+		assertSameAsFlat(t, tree, flatten(t, tree), queries)
+	}
+}
+
+func TestOpenMapped(t *testing.T) {
+	lists, tree := getFixtures()
+	dir, err := ioutil.TempDir("", "suffix_test_")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tree")
+	f, err := os.Create(path)
+	assert.Nil(t, err)
+	_, err = tree.WriteFlat(f)
+	assert.Nil(t, err)
+	f.Close()
+
+	flat, err := OpenMapped(path)
+	assert.Nil(t, err)
+	assertSameAsFlat(t, tree, flat, lists)
+	assert.Nil(t, flat.Close())
+	assert.Nil(t, flat.Close())
+
+	_, err = OpenMapped(filepath.Join(dir, "nonexist"))
+	assert.NotNil(t, err)
+	assert.Nil(t, ioutil.WriteFile(path, []byte("SFXF"), 0644))
+	_, err = OpenMapped(path)
+	assert.NotNil(t, err)
+}
+
+func TestNewFlatTree_Invalid(t *testing.T) {
+	_, tree := getFixtures()
+	var buf bytes.Buffer
+	tree.WriteFlat(&buf)
+	data := buf.Bytes()
+
+	_, err := NewFlatTree(data[:flatHeaderSize-1])
+	assert.EqualError(t, err, "suffix: not a tree in flat layout")
+	_, err = NewFlatTree(data[:flatHeaderSize])
+	assert.EqualError(t, err, "suffix: invalid flat layout header")
+	corrupted := append([]byte{}, data...)
+	corrupted[4] = 4
+	_, err = NewFlatTree(corrupted)
+	assert.EqualError(t, err, "suffix: unsupported flat layout version 4")
+	// Version 3 has a longer header
+	corrupted[4] = 3
+	_, err = NewFlatTree(corrupted[:flatWideHeaderSize-1])
+	assert.EqualError(t, err, "suffix: invalid flat layout header")
+	// Move the nodes to an unaligned offset
+	corrupted = append([]byte{}, data...)
+	corrupted[16]++
+	_, err = NewFlatTree(corrupted)
+	assert.EqualError(t, err, "suffix: invalid flat layout header")
+
+	// Let the first edge of root point to root
+	corrupted = append([]byte{}, data...)
+	flat, err := NewFlatTree(corrupted)
+	assert.Nil(t, err)
+	nodesOff := int(flat.nodes.off)
+	for i := 0; i < 4; i++ {
+		corrupted[nodesOff+12+i] = 0
+	}
+	err = flat.TryWalk(func(key []byte, value interface{}) bool {
+		return false
+	})
+	assert.EqualError(t, err, "suffix: corrupted data: flat tree node 0 points back to 0")
+	assert.True(t, errors.Is(err, ErrCorrupted))
+	// The queries without error don't crash
+	assert.NotPanics(t, func() {
+		flat.Walk(func(key []byte, value interface{}) bool {
+			return false
+		})
+		flat.Get([]byte("table"))
+		flat.LongestSuffix([]byte("table"))
+		flat.HasSequence([]byte("table"))
+	})
+	_, _, err = flat.TryGet([]byte("table"))
+	assert.True(t, errors.Is(err, ErrCorrupted))
+	_, _, _, err = flat.TryLongestSuffix([]byte("table"))
+	assert.True(t, errors.Is(err, ErrCorrupted))
+	_, err = flat.TryHasSequence([]byte("table"))
+	assert.True(t, errors.Is(err, ErrCorrupted))
+
+	_, err = NewTree().WriteFlat(&buf)
+	assert.Nil(t, err)
+	tree.Insert([]byte("sth"), struct{}{})
+	_, err = tree.WriteFlat(&buf)
+	assert.NotNil(t, err)
+}
+
+// getGoldenTree returns the tree stored in testdata/flat_v*.golden.
+func getGoldenTree() *Tree {
+	tree := NewTree()
+	keys := []string{"", "com", "example.com", "a.example.com", "org", "test.org", "bytes.org"}
+	values := []interface{}{nil, true, -1, uint32(7), 2.5, "str", []byte{0, 1, 2}}
+	for i, key := range keys {
+		tree.Insert([]byte(key), values[i])
+	}
+	return tree
+}
+
+// The flat layout doesn't depend on the architecture, so the golden files are the same
+// everywhere. Run the tests with GOARCH=386 or arm64 to check it.
+func TestFlatTree_Golden(t *testing.T) {
+	tree := getGoldenTree()
+	for version, wide := range map[int]bool{2: false, 3: true} {
+		var buf bytes.Buffer
+		_, err := tree.writeFlat(&buf, wide)
+		assert.Nil(t, err)
+		path := filepath.Join("testdata", fmt.Sprintf("flat_v%d.golden", version))
+		if *updateGolden {
+			assert.Nil(t, ioutil.WriteFile(path, buf.Bytes(), 0644))
+		}
+		golden, err := ioutil.ReadFile(path)
+		assert.Nil(t, err)
+		assert.Equal(t, golden, buf.Bytes())
+	}
+
+	queries := []string{"", "com", "a.example.com", "b.example.com", "org", "bytes.org", "x"}
+	for _, name := range []string{"flat_v1.golden", "flat_v2.golden", "flat_v3.golden"} {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+		assert.Nil(t, err)
+		flat, err := NewFlatTree(data)
+		assert.Nil(t, err, name)
+		assertSameAsFlat(t, tree, flat, queries)
+	}
+}
+
+func TestFlatTree_Aligned(t *testing.T) {
+	for _, tree := range []*Tree{NewTree(), getGoldenTree()} {
+		_, fixtures := getFixtures()
+		for i := 0; i < 4; i++ {
+			tree.Insert(bytes.Repeat([]byte{'x'}, i+1), nil)
+			flat := flatten(t, tree)
+			assert.Equal(t, uint64(0), flat.nodes.off%4)
+			assert.Equal(t, uint64(0), flat.valueOffsets.off%4)
+		}
+		fixtures.Walk(func(key []byte, value interface{}) bool {
+			tree.Insert(key, value)
+			return false
+		})
+		assertSameAsFlat(t, tree, flatten(t, tree), []string{"table", "xx", "sth"})
+	}
+}
+
+func TestFlatTree_Wide(t *testing.T) {
+	lists, tree := getFixtures()
+	tree.Insert([]byte{}, 1)
+	tree.Insert([]byte("able"), []byte("able"))
+	var buf bytes.Buffer
+	n, err := tree.writeFlat(&buf, true)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	flat, err := NewFlatTree(buf.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, flatWidth(8), flat.width)
+	assert.Equal(t, uint64(0), flat.nodes.off%8)
+	assert.Equal(t, uint64(0), flat.valueOffsets.off%8)
+	assertSameAsFlat(t, tree, flat, append([]string{"", "vegetable", "ble", "xyz"}, lists...))
+
+	paged, err := NewPagedFlatTree(bytes.NewReader(buf.Bytes()), int64(buf.Len()),
+		&PageCacheOptions{PageSize: 64})
+	assert.Nil(t, err)
+	assertSameAsFlat(t, tree, paged, lists)
+
+	// The trees fitting in version 2 are written in it
+	assert.Equal(t, flatWidth(4), flatten(t, tree).width)
+}
+
+func TestWriteFlat_WriterError(t *testing.T) {
+	_, tree := getFixtures()
+	_, err := tree.WriteFlat(failedWriter{})
+	assert.EqualError(t, err, "disk full")
+
+	// The layout is streamed in chunks, and n counts all of them
+	for i := 0; i < 1000; i++ {
+		tree.Insert([]byte(strconv.Itoa(i)), strings.Repeat("x", i%20))
+	}
+	var buf bytes.Buffer
+	n, err := tree.writeFlat(&buf, true)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	assert.True(t, n > 4096)
+	flat, err := NewFlatTree(buf.Bytes())
+	assert.Nil(t, err)
+	assertSameAsFlat(t, tree, flat, []string{"1", "999", "table"})
+}
+
+func TestFlatTree_Closed(t *testing.T) {
+	_, tree := getFixtures()
+	flat := flatten(t, tree)
+	assert.Nil(t, flat.Close())
+	_, found, err := flat.TryGet([]byte("table"))
+	assert.Equal(t, ErrClosed, err)
+	assert.False(t, found)
+	_, _, found = flat.LongestSuffix([]byte("table"))
+	assert.False(t, found)
+	assert.Equal(t, ErrClosed, flat.TryWalk(func(key []byte, value interface{}) bool {
+		return false
+	}))
+}
@@ -0,0 +1,194 @@
+package suffix
+
+import (
+	"bytes"
+	"math/bits"
+	"sort"
+)
+
+const (
+	// The rows between two checkpoints of the byte counts
+	fmBlockSize = 64
+	// One of this many positions of the text is sampled for Locate
+	fmSampleRate = 32
+)
+
+// FMIndex is a compressed, read-only index of a text, built by TextIndex.BuildFMIndex. It
+// counts and locates the occurrences of patterns like TextIndex, but only keeps the BWT of the
+// text with the byte counts sampled every 64 rows, and the positions of 1/32 of the suffixes.
+// For a text of σ distinct bytes, it takes about 1.25+σ/16 bytes per byte of the text, like
+// 1.5 bytes for DNA, instead of the dozens taken by the nodes of TextIndex. The text itself
+// is not kept.
+//
+// The rows are the suffixes of the text sorted like TextIndex.ToSuffixArray, with the empty
+// suffix as row 0. The positions are kept as uint32, so the text must be shorter than 4GiB.
+type FMIndex struct {
+	// The BWT of the text, with a zero byte in place of the terminator at primary
+	bwt     []byte
+	primary int
+	// alphabet maps the bytes of the text to 1 + their index in checkpoints, 0 if absent
+	alphabet [256]int
+	// starts[c] is the first row starting with c
+	starts [256]int
+	// checkpoints[k*sigma+a] counts the a-th byte of the alphabet in bwt[:k*fmBlockSize]
+	checkpoints []uint32
+	sigma       int
+	// sampled marks the rows whose positions are kept in samples, in the order of the rows.
+	// sampledRanks[w] is the number of set bits in sampled[:w].
+	sampled      []uint64
+	sampledRanks []uint32
+	samples      []uint32
+}
+
+// BuildFMIndex builds the FMIndex of the text, from the suffixes in the order of the leaves.
+func (idx *TextIndex) BuildFMIndex() *FMIndex {
+	text := idx.text
+	n := len(text)
+	sa, _ := idx.ToSuffixArray()
+	fm := &FMIndex{
+		bwt:     make([]byte, n+1),
+		sampled: make([]uint64, (n+1+63)/64),
+	}
+	sample := func(row, pos int) {
+		fm.sampled[row/64] |= 1 << uint(row%64)
+		fm.samples = append(fm.samples, uint32(pos))
+	}
+	if n > 0 {
+		fm.bwt[0] = text[n-1]
+	}
+	// The empty suffix, so the walk of Locate never passes the start of the text
+	sample(0, n)
+	var counts [256]int
+	for i, pos := range sa {
+		row := i + 1
+		if pos == 0 {
+			fm.primary = row
+		} else {
+			fm.bwt[row] = text[pos-1]
+		}
+		if pos%fmSampleRate == 0 {
+			sample(row, pos)
+		}
+	}
+	for _, c := range text {
+		counts[c]++
+	}
+	row := 1
+	for c, count := range counts {
+		fm.starts[c] = row
+		row += count
+		if count > 0 {
+			fm.sigma++
+			fm.alphabet[c] = fm.sigma
+		}
+	}
+
+	blocks := (n+1)/fmBlockSize + 1
+	fm.checkpoints = make([]uint32, blocks*fm.sigma)
+	running := make([]uint32, fm.sigma)
+	for row := 0; row <= n; row++ {
+		if row%fmBlockSize == 0 {
+			copy(fm.checkpoints[row/fmBlockSize*fm.sigma:], running)
+		}
+		if row != fm.primary {
+			running[fm.alphabet[fm.bwt[row]]-1]++
+		}
+	}
+	if (n+1)%fmBlockSize == 0 {
+		copy(fm.checkpoints[(n+1)/fmBlockSize*fm.sigma:], running)
+	}
+
+	fm.sampledRanks = make([]uint32, len(fm.sampled))
+	rank := 0
+	for w, word := range fm.sampled {
+		fm.sampledRanks[w] = uint32(rank)
+		rank += bits.OnesCount64(word)
+	}
+	return fm
+}
+
+// Len returns the length of the indexed text.
+func (fm *FMIndex) Len() int {
+	return len(fm.bwt) - 1
+}
+
+// rank returns the number of c in the BWT before row.
+func (fm *FMIndex) rank(c byte, row int) int {
+	a := fm.alphabet[c]
+	if a == 0 {
+		return 0
+	}
+	block := row / fmBlockSize
+	start := block * fmBlockSize
+	n := int(fm.checkpoints[block*fm.sigma+a-1]) + bytes.Count(fm.bwt[start:row], []byte{c})
+	if c == 0 && start <= fm.primary && fm.primary < row {
+		// The terminator is not a byte of the text
+		n--
+	}
+	return n
+}
+
+// lf returns the row of the suffix one byte longer than the suffix of row, which must not be
+// the whole text.
+func (fm *FMIndex) lf(row int) int {
+	c := fm.bwt[row]
+	return fm.starts[c] + fm.rank(c, row)
+}
+
+// rows returns the range of the rows starting with pattern.
+func (fm *FMIndex) rows(pattern []byte) (start, end int) {
+	start, end = 0, len(fm.bwt)
+	for i := len(pattern) - 1; i >= 0 && start < end; i-- {
+		c := pattern[i]
+		if fm.alphabet[c] == 0 {
+			return 0, 0
+		}
+		start = fm.starts[c] + fm.rank(c, start)
+		end = fm.starts[c] + fm.rank(c, end)
+	}
+	return start, end
+}
+
+// Count returns the number of occurrences of pattern in the text, including the overlapping
+// ones. The empty pattern occurs len(text)+1 times like bytes.Count. It takes O(len(pattern))
+// steps, whatever the length of the text.
+func (fm *FMIndex) Count(pattern []byte) int {
+	start, end := fm.rows(pattern)
+	if start >= end {
+		return 0
+	}
+	return end - start
+}
+
+// Locate returns the starts of the occurrences of pattern in the text in ascending order.
+// If limit is positive and there are more occurrences, only limit of them are returned, and
+// which ones is unspecified. Each occurrence takes up to 32 steps to locate.
+func (fm *FMIndex) Locate(pattern []byte, limit int) []int {
+	start, end := fm.rows(pattern)
+	if start >= end {
+		return nil
+	}
+	if limit > 0 && end-start > limit {
+		end = start + limit
+	}
+	positions := make([]int, 0, end-start)
+	for row := start; row < end; row++ {
+		positions = append(positions, fm.locate(row))
+	}
+	sort.Ints(positions)
+	return positions
+}
+
+// locate returns the position of the suffix of row, by walking to a sampled row.
+func (fm *FMIndex) locate(row int) int {
+	steps := 0
+	for {
+		w, bit := row/64, uint(row%64)
+		if fm.sampled[w]&(1<<bit) != 0 {
+			i := int(fm.sampledRanks[w]) + bits.OnesCount64(fm.sampled[w]&(1<<bit-1))
+			return int(fm.samples[i]) + steps
+		}
+		row = fm.lf(row)
+		steps++
+	}
+}
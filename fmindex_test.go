@@ -0,0 +1,62 @@
+package suffix
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func naiveLocate(text, pattern []byte) []int {
+	var positions []int
+	for i := 0; i+len(pattern) <= len(text); i++ {
+		if string(text[i:i+len(pattern)]) == string(pattern) {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+func TestFMIndex(t *testing.T) {
+	fm := NewTextIndex([]byte("mississippi")).BuildFMIndex()
+	assert.Equal(t, 11, fm.Len())
+	assert.Equal(t, 2, fm.Count([]byte("issi")))
+	assert.Equal(t, 4, fm.Count([]byte("s")))
+	assert.Equal(t, 0, fm.Count([]byte("sp")))
+	assert.Equal(t, 0, fm.Count([]byte("x")))
+	assert.Equal(t, 12, fm.Count(nil))
+	assert.Equal(t, []int{1, 4}, fm.Locate([]byte("issi"), 0))
+	assert.Equal(t, []int{2, 3, 5, 6}, fm.Locate([]byte("s"), -1))
+	assert.Equal(t, 2, len(fm.Locate([]byte("s"), 2)))
+	assert.Nil(t, fm.Locate([]byte("pis"), 0))
+
+	fm = NewTextIndex(nil).BuildFMIndex()
+	assert.Equal(t, 0, fm.Len())
+	assert.Equal(t, 1, fm.Count(nil))
+	assert.Equal(t, []int{0}, fm.Locate(nil, 0))
+	assert.Equal(t, 0, fm.Count([]byte("a")))
+}
+
+func TestFMIndex_Random(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		letters := "ab"
+		if i%2 == 1 {
+			letters = "\x00ab\xff"
+		}
+		text := randomText(letters, rand.Intn(300))
+		fm := NewTextIndex(text).BuildFMIndex()
+		for j := 0; j < 20; j++ {
+			pattern := randomText(letters, rand.Intn(5))
+			expected := naiveLocate(text, pattern)
+			assert.Equal(t, len(expected), fm.Count(pattern), "%q %q", text, pattern)
+			if len(pattern) > 0 {
+				assert.Equal(t, expected, fm.Locate(pattern, 0), "%q %q", text, pattern)
+			}
+		}
+		positions := fm.Locate(nil, 0)
+		assert.Equal(t, len(text)+1, len(positions))
+		for pos := range positions {
+			assert.Equal(t, pos, positions[pos])
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package suffix
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// The binary format written by WriteTo:
+//
+//	magic   "SFXT"
+//	version 1 byte, currently 2
+//	flags   1 byte, bit 0 is set if the sections are compressed, the other bits are reserved
+//	codec   1 byte, only exists if the sections are compressed, the ID of the Codec
+//	sections until the end of data, each of them is
+//		id       uvarint
+//		length   uvarint
+//		payload  length bytes
+//		checksum little-endian CRC-32C of the id, length and payload, since version 2
+//
+// Readers skip sections they don't know, so new sections can be added without breaking
+// them. There are three sections, which must all exist:
+//
+//	labels: all edge labels concatenated, in the order of the node table
+//	nodes:  the node table. Nodes are listed in BFS order, starting from the root.
+//		Each node has an uvarint edge count, followed by an uvarint per edge, which is
+//		the label length shifted left by one, with the lowest bit set if the edge
+//		points to a node. The nodes an edge points to are implied by the BFS order.
+//	values: the values of leaves in the order they appear in the node table, encoded
+//		like MarshalBinary.
+//
+// The labels section comes first and keeps all labels next to each other, so the repeated
+// parts of keys compress well.
+const (
+	formatMagic   = "SFXT"
+	formatVersion = 2
+	// Sections have no checksum in version 1
+	formatVersionNoChecksum = 1
+)
+
+// ErrCorrupted is wrapped by the errors of the decoders reading the data which is damaged,
+// like a checksum mismatch or a malformed record. Truncated data gives io.ErrUnexpectedEOF.
+var ErrCorrupted = errors.New("suffix: corrupted data")
+
+// Flags of the binary format
+const (
+	formatFlagCompressed = 1 << 0
+)
+
+// Section ids of the binary format
+const (
+	sectionLabels = 1
+	sectionNodes  = 2
+	sectionValues = 3
+)
+
+var sectionNames = map[uint64]string{
+	sectionLabels: "labels",
+	sectionNodes:  "nodes",
+	sectionValues: "values",
+}
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+func appendSection(buf []byte, id uint64, payload []byte) []byte {
+	start := len(buf)
+	buf = appendUvarint(buf, id)
+	buf = appendUvarint(buf, uint64(len(payload)))
+	buf = append(buf, payload...)
+	var checksum [4]byte
+	binary.LittleEndian.PutUint32(checksum[:], crc32.Checksum(buf[start:], castagnoliTable))
+	return append(buf, checksum[:]...)
+}
+
+// WriteTo implements io.WriterTo. It writes the tree in a versioned binary format, which keeps
+// the structure of the tree, so that ReadFrom doesn't need to insert the keys again.
+// The values should be nil, booleans, numbers, strings or []byte.
+func (tree *Tree) WriteTo(w io.Writer) (n int64, err error) {
+	sections, err := tree.encodeSections()
+	if err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 0, len(formatMagic)+2+len(sections))
+	buf = append(buf, formatMagic...)
+	buf = append(buf, formatVersion, 0)
+	buf = append(buf, sections...)
+	written, err := w.Write(buf)
+	return int64(written), err
+}
+
+func (tree *Tree) encodeSections() ([]byte, error) {
+	var labels, nodes, values []byte
+	var err error
+	queue := []*_Node{tree.root}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		nodes = appendUvarint(nodes, uint64(len(node.edges)))
+		for _, edge := range node.edges {
+			labels = append(labels, edge.label...)
+			desc := uint64(len(edge.label)) << 1
+			switch point := edge.point.(type) {
+			case *_Leaf:
+				values, err = appendValue(values, point.value)
+				if err != nil {
+					return nil, err
+				}
+			case *_Node:
+				desc |= 1
+				queue = append(queue, point)
+			}
+			nodes = appendUvarint(nodes, desc)
+		}
+	}
+
+	// Enough for the section prefixes
+	buf := make([]byte, 0, len(labels)+len(nodes)+len(values)+64)
+	buf = appendSection(buf, sectionLabels, labels)
+	buf = appendSection(buf, sectionNodes, nodes)
+	buf = appendSection(buf, sectionValues, values)
+	return buf, nil
+}
+
+// ReadFrom implements io.ReaderFrom. It replaces the content of the tree with the data written
+// by WriteTo or WriteCompressed. If the options of the tree change or reject keys, like
+// WithCaseFolding or WithMaxKeyLen, the keys are inserted again through them, otherwise the
+// decoded nodes are used as is.
+func (tree *Tree) ReadFrom(r io.Reader) (n int64, err error) {
+	if tree.frozen {
+		return 0, ErrReadOnly
+	}
+	data, err := ioutil.ReadAll(r)
+	n = int64(len(data))
+	if err != nil {
+		return n, err
+	}
+	newTree, err := decodeFormat(data)
+	if err != nil {
+		return n, err
+	}
+	if tree.rewritesKeys() {
+		// The stored keys are decoded as is, insert them again through the options
+		decoded := newTree
+		newTree = tree.emptyLike()
+		decoded.Walk(func(key []byte, value interface{}) bool {
+			err = newTree.insertDecoded(key, value)
+			return err != nil
+		})
+		if err != nil {
+			return n, err
+		}
+	}
+
+	tree.guard.acquire()
+	defer tree.guard.release(tree)
+	tree.root = newTree.root
+	tree.leavesNum = newTree.leavesNum
+	return n, nil
+}
+
+func decodeFormat(data []byte) (*Tree, error) {
+	if !bytes.HasPrefix(data, []byte(formatMagic)) {
+		return nil, fmt.Errorf("suffix: not a tree in binary format")
+	}
+	d := decodeBuffer{data: data[len(formatMagic):]}
+	header, err := d.bytes(2)
+	if err != nil {
+		return nil, err
+	}
+	version := header[0]
+	if version != formatVersion && version != formatVersionNoChecksum {
+		return nil, fmt.Errorf("suffix: unsupported format version %d", version)
+	}
+	flags := header[1]
+	if flags&^formatFlagCompressed != 0 {
+		return nil, fmt.Errorf("suffix: unsupported format flags %#x", flags)
+	}
+	if flags&formatFlagCompressed != 0 {
+		id, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		d.data, err = decompress(id, d.data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sections := map[uint64][]byte{}
+	for len(d.data) > 0 {
+		section := d.data
+		id, err := d.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		payload, err := d.lenBytes()
+		if err != nil {
+			return nil, err
+		}
+		if version != formatVersionNoChecksum {
+			section = section[:len(section)-len(d.data)]
+			checksum, err := d.bytes(4)
+			if err != nil {
+				return nil, err
+			}
+			if crc32.Checksum(section, castagnoliTable) != binary.LittleEndian.Uint32(checksum) {
+				name, ok := sectionNames[id]
+				if !ok {
+					name = "unknown"
+				}
+				return nil, fmt.Errorf("%w: checksum mismatch in section %d (%s)",
+					ErrCorrupted, id, name)
+			}
+		}
+		sections[id] = payload
+	}
+	for _, id := range []uint64{sectionLabels, sectionNodes, sectionValues} {
+		if _, ok := sections[id]; !ok {
+			return nil, fmt.Errorf("suffix: section %d is missing", id)
+		}
+	}
+	return decodeSections(sections[sectionLabels], sections[sectionNodes],
+		sections[sectionValues])
+}
+
+func decodeSections(labelData, nodeData, valueData []byte) (*Tree, error) {
+	// The labels are referred by the tree, so they can't share the memory with the caller
+	labels := decodeBuffer{data: append([]byte{}, labelData...)}
+	nodes := decodeBuffer{data: nodeData}
+	values := decodeBuffer{data: valueData}
+
+	tree := NewTree()
+	queue := []*_Node{tree.root}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		edgeNum, err := nodes.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if node != tree.root && edgeNum < 2 {
+			return nil, fmt.Errorf("%w: node with %d edges", ErrCorrupted, edgeNum)
+		}
+		if edgeNum > uint64(len(nodes.data)) {
+			// Each edge takes at least one byte
+			return nil, io.ErrUnexpectedEOF
+		}
+		node.edges = make([]*_Edge, edgeNum)
+		for i := range node.edges {
+			desc, err := nodes.uvarint()
+			if err != nil {
+				return nil, err
+			}
+			label, err := labels.bytes(desc >> 1)
+			if err != nil {
+				return nil, err
+			}
+			edge := &_Edge{label: label}
+			if desc&1 == 1 {
+				child := &_Node{}
+				edge.point = child
+				queue = append(queue, child)
+			} else {
+				value, err := values.value()
+				if err != nil {
+					return nil, err
+				}
+				edge.point = &_Leaf{value: value}
+				tree.leavesNum++
+			}
+			node.edges[i] = edge
+		}
+		// Older writers kept the labels with the same length in the insertion order
+		sort.SliceStable(node.edges, func(i, j int) bool {
+			return labelLess(node.edges[i].label, node.edges[j].label)
+		})
+	}
+	if len(labels.data) != 0 || len(nodes.data) != 0 || len(values.data) != 0 {
+		return nil, fmt.Errorf("%w: trailing data in sections", ErrCorrupted)
+	}
+
+	tree.root.fillOriginKeys(nil)
+	// The checksums don't cover the writers breaking the structure, and version 1 has none,
+	// so the tree is checked like Validate before it is used
+	if _, err := tree.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCorrupted, strings.TrimPrefix(err.Error(), "suffix: "))
+	}
+	return tree, nil
+}
+
+// fillOriginKeys sets the originKey of leaves under the node, according to the labels from the
+// node up to the root.
+func (node *_Node) fillOriginKeys(labels [][]byte) {
+	for _, edge := range node.edges {
+		switch point := edge.point.(type) {
+		case *_Leaf:
+			size := len(edge.label)
+			for _, label := range labels {
+				size += len(label)
+			}
+			key := make([]byte, 0, size)
+			key = append(key, edge.label...)
+			for i := len(labels) - 1; i >= 0; i-- {
+				key = append(key, labels[i]...)
+			}
+			point.originKey = key
+		case *_Node:
+			point.fillOriginKeys(append(labels, edge.label))
+		}
+	}
+}
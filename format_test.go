@@ -0,0 +1,213 @@
+package suffix
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ io.WriterTo   = (*Tree)(nil)
+	_ io.ReaderFrom = (*Tree)(nil)
+)
+
+func assertSameStructure(t *testing.T, expected, actual *Tree) {
+	var expectedNodes, actualNodes [][][]byte
+	expected.walkNode(func(labels [][]byte, value interface{}) {
+		expectedNodes = append(expectedNodes, labels)
+	})
+	actual.walkNode(func(labels [][]byte, value interface{}) {
+		actualNodes = append(actualNodes, labels)
+	})
+	assert.Equal(t, expectedNodes, actualNodes)
+}
+
+func TestWriteTo(t *testing.T) {
+	_, tree := getFixtures()
+	tree.Insert([]byte{}, nil)
+	var buf bytes.Buffer
+	n, err := tree.WriteTo(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	newTree := NewTree()
+	newTree.Insert([]byte("sth"), "sth")
+	size := int64(buf.Len())
+	n, err = newTree.ReadFrom(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, size, n)
+	assertSameContent(t, tree, newTree)
+	assertSameStructure(t, tree, newTree)
+	assertGet(t, newTree, "sth", false)
+
+	// The read tree can be modified as usual
+	for _, s := range []string{"sth", "vegetable", "able", "credible"} {
+		newTree.Insert([]byte(s), s)
+		tree.Insert([]byte(s), s)
+	}
+	newTree.Remove([]byte("edible"))
+	tree.Remove([]byte("edible"))
+	assertSameContent(t, tree, newTree)
+	assertSameStructure(t, tree, newTree)
+}
+
+func TestWriteTo_EmptyTree(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := NewTree().WriteTo(&buf)
+	assert.Nil(t, err)
+	tree := NewTree()
+	_, err = tree.ReadFrom(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, tree.Len())
+}
+
+func TestWriteTo_UnsupportedValue(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("sth"), struct{}{})
+	var buf bytes.Buffer
+	_, err := tree.WriteTo(&buf)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, buf.Len())
+}
+
+func TestReadFrom_UnknownSection(t *testing.T) {
+	_, tree := getFixtures()
+	var buf bytes.Buffer
+	tree.WriteTo(&buf)
+	data := appendSection(buf.Bytes(), 100, []byte("from the future"))
+
+	newTree := NewTree()
+	_, err := newTree.ReadFrom(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assertSameContent(t, tree, newTree)
+}
+
+func TestReadFrom_Invalid(t *testing.T) {
+	_, tree := getFixtures()
+	var buf bytes.Buffer
+	tree.WriteTo(&buf)
+	data := buf.Bytes()
+
+	newTree := NewTree()
+	newTree.Insert([]byte("sth"), "sth")
+	readFrom := func(data []byte) error {
+		_, err := newTree.ReadFrom(bytes.NewReader(data))
+		return err
+	}
+	assert.EqualError(t, readFrom([]byte("SFX")), "suffix: not a tree in binary format")
+	assert.Equal(t, io.ErrUnexpectedEOF, readFrom([]byte("SFXT")))
+	assert.EqualError(t, readFrom([]byte("SFXT\x03\x00")), "suffix: unsupported format version 3")
+	assert.EqualError(t, readFrom([]byte("SFXT\x02\x02")), "suffix: unsupported format flags 0x2")
+	assert.EqualError(t, readFrom([]byte("SFXT\x02\x00")), "suffix: section 1 is missing")
+	for i := len(formatMagic) + 2; i < len(data); i++ {
+		assert.NotNil(t, readFrom(data[:i]), "truncated at %d", i)
+	}
+
+	// A node with only one edge
+	var labels, nodes, values []byte
+	nodes = append(nodes, 1, 1, 1, 0)
+	values = append(values, valueNil)
+	data = append([]byte(formatMagic), formatVersion, 0)
+	data = appendSection(data, sectionLabels, labels)
+	data = appendSection(data, sectionNodes, nodes)
+	data = appendSection(data, sectionValues, values)
+	assert.EqualError(t, readFrom(data), "suffix: corrupted data: node with 1 edges")
+	assert.Equal(t, 1, newTree.Len())
+}
+
+func TestReadFrom_Corrupted(t *testing.T) {
+	_, tree := getFixtures()
+	var buf bytes.Buffer
+	tree.WriteTo(&buf)
+	data := buf.Bytes()
+
+	newTree := NewTree()
+	for i := range data {
+		corrupted := append([]byte{}, data...)
+		corrupted[i] ^= 0x10
+		_, err := newTree.ReadFrom(bytes.NewReader(corrupted))
+		assert.NotNil(t, err, "corrupted at %d", i)
+	}
+	assert.Equal(t, 0, newTree.Len())
+
+	corrupted := append([]byte{}, data...)
+	// The first label byte
+	corrupted[len(formatMagic)+2+2] ^= 0x10
+	_, err := newTree.ReadFrom(bytes.NewReader(corrupted))
+	assert.EqualError(t, err,
+		"suffix: corrupted data: checksum mismatch in section 1 (labels)")
+	assert.True(t, errors.Is(err, ErrCorrupted))
+}
+
+func TestReadFrom_Version1(t *testing.T) {
+	// Version 1 has no checksum
+	data := append([]byte(formatMagic), formatVersionNoChecksum, 0)
+	data = append(data, sectionLabels, 3, 's', 't', 'h')
+	data = append(data, sectionNodes, 2, 1, 3<<1)
+	data = append(data, sectionValues, 1, valueNil)
+
+	tree := NewTree()
+	_, err := tree.ReadFrom(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, tree.Len())
+	_, found := tree.Get([]byte("sth"))
+	assert.True(t, found)
+}
+
+func TestReadFrom_UnorderedLabels(t *testing.T) {
+	data := append([]byte(formatMagic), formatVersion, 0)
+	data = appendSection(data, sectionLabels, []byte("baab"))
+	data = appendSection(data, sectionNodes, []byte{2, 2 << 1, 2 << 1})
+	data = appendSection(data, sectionValues, []byte{valueNil, valueNil})
+
+	tree := NewTree()
+	_, err := tree.ReadFrom(bytes.NewReader(data))
+	assert.Nil(t, err)
+	var keys []string
+	tree.Walk(func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return false
+	})
+	assert.Equal(t, []string{"ab", "ba"}, keys)
+}
+
+func TestReadFrom_InvalidStructure(t *testing.T) {
+	for _, version := range []byte{formatVersionNoChecksum, formatVersion} {
+		sections := func(labels string, nodes []byte, values []byte) []byte {
+			data := append([]byte(formatMagic), version, 0)
+			if version == formatVersionNoChecksum {
+				data = append(data, sectionLabels, byte(len(labels)))
+				data = append(data, labels...)
+				data = append(data, sectionNodes, byte(len(nodes)))
+				data = append(data, nodes...)
+				data = append(data, sectionValues, byte(len(values)))
+				return append(data, values...)
+			}
+			data = appendSection(data, sectionLabels, []byte(labels))
+			data = appendSection(data, sectionNodes, nodes)
+			return appendSection(data, sectionValues, values)
+		}
+		tree := NewTree()
+		tree.Insert([]byte("sth"), "sth")
+
+		// Two edges ending with the same byte
+		_, err := tree.ReadFrom(bytes.NewReader(sections("abcb",
+			[]byte{2, 2 << 1, 2 << 1}, []byte{valueNil, valueNil})))
+		assert.EqualError(t, err,
+			`suffix: corrupted data: invalid tree: edges at "" share the common suffix "b"`)
+		assert.True(t, errors.Is(err, ErrCorrupted))
+		// Duplicate keys
+		_, err = tree.ReadFrom(bytes.NewReader(sections("abab",
+			[]byte{2, 2 << 1, 2 << 1}, []byte{valueNil, valueNil})))
+		assert.True(t, errors.Is(err, ErrCorrupted))
+		// An empty label to a node
+		_, err = tree.ReadFrom(bytes.NewReader(sections("ab",
+			[]byte{1, 0<<1 | 1, 2, 1 << 1, 1 << 1}, []byte{valueNil, valueNil})))
+		assert.EqualError(t, err,
+			`suffix: corrupted data: invalid tree: empty label to a node at ""`)
+		assert.Equal(t, 1, tree.Len())
+	}
+}
@@ -0,0 +1,25 @@
+package suffix
+
+import (
+	"errors"
+)
+
+// ErrReadOnly is returned by the mutations of a tree made read-only by Freeze.
+var ErrReadOnly = errors.New("suffix: tree is read-only")
+
+// Freeze makes the tree read-only, so it can be shared by goroutines without a write racing
+// with their reads. TryInsert and TryRemove return ErrReadOnly, the decoders replacing the
+// content of the tree, like UnmarshalBinary and ReadFrom, return ErrReadOnly, and the other
+// mutations do nothing and return false.
+//
+// A frozen tree can't be unfrozen, but its Snapshot is writable, which doesn't copy the
+// nodes until they are modified. Snapshot doesn't modify a frozen tree, so it can be called
+// concurrently too.
+func (tree *Tree) Freeze() {
+	tree.frozen = true
+}
+
+// Frozen reports whether the tree is made read-only by Freeze.
+func (tree *Tree) Frozen() bool {
+	return tree.frozen
+}
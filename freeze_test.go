@@ -0,0 +1,101 @@
+package suffix
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreeze(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("com"), 1)
+	tree.Insert([]byte("example.com"), 2)
+	assert.False(t, tree.Frozen())
+	data, err := tree.MarshalBinary()
+	assert.Nil(t, err)
+
+	tree.Freeze()
+	assert.True(t, tree.Frozen())
+	_, err = tree.TryInsert([]byte("org"), nil)
+	assert.Equal(t, ErrReadOnly, err)
+	_, ok := tree.Insert([]byte("org"), nil)
+	assert.False(t, ok)
+	_, found, err := tree.TryRemove([]byte("com"))
+	assert.False(t, found)
+	assert.Equal(t, ErrReadOnly, err)
+	_, found = tree.Remove([]byte("com"))
+	assert.False(t, found)
+	assert.False(t, tree.CompareAndSwap([]byte("com"), 1, 3))
+	assert.False(t, tree.CompareAndDelete([]byte("com"), 1))
+	assert.Equal(t, ErrReadOnly, tree.UnmarshalBinary(data))
+	assert.Equal(t, ErrReadOnly, tree.UnmarshalJSON([]byte("[]")))
+
+	value, found := tree.Get([]byte("com"))
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 2, tree.Len())
+
+	snapshot := tree.Snapshot()
+	assert.False(t, snapshot.Frozen())
+	_, ok = snapshot.Insert([]byte("org"), nil)
+	assert.True(t, ok)
+	assert.Equal(t, 2, tree.Len())
+}
+
+func TestTryRemove(t *testing.T) {
+	tree := NewTree(WithNilKeyPolicy(RejectEmptyKey))
+	tree.Insert([]byte("com"), 1)
+
+	oldValue, found, err := tree.TryRemove([]byte("com"))
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 1, oldValue)
+	_, found, err = tree.TryRemove([]byte("com"))
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	_, _, err = tree.TryRemove(nil)
+	assert.Equal(t, ErrNilKey, err)
+	_, _, err = tree.TryRemove([]byte{})
+	assert.Equal(t, ErrEmptyKey, err)
+}
+
+func TestSentinelErrors(t *testing.T) {
+	tree := NewTree(WithMaxKeyLen(2))
+	_, err := tree.TryInsert(nil, nil)
+	assert.True(t, errors.Is(err, ErrNilKey))
+	_, err = tree.TryInsert([]byte("com"), nil)
+	assert.True(t, errors.Is(err, ErrKeyTooLong))
+
+	assert.True(t, errors.Is(tree.UnmarshalBinary([]byte{binaryVersion, 0, 0}), ErrCorrupted))
+	assert.True(t, errors.Is(tree.UnmarshalBinary([]byte{binaryVersion, 1, 0, 255}),
+		ErrCorrupted))
+}
+
+func TestFreeze_ConcurrentSnapshot(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("com"), 1)
+	tree.Insert([]byte("example.com"), 2)
+	tree.Freeze()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			snapshot := tree.Snapshot()
+			assert.False(t, snapshot.Frozen())
+			snapshot.Insert([]byte("example.org"), i)
+			snapshot.Remove([]byte("com"))
+			assert.Equal(t, 2, snapshot.Len())
+			tree.Get([]byte("example.com"))
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 2, tree.Len())
+	value, found := tree.Get([]byte("com"))
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+	assert.Nil(t, tree.Validate())
+}
@@ -0,0 +1,52 @@
+package suffix
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spacewander/go-suffix-tree/suffixtest"
+)
+
+// fuzzSeeds are the inputs splitting and merging the labels in the ways which broke before,
+// like the mismatches after the first byte of a label (CASE 3 of insert).
+var fuzzSeeds = [][][]byte{
+	{[]byte("sth"), []byte("else sth"), []byte("any sth"), []byte("th")},
+	{[]byte("abc"), []byte("xbc"), []byte("c"), []byte("")},
+	{[]byte("example.com"), []byte("ample.com"), []byte("com"), []byte("a.example.com")},
+	{[]byte("aaaa"), []byte("aa"), []byte("baaa"), []byte("a")},
+}
+
+func FuzzInsertLookup(f *testing.F) {
+	for _, keys := range fuzzSeeds {
+		for _, seed := range suffixtest.SeedOps(keys) {
+			f.Add(seed, []byte("any sth"))
+			f.Add(seed, keys[0])
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte, query []byte) {
+		tree := NewTree()
+		model := suffixtest.NewModel()
+		for i, op := range suffixtest.DecodeOps(data) {
+			got := suffixtest.Apply(tree, op)
+			want := suffixtest.Apply(model, op)
+			if got != want {
+				t.Fatalf("op %d %v %q: got %v, want %v", i, op.Kind, op.Key, got, want)
+			}
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("op %d %v %q: %v", i, op.Kind, op.Key, err)
+			}
+		}
+		if tree.Len() != model.Len() {
+			t.Fatalf("Len: got %d, want %d", tree.Len(), model.Len())
+		}
+		key, value, found := tree.LongestSuffix(query)
+		wantKey, wantValue, wantFound := model.LongestSuffix(query)
+		if found != wantFound || !bytes.Equal(key, wantKey) || value != wantValue {
+			t.Fatalf("LongestSuffix(%q): got %q %v %v, want %q %v %v", query, key, value,
+				found, wantKey, wantValue, wantFound)
+		}
+		if got, want := tree.HasSequence(query), model.HasSequence(query); got != want {
+			t.Fatalf("HasSequence(%q): got %v, want %v", query, got, want)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package suffix
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+func init() {
+	// Allow trees to be sent as interface values, including the values of other trees
+	gob.Register(&Tree{})
+}
+
+// gobTree is the exported form of Tree for gob.
+type gobTree struct {
+	Keys   [][]byte
+	Values []interface{}
+}
+
+// GobEncode implements gob.GobEncoder. Unlike MarshalBinary, values can be of any type gob
+// supports, but the concrete types stored in the tree need to be registered with gob.Register
+// first, except the basic types which gob registers itself.
+func (tree *Tree) GobEncode() ([]byte, error) {
+	t := gobTree{
+		Keys:   make([][]byte, 0, tree.Len()),
+		Values: make([]interface{}, 0, tree.Len()),
+	}
+	tree.Walk(func(key []byte, value interface{}) bool {
+		t.Keys = append(t.Keys, key)
+		t.Values = append(t.Values, value)
+		return false
+	})
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&t); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder. It replaces the content of the tree with the data
+// encoded by GobEncode. Like UnmarshalBinary, the keys are inserted through the options of
+// the tree.
+func (tree *Tree) GobDecode(data []byte) error {
+	if tree.frozen {
+		return ErrReadOnly
+	}
+	var t gobTree
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&t); err != nil {
+		return err
+	}
+	newTree := tree.emptyLike()
+	for i, key := range t.Keys {
+		// gob decodes empty slice as nil
+		if key == nil {
+			key = []byte{}
+		}
+		var value interface{}
+		if i < len(t.Values) {
+			value = t.Values[i]
+		}
+		if err := newTree.insertDecoded(key, value); err != nil {
+			return err
+		}
+	}
+
+	tree.guard.acquire()
+	defer tree.guard.release(tree)
+	tree.root = newTree.root
+	tree.leavesNum = newTree.leavesNum
+	return nil
+}
@@ -0,0 +1,63 @@
+package suffix
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type gobRule struct {
+	Name  string
+	Score int
+}
+
+func init() {
+	gob.Register(gobRule{})
+}
+
+func TestGob(t *testing.T) {
+	_, tree := getFixtures()
+	tree.Insert([]byte{}, nil)
+	tree.Insert([]byte("rule"), gobRule{"rule", 1})
+
+	var buf bytes.Buffer
+	assert.Nil(t, gob.NewEncoder(&buf).Encode(tree))
+	newTree := NewTree()
+	newTree.Insert([]byte("sth"), "sth")
+	assert.Nil(t, gob.NewDecoder(&buf).Decode(newTree))
+	assertSameContent(t, tree, newTree)
+	assertGet(t, newTree, "sth", false)
+}
+
+func TestGob_Embedded(t *testing.T) {
+	type config struct {
+		Name  string
+		Rules *Tree
+		Any   interface{}
+	}
+	_, tree := getFixtures()
+	nested := NewTree()
+	nested.Insert([]byte("tree"), tree)
+
+	var buf bytes.Buffer
+	assert.Nil(t, gob.NewEncoder(&buf).Encode(config{"sth", tree, nested}))
+	var c config
+	assert.Nil(t, gob.NewDecoder(&buf).Decode(&c))
+	assert.Equal(t, "sth", c.Name)
+	assertSameContent(t, tree, c.Rules)
+	value, found := c.Any.(*Tree).Get([]byte("tree"))
+	assert.True(t, found)
+	assertSameContent(t, tree, value.(*Tree))
+}
+
+func TestGob_Invalid(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("sth"), struct{ unexported int }{})
+	_, err := tree.GobEncode()
+	assert.NotNil(t, err)
+
+	assert.NotNil(t, tree.GobDecode([]byte("invalid")))
+	assert.Equal(t, 1, tree.Len())
+}
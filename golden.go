@@ -0,0 +1,93 @@
+package suffix
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// goldenLines returns the lines of WriteGolden, in the order of the keys.
+func (tree *Tree) goldenLines() []string {
+	type entry struct {
+		key  []byte
+		line string
+	}
+	entries := make([]entry, 0, tree.Len())
+	tree.Walk(func(key []byte, value interface{}) bool {
+		line := quoteKey(key)
+		if value != nil {
+			line += " = " + quoteValue(value)
+		}
+		entries = append(entries, entry{key, line})
+		return false
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = e.line
+	}
+	return lines
+}
+
+// WriteGolden writes the keys and values of the tree as a canonical text listing, to be
+// checked into the golden files of snapshot tests. Unlike Dump, it doesn't depend on the
+// shape of the tree. There is a line per key, sorted by the keys:
+//
+//	"a.example.com" = "a"
+//	"com"
+//	"example.com" = 1
+//
+// The keys, and the values which are strings or []byte, are quoted like Dump does. The
+// other values are formatted with %v, and nil values are left out.
+func (tree *Tree) WriteGolden(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, line := range tree.goldenLines() {
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// CompareGolden compares the tree with a listing written by WriteGolden. If they differ, the
+// error lists the lines only in the listing prefixed with "-", and the lines only in the tree
+// prefixed with "+", so a test failure shows what changed.
+func (tree *Tree) CompareGolden(r io.Reader) error {
+	var golden []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			golden = append(golden, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	lines := tree.goldenLines()
+	sort.Strings(golden)
+	sort.Strings(lines)
+
+	var diff strings.Builder
+	i, j := 0, 0
+	for i < len(golden) || j < len(lines) {
+		switch {
+		case j == len(lines) || i < len(golden) && golden[i] < lines[j]:
+			diff.WriteString("-" + golden[i] + "\n")
+			i++
+		case i == len(golden) || lines[j] < golden[i]:
+			diff.WriteString("+" + lines[j] + "\n")
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	if diff.Len() > 0 {
+		return fmt.Errorf("suffix: tree differs from the golden listing:\n%s", diff.String())
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package suffix
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteGolden(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("org"), nil)
+	tree.Insert([]byte("example.com"), 1)
+	tree.Insert([]byte("a.example.com"), "a\n")
+	tree.Insert([]byte("com"), []byte{0xff})
+	tree.Insert([]byte("\x00\xff"), []string{"x", "y"})
+	tree.Insert([]byte(""), nil)
+
+	var buf bytes.Buffer
+	assert.Nil(t, tree.WriteGolden(&buf))
+	golden := `""
+"\x00\xff" = [x y]
+"a.example.com" = "a\x0a"
+"com" = "\xff"
+"example.com" = 1
+"org"
+`
+	assert.Equal(t, golden, buf.String())
+
+	buf.Reset()
+	assert.Nil(t, NewTree().WriteGolden(&buf))
+	assert.Equal(t, "", buf.String())
+
+	// The listing doesn't depend on the order of insertion
+	other := NewTree()
+	tree.Walk(func(key []byte, value interface{}) bool {
+		other.Insert(key, value)
+		return false
+	})
+	buf.Reset()
+	assert.Nil(t, other.WriteGolden(&buf))
+	assert.Equal(t, golden, buf.String())
+}
+
+func TestCompareGolden(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("example.com"), 1)
+	tree.Insert([]byte("org"), nil)
+
+	assert.Nil(t, tree.CompareGolden(strings.NewReader("\"com\"\n\"example.com\" = 1\n\"org\"\n")))
+	assert.Nil(t, tree.CompareGolden(strings.NewReader("\"org\"\n\n\"com\"\n\"example.com\" = 1")))
+
+	err := tree.CompareGolden(strings.NewReader("\"com\"\n\"example.com\" = 2\n\"net\"\n"))
+	assert.Equal(t, `suffix: tree differs from the golden listing:
++"example.com" = 1
+-"example.com" = 2
+-"net"
++"org"
+`, err.Error())
+
+	assert.NotNil(t, NewTree().CompareGolden(strings.NewReader("\"com\"\n")))
+	assert.Nil(t, NewTree().CompareGolden(strings.NewReader("")))
+}
@@ -0,0 +1,11 @@
+//go:build !suffixdebug
+// +build !suffixdebug
+
+package suffix
+
+// writerGuard is a no-op unless the package is built with the suffixdebug tag.
+type writerGuard struct{}
+
+func (g *writerGuard) acquire() {}
+
+func (g *writerGuard) release(tree *Tree) {}
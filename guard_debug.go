@@ -0,0 +1,84 @@
+//go:build suffixdebug
+// +build suffixdebug
+
+package suffix
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// writerGuard records the goroutine which is mutating the tree. Tree is not safe for
+// concurrent writes, so a second goroutine entering a mutation panics instead of silently
+// corrupting the tree.
+//
+// It also validates the tree at the end of each mutation, and panics with the error of
+// Validate and a dump of the broken node, so a corruption is caught by the mutation causing
+// it instead of a later lookup. Validating walks through the whole tree, so the mutations
+// are O(n) in this build.
+type writerGuard struct {
+	owner atomic.Int64
+	// Only touched by the owner
+	depth int
+}
+
+func (g *writerGuard) acquire() {
+	id := goroutineID()
+	if g.owner.Load() == id {
+		// Reentered by the same goroutine, like a bulk operation calling Insert
+		g.depth++
+		return
+	}
+	if !g.owner.CompareAndSwap(0, id) {
+		panic(fmt.Sprintf(
+			"suffix: concurrent mutation of Tree detected: goroutine %d writes while goroutine %d is writing",
+			id, g.owner.Load()))
+	}
+	g.depth = 1
+}
+
+func (g *writerGuard) release(tree *Tree) {
+	g.depth--
+	if g.depth == 0 {
+		// Validate before releasing, so no other writer can mutate the tree during the check.
+		// The guard is released even if the tree is invalid.
+		defer g.owner.Store(0)
+		tree.assertValid()
+	}
+}
+
+// assertValid panics if the tree is invalid.
+func (tree *Tree) assertValid() {
+	node, err := tree.validate()
+	if err == nil {
+		return
+	}
+	var buf strings.Builder
+	bw := bufio.NewWriter(&buf)
+	dumpNode(bw, node, 0)
+	bw.Flush()
+	panic(fmt.Sprintf("%v, the node is:\n%s", err, buf.String()))
+}
+
+var goroutinePrefix = []byte("goroutine ")
+
+// goroutineID parses the id of current goroutine from its stack trace. It is slow, but only
+// used in debug build.
+func goroutineID() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, goroutinePrefix)
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		panic("suffix: failed to get goroutine id: " + err.Error())
+	}
+	return id
+}
@@ -0,0 +1,91 @@
+//go:build suffixdebug
+// +build suffixdebug
+
+package suffix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentMutationPanics(t *testing.T) {
+	tree := NewTree()
+	acquired := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		tree.guard.acquire()
+		close(acquired)
+		<-done
+		tree.guard.release(tree)
+	}()
+	<-acquired
+
+	assert.Panics(t, func() {
+		tree.Insert([]byte("sth"), "sth")
+	})
+	assert.Panics(t, func() {
+		tree.Remove([]byte("sth"))
+	})
+	close(done)
+}
+
+func TestReentrantMutation(t *testing.T) {
+	tree := NewTree()
+	tree.guard.acquire()
+	assert.NotPanics(t, func() {
+		tree.Insert([]byte("sth"), "sth")
+	})
+	tree.guard.release(tree)
+
+	done := make(chan struct{})
+	go func() {
+		tree.Insert([]byte("else"), "else")
+		close(done)
+	}()
+	<-done
+	_, found := tree.Get([]byte("else"))
+	assert.True(t, found)
+}
+
+func TestMutationValidatesTree(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("example.com"), nil)
+	tree.Insert([]byte("a.example.com"), nil)
+	assert.NotPanics(t, func() {
+		tree.Insert([]byte("org"), nil)
+		tree.Remove([]byte("org"))
+	})
+
+	// Break the order of the edges under "com"
+	node := tree.root.edges[0].point.(*_Node)
+	node.edges[0], node.edges[1] = node.edges[1], node.edges[0]
+	assert.PanicsWithValue(t, `suffix: invalid tree: edges "example." and "" at "com" are not sorted, the node is:
+"example."
+    "" -> "example.com"
+    "a." -> "a.example.com"
+"" -> "com"
+`, func() {
+		tree.Insert([]byte("org"), nil)
+	})
+}
+
+func TestPanickingMutationReleasesGuard(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("sth"), []int{1})
+	assert.Panics(t, func() {
+		// The values are not comparable
+		tree.CompareAndSwap([]byte("sth"), []int{1}, []int{2})
+	})
+	assert.Equal(t, int64(0), tree.guard.owner.Load())
+
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("example.com"), nil)
+	node := tree.root.edges[0].point.(*_Node)
+	node.edges[0], node.edges[1] = node.edges[1], node.edges[0]
+	assert.Panics(t, func() {
+		tree.Insert([]byte("org"), nil)
+	})
+	assert.Equal(t, int64(0), tree.guard.owner.Load())
+}
@@ -0,0 +1,31 @@
+package suffix
+
+import (
+	"crypto/sha256"
+)
+
+// Hash returns a SHA-256 digest of the keys and values in the tree. Trees with the same keys
+// and values have the same digest, whatever the order of insertion and the history of
+// removals, so it can be used as a cache key or to detect changes.
+//
+// Like MarshalBinary, the values should be nil, booleans, numbers, strings or []byte. Values
+// of different types are different, so 1 and int64(1) don't give the same digest. The digest
+// is stable across versions of this package.
+func (tree *Tree) Hash() (sum [sha256.Size]byte, err error) {
+	h := sha256.New()
+	buf := appendUvarint(nil, uint64(tree.Len()))
+	h.Write(buf)
+	// The shape of the tree only depends on its keys, so does the order of walking
+	tree.Walk(func(key []byte, value interface{}) bool {
+		buf = appendUvarint(buf[:0], uint64(len(key)))
+		buf = append(buf, key...)
+		buf, err = appendValue(buf, value)
+		h.Write(buf)
+		return err != nil
+	})
+	if err != nil {
+		return sum, err
+	}
+	h.Sum(sum[:0])
+	return sum, nil
+}
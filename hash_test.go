@@ -0,0 +1,65 @@
+package suffix
+
+import (
+	"encoding/hex"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHash(t *testing.T) {
+	keys := []string{"com", "example.com", "a.example.com", "org", "", "\x00\xff"}
+	tree := NewTree()
+	for i, key := range keys {
+		tree.Insert([]byte(key), i)
+	}
+	sum, err := tree.Hash()
+	assert.Nil(t, err)
+
+	// Another order of insertion, with keys inserted and removed in between
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 10; n++ {
+		other := NewTree()
+		other.Insert([]byte("x.example.com"), nil)
+		for _, i := range r.Perm(len(keys)) {
+			other.Insert([]byte(keys[i]), i)
+		}
+		other.Remove([]byte("x.example.com"))
+		otherSum, err := other.Hash()
+		assert.Nil(t, err)
+		assert.Equal(t, sum, otherSum)
+	}
+
+	tree.Insert([]byte("com"), int64(0))
+	changed, err := tree.Hash()
+	assert.Nil(t, err)
+	assert.NotEqual(t, sum, changed)
+	tree.Insert([]byte("com"), 0)
+	changed, err = tree.Hash()
+	assert.Nil(t, err)
+	assert.Equal(t, sum, changed)
+
+	tree.Remove([]byte(""))
+	changed, err = tree.Hash()
+	assert.Nil(t, err)
+	assert.NotEqual(t, sum, changed)
+
+	tree.Insert([]byte("com"), struct{}{})
+	_, err = tree.Hash()
+	assert.NotNil(t, err)
+}
+
+func TestHash_Stable(t *testing.T) {
+	sum, err := NewTree().Hash()
+	assert.Nil(t, err)
+	assert.Equal(t, "6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+		hex.EncodeToString(sum[:]))
+
+	tree := NewTree()
+	tree.Insert([]byte("com"), "x")
+	sum, err = tree.Hash()
+	assert.Nil(t, err)
+	assert.Equal(t, "ec55096581a9bf71efcc3541e009cfb212eef8635f37a8e4e3a72b89dc59cc26",
+		hex.EncodeToString(sum[:]))
+}
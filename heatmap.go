@@ -0,0 +1,85 @@
+package suffix
+
+import (
+	"sync"
+)
+
+// edgeCounter counts the lookups traversing each edge, keyed by the path of the edge: its
+// label followed by the labels above it, which is the suffix shared by all keys below it.
+type edgeCounter struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+// WithEdgeCounts counts how many times Get and LongestSuffix traverse each edge, so the
+// subtrees carrying the real traffic can be told apart before pruning a rule set. Read the
+// counts with EdgeCounts, or draw them with MermaidOptions.Heatmap.
+//
+// The counted lookups are slower and serialized on a lock, so the option is meant for
+// profiling. A Snapshot shares the counts with the tree.
+func WithEdgeCounts() Option {
+	return func(tree *Tree) {
+		tree.edgeCounts = &edgeCounter{counts: map[string]uint64{}}
+	}
+}
+
+// count counts the edge of the step, if the lookup of query goes through it.
+func (c *edgeCounter) count(query []byte, step TraceStep) {
+	// An empty label has the same path as the edge above it, which already counts the
+	// lookups going through it
+	if step.Action == TraceSkip || len(step.Label) == 0 {
+		return
+	}
+	path := query[len(step.Rest)-len(step.Label):]
+	c.mu.Lock()
+	c.counts[string(path)]++
+	c.mu.Unlock()
+}
+
+// lookupTracer returns the function receiving the steps of a lookup of query, or nil if the
+// lookups are neither traced nor counted.
+func (tree *Tree) lookupTracer(query []byte) func(step TraceStep) {
+	switch {
+	case tree.edgeCounts == nil:
+		return tree.tracer
+	case tree.tracer == nil:
+		return func(step TraceStep) {
+			tree.edgeCounts.count(query, step)
+		}
+	default:
+		return func(step TraceStep) {
+			tree.edgeCounts.count(query, step)
+			tree.tracer(step)
+		}
+	}
+}
+
+// EdgeCounts returns the number of lookups which traversed each edge, keyed by the path of
+// the edge: its label followed by the labels above it, so "example.com" is the edge
+// "example." under the edge "com". The empty labels ending the keys are not counted. It
+// returns nil if WithEdgeCounts isn't set.
+//
+// The paths are stable while the tree changes, but an edge split or merged by later changes
+// starts with the counts of its new path.
+func (tree *Tree) EdgeCounts() map[string]uint64 {
+	if tree.edgeCounts == nil {
+		return nil
+	}
+	tree.edgeCounts.mu.Lock()
+	defer tree.edgeCounts.mu.Unlock()
+	counts := make(map[string]uint64, len(tree.edgeCounts.counts))
+	for path, n := range tree.edgeCounts.counts {
+		counts[path] = n
+	}
+	return counts
+}
+
+// ResetEdgeCounts sets all edge counts to zero.
+func (tree *Tree) ResetEdgeCounts() {
+	if tree.edgeCounts == nil {
+		return
+	}
+	tree.edgeCounts.mu.Lock()
+	tree.edgeCounts.counts = map[string]uint64{}
+	tree.edgeCounts.mu.Unlock()
+}
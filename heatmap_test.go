@@ -0,0 +1,92 @@
+package suffix
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEdgeCounts(t *testing.T) {
+	tree := NewTree()
+	assert.Nil(t, tree.EdgeCounts())
+	tree.ResetEdgeCounts()
+
+	var steps int
+	tree = NewTree(WithEdgeCounts(), WithTracer(func(step TraceStep) { steps++ }))
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("example.com"), 1)
+	tree.Insert([]byte("a.example.com"), "a")
+	tree.Insert([]byte("org"), nil)
+
+	tree.Get([]byte("example.com"))
+	tree.Get([]byte("b.example.com"))
+	tree.LongestSuffix([]byte("b.example.com"))
+	tree.LongestSuffix([]byte("net"))
+	assert.Equal(t, map[string]uint64{
+		"com":         3,
+		"example.com": 3,
+	}, tree.EdgeCounts())
+	assert.NotZero(t, steps)
+
+	snapshot := tree.Snapshot()
+	snapshot.Get([]byte("a.example.com"))
+	assert.Equal(t, map[string]uint64{
+		"com":           4,
+		"example.com":   4,
+		"a.example.com": 1,
+	}, tree.EdgeCounts())
+
+	tree.ResetEdgeCounts()
+	assert.Equal(t, map[string]uint64{}, tree.EdgeCounts())
+}
+
+func TestWriteMermaid_Heatmap(t *testing.T) {
+	tree := NewTree(WithEdgeCounts())
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("example.com"), 1)
+	tree.Insert([]byte("a.example.com"), "a")
+	tree.Insert([]byte("org"), nil)
+
+	for i := 0; i < 3; i++ {
+		tree.Get([]byte("com"))
+	}
+	tree.Get([]byte("a.example.com"))
+
+	var buf bytes.Buffer
+	assert.Nil(t, tree.WriteMermaid(&buf, &MermaidOptions{Heatmap: true}))
+	assert.Equal(t, `flowchart RL
+    n0((" "))
+    n1((" "))
+    n2["com"]
+    n1 --> n2
+    n3((" "))
+    n4["example.com = 1"]
+    n3 --> n4
+    n5["a.example.com = a"]
+    n3 -->|"a. (1)"| n5
+    n1 -->|"example. (1)"| n3
+    n0 -->|"com (4)"| n1
+    n6["org"]
+    n0 -->|"org"| n6
+    linkStyle 2 stroke:#f4c542,stroke-width:2px
+    linkStyle 3 stroke:#f4c542,stroke-width:2px
+    linkStyle 4 stroke:#d7191c,stroke-width:5px
+`, buf.String())
+
+	buf.Reset()
+	assert.Nil(t, tree.WriteMermaid(&buf, &MermaidOptions{Suffix: []byte("le.com"), Heatmap: true}))
+	assert.Equal(t, `flowchart RL
+    n0((" "))
+    n1["example.com = 1"]
+    n0 --> n1
+    n2["a.example.com = a"]
+    n0 -->|"a. (1)"| n2
+    linkStyle 1 stroke:#f4c542,stroke-width:2px
+`, buf.String())
+
+	// Without Heatmap the counts are not drawn
+	buf.Reset()
+	assert.Nil(t, tree.WriteMermaid(&buf, &MermaidOptions{Suffix: []byte("le.com")}))
+	assert.NotContains(t, buf.String(), "linkStyle")
+}
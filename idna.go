@@ -0,0 +1,276 @@
+package suffix
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// Parameters of Punycode, see RFC 3492
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+	// Larger than any rune, so the arithmetic never overflows an int32
+	punyMaxInt = 1<<31 - 1
+
+	acePrefix = "xn--"
+)
+
+var errPunycode = errors.New("suffix: invalid punycode")
+
+func punyAdapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > (punyBase-punyTMin)*punyTMax/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
+
+func punyThreshold(k, bias int) int {
+	switch {
+	case k <= bias:
+		return punyTMin
+	case k >= bias+punyTMax:
+		return punyTMax
+	}
+	return k - bias
+}
+
+func punyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+// punyEncode encodes the runes of a label with Punycode, without the ACE prefix.
+func punyEncode(label []rune) ([]byte, error) {
+	var out []byte
+	for _, r := range label {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+	basic := len(out)
+	if basic > 0 {
+		out = append(out, '-')
+	}
+	n, delta, bias := punyInitialN, 0, punyInitialBias
+	for h := basic; h < len(label); {
+		m := punyMaxInt
+		for _, r := range label {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+		if m-n > (punyMaxInt-delta)/(h+1) {
+			return nil, errPunycode
+		}
+		delta += (m - n) * (h + 1)
+		n = m
+		for _, r := range label {
+			if int(r) < n {
+				delta++
+				if delta == punyMaxInt {
+					return nil, errPunycode
+				}
+			}
+			if int(r) != n {
+				continue
+			}
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := punyThreshold(k, bias)
+				if q < t {
+					break
+				}
+				out = append(out, punyDigit(t+(q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			out = append(out, punyDigit(q))
+			bias = punyAdapt(delta, h+1, h == basic)
+			delta = 0
+			h++
+		}
+		delta++
+		n++
+	}
+	return out, nil
+}
+
+// punyDecode decodes a label encoded with Punycode, without the ACE prefix.
+func punyDecode(encoded []byte) ([]rune, error) {
+	var out []rune
+	pos := 0
+	if i := bytes.LastIndexByte(encoded, '-'); i >= 0 {
+		for _, c := range encoded[:i] {
+			if c >= utf8.RuneSelf {
+				return nil, errPunycode
+			}
+			out = append(out, rune(c))
+		}
+		pos = i + 1
+	}
+	n, i, bias := punyInitialN, 0, punyInitialBias
+	for pos < len(encoded) {
+		oldI, w := i, 1
+		for k := punyBase; ; k += punyBase {
+			if pos >= len(encoded) {
+				return nil, errPunycode
+			}
+			c := encoded[pos]
+			pos++
+			var digit int
+			switch {
+			case 'a' <= c && c <= 'z':
+				digit = int(c - 'a')
+			case 'A' <= c && c <= 'Z':
+				digit = int(c - 'A')
+			case '0' <= c && c <= '9':
+				digit = int(c-'0') + 26
+			default:
+				return nil, errPunycode
+			}
+			if digit > (punyMaxInt-i)/w {
+				return nil, errPunycode
+			}
+			i += digit * w
+			t := punyThreshold(k, bias)
+			if digit < t {
+				break
+			}
+			if w > punyMaxInt/(punyBase-t) {
+				return nil, errPunycode
+			}
+			w *= punyBase - t
+		}
+		bias = punyAdapt(i-oldI, len(out)+1, oldI == 0)
+		if i/(len(out)+1) > punyMaxInt-n {
+			return nil, errPunycode
+		}
+		n += i / (len(out) + 1)
+		i %= len(out) + 1
+		if n > utf8.MaxRune || (n >= 0xd800 && n <= 0xdfff) {
+			return nil, errPunycode
+		}
+		out = append(out, 0)
+		copy(out[i+1:], out[i:])
+		out[i] = rune(n)
+		i++
+	}
+	return out, nil
+}
+
+// isIDNADot reports whether r separates labels in IDNA, which are the full stop and the dots
+// used by CJK scripts.
+func isIDNADot(r rune) bool {
+	return r == '.' || r == '\u3002' || r == '\uff0e' || r == '\uff61'
+}
+
+// idnaToASCII converts the U-labels of a domain to A-labels. The labels which are ASCII,
+// invalid UTF-8 or too large to encode are kept as is.
+func idnaToASCII(key []byte) []byte {
+	ascii := true
+	for _, c := range key {
+		if c >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii || !utf8.Valid(key) {
+		return key
+	}
+	out := make([]byte, 0, len(key)+len(acePrefix))
+	var label []rune
+	flush := func() {
+		isASCII := true
+		for _, r := range label {
+			if r >= utf8.RuneSelf {
+				isASCII = false
+				break
+			}
+		}
+		if !isASCII {
+			if encoded, err := punyEncode(label); err == nil {
+				out = append(append(out, acePrefix...), encoded...)
+				return
+			}
+		}
+		out = append(out, string(label)...)
+	}
+	for _, r := range string(key) {
+		if isIDNADot(r) {
+			flush()
+			out = append(out, '.')
+			label = label[:0]
+			continue
+		}
+		label = append(label, r)
+	}
+	flush()
+	return out
+}
+
+// isACE reports whether s starts with the ACE prefix of A-labels.
+func isACE(s []byte) bool {
+	return len(s) > len(acePrefix) && strings.EqualFold(string(s[:len(acePrefix)]), acePrefix)
+}
+
+// idnaToUnicode converts the A-labels of a domain to U-labels. The labels which fail to
+// decode are kept as is.
+func idnaToUnicode(key []byte) []byte {
+	// Most keys have no A-labels, return them without splitting
+	hasACE := false
+	for i := 0; i < len(key); {
+		if isACE(key[i:]) {
+			hasACE = true
+			break
+		}
+		next := bytes.IndexByte(key[i:], '.')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	if !hasACE {
+		return key
+	}
+	labels := bytes.Split(key, []byte("."))
+	for i, label := range labels {
+		if !isACE(label) {
+			continue
+		}
+		if decoded, err := punyDecode(label[len(acePrefix):]); err == nil {
+			labels[i] = []byte(string(decoded))
+		}
+	}
+	return bytes.Join(labels, []byte("."))
+}
+
+// WithIDNA stores the internationalized domain names in keys as A-labels, so
+// "bücher.example" and "xn--bcher-kva.example" are the same key, and queries in either
+// form match it. The U-labels are encoded with Punycode, and the CJK full stops are
+// converted to ".". The keys are converted back to U-labels when they are returned by
+// LongestSuffix, Walk and WalkSuffix.
+//
+// Only the encoding is done. To match the labels which differ in case or composition, add
+// WithCaseFolding or WithNormalization before this option.
+func WithIDNA() Option {
+	return func(tree *Tree) {
+		tree.transforms = append(tree.transforms, transformStep(idnaToASCII))
+		tree.outputKey = idnaToUnicode
+	}
+}
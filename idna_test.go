@@ -0,0 +1,97 @@
+package suffix
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPunycode(t *testing.T) {
+	for decoded, encoded := range map[string]string{
+		"bücher":  "bcher-kva",
+		"münchen": "mnchen-3ya",
+		"ü":       "tda",
+		// From RFC 3492
+		"他们为什么不说中文": "ihqwcrb4cv8a8dqg056pqjye",
+		"日本語":       "wgv71a119e",
+		"abc":       "abc-",
+	} {
+		b, err := punyEncode([]rune(decoded))
+		assert.Nil(t, err)
+		assert.Equal(t, encoded, string(b), decoded)
+		r, err := punyDecode([]byte(encoded))
+		assert.Nil(t, err)
+		assert.Equal(t, decoded, string(r), encoded)
+	}
+
+	for _, invalid := range []string{"bü-cher", "bcher-kv!", "99999999999"} {
+		_, err := punyDecode([]byte(invalid))
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestPunycode_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabet := []rune("ab-é中\U0001f600")
+	for i := 0; i < 1000; i++ {
+		label := make([]rune, r.Intn(10))
+		for j := range label {
+			label[j] = alphabet[r.Intn(len(alphabet))]
+		}
+		encoded, err := punyEncode(label)
+		assert.Nil(t, err)
+		decoded, err := punyDecode(encoded)
+		assert.Nil(t, err)
+		assert.Equal(t, string(label), string(decoded))
+	}
+}
+
+func TestWithIDNA(t *testing.T) {
+	tree := NewTree(WithCaseFolding(), WithIDNA())
+	tree.Insert([]byte("bücher.example"), 1)
+	tree.Insert([]byte("xn--wgv71a119e.jp"), 2)
+	tree.Insert([]byte("example.com"), 3)
+
+	value, found := tree.Get([]byte("xn--bcher-kva.example"))
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+	value, found = tree.Get([]byte("BÜCHER.example"))
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+	// Ideographic full stop
+	value, found = tree.Get([]byte("日本語。jp"))
+	assert.True(t, found)
+	assert.Equal(t, 2, value)
+
+	key, _, found := tree.LongestSuffix([]byte("www.xn--bcher-kva.example"))
+	assert.True(t, found)
+	assert.Equal(t, "bücher.example", string(key))
+	key, _, _ = tree.LongestSuffix([]byte("www.example.com"))
+	assert.Equal(t, "example.com", string(key))
+
+	var keys []string
+	tree.Walk(func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return false
+	})
+	assert.ElementsMatch(t, []string{"bücher.example", "日本語.jp", "example.com"}, keys)
+
+	keys = nil
+	tree.WalkSuffix([]byte(".jp"), func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return false
+	})
+	assert.Equal(t, []string{"日本語.jp"}, keys)
+
+	_, found = tree.Remove([]byte("bücher.example"))
+	assert.True(t, found)
+	assert.Equal(t, 2, tree.Len())
+}
+
+func TestIDNAToUnicode(t *testing.T) {
+	assert.Equal(t, "example.com", string(idnaToUnicode([]byte("example.com"))))
+	assert.Equal(t, "bücher.XN--", string(idnaToUnicode([]byte("XN--bcher-kva.XN--"))))
+	// Invalid labels are kept
+	assert.Equal(t, "xn--a!.com", string(idnaToUnicode([]byte("xn--a!.com"))))
+}
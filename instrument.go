@@ -0,0 +1,151 @@
+package suffix
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+// OpStats are the totals of an operation of InstrumentedTree.
+type OpStats struct {
+	// OpInsert, OpGet, OpLongestSuffix or OpRemove
+	Op    string
+	Calls uint64
+	// The bytes of the labels compared with the keys
+	ByteComparisons uint64
+	// The nodes visited, including the root
+	NodesVisited uint64
+	// The heap allocations and their bytes
+	Allocs     uint64
+	AllocBytes uint64
+}
+
+// Report is the statistics of the operations of an InstrumentedTree, in the order of OpInsert,
+// OpGet, OpLongestSuffix and OpRemove.
+type Report []OpStats
+
+// String formats the report as a table with the averages per call, to be pasted into an
+// issue.
+func (r Report) String() string {
+	var buf strings.Builder
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "op\tcalls\tcmp bytes/op\tnodes/op\tallocs/op\tB/op\t")
+	for _, stats := range r {
+		calls := float64(stats.Calls)
+		if calls == 0 {
+			calls = 1
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t\n", stats.Op, stats.Calls,
+			float64(stats.ByteComparisons)/calls, float64(stats.NodesVisited)/calls,
+			float64(stats.Allocs)/calls, float64(stats.AllocBytes)/calls)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+var instrumentedOps = []string{OpInsert, OpGet, OpLongestSuffix, OpRemove}
+
+// InstrumentedTree wraps a Tree to count the work of each operation: the bytes compared, the
+// nodes visited and the heap allocations. It is meant to attach numbers to a performance
+// issue, not for production: each operation reads runtime.MemStats twice, which stops the
+// world, and looks the key up again to count the comparisons.
+//
+// The allocations are counted for the whole process, so the other goroutines must be idle for
+// the counts to be accurate. Insert and Remove count the comparisons of finding the key,
+// which is the walk they do before changing the tree. Like Tree, InstrumentedTree is not
+// safe for concurrent writes.
+type InstrumentedTree struct {
+	tree  *Tree
+	stats map[string]*OpStats
+}
+
+// NewInstrumentedTree wraps tree.
+func NewInstrumentedTree(tree *Tree) *InstrumentedTree {
+	t := &InstrumentedTree{tree: tree, stats: map[string]*OpStats{}}
+	for _, op := range instrumentedOps {
+		t.stats[op] = &OpStats{Op: op}
+	}
+	return t
+}
+
+// Tree returns the wrapped tree. The operations done on it directly are not counted.
+func (t *InstrumentedTree) Tree() *Tree {
+	return t.tree
+}
+
+// measure runs do, counting its allocations, and the comparisons and nodes of looking key up
+// in the tree before do changes it.
+func (t *InstrumentedTree) measure(op string, key []byte, do func()) {
+	stats := t.stats[op]
+	if key, err := t.tree.prepareKey(key); err == nil {
+		stats.NodesVisited++
+		exact := op != OpLongestSuffix
+		t.tree.root.traceLookup(op, key, t.tree.separator(), exact, 0, func(step TraceStep) {
+			if len(step.Rest) >= len(step.Label) {
+				stats.ByteComparisons += uint64(len(step.Label))
+			}
+			if step.Action == TraceDescend {
+				stats.NodesVisited++
+			}
+		})
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	do()
+	runtime.ReadMemStats(&after)
+	stats.Calls++
+	stats.Allocs += after.Mallocs - before.Mallocs
+	stats.AllocBytes += after.TotalAlloc - before.TotalAlloc
+}
+
+// Insert is like Tree.Insert.
+func (t *InstrumentedTree) Insert(key []byte, value interface{}) (oldValue interface{}, ok bool) {
+	t.measure(OpInsert, key, func() {
+		oldValue, ok = t.tree.Insert(key, value)
+	})
+	return oldValue, ok
+}
+
+// Get is like Tree.Get.
+func (t *InstrumentedTree) Get(key []byte) (value interface{}, found bool) {
+	t.measure(OpGet, key, func() {
+		value, found = t.tree.Get(key)
+	})
+	return value, found
+}
+
+// LongestSuffix is like Tree.LongestSuffix.
+func (t *InstrumentedTree) LongestSuffix(key []byte) (matchedKey []byte, value interface{},
+	found bool) {
+
+	t.measure(OpLongestSuffix, key, func() {
+		matchedKey, value, found = t.tree.LongestSuffix(key)
+	})
+	return matchedKey, value, found
+}
+
+// Remove is like Tree.Remove.
+func (t *InstrumentedTree) Remove(key []byte) (oldValue interface{}, found bool) {
+	t.measure(OpRemove, key, func() {
+		oldValue, found = t.tree.Remove(key)
+	})
+	return oldValue, found
+}
+
+// Report returns the totals of the operations so far.
+func (t *InstrumentedTree) Report() Report {
+	report := make(Report, len(instrumentedOps))
+	for i, op := range instrumentedOps {
+		report[i] = *t.stats[op]
+	}
+	return report
+}
+
+// Reset sets the counts to zero.
+func (t *InstrumentedTree) Reset() {
+	for _, op := range instrumentedOps {
+		t.stats[op] = &OpStats{Op: op}
+	}
+}
@@ -0,0 +1,52 @@
+package suffix
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentedTree(t *testing.T) {
+	tree := NewInstrumentedTree(NewTree())
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("example.com"), 1)
+	tree.Insert([]byte("a.example.com"), "a")
+
+	value, found := tree.Get([]byte("example.com"))
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+	// Compares "com", "" (nothing to compare) and "example."
+	report := tree.Report()
+	assert.Equal(t, OpStats{Op: OpGet, Calls: 1, ByteComparisons: 3 + 8, NodesVisited: 3},
+		report[1])
+
+	matched, _, found := tree.LongestSuffix([]byte("b.example.com"))
+	assert.True(t, found)
+	assert.Equal(t, "example.com", string(matched))
+	_, found = tree.Remove([]byte("com"))
+	assert.True(t, found)
+	_, found = tree.Get(nil)
+	assert.False(t, found)
+
+	report = tree.Report()
+	assert.Equal(t, []string{OpInsert, OpGet, OpLongestSuffix, OpRemove},
+		[]string{report[0].Op, report[1].Op, report[2].Op, report[3].Op})
+	assert.Equal(t, uint64(3), report[0].Calls)
+	assert.True(t, report[0].Allocs > 0)
+	assert.True(t, report[0].AllocBytes > 0)
+	assert.Equal(t, uint64(2), report[1].Calls)
+	assert.Equal(t, uint64(3), report[1].NodesVisited)
+	// "com", "", "example.", "" and "a."
+	assert.Equal(t, uint64(3+8+2), report[2].ByteComparisons)
+	assert.Equal(t, uint64(1), report[3].Calls)
+
+	text := report.String()
+	assert.Equal(t, 5, strings.Count(text, "\n"))
+	assert.Contains(t, text, "cmp bytes/op")
+	assert.Contains(t, text, "LongestSuffix")
+
+	assert.Same(t, tree.tree, tree.Tree())
+	tree.Reset()
+	assert.Equal(t, uint64(0), tree.Report()[0].Calls)
+}
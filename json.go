@@ -0,0 +1,90 @@
+package suffix
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// jsonEntry is an element of the JSON form of Tree. The key is stored in "key" if it is valid
+// UTF-8, otherwise in "keyBytes" as base64.
+type jsonEntry struct {
+	Key      *string     `json:"key,omitempty"`
+	KeyBytes []byte      `json:"keyBytes,omitempty"`
+	Value    interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. The tree is encoded as an array of entries like
+//
+//	[{"key": "example.com", "value": 1}, {"key": "example.org"}, {"keyBytes": "/w=="}]
+//
+// The value is omitted if it is nil. Keys which are not valid UTF-8 are encoded as base64
+// in "keyBytes".
+func (tree *Tree) MarshalJSON() ([]byte, error) {
+	entries := make([]jsonEntry, 0, tree.Len())
+	tree.Walk(func(key []byte, value interface{}) bool {
+		entry := jsonEntry{Value: value}
+		if utf8.Valid(key) {
+			s := string(key)
+			entry.Key = &s
+		} else {
+			entry.KeyBytes = key
+		}
+		entries = append(entries, entry)
+		return false
+	})
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It replaces the content of the tree with the
+// entries encoded like MarshalJSON. An entry can also be a plain string, which is the key
+// with nil value:
+//
+//	["example.com", {"key": "example.org", "value": {"backend": "10.0.0.1"}}]
+//
+// The values are decoded like json.Unmarshal does for interface{}. Like UnmarshalBinary, the
+// keys are inserted through the options of the tree.
+func (tree *Tree) UnmarshalJSON(data []byte) error {
+	if tree.frozen {
+		return ErrReadOnly
+	}
+	var elems []json.RawMessage
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return err
+	}
+	newTree := tree.emptyLike()
+	for i, elem := range elems {
+		if len(elem) > 0 && elem[0] == '"' {
+			var key string
+			if err := json.Unmarshal(elem, &key); err != nil {
+				return err
+			}
+			if err := newTree.insertDecoded([]byte(key), nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var entry jsonEntry
+		if err := json.Unmarshal(elem, &entry); err != nil {
+			return err
+		}
+		var key []byte
+		if entry.Key != nil {
+			key = []byte(*entry.Key)
+		} else if entry.KeyBytes != nil {
+			key = entry.KeyBytes
+		} else {
+			return fmt.Errorf("suffix: entry %d of JSON has no key", i)
+		}
+		if err := newTree.insertDecoded(key, entry.Value); err != nil {
+			return err
+		}
+	}
+
+	tree.guard.acquire()
+	defer tree.guard.release(tree)
+	tree.root = newTree.root
+	tree.leavesNum = newTree.leavesNum
+	return nil
+}
@@ -0,0 +1,60 @@
+package suffix
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSON(t *testing.T) {
+	_, tree := getFixtures()
+	tree.Insert([]byte{}, nil)
+	tree.Insert([]byte{0xff, 'a'}, 1.5)
+	data, err := json.Marshal(tree)
+	assert.Nil(t, err)
+
+	newTree := NewTree()
+	newTree.Insert([]byte("sth"), "sth")
+	assert.Nil(t, json.Unmarshal(data, newTree))
+	assertSameContent(t, tree, newTree)
+	assertGet(t, newTree, "sth", false)
+}
+
+func TestJSON_Format(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("example.com"), 1)
+	tree.Insert([]byte("example.org"), nil)
+	tree.Insert([]byte{0xff}, "binary")
+	data, err := json.Marshal(tree)
+	assert.Nil(t, err)
+	assert.Equal(t,
+		`[{"keyBytes":"/w==","value":"binary"},{"key":"example.com","value":1},{"key":"example.org"}]`,
+		string(data))
+
+	data = []byte(`["example.com", {"key": "", "value": {"backend": "10.0.0.1"}}, {"keyBytes": "/w=="}]`)
+	assert.Nil(t, json.Unmarshal(data, tree))
+	assert.Equal(t, 3, tree.Len())
+	value, found := tree.Get([]byte("example.com"))
+	assert.True(t, found)
+	assert.Nil(t, value)
+	value, found = tree.Get([]byte{})
+	assert.True(t, found)
+	assert.Equal(t, map[string]interface{}{"backend": "10.0.0.1"}, value)
+	_, found = tree.Get([]byte{0xff})
+	assert.True(t, found)
+}
+
+func TestJSON_Invalid(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("sth"), "sth")
+	assert.NotNil(t, json.Unmarshal([]byte(`{"key": "sth"}`), tree))
+	assert.NotNil(t, json.Unmarshal([]byte(`[1]`), tree))
+	assert.EqualError(t, json.Unmarshal([]byte(`["sth", {"value": 1}]`), tree),
+		"suffix: entry 1 of JSON has no key")
+	assert.Equal(t, 1, tree.Len())
+
+	tree.Insert([]byte("ch"), make(chan int))
+	_, err := json.Marshal(tree)
+	assert.NotNil(t, err)
+}
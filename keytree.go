@@ -0,0 +1,121 @@
+package suffix
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Key is the constraint of the keys of KeyTree: any type whose underlying type is []byte or
+// string.
+type Key interface {
+	~[]byte | ~string
+}
+
+// KeyTree is a Tree typed by its keys, so a codebase using string keys gets a tree of strings
+// without converting keys at each call.
+//
+// String keys are copied once when they are inserted, and never copied when they are looked up
+// or returned. []byte keys are kept and returned as is, like Tree does.
+type KeyTree[K Key] struct {
+	tree     *Tree
+	isString bool
+}
+
+// NewKeyTree creates a KeyTree for future usage.
+func NewKeyTree[K Key](opts ...Option) *KeyTree[K] {
+	var zero K
+	return &KeyTree[K]{
+		tree:     NewTree(opts...),
+		isString: reflect.TypeOf(zero).Kind() == reflect.String,
+	}
+}
+
+// Tree returns the underlying Tree. The keys inserted through the KeyTree must not be
+// modified via it.
+func (tree *KeyTree[K]) Tree() *Tree {
+	return tree.tree
+}
+
+// view returns the bytes of key without copying it. The result is only used as a query: the
+// tree doesn't keep or modify it.
+func (tree *KeyTree[K]) view(key K) []byte {
+	if !tree.isString {
+		return []byte(key)
+	}
+	if len(key) == 0 {
+		return []byte{}
+	}
+	s := string(key)
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
+// key converts a key kept by the tree to K. The strings share the memory with the tree,
+// which is fine as the keys in the tree are never modified.
+func (tree *KeyTree[K]) key(b []byte) K {
+	if !tree.isString {
+		return K(b)
+	}
+	if len(b) == 0 {
+		return K("")
+	}
+	return K(unsafe.String(unsafe.SliceData(b), len(b)))
+}
+
+// Insert is like Tree.Insert.
+func (tree *KeyTree[K]) Insert(key K, value interface{}) (oldValue interface{}, ok bool) {
+	// It copies string keys and keeps []byte keys
+	return tree.tree.Insert([]byte(key), value)
+}
+
+// Get is like Tree.Get.
+func (tree *KeyTree[K]) Get(key K) (value interface{}, found bool) {
+	return tree.tree.Get(tree.view(key))
+}
+
+// LongestSuffix is like Tree.LongestSuffix.
+func (tree *KeyTree[K]) LongestSuffix(key K) (matchedKey K, value interface{}, found bool) {
+	k, value, found := tree.tree.LongestSuffix(tree.view(key))
+	if !found {
+		return matchedKey, nil, false
+	}
+	return tree.key(k), value, true
+}
+
+// Remove is like Tree.Remove.
+func (tree *KeyTree[K]) Remove(key K) (oldValue interface{}, found bool) {
+	return tree.tree.Remove(tree.view(key))
+}
+
+// CompareAndSwap is like Tree.CompareAndSwap.
+func (tree *KeyTree[K]) CompareAndSwap(key K, oldValue, newValue interface{}) (swapped bool) {
+	return tree.tree.CompareAndSwap(tree.view(key), oldValue, newValue)
+}
+
+// CompareAndDelete is like Tree.CompareAndDelete.
+func (tree *KeyTree[K]) CompareAndDelete(key K, oldValue interface{}) (deleted bool) {
+	return tree.tree.CompareAndDelete(tree.view(key), oldValue)
+}
+
+// HasSequence is like Tree.HasSequence.
+func (tree *KeyTree[K]) HasSequence(seq K) bool {
+	return tree.tree.HasSequence(tree.view(seq))
+}
+
+// Len returns the number of keys in the tree.
+func (tree *KeyTree[K]) Len() int {
+	return tree.tree.Len()
+}
+
+// Walk is like Tree.Walk.
+func (tree *KeyTree[K]) Walk(f func(key K, value interface{}) (stop bool)) {
+	tree.tree.Walk(func(key []byte, value interface{}) bool {
+		return f(tree.key(key), value)
+	})
+}
+
+// WalkSuffix is like Tree.WalkSuffix.
+func (tree *KeyTree[K]) WalkSuffix(suffix K, f func(key K, value interface{}) (stop bool)) {
+	tree.tree.WalkSuffix(tree.view(suffix), func(key []byte, value interface{}) bool {
+		return f(tree.key(key), value)
+	})
+}
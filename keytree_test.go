@@ -0,0 +1,80 @@
+package suffix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hostname string
+
+func TestKeyTree_String(t *testing.T) {
+	tree := NewKeyTree[hostname]()
+	tree.Insert("example.com", 1)
+	tree.Insert(".com", 2)
+	tree.Insert("", 3)
+	assert.Equal(t, 3, tree.Len())
+
+	value, found := tree.Get("example.com")
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+	value, found = tree.Get("")
+	assert.True(t, found)
+	assert.Equal(t, 3, value)
+
+	key, value, found := tree.LongestSuffix("www.golang.com")
+	assert.True(t, found)
+	assert.Equal(t, hostname(".com"), key)
+	assert.Equal(t, 2, value)
+
+	assert.True(t, tree.HasSequence("ample"))
+	assert.True(t, tree.CompareAndSwap(".com", 2, 4))
+	assert.False(t, tree.CompareAndDelete(".com", 2))
+	assert.True(t, tree.CompareAndDelete(".com", 4))
+	oldValue, found := tree.Remove("")
+	assert.True(t, found)
+	assert.Equal(t, 3, oldValue)
+
+	var keys []hostname
+	tree.WalkSuffix("com", func(key hostname, value interface{}) bool {
+		keys = append(keys, key)
+		return false
+	})
+	assert.Equal(t, []hostname{"example.com"}, keys)
+}
+
+func TestKeyTree_Bytes(t *testing.T) {
+	tree := NewKeyTree[[]byte]()
+	key := []byte("example.com")
+	tree.Insert(key, 1)
+	tree.Insert(nil, 2)
+	assert.Equal(t, 1, tree.Len())
+
+	matched, _, found := tree.LongestSuffix([]byte("www.example.com"))
+	assert.True(t, found)
+	// The key is kept as is, like Tree
+	assert.Equal(t, &key[0], &matched[0])
+	_, _, found = tree.LongestSuffix([]byte("org"))
+	assert.False(t, found)
+}
+
+func TestKeyTree_Snapshot(t *testing.T) {
+	tree := NewKeyTree[string]()
+	tree.Insert("a", 1)
+	snapshot := tree.Tree().Snapshot()
+	assert.True(t, tree.CompareAndSwap("a", 1, 2))
+	value, _ := snapshot.Get([]byte("a"))
+	assert.Equal(t, 1, value)
+	value, _ = tree.Get("a")
+	assert.Equal(t, 2, value)
+}
+
+func TestKeyTree_NoCopy(t *testing.T) {
+	tree := NewKeyTree[string]()
+	tree.Insert("example.com", 1)
+	allocs := testing.AllocsPerRun(100, func() {
+		tree.Get("www.example.com")
+		tree.LongestSuffix("www.example.com")
+	})
+	assert.Equal(t, 0.0, allocs)
+}
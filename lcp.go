@@ -0,0 +1,124 @@
+package suffix
+
+import (
+	"bytes"
+)
+
+// keyPath returns the nodes from the root to the node holding the leaf of key, and the
+// number of bytes from the end of the key to each of them, with the key transformed by the
+// options of the tree. It returns nil if key is not in the tree.
+func (tree *Tree) keyPath(key []byte) (nodes []*_Node, depths []int, prepared []byte) {
+	key, err := tree.prepareKey(key)
+	if err != nil {
+		return nil, nil, nil
+	}
+	prepared = key
+	node, depth := tree.root, 0
+	for {
+		nodes, depths = append(nodes, node), append(depths, depth)
+		var next *_Node
+		for _, edge := range node.edges {
+			if !bytes.HasSuffix(key, edge.label) {
+				continue
+			}
+			rest := key[:len(key)-len(edge.label)]
+			switch point := edge.point.(type) {
+			case *_Leaf:
+				if len(rest) == 0 {
+					return nodes, depths, prepared
+				}
+				continue
+			case *_Node:
+				next, key, depth = point, rest, depth+len(edge.label)
+			}
+			break
+		}
+		if next == nil {
+			return nil, nil, nil
+		}
+		node = next
+	}
+}
+
+// lcaDepth returns the depth of the lowest common ancestor of two paths of keyPath, which is
+// the length of the common suffix of their keys.
+func lcaDepth(a, b []*_Node, depthsA []int) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return depthsA[i-1]
+}
+
+// LCP returns the length of the longest common suffix of two keys in the tree, which is the
+// longest common prefix of the reversed keys the tree is built on. It is the depth of the
+// lowest common ancestor of their leaves, so the keys are not compared byte by byte. found is
+// false if a key is not in the tree.
+func (tree *Tree) LCP(a, b []byte) (length int, found bool) {
+	pathA, depthsA, a := tree.keyPath(a)
+	pathB, _, b := tree.keyPath(b)
+	if pathA == nil || pathB == nil {
+		return 0, false
+	}
+	if bytes.Equal(a, b) {
+		return len(a), true
+	}
+	return lcaDepth(pathA, pathB, depthsA), true
+}
+
+// LCPMatrix returns the LCP of each pair of the keys, to cluster the keys by the length of
+// their shared suffixes. m[i][j] is the LCP of keys[i] and keys[j], and m[i][i] is the length
+// of keys[i]. The entries of the keys not in the tree are -1. The lengths are of the keys
+// transformed by the options of the tree, like the keys stored in it.
+func (tree *Tree) LCPMatrix(keys [][]byte) [][]int {
+	paths := make([][]*_Node, len(keys))
+	depths := make([][]int, len(keys))
+	prepared := make([][]byte, len(keys))
+	for i, key := range keys {
+		paths[i], depths[i], prepared[i] = tree.keyPath(key)
+	}
+	m := make([][]int, len(keys))
+	for i := range keys {
+		m[i] = make([]int, len(keys))
+		for j := range keys {
+			switch {
+			case paths[i] == nil || paths[j] == nil:
+				m[i][j] = -1
+			case j < i:
+				m[i][j] = m[j][i]
+			case bytes.Equal(prepared[i], prepared[j]):
+				m[i][j] = len(prepared[i])
+			default:
+				m[i][j] = lcaDepth(paths[i], paths[j], depths[i])
+			}
+		}
+	}
+	return m
+}
+
+// MinimalUniqueSuffix returns the shortest suffix of key which is not a suffix of any other key
+// in the tree, like the shortest alias telling a host name from the others. In the separator
+// mode, the suffix is extended to whole labels, so "example.com" rather than "xample.com" is
+// told from "sample.com". found is false if key is not in the tree or is itself a suffix of
+// another key, like "example.com" when "www.example.com" is stored.
+func (tree *Tree) MinimalUniqueSuffix(key []byte) (suffix []byte, found bool) {
+	path, depths, key := tree.keyPath(key)
+	if path == nil {
+		return nil, false
+	}
+	n := 0
+	if tree.leavesNum > 1 {
+		// The other keys under the node holding the leaf share depth bytes with key, and
+		// those outside share less. The label of the leaf has no common last byte with its
+		// siblings, so one more byte tells key from them, if there is such a byte.
+		n = depths[len(depths)-1] + 1
+		if n > len(key) {
+			return nil, false
+		}
+	}
+	if sep := tree.separator(); sep >= 0 && n > 0 {
+		start := bytes.LastIndexByte(key[:len(key)-n+1], byte(sep))
+		n = len(key) - start - 1
+	}
+	return tree.output(key[len(key)-n:]), true
+}
@@ -0,0 +1,159 @@
+package suffix
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLCP(t *testing.T) {
+	tree := NewTree()
+	for _, key := range []string{"com", "example.com", "a.example.com", "b.example.com",
+		"xample.com", "org", ""} {
+		tree.Insert([]byte(key), nil)
+	}
+	for _, c := range []struct {
+		a, b   string
+		length int
+	}{
+		{"com", "example.com", 3},
+		{"a.example.com", "b.example.com", len(".example.com")},
+		{"a.example.com", "xample.com", len("xample.com")},
+		{"example.com", "xample.com", len("xample.com")},
+		{"com", "org", 0},
+		{"", "org", 0},
+		{"example.com", "example.com", len("example.com")},
+	} {
+		length, found := tree.LCP([]byte(c.a), []byte(c.b))
+		assert.True(t, found)
+		assert.Equal(t, c.length, length, "%s %s", c.a, c.b)
+		length, _ = tree.LCP([]byte(c.b), []byte(c.a))
+		assert.Equal(t, c.length, length, "%s %s", c.b, c.a)
+	}
+	_, found := tree.LCP([]byte("com"), []byte("net"))
+	assert.False(t, found)
+	_, found = tree.LCP([]byte("ample.com"), []byte("com"))
+	assert.False(t, found)
+	_, found = tree.LCP(nil, []byte("com"))
+	assert.False(t, found)
+
+	assert.Equal(t, [][]int{
+		{3, 3, 0, -1},
+		{3, 11, 0, -1},
+		{0, 0, 3, -1},
+		{-1, -1, -1, -1},
+	}, tree.LCPMatrix([][]byte{[]byte("com"), []byte("example.com"), []byte("org"),
+		[]byte("net")}))
+}
+
+func TestLCP_Random(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		tree := NewTree()
+		var keys [][]byte
+		for j := 0; j < 20; j++ {
+			key := randomText("ab.", rand.Intn(10))
+			if _, found := tree.Get(key); !found {
+				keys = append(keys, key)
+				tree.Insert(key, nil)
+			}
+		}
+		m := tree.LCPMatrix(keys)
+		for a := range keys {
+			for b := range keys {
+				expected := seqSuffixLen(keys[a], keys[b])
+				assert.Equal(t, expected, m[a][b], "%q %q", keys[a], keys[b])
+				length, found := tree.LCP(keys[a], keys[b])
+				assert.True(t, found)
+				assert.Equal(t, expected, length)
+			}
+		}
+	}
+}
+
+func TestLCP_Transform(t *testing.T) {
+	tree := NewTree(WithCaseFolding())
+	tree.Insert([]byte("Example.COM"), nil)
+	tree.Insert([]byte("a.example.com"), nil)
+	length, found := tree.LCP([]byte("EXAMPLE.com"), []byte("example.com"))
+	assert.True(t, found)
+	assert.Equal(t, len("example.com"), length)
+	assert.Equal(t, [][]int{{11, 11}, {11, 13}},
+		tree.LCPMatrix([][]byte{[]byte("example.com"), []byte("A.example.com")}))
+}
+
+func TestMinimalUniqueSuffix(t *testing.T) {
+	tree := NewTree()
+	for _, key := range []string{"com", "example.com", "sample.com", "www.example.com",
+		"org"} {
+		tree.Insert([]byte(key), nil)
+	}
+	for key, suffix := range map[string]string{
+		"org":             "g",
+		"sample.com":      "sample.com",
+		"www.example.com": ".example.com",
+	} {
+		s, found := tree.MinimalUniqueSuffix([]byte(key))
+		assert.True(t, found, key)
+		assert.Equal(t, suffix, string(s), key)
+	}
+	for _, key := range []string{"com", "example.com", "net", ""} {
+		_, found := tree.MinimalUniqueSuffix([]byte(key))
+		assert.False(t, found, key)
+	}
+
+	tree = NewTree(WithSeparator('.'))
+	for _, key := range []string{"example.com", "sample.com", "www.example.org", "a.b.c"} {
+		tree.Insert([]byte(key), nil)
+	}
+	for key, suffix := range map[string]string{
+		"example.com":     "example.com",
+		"sample.com":      "sample.com",
+		"www.example.org": "org",
+		"a.b.c":           "c",
+	} {
+		s, found := tree.MinimalUniqueSuffix([]byte(key))
+		assert.True(t, found, key)
+		assert.Equal(t, suffix, string(s), key)
+	}
+
+	tree = NewTree()
+	tree.Insert([]byte("only"), nil)
+	s, found := tree.MinimalUniqueSuffix([]byte("only"))
+	assert.True(t, found)
+	assert.Equal(t, "", string(s))
+}
+
+func TestMinimalUniqueSuffix_Random(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		tree := NewTree()
+		var keys [][]byte
+		for j := rand.Intn(20); j >= 0; j-- {
+			key := randomText("ab", rand.Intn(8))
+			if _, found := tree.Get(key); !found {
+				tree.Insert(key, nil)
+				keys = append(keys, key)
+			}
+		}
+		for _, key := range keys {
+			expected := -1
+			for n := 0; n <= len(key) && expected < 0; n++ {
+				unique := true
+				for _, other := range keys {
+					if !bytes.Equal(other, key) && bytes.HasSuffix(other, key[len(key)-n:]) {
+						unique = false
+					}
+				}
+				if unique {
+					expected = n
+				}
+			}
+			s, found := tree.MinimalUniqueSuffix(key)
+			assert.Equal(t, expected >= 0, found, "%q %q", keys, key)
+			if found {
+				assert.Equal(t, key[len(key)-expected:], s, "%q %q", keys, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package suffix
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+)
+
+// LoadOptions controls how LoadKeys parses the lines.
+type LoadOptions struct {
+	// Skip the lines starting with the prefix, like "#". Empty means there is no comment.
+	CommentPrefix string
+	// Remove the whitespaces at the end of each line, and at the end of the key before the tab.
+	TrimTrailingSpace bool
+	// Convert the keys, but not the values, to lower case.
+	Lowercase bool
+}
+
+// LoadKeys inserts the keys read from r into the tree, one key per line. If a line contains a
+// tab, the part after the first tab is used as the value in string, otherwise the value is nil.
+// Empty lines are skipped. It returns the number of keys inserted, not counting the keys
+// rejected by the tree.
+func LoadKeys(tree *Tree, r io.Reader, opts *LoadOptions) (n int, err error) {
+	if opts == nil {
+		opts = &LoadOptions{}
+	}
+	commentPrefix := []byte(opts.CommentPrefix)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(commentPrefix) > 0 && bytes.HasPrefix(line, commentPrefix) {
+			continue
+		}
+		if opts.TrimTrailingSpace {
+			line = bytes.TrimRight(line, " \t\r\n\v\f")
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		var value interface{}
+		if i := bytes.IndexByte(line, '\t'); i >= 0 {
+			value = string(line[i+1:])
+			line = line[:i]
+			if opts.TrimTrailingSpace {
+				line = bytes.TrimRight(line, " \t\r\n\v\f")
+			}
+		}
+		// The scanner reuses its buffer, so we need to copy the key
+		var key []byte
+		if opts.Lowercase {
+			key = bytes.ToLower(line)
+		} else {
+			key = append([]byte{}, line...)
+		}
+		if _, ok := tree.Insert(key, value); ok {
+			n++
+		}
+	}
+	return n, scanner.Err()
+}
+
+// LoadKeysFile is like LoadKeys, but reads the keys from the file.
+func LoadKeysFile(tree *Tree, path string, opts *LoadOptions) (n int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return LoadKeys(tree, f, opts)
+}
@@ -0,0 +1,88 @@
+package suffix
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const domainList = `# domains
+Example.COM	Example
+example.org  
+	tab
+
+# comment
+sub.example.net	a	b
+example.net  	net
+`
+
+func TestLoadKeys(t *testing.T) {
+	tree := NewTree()
+	n, err := LoadKeys(tree, strings.NewReader(domainList), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 7, n)
+	value, found := tree.Get([]byte("Example.COM"))
+	assert.True(t, found)
+	assert.Equal(t, "Example", value)
+	_, found = tree.Get([]byte("example.org  "))
+	assert.True(t, found)
+	value, found = tree.Get([]byte{})
+	assert.True(t, found)
+	assert.Equal(t, "tab", value)
+	_, found = tree.Get([]byte("# comment"))
+	assert.True(t, found)
+	value, _ = tree.Get([]byte("sub.example.net"))
+	assert.Equal(t, "a\tb", value)
+
+	tree = NewTree()
+	n, err = LoadKeys(tree, strings.NewReader(domainList), &LoadOptions{
+		CommentPrefix:     "#",
+		TrimTrailingSpace: true,
+		Lowercase:         true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, n)
+	value, found = tree.Get([]byte("example.com"))
+	assert.True(t, found)
+	assert.Equal(t, "Example", value)
+	value, found = tree.Get([]byte("example.org"))
+	assert.True(t, found)
+	assert.Nil(t, value)
+	_, found = tree.Get([]byte("# comment"))
+	assert.False(t, found)
+	value, found = tree.Get([]byte("example.net"))
+	assert.True(t, found)
+	assert.Equal(t, "net", value)
+
+	// The rejected keys are not counted
+	tree = NewTree(WithMaxKeyLen(11))
+	n, err = LoadKeys(tree, strings.NewReader(domainList), &LoadOptions{
+		CommentPrefix:     "#",
+		TrimTrailingSpace: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, 4, tree.Len())
+}
+
+func TestLoadKeysFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "suffix_test_")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "domains.txt")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("example.com\r\nexample.org\r\n"), 0644))
+
+	tree := NewTree()
+	n, err := LoadKeysFile(tree, path, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	_, found := tree.Get([]byte("example.com"))
+	assert.True(t, found)
+
+	_, err = LoadKeysFile(tree, filepath.Join(dir, "nonexist"), nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,67 @@
+package suffix
+
+import (
+	"bytes"
+)
+
+// eventLogger receives the structural events of WithLogger. It is an interface so that the
+// tree doesn't depend on log/slog, which needs Go 1.21.
+type eventLogger interface {
+	enabled() bool
+	event(msg string, args ...interface{})
+}
+
+// logDepth is the depth from which WithLogger reports a key as too deep. A long chain of
+// nodes usually means many keys sharing long suffixes, which takes more memory than a map.
+const logDepth = 16
+
+// leafPath finds the leaf of key. It returns the node holding the edge to the leaf, the edge
+// above that node, nil for the root, and the number of edges from the root to the leaf. The
+// node is nil if key is not in the tree.
+func (node *_Node) leafPath(key []byte) (parent *_Node, above *_Edge, depth int) {
+	for depth = 1; ; depth++ {
+		var next *_Edge
+		for _, edge := range node.edges {
+			if !bytes.HasSuffix(key, edge.label) {
+				continue
+			}
+			rest := key[:len(key)-len(edge.label)]
+			if _, ok := edge.point.(*_Leaf); ok {
+				if len(rest) == 0 {
+					return node, above, depth
+				}
+				continue
+			}
+			next, key = edge, rest
+			break
+		}
+		if next == nil {
+			return nil, nil, 0
+		}
+		node, above = next.point.(*_Node), next
+	}
+}
+
+// logInserted reports the node created for a new key, and the key if it is too deep.
+func (tree *Tree) logInserted(key []byte) {
+	parent, above, depth := tree.root.leafPath(key)
+	if parent == nil {
+		return
+	}
+	// The nodes other than the root have at least 2 edges, so a node with 2 edges under a new
+	// key is created by splitting the edge above it
+	if above != nil && len(parent.edges) == 2 {
+		tree.logger.event("split edge", "key", quoteKey(key), "label", quoteKey(above.label),
+			"depth", depth)
+	}
+	if depth > logDepth {
+		tree.logger.event("deep key", "key", quoteKey(key), "depth", depth)
+	}
+}
+
+// removalMerges reports whether removing key merges the node of its leaf into the edge
+// above it, as the node is left with a single edge.
+func (tree *Tree) removalMerges(key []byte) bool {
+	parent, above, _ := tree.root.leafPath(key)
+	return above != nil && len(parent.edges) == 2
+}
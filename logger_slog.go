@@ -0,0 +1,42 @@
+//go:build go1.21
+// +build go1.21
+
+package suffix
+
+import (
+	"context"
+	"log/slog"
+)
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) enabled() bool {
+	return l.logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+func (l slogLogger) event(msg string, args ...interface{}) {
+	l.logger.Debug(msg, args...)
+}
+
+// WithLogger logs the structural events of Insert, TryInsert and Remove at the debug level,
+// to find out why a tree takes more memory than expected:
+//
+//   - "split edge": a new key splits an edge, which creates a node
+//   - "merged edge": a removed key leaves a node with one edge, which is merged into the
+//     edge above it
+//   - "deep key": a new key is more than 16 edges deep
+//   - "rejected key": Insert rejects a key, with the error of TryInsert
+//
+// The keys and labels are logged quoted like Dump does. The events are only looked for when
+// the logger is enabled for the debug level. A nil logger disables the logging.
+func WithLogger(logger *slog.Logger) Option {
+	return func(tree *Tree) {
+		if logger == nil {
+			tree.logger = nil
+			return
+		}
+		tree.logger = slogLogger{logger}
+	}
+}
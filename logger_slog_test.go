@@ -0,0 +1,85 @@
+//go:build go1.21
+// +build go1.21
+
+package suffix
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLogger(buf *bytes.Buffer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey || attr.Key == slog.LevelKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}))
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	tree := NewTree(WithLogger(newTestLogger(&buf, slog.LevelDebug)), WithMaxKeyLen(20))
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("org"), nil)
+	assert.Equal(t, "", buf.String())
+
+	tree.Insert([]byte("example.com"), nil)
+	tree.Insert([]byte("a.example.com"), nil)
+	tree.Insert([]byte("b.example.com"), nil)
+	tree.Insert([]byte("b.example.com"), 1)
+	tree.Insert(nil, nil)
+	tree.Insert([]byte("a-very-long.example.com"), nil)
+	assert.Equal(t, `msg="split edge" key="\"example.com\"" label="\"com\"" depth=2
+msg="split edge" key="\"a.example.com\"" label="\"example.\"" depth=3
+msg="split edge" key="\"b.example.com\"" label="\".\"" depth=4
+msg="rejected key" key="\"\"" err="suffix: nil key"
+msg="rejected key" key="\"a-very-long.example.com\"" err="suffix: key is too long: 23 bytes, the limit is 20"
+`, buf.String())
+
+	buf.Reset()
+	tree.Remove([]byte("b.example.com"))
+	tree.Remove([]byte("a.example.com"))
+	tree.Remove([]byte("net"))
+	tree.Remove([]byte("org"))
+	assert.Equal(t, `msg="merged edge" key="\"b.example.com\""
+msg="merged edge" key="\"a.example.com\""
+`, buf.String())
+	assert.Nil(t, tree.Validate())
+
+	buf.Reset()
+	tree.Snapshot().Insert([]byte("x.com"), nil)
+	assert.Contains(t, buf.String(), "split edge")
+}
+
+func TestWithLogger_DeepKey(t *testing.T) {
+	var buf bytes.Buffer
+	tree := NewTree(WithLogger(newTestLogger(&buf, slog.LevelDebug)))
+	key := ""
+	for i := 0; i < logDepth+1; i++ {
+		key = "x" + key
+		tree.Insert([]byte(key), nil)
+	}
+	assert.Equal(t, 1, strings.Count(buf.String(), "deep key"))
+	assert.Contains(t, buf.String(), `msg="deep key" key="\"xxxxxxxxxxxxxxxxx\"" depth=17`)
+}
+
+func TestWithLogger_Disabled(t *testing.T) {
+	var buf bytes.Buffer
+	tree := NewTree(WithLogger(newTestLogger(&buf, slog.LevelInfo)))
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("example.com"), nil)
+	tree.Insert(nil, nil)
+	assert.Equal(t, "", buf.String())
+
+	tree = NewTree(WithLogger(nil))
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("example.com"), nil)
+}
@@ -0,0 +1,125 @@
+package suffix
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+)
+
+// WithMerkleHashes caches the hash of each subtree reported by MerkleRoot and MerkleChildren,
+// so they are only computed again for the subtrees changed since. Without it, the hashes are
+// computed for each call. The cache is cleared along the path of each change, and kept in
+// the nodes shared with snapshots, since those are never changed.
+func WithMerkleHashes() Option {
+	return func(tree *Tree) {
+		tree.merkleHashes = true
+	}
+}
+
+// MerkleNode is the hash of a subtree, see MerkleChildren.
+type MerkleNode struct {
+	// The suffix of all keys in the subtree, which identifies it in the tree. It is the key
+	// of a leaf, transformed by the options like the stored keys.
+	Suffix []byte
+	Hash   [sha256.Size]byte
+	Leaf   bool
+}
+
+// leafHash hashes the value of a leaf. The first byte tells leaves from nodes.
+func leafHash(value interface{}) (sum [sha256.Size]byte, err error) {
+	buf, err := appendValue([]byte{0}, value)
+	if err != nil {
+		return sum, err
+	}
+	return sha256.Sum256(buf), nil
+}
+
+// merkleHash hashes the labels and the hashes of the edges of node. The result is cached
+// if cache is true.
+func (node *_Node) merkleHash(cache bool) (sum [sha256.Size]byte, err error) {
+	if cached := node.merkle.Load(); cached != nil {
+		return *cached, nil
+	}
+	h := sha256.New()
+	h.Write([]byte{1})
+	var buf []byte
+	for _, edge := range node.edges {
+		buf = appendUvarint(buf[:0], uint64(len(edge.label)))
+		buf = append(buf, edge.label...)
+		var child [sha256.Size]byte
+		switch point := edge.point.(type) {
+		case *_Leaf:
+			child, err = leafHash(point.value)
+		case *_Node:
+			child, err = point.merkleHash(cache)
+		}
+		if err != nil {
+			return sum, err
+		}
+		buf = append(buf, child[:]...)
+		h.Write(buf)
+	}
+	h.Sum(sum[:0])
+	if cache {
+		node.merkle.Store(&sum)
+	}
+	return sum, nil
+}
+
+// MerkleRoot returns the hash of the whole tree, with an empty Suffix. Like Hash, the trees
+// with the same keys and values have the same hash, and the values should be nil, booleans,
+// numbers, strings or []byte.
+//
+// Two replicas can be synchronized by comparing their hashes from the root down: the
+// subtrees with the same Suffix and Hash are equal, so only the differing ones are walked
+// with MerkleChildren, and their keys are transferred.
+func (tree *Tree) MerkleRoot() (MerkleNode, error) {
+	sum, err := tree.root.merkleHash(tree.merkleHashes)
+	if err != nil {
+		return MerkleNode{}, err
+	}
+	return MerkleNode{Suffix: []byte{}, Hash: sum}, nil
+}
+
+// MerkleChildren returns the hashes of the subtrees under the subtree of suffix, which is
+// the Suffix of a MerkleNode other than a leaf, in the order of Walk. It returns an error if
+// there is no such subtree in the tree.
+func (tree *Tree) MerkleChildren(suffix []byte) ([]MerkleNode, error) {
+	node := tree.root
+	rest := suffix
+	for len(rest) > 0 {
+		var next *_Node
+		for _, edge := range node.edges {
+			if point, ok := edge.point.(*_Node); ok && len(edge.label) > 0 &&
+				bytes.HasSuffix(rest, edge.label) {
+
+				next = point
+				rest = rest[:len(rest)-len(edge.label)]
+				break
+			}
+		}
+		if next == nil {
+			return nil, fmt.Errorf("suffix: no subtree of suffix %s", quoteKey(suffix))
+		}
+		node = next
+	}
+
+	children := make([]MerkleNode, len(node.edges))
+	for i, edge := range node.edges {
+		child := &children[i]
+		child.Suffix = make([]byte, 0, len(edge.label)+len(suffix))
+		child.Suffix = append(append(child.Suffix, edge.label...), suffix...)
+		var err error
+		switch point := edge.point.(type) {
+		case *_Leaf:
+			child.Leaf = true
+			child.Hash, err = leafHash(point.value)
+		case *_Node:
+			child.Hash, err = point.merkleHash(tree.merkleHashes)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return children, nil
+}
@@ -0,0 +1,124 @@
+package suffix
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// syncKeys compares the trees from the root down, and returns the suffixes of the subtrees
+// which differ, with the number of nodes compared.
+func syncKeys(t *testing.T, a, b *Tree) (differing []string, compared int) {
+	rootA, err := a.MerkleRoot()
+	assert.Nil(t, err)
+	rootB, _ := b.MerkleRoot()
+	if rootA.Hash == rootB.Hash {
+		return nil, 1
+	}
+	var visit func(suffix []byte)
+	visit = func(suffix []byte) {
+		childrenA, err := a.MerkleChildren(suffix)
+		assert.Nil(t, err)
+		childrenB, err := b.MerkleChildren(suffix)
+		assert.Nil(t, err)
+		others := map[string]MerkleNode{}
+		for _, child := range childrenB {
+			if !child.Leaf {
+				others[string(child.Suffix)] = child
+			}
+		}
+		for _, child := range childrenA {
+			compared++
+			other, ok := others[string(child.Suffix)]
+			switch {
+			case child.Leaf:
+				value, _ := b.Get(child.Suffix)
+				hash, _ := leafHash(value)
+				if _, found := b.Get(child.Suffix); !found || hash != child.Hash {
+					differing = append(differing, string(child.Suffix))
+				}
+			case !ok:
+				differing = append(differing, string(child.Suffix))
+			case other.Hash != child.Hash:
+				visit(child.Suffix)
+			}
+		}
+	}
+	visit(nil)
+	return differing, compared
+}
+
+func TestMerkle(t *testing.T) {
+	keys := []string{"com", "example.com", "www.example.com", "api.example.com", "org",
+		"example.org", "net"}
+	a := NewTree(WithMerkleHashes())
+	b := NewTree()
+	for i, key := range keys {
+		a.Insert([]byte(key), i)
+		b.Insert([]byte(keys[len(keys)-1-i]), len(keys)-1-i)
+	}
+	rootA, err := a.MerkleRoot()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{}, rootA.Suffix)
+	rootB, _ := b.MerkleRoot()
+	assert.Equal(t, rootA.Hash, rootB.Hash)
+
+	children, err := a.MerkleChildren([]byte("com"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(children))
+	assert.Equal(t, "com", string(children[0].Suffix))
+	assert.True(t, children[0].Leaf)
+	assert.Equal(t, "example.com", string(children[1].Suffix))
+	assert.False(t, children[1].Leaf)
+	_, err = a.MerkleChildren([]byte("ample.com"))
+	assert.NotNil(t, err)
+	_, err = a.MerkleChildren([]byte("net"))
+	assert.NotNil(t, err)
+
+	// The cached hashes are cleared on the path of the changes
+	snapshot := a.Snapshot()
+	a.Insert([]byte("api.example.com"), "changed")
+	assert.True(t, a.CompareAndSwap([]byte("org"), 4, "swapped"))
+	differing, _ := syncKeys(t, a, b)
+	assert.Equal(t, []string{"api.example.com", "org"}, differing)
+	differing, _ = syncKeys(t, snapshot, b)
+	assert.Empty(t, differing)
+
+	a.Remove([]byte("example.org"))
+	a.Insert([]byte("api.example.com"), 3)
+	a.Insert([]byte("org"), 4)
+	a.Insert([]byte("example.org"), 5)
+	rootA, _ = a.MerkleRoot()
+	assert.Equal(t, rootB.Hash, rootA.Hash)
+
+	b.Insert([]byte("chan"), make(chan int))
+	_, err = b.MerkleRoot()
+	assert.NotNil(t, err)
+}
+
+func TestMerkle_Random(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		a, b := NewTree(WithMerkleHashes()), NewTree()
+		var keys [][]byte
+		for j := 0; j < 200; j++ {
+			key := randomText("abc", 1+rand.Intn(10))
+			keys = append(keys, key)
+			a.Insert(key, j)
+			b.Insert(key, j)
+		}
+		a.MerkleRoot()
+		changed := map[string]bool{}
+		for j := 0; j < 3; j++ {
+			key := keys[rand.Intn(len(keys))]
+			a.Insert(key, "changed")
+			changed[string(key)] = true
+		}
+		differing, compared := syncKeys(t, a, b)
+		assert.Equal(t, len(changed), len(differing))
+		for _, key := range differing {
+			assert.True(t, changed[key], key)
+		}
+		assert.True(t, compared < a.Len(), "%d", compared)
+	}
+}
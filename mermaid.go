@@ -0,0 +1,135 @@
+package suffix
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MermaidOptions controls which part of the tree WriteMermaid draws.
+type MermaidOptions struct {
+	// Only draw the keys ending with the suffix. Empty means the whole tree.
+	Suffix []byte
+	// The maximum number of edges from the top of the drawing to a leaf. Nodes deeper than
+	// that are folded into a box with the number of keys under it. 0 means no limit.
+	MaxDepth int
+	// Add the edge counts of WithEdgeCounts to the labels, and color the edges from gray for
+	// the cold ones to red for the hottest one. It does nothing without WithEdgeCounts.
+	Heatmap bool
+}
+
+// heatColors are the colors of the edges in a heatmap, from the coldest to the hottest.
+var heatColors = []string{"#bbbbbb", "#f4c542", "#f49d37", "#e8592c", "#d7191c"}
+
+var mermaidReplacer = strings.NewReplacer(`#`, `#35;`, `\"`, `#quot;`, `<`, `#lt;`, `>`, `#gt;`)
+
+// mermaidText quotes b as a Mermaid string, with non-printable bytes escaped by quoteKey.
+func mermaidText(b []byte) string {
+	quoted := quoteKey(b)
+	return `"` + mermaidReplacer.Replace(quoted[1:len(quoted)-1]) + `"`
+}
+
+// WriteMermaid writes the tree as a Mermaid flowchart, which is rendered inline by GitHub and
+// many documentation tools. The root is on the right, so the labels read in the same order
+// as the keys. A nil opts draws the whole tree.
+func (tree *Tree) WriteMermaid(w io.Writer, opts *MermaidOptions) error {
+	if opts == nil {
+		opts = &MermaidOptions{}
+	}
+	var buf bytes.Buffer
+	buf.WriteString("flowchart RL\n")
+
+	var counts map[string]uint64
+	var maxCount uint64
+	if opts.Heatmap {
+		counts = tree.EdgeCounts()
+		for _, n := range counts {
+			if n > maxCount {
+				maxCount = n
+			}
+		}
+	}
+
+	var top interface{} = tree.root
+	suffix := opts.Suffix
+	// The path of top, for the edge counts
+	var topPath []byte
+	for len(suffix) > 0 && top != nil {
+		node, ok := top.(*_Node)
+		if !ok {
+			top = nil
+			break
+		}
+		top = nil
+		for _, edge := range node.edges {
+			if bytes.HasSuffix(edge.label, suffix) {
+				// The suffix ends inside this label
+				top = edge.point
+				topPath = append(append([]byte{}, edge.label...), topPath...)
+				suffix = nil
+				break
+			}
+			if len(edge.label) > 0 && bytes.HasSuffix(suffix, edge.label) {
+				top = edge.point
+				topPath = append(append([]byte{}, edge.label...), topPath...)
+				suffix = suffix[:len(suffix)-len(edge.label)]
+				break
+			}
+		}
+	}
+
+	id, link := 0, 0
+	var styles []string
+	var draw func(point interface{}, path []byte, depth int) string
+	draw = func(point interface{}, path []byte, depth int) string {
+		name := "n" + strconv.Itoa(id)
+		id++
+		switch point := point.(type) {
+		case *_Leaf:
+			text := point.originKey
+			if point.value != nil {
+				text = append(append(append([]byte{}, text...), " = "...), fmt.Sprint(point.value)...)
+			}
+			fmt.Fprintf(&buf, "    %s[%s]\n", name, mermaidText(text))
+		case *_Node:
+			if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+				n := 0
+				point.walk(func(key []byte, value interface{}) bool {
+					n++
+					return false
+				})
+				fmt.Fprintf(&buf, "    %s[\"... %d keys\"]\n", name, n)
+				break
+			}
+			fmt.Fprintf(&buf, "    %s((\" \"))\n", name)
+			for _, edge := range point.edges {
+				edgePath := append(append([]byte{}, edge.label...), path...)
+				child := draw(edge.point, edgePath, depth+1)
+				label := edge.label
+				if n := counts[string(edgePath)]; n > 0 && len(label) > 0 {
+					label = append(append([]byte{}, label...), fmt.Sprintf(" (%d)", n)...)
+					heat := int(n * uint64(len(heatColors)-1) / maxCount)
+					styles = append(styles, fmt.Sprintf("    linkStyle %d stroke:%s,stroke-width:%dpx\n",
+						link, heatColors[heat], 1+heat))
+				}
+				link++
+				if len(label) == 0 {
+					fmt.Fprintf(&buf, "    %s --> %s\n", name, child)
+				} else {
+					fmt.Fprintf(&buf, "    %s -->|%s| %s\n", name, mermaidText(label), child)
+				}
+			}
+		}
+		return name
+	}
+	if top != nil {
+		draw(top, topPath, 0)
+	}
+	for _, style := range styles {
+		buf.WriteString(style)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,77 @@
+package suffix
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteMermaid(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("example.com"), 1)
+	tree.Insert([]byte("a.example.com"), "a")
+	tree.Insert([]byte("\"x#<y>\".org"), nil)
+
+	var buf bytes.Buffer
+	assert.Nil(t, tree.WriteMermaid(&buf, nil))
+	assert.Equal(t, `flowchart RL
+    n0((" "))
+    n1((" "))
+    n2["com"]
+    n1 --> n2
+    n3((" "))
+    n4["example.com = 1"]
+    n3 --> n4
+    n5["a.example.com = a"]
+    n3 -->|"a."| n5
+    n1 -->|"example."| n3
+    n0 -->|"com"| n1
+    n6["#quot;x#35;#lt;y#gt;#quot;.org"]
+    n0 -->|"#quot;x#35;#lt;y#gt;#quot;.org"| n6
+`, buf.String())
+
+	buf.Reset()
+	assert.Nil(t, tree.WriteMermaid(&buf, &MermaidOptions{Suffix: []byte("le.com")}))
+	assert.Equal(t, `flowchart RL
+    n0((" "))
+    n1["example.com = 1"]
+    n0 --> n1
+    n2["a.example.com = a"]
+    n0 -->|"a."| n2
+`, buf.String())
+
+	buf.Reset()
+	assert.Nil(t, tree.WriteMermaid(&buf, &MermaidOptions{Suffix: []byte(".org")}))
+	assert.Equal(t, `flowchart RL
+    n0["#quot;x#35;#lt;y#gt;#quot;.org"]
+`, buf.String())
+
+	buf.Reset()
+	assert.Nil(t, tree.WriteMermaid(&buf, &MermaidOptions{Suffix: []byte("net")}))
+	assert.Equal(t, "flowchart RL\n", buf.String())
+
+	buf.Reset()
+	assert.Nil(t, tree.WriteMermaid(&buf, &MermaidOptions{MaxDepth: 1}))
+	assert.Equal(t, `flowchart RL
+    n0((" "))
+    n1["... 3 keys"]
+    n0 -->|"com"| n1
+    n2["#quot;x#35;#lt;y#gt;#quot;.org"]
+    n0 -->|"#quot;x#35;#lt;y#gt;#quot;.org"| n2
+`, buf.String())
+}
+
+func TestWriteMermaid_Binary(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("\x00\xff"), nil)
+
+	var buf bytes.Buffer
+	assert.Nil(t, tree.WriteMermaid(&buf, nil))
+	assert.Equal(t, `flowchart RL
+    n0((" "))
+    n1["\x00\xff"]
+    n0 -->|"\x00\xff"| n1
+`, buf.String())
+}
@@ -0,0 +1,77 @@
+package suffix
+
+import (
+	"expvar"
+	"time"
+)
+
+// The operations reported to MetricsSink
+const (
+	OpInsert        = "Insert"
+	OpGet           = "Get"
+	OpLongestSuffix = "LongestSuffix"
+	OpRemove        = "Remove"
+	OpHasSequence   = "HasSequence"
+)
+
+// MetricsSink receives the metrics of the operations on a tree, see WithMetrics.
+type MetricsSink interface {
+	// Observe is called after each operation with its latency. hit tells whether the key is
+	// inserted by Insert, found by Get, LongestSuffix and Remove, or whether HasSequence
+	// returns true.
+	Observe(op string, latency time.Duration, hit bool)
+}
+
+// MetricsFunc adapts a function to MetricsSink.
+type MetricsFunc func(op string, latency time.Duration, hit bool)
+
+// Observe calls f.
+func (f MetricsFunc) Observe(op string, latency time.Duration, hit bool) {
+	f(op, latency, hit)
+}
+
+// WithMetrics reports the operations Insert, Get, LongestSuffix, Remove and HasSequence to the
+// sink, so their counts, latencies and hit ratios can be charted without wrapping every call.
+// TryInsert and TryRemove are reported as Insert and Remove. The sink is called by the
+// goroutine doing the operation, so it must be safe for concurrent use if the tree is read
+// concurrently.
+func WithMetrics(sink MetricsSink) Option {
+	return func(tree *Tree) {
+		tree.metrics = sink
+	}
+}
+
+// observe reports an operation started at start. It is deferred by the operations, so hit is
+// read after they return.
+func (tree *Tree) observe(op string, start time.Time, hit *bool) {
+	tree.metrics.Observe(op, time.Since(start), *hit)
+}
+
+// ExpvarMetrics is a MetricsSink publishing the metrics as an expvar.Map, which is served at
+// /debug/vars by the expvar package. For each operation, it counts "<op>.calls", "<op>.hits",
+// "<op>.misses", and the total latency in "<op>.nanos".
+type ExpvarMetrics struct {
+	m *expvar.Map
+}
+
+// NewExpvarMetrics creates an ExpvarMetrics published under name. Like expvar.Publish, it
+// panics if the name is already published.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	return &ExpvarMetrics{m: expvar.NewMap(name)}
+}
+
+// Observe implements MetricsSink.
+func (e *ExpvarMetrics) Observe(op string, latency time.Duration, hit bool) {
+	e.m.Add(op+".calls", 1)
+	if hit {
+		e.m.Add(op+".hits", 1)
+	} else {
+		e.m.Add(op+".misses", 1)
+	}
+	e.m.Add(op+".nanos", int64(latency))
+}
+
+// Map returns the published map.
+func (e *ExpvarMetrics) Map() *expvar.Map {
+	return e.m
+}
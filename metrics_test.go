@@ -0,0 +1,53 @@
+package suffix
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type observation struct {
+	op  string
+	hit bool
+}
+
+func TestWithMetrics(t *testing.T) {
+	var observed []observation
+	tree := NewTree(WithMetrics(MetricsFunc(func(op string, latency time.Duration, hit bool) {
+		assert.True(t, latency >= 0)
+		observed = append(observed, observation{op, hit})
+	})))
+	tree.Insert([]byte("com"), 1)
+	tree.Insert(nil, 1)
+	tree.Get([]byte("com"))
+	tree.Get([]byte("org"))
+	tree.LongestSuffix([]byte("example.com"))
+	tree.HasSequence([]byte("x"))
+	tree.Snapshot().Remove([]byte("com"))
+	assert.Equal(t, []observation{
+		{OpInsert, true},
+		{OpInsert, false},
+		{OpGet, true},
+		{OpGet, false},
+		{OpLongestSuffix, true},
+		{OpHasSequence, false},
+		{OpRemove, true},
+	}, observed)
+}
+
+func TestExpvarMetrics(t *testing.T) {
+	metrics := NewExpvarMetrics("suffix_test_metrics")
+	tree := NewTree(WithMetrics(metrics))
+	tree.Insert([]byte("com"), 1)
+	tree.Get([]byte("com"))
+	tree.Get([]byte("org"))
+
+	m := metrics.Map()
+	assert.Equal(t, "2", m.Get("Get.calls").String())
+	assert.Equal(t, "1", m.Get("Get.hits").String())
+	assert.Equal(t, "1", m.Get("Get.misses").String())
+	assert.Equal(t, "1", m.Get("Insert.calls").String())
+	assert.NotNil(t, m.Get("Get.nanos"))
+	assert.Panics(t, func() { NewExpvarMetrics("suffix_test_metrics") })
+}
@@ -0,0 +1,282 @@
+package suffix
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+)
+
+// DAWG is a read-only suffix tree whose isomorphic subtrees are merged, so it is a directed
+// acyclic word graph instead of a tree, see Tree.Minimize. Keys sharing their heads across
+// different tails, like "www.example.com" and "www.example.org", share the nodes below the
+// tails, which are repeated in Tree.
+//
+// The leaves don't keep the stored keys, so the keys are sliced from the queries or rebuilt
+// from the labels. Like a frozen Tree, a DAWG can be read by goroutines concurrently.
+type DAWG struct {
+	root      *_DAGNode
+	leavesNum int
+	nodesNum  int
+	// An empty tree with the options of the minimized tree, to prepare the queries like it
+	settings *Tree
+}
+
+type _DAGNode struct {
+	edges []_DAGEdge
+}
+
+// _DAGEdge points to a leaf if child is nil.
+type _DAGEdge struct {
+	label []byte
+	child *_DAGNode
+	value interface{}
+}
+
+// Minimize merges the isomorphic subtrees of a frozen tree, which have the same labels and
+// values, into a DAWG answering the same queries. It shrinks the memory of dictionaries with
+// many shared heads, like the plural forms of words or the same names under many TLDs, but it
+// takes O(n) time and memory to build, and the DAWG can't be modified.
+//
+// The values are merged if they are equal by == or, for []byte, by their bytes. The other
+// values which are not comparable, like slices and maps, are never merged.
+func (tree *Tree) Minimize() (*DAWG, error) {
+	if !tree.frozen {
+		return nil, errors.New("suffix: only a frozen tree can be minimized")
+	}
+	m := minimizer{
+		nodes:    map[*_Node]*_DAGNode{},
+		canon:    map[string]*_DAGNode{},
+		ids:      map[*_DAGNode]uint64{},
+		values:   map[interface{}]uint64{},
+		bytesIDs: map[string]uint64{},
+	}
+	return &DAWG{
+		root:      m.node(tree.root),
+		leavesNum: tree.leavesNum,
+		nodesNum:  len(m.canon),
+		settings:  tree.emptyLike(),
+	}, nil
+}
+
+// minimizer builds the DAWG bottom-up. Each node is identified by its labels and the ids of
+// its children and values, and the nodes with the same identity are merged.
+type minimizer struct {
+	// The tree nodes converted, since the nodes of a tree can be shared by its snapshots
+	nodes map[*_Node]*_DAGNode
+	canon map[string]*_DAGNode
+	ids   map[*_DAGNode]uint64
+	// The ids of values, which are distinct from the ids of nodes
+	values   map[interface{}]uint64
+	bytesIDs map[string]uint64
+	nextID   uint64
+}
+
+func (m *minimizer) newID() uint64 {
+	m.nextID++
+	return m.nextID
+}
+
+func (m *minimizer) valueID(value interface{}) uint64 {
+	if b, ok := value.([]byte); ok {
+		id, ok := m.bytesIDs[string(b)]
+		if !ok {
+			id = m.newID()
+			m.bytesIDs[string(b)] = id
+		}
+		return id
+	}
+	if value != nil && !reflect.TypeOf(value).Comparable() {
+		return m.newID()
+	}
+	id, ok := m.values[value]
+	if !ok {
+		id = m.newID()
+		m.values[value] = id
+	}
+	return id
+}
+
+func (m *minimizer) node(node *_Node) *_DAGNode {
+	if converted, ok := m.nodes[node]; ok {
+		return converted
+	}
+	dag := &_DAGNode{edges: make([]_DAGEdge, len(node.edges))}
+	var sig []byte
+	for i, edge := range node.edges {
+		dag.edges[i].label = edge.label
+		sig = appendUvarint(sig, uint64(len(edge.label)))
+		sig = append(sig, edge.label...)
+		switch point := edge.point.(type) {
+		case *_Leaf:
+			dag.edges[i].value = point.value
+			sig = append(sig, 'v')
+			sig = appendUvarint(sig, m.valueID(point.value))
+		case *_Node:
+			child := m.node(point)
+			dag.edges[i].child = child
+			sig = append(sig, 'n')
+			sig = appendUvarint(sig, m.ids[child])
+		}
+	}
+	if same, ok := m.canon[string(sig)]; ok {
+		dag = same
+	} else {
+		m.canon[string(sig)] = dag
+		m.ids[dag] = m.newID()
+	}
+	m.nodes[node] = dag
+	return dag
+}
+
+// Len returns the number of keys.
+func (dawg *DAWG) Len() int {
+	return dawg.leavesNum
+}
+
+// Nodes returns the number of nodes after merging, including the root.
+func (dawg *DAWG) Nodes() int {
+	return dawg.nodesNum
+}
+
+// Get is like Tree.Get.
+func (dawg *DAWG) Get(key []byte) (value interface{}, found bool) {
+	key, err := dawg.settings.prepareKey(key)
+	if err != nil {
+		return nil, false
+	}
+	node := dawg.root
+	for node != nil {
+		var next *_DAGNode
+		for _, edge := range node.edges {
+			if !bytes.HasSuffix(key, edge.label) {
+				continue
+			}
+			subKey := key[:len(key)-len(edge.label)]
+			if edge.child == nil {
+				if len(subKey) == 0 {
+					return edge.value, true
+				}
+				continue
+			}
+			next, key = edge.child, subKey
+			break
+		}
+		node = next
+	}
+	return nil, false
+}
+
+// LongestSuffix is like Tree.LongestSuffix. The matchedKey is a slice of the given key, or of
+// its copy made by the transforms of the tree.
+func (dawg *DAWG) LongestSuffix(key []byte) (matchedKey []byte, value interface{}, found bool) {
+	key, err := dawg.settings.prepareKey(key)
+	if err != nil {
+		return nil, nil, false
+	}
+	matchedKey, value, found = dawg.root.longestSuffix(key, key, dawg.settings.separator())
+	if found {
+		matchedKey = dawg.settings.output(matchedKey)
+	}
+	return matchedKey, value, found
+}
+
+// longestSuffix is like _Node.longestSuffix, rest is the part of key left to match.
+func (node *_DAGNode) longestSuffix(key, rest []byte, sep int) (matchedKey []byte,
+	value interface{}, found bool) {
+
+	for _, edge := range node.edges {
+		if !bytes.HasSuffix(rest, edge.label) {
+			continue
+		}
+		subKey := rest[:len(rest)-len(edge.label)]
+		if edge.child == nil {
+			if !atBoundary(subKey, key[len(subKey):], sep) {
+				if len(edge.label) == 0 {
+					continue
+				}
+				return matchedKey, value, found
+			}
+			if len(edge.label) == 0 {
+				matchedKey, value, found = key[len(subKey):], edge.value, true
+				continue
+			}
+			return key[len(subKey):], edge.value, true
+		}
+		childKey, childValue, childFound := edge.child.longestSuffix(key, subKey, sep)
+		if childFound {
+			return childKey, childValue, true
+		}
+		return matchedKey, value, found
+	}
+	return matchedKey, value, found
+}
+
+// AllSuffixesOf is like Tree.AllSuffixesOf.
+func (dawg *DAWG) AllSuffixesOf(key []byte, f func(key []byte, value interface{}) (stop bool)) {
+	key, err := dawg.settings.prepareKey(key)
+	if err != nil {
+		return
+	}
+	dawg.root.suffixesOf(key, key, dawg.settings.separator(), dawg.settings.outputFunc(f))
+}
+
+func (node *_DAGNode) suffixesOf(key, rest []byte, sep int,
+	f func(key []byte, value interface{}) bool) (stop bool) {
+
+	ended := -1
+	for i, edge := range node.edges {
+		if !bytes.HasSuffix(rest, edge.label) {
+			continue
+		}
+		subKey := rest[:len(rest)-len(edge.label)]
+		if edge.child == nil {
+			if !atBoundary(subKey, key[len(subKey):], sep) {
+				continue
+			}
+			if len(edge.label) == 0 {
+				ended = i
+				continue
+			}
+			if f(key[len(subKey):], edge.value) {
+				return true
+			}
+		} else if edge.child.suffixesOf(key, subKey, sep, f) {
+			return true
+		}
+	}
+	if ended >= 0 {
+		return f(key[len(rest):], node.edges[ended].value)
+	}
+	return false
+}
+
+// Walk is like Tree.Walk. The keys are rebuilt from the labels, so each of them is a new slice.
+func (dawg *DAWG) Walk(f func(key []byte, value interface{}) (stop bool)) {
+	dawg.root.walk(nil, dawg.settings.outputFunc(f))
+}
+
+func (node *_DAGNode) walk(labels [][]byte, f func(key []byte, value interface{}) bool) (
+	stop bool) {
+
+	for _, edge := range node.edges {
+		if edge.child != nil {
+			if edge.child.walk(append(labels, edge.label), f) {
+				return true
+			}
+			continue
+		}
+		size := len(edge.label)
+		for _, label := range labels {
+			size += len(label)
+		}
+		key := make([]byte, 0, size)
+		key = append(key, edge.label...)
+		for i := len(labels) - 1; i >= 0; i-- {
+			key = append(key, labels[i]...)
+		}
+		if f(key, edge.value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package suffix
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinimize(t *testing.T) {
+	tree := NewTree(WithSeparator('.'))
+	for _, tld := range []string{"com", "org", "net"} {
+		tree.Insert([]byte(tld), "tld")
+		for _, name := range []string{"www.example", "mail.example", "example"} {
+			tree.Insert([]byte(name+"."+tld), name)
+		}
+	}
+	_, err := tree.Minimize()
+	assert.NotNil(t, err)
+	tree.Freeze()
+	dawg, err := tree.Minimize()
+	assert.Nil(t, err)
+	assert.Equal(t, tree.Len(), dawg.Len())
+	// The subtrees under "com", "org" and "net" are merged
+	assert.Equal(t, (tree.Stats().Nodes-1)/3+1, dawg.Nodes())
+
+	value, found := dawg.Get([]byte("mail.example.org"))
+	assert.True(t, found)
+	assert.Equal(t, "mail.example", value)
+	_, found = dawg.Get([]byte("ample.org"))
+	assert.False(t, found)
+	_, found = dawg.Get(nil)
+	assert.False(t, found)
+
+	key, value, found := dawg.LongestSuffix([]byte("api.example.net"))
+	assert.True(t, found)
+	assert.Equal(t, "example.net", string(key))
+	assert.Equal(t, "example", value)
+	key, _, _ = dawg.LongestSuffix([]byte("www.sample.net"))
+	assert.Equal(t, "net", string(key))
+
+	var keys []string
+	dawg.AllSuffixesOf([]byte("www.example.com"), func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return false
+	})
+	assert.Equal(t, []string{"www.example.com", "example.com", "com"}, keys)
+}
+
+func TestMinimize_Random(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		var opts []Option
+		if i%2 == 1 {
+			opts = append(opts, WithSeparator('.'))
+		}
+		tree := NewTree(opts...)
+		for j := rand.Intn(50); j >= 0; j-- {
+			tree.Insert(randomText("ab.", rand.Intn(8)), rand.Intn(2))
+		}
+		tree.Freeze()
+		dawg, err := tree.Minimize()
+		assert.Nil(t, err)
+		assert.True(t, dawg.Nodes() <= tree.Stats().Nodes)
+
+		type entry struct {
+			key   string
+			value interface{}
+		}
+		var expected, actual []entry
+		tree.Walk(func(key []byte, value interface{}) bool {
+			expected = append(expected, entry{string(key), value})
+			return false
+		})
+		dawg.Walk(func(key []byte, value interface{}) bool {
+			actual = append(actual, entry{string(key), value})
+			return false
+		})
+		assert.Equal(t, expected, actual)
+
+		for j := 0; j < 50; j++ {
+			query := randomText("ab.", rand.Intn(10))
+			value, found := tree.Get(query)
+			dawgValue, dawgFound := dawg.Get(query)
+			assert.Equal(t, found, dawgFound, "%q", query)
+			assert.Equal(t, value, dawgValue, "%q", query)
+
+			key, value, found := tree.LongestSuffix(query)
+			dawgKey, dawgValue, dawgFound := dawg.LongestSuffix(query)
+			assert.Equal(t, found, dawgFound, "%q", query)
+			assert.Equal(t, string(key), string(dawgKey), "%q", query)
+			assert.Equal(t, value, dawgValue, "%q", query)
+
+			expected, actual = nil, nil
+			tree.AllSuffixesOf(query, func(key []byte, value interface{}) bool {
+				expected = append(expected, entry{string(key), value})
+				return false
+			})
+			dawg.AllSuffixesOf(query, func(key []byte, value interface{}) bool {
+				actual = append(actual, entry{string(key), value})
+				return false
+			})
+			assert.Equal(t, expected, actual, "%q", query)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package suffix
+
+import (
+	"io/ioutil"
+)
+
+// OpenMapped reads the file written by WriteFlat, and returns a FlatTree reading from it.
+// Memory mapping is not supported on this platform, so the whole file is read into memory.
+func OpenMapped(path string) (*FlatTree, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewFlatTree(data)
+}
@@ -0,0 +1,45 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package suffix
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// OpenMapped maps the file written by WriteFlat into memory, and returns a FlatTree reading
+// from it. The pages are loaded on demand and shared between processes. Call Close to unmap
+// the file.
+func OpenMapped(path string) (*FlatTree, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size < flatHeaderSize {
+		return nil, fmt.Errorf("suffix: not a tree in flat layout")
+	}
+	if int64(int(size)) != size {
+		return nil, fmt.Errorf("suffix: file %s is too large to map", path)
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := NewFlatTree(data)
+	if err != nil {
+		syscall.Munmap(data)
+		return nil, err
+	}
+	tree.closer = func() error {
+		return syscall.Munmap(data)
+	}
+	return tree, nil
+}
@@ -0,0 +1,298 @@
+package suffix
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Option configures a Tree created by NewTree.
+type Option func(*Tree)
+
+// keyStep transforms a key or a query, or rejects it with an error.
+type keyStep func(key []byte) ([]byte, error)
+
+// transformStep makes a keyStep which never rejects keys.
+func transformStep(transform func([]byte) []byte) keyStep {
+	return func(key []byte) ([]byte, error) {
+		return transform(key), nil
+	}
+}
+
+// transformKey runs key through the transforms of the tree. The result is never nil if the
+// key is not rejected.
+func (tree *Tree) transformKey(key []byte) ([]byte, error) {
+	for _, transform := range tree.transforms {
+		var err error
+		key, err = transform(key)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			key = []byte{}
+		}
+	}
+	return key, nil
+}
+
+// Normalizer converts text into a normal form. The forms in golang.org/x/text/unicode/norm,
+// like norm.NFC and norm.NFKC, implement it.
+type Normalizer interface {
+	Bytes(b []byte) []byte
+}
+
+// prepareKey applies the NilKeyPolicy and the transforms of the tree to a key or a query.
+func (tree *Tree) prepareKey(key []byte) ([]byte, error) {
+	if key == nil {
+		if tree.nilKeys != NilKeyAsEmpty {
+			return nil, ErrNilKey
+		}
+		key = []byte{}
+	}
+	key, err := tree.transformKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 && tree.nilKeys == RejectEmptyKey {
+		return nil, ErrEmptyKey
+	}
+	return key, nil
+}
+
+// checkKey returns the error of TryInsert if key is rejected, including ErrReadOnly for a
+// frozen tree.
+func (tree *Tree) checkKey(key []byte) error {
+	if tree.frozen {
+		return ErrReadOnly
+	}
+	key, err := tree.prepareKey(key)
+	if err != nil {
+		return err
+	}
+	return tree.checkKeyLen(key)
+}
+
+// WithNormalization converts keys and queries into the normal form of form before matching,
+// so that the strings which look the same but are composed differently, like "é" and
+// "é", hit the same key. The keys are stored and walked in the normal form.
+//
+// HasSequence normalizes the sequence by itself, so a sequence starting with a combining
+// character may not match the keys which compose it with the character before.
+func WithNormalization(form Normalizer) Option {
+	return func(tree *Tree) {
+		tree.transforms = append(tree.transforms, transformStep(form.Bytes))
+	}
+}
+
+// foldRune returns the representative of the runes equal to r under simple case folding.
+// It is the smallest lowercase rune of them if there is one, like 'k' for "kKK".
+func foldRune(r rune) rune {
+	lower, min := rune(-1), r
+	for f := r; ; {
+		if unicode.IsLower(f) && (lower < 0 || f < lower) {
+			lower = f
+		}
+		if f < min {
+			min = f
+		}
+		f = unicode.SimpleFold(f)
+		if f == r {
+			break
+		}
+	}
+	if lower >= 0 {
+		return lower
+	}
+	return min
+}
+
+// foldKey converts key with simple case folding. Invalid UTF-8 is kept as is.
+func foldKey(key []byte) []byte {
+	folded := make([]byte, 0, len(key))
+	var buf [utf8.UTFMax]byte
+	for i := 0; i < len(key); {
+		c := key[i]
+		if c < utf8.RuneSelf {
+			if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			folded = append(folded, c)
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(key[i:])
+		if r == utf8.RuneError && size == 1 {
+			folded = append(folded, c)
+		} else {
+			n := utf8.EncodeRune(buf[:], foldRune(r))
+			folded = append(folded, buf[:n]...)
+		}
+		i += size
+	}
+	return folded
+}
+
+// WithCaseFolding matches keys and queries case-insensitively under Unicode simple case
+// folding, so "Straße" matches "STRAßE" and "K" (Kelvin sign) matches "k". Each character is
+// replaced with a representative of its case-folding class, which is its lowercase form in
+// most cases, and the keys are stored and walked in that form.
+//
+// Simple folding maps one character to one character, so "ß" doesn't match "SS".
+func WithCaseFolding() Option {
+	return func(tree *Tree) {
+		tree.transforms = append(tree.transforms, transformStep(foldKey))
+	}
+}
+
+// WithKeyTransform runs keys and queries through transform before matching, so the
+// canonicalization like trimming spaces or the trailing dot of a domain is done in one place
+// instead of at every call site. It applies to every method taking a key, including Remove
+// and the query methods. The transformed keys are the ones stored and walked.
+//
+// The transforms run in the order of the options. A transform must not modify its argument,
+// which may be the caller's query, but it can return it as is. A nil result is treated as the
+// empty key.
+func WithKeyTransform(transform func(key []byte) []byte) Option {
+	return func(tree *Tree) {
+		tree.transforms = append(tree.transforms, transformStep(transform))
+	}
+}
+
+// WithSeparator splits keys and queries into labels at sep, and matches them label by label
+// instead of byte by byte. With '.' as the separator, "example.com" matches
+// "www.example.com" in LongestSuffix, but "ample.com" doesn't, and WalkSuffix and
+// HasSequence only match whole labels too. The empty key still matches everything.
+//
+// Each separator starts a new label, so write the keys without the leading or trailing
+// separators, or remove them with WithKeyTransform. The tree is stored as usual, only the
+// matching is changed. HasSequence may check all keys when the sequence is only found
+// across the labels, or at the start or end of keys.
+func WithSeparator(sep byte) Option {
+	return func(tree *Tree) {
+		tree.tokenMode = true
+		tree.sep = sep
+	}
+}
+
+// separator returns the separator of the tree, or -1 if it is not in the separator mode.
+func (tree *Tree) separator() int {
+	if !tree.tokenMode {
+		return -1
+	}
+	return int(tree.sep)
+}
+
+// atBoundary reports whether matched, which follows rest in the query, starts at a label
+// boundary. It always holds if sep is negative.
+func atBoundary(rest, matched []byte, sep int) bool {
+	return sep < 0 || len(matched) == 0 || len(rest) == 0 || int(rest[len(rest)-1]) == sep
+}
+
+// hasLabels reports whether seq occurs in key as whole labels split by sep.
+func hasLabels(key, seq []byte, sep byte) bool {
+	for i := 0; i+len(seq) <= len(key); i++ {
+		j := bytes.Index(key[i:], seq)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(seq)
+		if (start == 0 || key[start-1] == sep) && (end == len(key) || key[end] == sep) {
+			return true
+		}
+		i = start
+	}
+	return false
+}
+
+// hostKey trims the spaces around a host name and strips its trailing dot. It rejects the
+// host names with empty labels.
+func hostKey(key []byte) ([]byte, error) {
+	host := bytes.TrimSpace(key)
+	host = bytes.TrimSuffix(host, []byte("."))
+	if len(host) == 0 {
+		return host, nil
+	}
+	if host[0] == '.' || host[len(host)-1] == '.' || bytes.Contains(host, []byte("..")) {
+		return nil, fmt.Errorf("suffix: host %s has an empty label", quoteKey(key))
+	}
+	return host, nil
+}
+
+// WithHostMode treats keys and queries as host names, since DNS data commonly mixes
+// "example.com." and "example.com": the spaces around them are trimmed and a single trailing
+// dot is stripped, so both forms hit the same key. The host names with empty labels, like
+// ".com" or "example..com", are rejected: Insert returns false, and the queries find nothing.
+// The root domain "." becomes the empty key, which matches every host in LongestSuffix.
+func WithHostMode() Option {
+	return func(tree *Tree) {
+		tree.transforms = append(tree.transforms, hostKey)
+	}
+}
+
+func checkUTF8(key []byte) ([]byte, error) {
+	if !utf8.Valid(key) {
+		return nil, fmt.Errorf("suffix: key %s is not valid UTF-8", quoteKey(key))
+	}
+	return key, nil
+}
+
+// WithValidateUTF8 rejects the keys which are not valid UTF-8, for the trees holding text:
+// Insert returns false, TryInsert returns the error, and the queries find nothing. It checks
+// the keys as they are when the option is applied, so put it before the options transforming
+// keys to check the input of the callers.
+func WithValidateUTF8() Option {
+	return func(tree *Tree) {
+		tree.transforms = append(tree.transforms, checkUTF8)
+	}
+}
+
+// ErrKeyTooLong is returned by TryInsert for the keys longer than the limit of WithMaxKeyLen.
+var ErrKeyTooLong = errors.New("suffix: key is too long")
+
+func (tree *Tree) checkKeyLen(key []byte) error {
+	if tree.maxKeyLen > 0 && len(key) > tree.maxKeyLen {
+		return fmt.Errorf("%w: %d bytes, the limit is %d", ErrKeyTooLong, len(key), tree.maxKeyLen)
+	}
+	return nil
+}
+
+// WithMaxKeyLen rejects the keys longer than n bytes, so the memory of each entry is bounded
+// when the keys come from untrusted input: Insert returns false, and TryInsert returns an
+// error wrapping ErrKeyTooLong. The length is checked after the other options transform the
+// key, and only for insertion, so a longer query can still match the keys in LongestSuffix.
+// n <= 0 means no limit.
+func WithMaxKeyLen(n int) Option {
+	return func(tree *Tree) {
+		tree.maxKeyLen = n
+	}
+}
+
+// NilKeyPolicy decides how a tree treats the nil and empty keys.
+type NilKeyPolicy int
+
+const (
+	// RejectNilKey rejects nil keys, while the empty key is a valid key which is a suffix of
+	// every key. It is the default policy.
+	RejectNilKey NilKeyPolicy = iota
+	// NilKeyAsEmpty treats nil keys as the empty key.
+	NilKeyAsEmpty
+	// RejectEmptyKey rejects both the nil and empty keys, including the keys which become
+	// empty after the other options transform them.
+	RejectEmptyKey
+)
+
+// ErrEmptyKey is returned by TryInsert and TryRemove for the empty keys of a tree created with
+// RejectEmptyKey.
+var ErrEmptyKey = errors.New("suffix: empty key")
+
+// WithNilKeyPolicy sets how the tree treats the nil and empty keys. The keys rejected by the
+// policy are handled like the keys rejected by other options: Insert returns false, TryInsert
+// returns the error, and the queries, including HasSequence, find nothing. WalkSuffix is not
+// affected, a nil or empty suffix walks all keys.
+func WithNilKeyPolicy(policy NilKeyPolicy) Option {
+	return func(tree *Tree) {
+		tree.nilKeys = policy
+	}
+}
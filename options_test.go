@@ -0,0 +1,309 @@
+package suffix
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// composer composes "e\u0301" into "\u00e9", like norm.NFC does.
+type composer struct{}
+
+func (composer) Bytes(b []byte) []byte {
+	return bytes.Replace(b, []byte("e\u0301"), []byte("\u00e9"), -1)
+}
+
+func TestWithNormalization(t *testing.T) {
+	tree := NewTree(WithNormalization(composer{}))
+	tree.Insert([]byte("caf\u00e9"), 1)
+	_, ok := tree.Insert([]byte("cafe\u0301"), 2)
+	assert.True(t, ok)
+	assert.Equal(t, 1, tree.Len())
+
+	value, found := tree.Get([]byte("caf\u00e9"))
+	assert.True(t, found)
+	assert.Equal(t, 2, value)
+	key, _, found := tree.LongestSuffix([]byte("le cafe\u0301"))
+	assert.True(t, found)
+	assert.Equal(t, "caf\u00e9", string(key))
+	assert.True(t, tree.HasSequence([]byte("fe\u0301")))
+	assert.True(t, tree.CompareAndSwap([]byte("cafe\u0301"), 2, 3))
+
+	var keys []string
+	tree.WalkSuffix([]byte("e\u0301"), func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return false
+	})
+	assert.Equal(t, []string{"caf\u00e9"}, keys)
+
+	snapshot := tree.Snapshot()
+	_, found = snapshot.Get([]byte("cafe\u0301"))
+	assert.True(t, found)
+
+	assert.False(t, tree.CompareAndDelete([]byte("cafe\u0301"), 2))
+	_, found = tree.Remove([]byte("cafe\u0301"))
+	assert.True(t, found)
+	assert.Equal(t, 0, tree.Len())
+}
+
+func TestWithCaseFolding(t *testing.T) {
+	tree := NewTree(WithCaseFolding())
+	tree.Insert([]byte("Example.COM"), 1)
+	tree.Insert([]byte("stra\u00dfe"), 2)
+	tree.Insert([]byte("\u03bf\u03b4\u03bf\u03c2"), 3)
+	tree.Insert([]byte("bad\xffKEY"), 4)
+	assert.Equal(t, 4, tree.Len())
+
+	for query, expected := range map[string]interface{}{
+		"example.com": 1,
+		"EXAMPLE.com": 1,
+		// Capital sharp s
+		"STRA\u1e9eE": 2,
+		"strasse":     nil,
+		// Final and non-final sigma
+		"\u039f\u0394\u039f\u03a3": 3,
+		"\u03bf\u03b4\u03bf\u03c3": 3,
+		// Kelvin sign
+		"bad\xff\u212aey": 4,
+		"bad\xfekey":      nil,
+		"example.com ":    nil,
+	} {
+		value, found := tree.Get([]byte(query))
+		assert.Equal(t, expected != nil, found, query)
+		assert.Equal(t, expected, value, query)
+	}
+
+	key, _, found := tree.LongestSuffix([]byte("WWW.EXAMPLE.COM"))
+	assert.True(t, found)
+	assert.Equal(t, "example.com", string(key))
+	assert.True(t, tree.HasSequence([]byte("PLE.C")))
+	_, found = tree.Remove([]byte("EXAMPLE.COM"))
+	assert.True(t, found)
+}
+
+func TestFoldRune(t *testing.T) {
+	for _, runes := range []string{"aA", "k\u212aK", "s\u017fS", "\u03c2\u03c3\u03a3",
+		"\u00df\u1e9e", "1"} {
+		expected := foldRune([]rune(runes)[0])
+		for _, r := range runes {
+			assert.Equal(t, expected, foldRune(r), runes)
+		}
+	}
+	assert.Equal(t, 'a', foldRune('A'))
+	assert.Equal(t, 'k', foldRune('\u212a'))
+}
+
+func TestWithKeyTransform(t *testing.T) {
+	trimDot := func(key []byte) []byte {
+		return bytes.TrimSuffix(key, []byte("."))
+	}
+	tree := NewTree(WithKeyTransform(trimDot), WithCaseFolding())
+	tree.Insert([]byte("Example.com."), 1)
+	tree.Insert([]byte("."), 2)
+	assert.Equal(t, 2, tree.Len())
+
+	value, found := tree.Get([]byte("example.COM"))
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+	// The root domain becomes the empty key
+	value, found = tree.Get([]byte(""))
+	assert.True(t, found)
+	assert.Equal(t, 2, value)
+
+	key, _, found := tree.LongestSuffix([]byte("www.example.com."))
+	assert.True(t, found)
+	assert.Equal(t, "example.com", string(key))
+	assert.True(t, tree.HasSequence([]byte("ample.com.")))
+	assert.True(t, tree.CompareAndSwap([]byte("EXAMPLE.COM."), 1, 3))
+	assert.True(t, tree.CompareAndDelete([]byte("example.com"), 3))
+	_, found = tree.Remove([]byte("."))
+	assert.True(t, found)
+	assert.Equal(t, 0, tree.Len())
+}
+
+func TestWithSeparator(t *testing.T) {
+	tree := NewTree(WithSeparator('.'))
+	tree.Insert([]byte("ample.com"), 1)
+	tree.Insert([]byte("com"), 2)
+	tree.Insert([]byte("example.com"), 3)
+	tree.Insert([]byte("le.com"), 4)
+
+	for query, expected := range map[string]interface{}{
+		"example.com":     3,
+		"www.example.com": 3,
+		"sample.com":      2,
+		"ample.com":       1,
+		"x.ample.com":     1,
+		"com":             2,
+		"dotcom":          nil,
+		"example.org":     nil,
+	} {
+		_, value, found := tree.LongestSuffix([]byte(query))
+		assert.Equal(t, expected != nil, found, query)
+		assert.Equal(t, expected, value, query)
+	}
+
+	// The empty key matches everything
+	tree.Insert([]byte(""), 5)
+	_, value, _ := tree.LongestSuffix([]byte("dotcom"))
+	assert.Equal(t, 5, value)
+	tree.Remove([]byte(""))
+
+	var keys []string
+	tree.WalkSuffix([]byte("e.com"), func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return false
+	})
+	assert.Empty(t, keys)
+	tree.WalkSuffix([]byte("le.com"), func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return false
+	})
+	assert.Equal(t, []string{"le.com"}, keys)
+
+	assert.True(t, tree.HasSequence([]byte("ample")))
+	assert.True(t, tree.HasSequence([]byte("example.com")))
+	assert.True(t, tree.HasSequence([]byte("com")))
+	assert.False(t, tree.HasSequence([]byte("xample")))
+	assert.False(t, tree.HasSequence([]byte("le.c")))
+	assert.False(t, tree.HasSequence([]byte("org")))
+
+	tree.Insert([]byte("a.b.c.d"), 6)
+	assert.True(t, tree.HasSequence([]byte("b.c")))
+	assert.True(t, tree.HasSequence([]byte("a.b")))
+	assert.False(t, tree.HasSequence([]byte(".b")))
+
+	// Matching stays the same in snapshots
+	key, _, found := tree.Snapshot().LongestSuffix([]byte("sample.com"))
+	assert.True(t, found)
+	assert.Equal(t, "com", string(key))
+}
+
+func TestHasLabels(t *testing.T) {
+	assert.True(t, hasLabels([]byte("a.b.c"), []byte("a"), '.'))
+	assert.True(t, hasLabels([]byte("a.b.c"), []byte("c"), '.'))
+	assert.True(t, hasLabels([]byte("ab.b.c"), []byte("b"), '.'))
+	assert.False(t, hasLabels([]byte("ab.bc"), []byte("b"), '.'))
+	assert.True(t, hasLabels([]byte("bb.b"), []byte("b"), '.'))
+}
+
+func TestWithHostMode(t *testing.T) {
+	tree := NewTree(WithHostMode())
+	_, ok := tree.Insert([]byte(" example.com.\n"), 1)
+	assert.True(t, ok)
+	_, ok = tree.Insert([]byte("."), 2)
+	assert.True(t, ok)
+	for _, invalid := range []string{".com", "example..com", "example.com.."} {
+		_, ok = tree.Insert([]byte(invalid), 3)
+		assert.False(t, ok, invalid)
+	}
+	assert.Equal(t, 2, tree.Len())
+
+	for query, expected := range map[string]interface{}{
+		"example.com":   1,
+		"example.com.":  1,
+		"\texample.com": 1,
+		"":              2,
+		"example..com":  nil,
+	} {
+		value, found := tree.Get([]byte(query))
+		assert.Equal(t, expected != nil, found, query)
+		assert.Equal(t, expected, value, query)
+	}
+
+	key, value, found := tree.LongestSuffix([]byte("www.example.com."))
+	assert.True(t, found)
+	assert.Equal(t, "example.com", string(key))
+	assert.Equal(t, 1, value)
+	_, value, _ = tree.LongestSuffix([]byte("golang.org."))
+	assert.Equal(t, 2, value)
+	_, _, found = tree.LongestSuffix([]byte("www..example.com"))
+	assert.False(t, found)
+
+	assert.False(t, tree.CompareAndDelete([]byte("..com"), 1))
+	assert.True(t, tree.CompareAndDelete([]byte("example.com. "), 1))
+}
+
+func TestWithValidateUTF8(t *testing.T) {
+	tree := NewTree(WithValidateUTF8())
+	_, err := tree.TryInsert([]byte("caf\u00e9"), 1)
+	assert.Nil(t, err)
+	_, err = tree.TryInsert([]byte("caf\xe9"), 2)
+	assert.EqualError(t, err, `suffix: key "caf\xe9" is not valid UTF-8`)
+	_, ok := tree.Insert([]byte("\xc3"), 3)
+	assert.False(t, ok)
+	assert.Equal(t, 1, tree.Len())
+
+	_, found := tree.Get([]byte("caf\u00e9"))
+	assert.True(t, found)
+	assert.False(t, tree.HasSequence([]byte("\xc3")))
+	_, _, found = tree.LongestSuffix([]byte("\xffcaf\u00e9"))
+	assert.False(t, found)
+}
+
+func TestWithMaxKeyLen(t *testing.T) {
+	tree := NewTree(WithMaxKeyLen(11))
+	_, err := tree.TryInsert([]byte("example.com"), 1)
+	assert.Nil(t, err)
+	_, err = tree.TryInsert([]byte("example.com."), 2)
+	assert.True(t, errors.Is(err, ErrKeyTooLong))
+	assert.EqualError(t, err, "suffix: key is too long: 12 bytes, the limit is 11")
+	_, ok := tree.Insert([]byte("www.example.com"), 3)
+	assert.False(t, ok)
+	assert.Equal(t, 1, tree.Len())
+
+	// Queries are not limited
+	_, value, found := tree.LongestSuffix([]byte("www.example.com"))
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+
+	// The limit applies to the transformed key
+	tree = NewTree(WithHostMode(), WithMaxKeyLen(11))
+	_, err = tree.TryInsert([]byte(" example.com. "), 1)
+	assert.Nil(t, err)
+}
+
+func TestWithNilKeyPolicy(t *testing.T) {
+	tree := NewTree(WithNilKeyPolicy(NilKeyAsEmpty))
+	_, ok := tree.Insert(nil, 1)
+	assert.True(t, ok)
+	value, found := tree.Get([]byte{})
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+	_, value, found = tree.LongestSuffix(nil)
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+	assert.True(t, tree.HasSequence(nil))
+	_, found = tree.Remove(nil)
+	assert.True(t, found)
+
+	tree = NewTree(WithHostMode(), WithNilKeyPolicy(RejectEmptyKey))
+	_, err := tree.TryInsert(nil, 1)
+	assert.EqualError(t, err, "suffix: nil key")
+	_, err = tree.TryInsert([]byte{}, 1)
+	assert.EqualError(t, err, "suffix: empty key")
+	// Empty after the trailing dot is stripped
+	_, err = tree.TryInsert([]byte("."), 1)
+	assert.EqualError(t, err, "suffix: empty key")
+	tree.Insert([]byte("com"), 2)
+	assert.False(t, tree.HasSequence([]byte{}))
+	assert.True(t, tree.HasSequence([]byte("co")))
+	// WalkSuffix still walks all keys
+	n := 0
+	tree.WalkSuffix(nil, func(key []byte, value interface{}) bool {
+		n++
+		return false
+	})
+	assert.Equal(t, 1, n)
+
+	// The default policy
+	tree = NewTree()
+	_, ok = tree.Insert(nil, 1)
+	assert.False(t, ok)
+	_, ok = tree.Insert([]byte{}, 1)
+	assert.True(t, ok)
+	assert.False(t, tree.HasSequence(nil))
+	assert.True(t, tree.HasSequence([]byte{}))
+}
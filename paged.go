@@ -0,0 +1,176 @@
+package suffix
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// PageCacheOptions controls how a paged FlatTree caches the layout in memory.
+type PageCacheOptions struct {
+	// The size of each page read from the file. It is rounded up to a multiple of 4 bytes.
+	// 64KiB is used if it is 0.
+	PageSize int
+	// The maximum bytes of pages kept in memory. At least one page is kept. 64MiB is used
+	// if it is 0.
+	CacheSize int
+}
+
+const (
+	defaultPageSize  = 64 << 10
+	defaultCacheSize = 64 << 20
+)
+
+type cachedPage struct {
+	index uint64
+	data  []byte
+}
+
+// pageCache reads the pages of the flat layout on demand, and keeps the recently used
+// pages in memory.
+type pageCache struct {
+	r        io.ReaderAt
+	size     uint64
+	pageSize uint64
+	maxPages int
+
+	lock sync.Mutex
+	// The front is the most recently used page
+	lru   *list.List
+	pages map[uint64]*list.Element
+}
+
+func newPageCache(r io.ReaderAt, size uint64, opts *PageCacheOptions) *pageCache {
+	pageSize := uint64(defaultPageSize)
+	cacheSize := uint64(defaultCacheSize)
+	if opts != nil && opts.PageSize > 0 {
+		pageSize = (uint64(opts.PageSize) + 3) &^ 3
+	}
+	if opts != nil && opts.CacheSize > 0 {
+		cacheSize = uint64(opts.CacheSize)
+	}
+	maxPages := int(cacheSize / pageSize)
+	if maxPages < 1 {
+		maxPages = 1
+	}
+	return &pageCache{
+		r:        r,
+		size:     size,
+		pageSize: pageSize,
+		maxPages: maxPages,
+		lru:      list.New(),
+		pages:    map[uint64]*list.Element{},
+	}
+}
+
+func (c *pageCache) page(index uint64) ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if elem, ok := c.pages[index]; ok {
+		c.lru.MoveToFront(elem)
+		return elem.Value.(*cachedPage).data, nil
+	}
+
+	start := index * c.pageSize
+	end := start + c.pageSize
+	if end > c.size {
+		end = c.size
+	}
+	// Evicted pages are not reused, since the slices returned by read may still refer to them
+	data := make([]byte, end-start)
+	n, err := c.r.ReadAt(data, int64(start))
+	if n < len(data) {
+		if err == nil || err == io.EOF {
+			// The reader is shorter than the size of the layout
+			return nil, fmt.Errorf("%w: flat tree is truncated at %d", ErrCorrupted,
+				start+uint64(n))
+		}
+		return nil, fmt.Errorf("suffix: failed to read flat tree at %d: %w", start, err)
+	}
+	if c.lru.Len() >= c.maxPages {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.pages, oldest.Value.(*cachedPage).index)
+	}
+	c.pages[index] = c.lru.PushFront(&cachedPage{index, data})
+	return data, nil
+}
+
+// read returns n bytes at off. The result shares memory with the page if it doesn't cross
+// pages.
+func (c *pageCache) read(off, n uint64) ([]byte, error) {
+	index := off / c.pageSize
+	start := off - index*c.pageSize
+	if start+n <= c.pageSize {
+		page, err := c.page(index)
+		if err != nil {
+			return nil, err
+		}
+		return page[start : start+n : start+n], nil
+	}
+	buf := make([]byte, 0, n)
+	for uint64(len(buf)) < n {
+		page, err := c.page(index)
+		if err != nil {
+			return nil, err
+		}
+		rest := n - uint64(len(buf))
+		if rest > uint64(len(page))-start {
+			rest = uint64(len(page)) - start
+		}
+		buf = append(buf, page[start:start+rest]...)
+		index++
+		start = 0
+	}
+	return buf, nil
+}
+
+// NewPagedFlatTree creates a FlatTree reading the flat layout of the given size from r.
+// Unlike NewFlatTree, the layout is read in pages when it is needed, and only the recently
+// used pages are kept in memory, so the layout can be much larger than the memory.
+// A nil opts uses the default options.
+//
+// The FlatTree is safe for concurrent use. If r fails or is shorter than size, the queries
+// treat it like a corrupted layout, and their Try variants return the error.
+func NewPagedFlatTree(r io.ReaderAt, size int64, opts *PageCacheOptions) (*FlatTree, error) {
+	if size < flatHeaderSize {
+		return nil, fmt.Errorf("suffix: not a tree in flat layout")
+	}
+	headerSize := int64(flatWideHeaderSize)
+	if size < headerSize {
+		headerSize = size
+	}
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	tree, err := newFlatTree(header, uint64(size))
+	if err != nil {
+		return nil, err
+	}
+	tree.pages = newPageCache(r, uint64(size), opts)
+	return tree, nil
+}
+
+// OpenPaged opens the file written by WriteFlat, and returns a paged FlatTree reading from it.
+// See NewPagedFlatTree for the details. Call Close to close the file.
+func OpenPaged(path string, opts *PageCacheOptions) (*FlatTree, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	tree, err := NewPagedFlatTree(f, info.Size(), opts)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	tree.closer = f.Close
+	return tree, nil
+}
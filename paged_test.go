@@ -0,0 +1,124 @@
+package suffix
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingReaderAt counts the reads, and fails once err is set.
+type countingReaderAt struct {
+	r     *bytes.Reader
+	reads int
+	err   error
+}
+
+func (r *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	r.reads++
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.r.ReadAt(p, off)
+}
+
+func TestNewPagedFlatTree(t *testing.T) {
+	lists, tree := getFixtures()
+	tree.Insert([]byte{}, 1)
+	tree.Insert([]byte("able"), []byte("able"))
+	var buf bytes.Buffer
+	_, err := tree.WriteFlat(&buf)
+	assert.Nil(t, err)
+	queries := append([]string{
+		"", "vegetable", "ble", "bl", "xyz", "something else", "words", "sword",
+	}, lists...)
+
+	for _, opts := range []*PageCacheOptions{
+		nil,
+		{PageSize: 1, CacheSize: 1},
+		{PageSize: 7, CacheSize: 64},
+		{PageSize: 64, CacheSize: 256},
+		{PageSize: buf.Len() * 2},
+	} {
+		r := &countingReaderAt{r: bytes.NewReader(buf.Bytes())}
+		flat, err := NewPagedFlatTree(r, int64(buf.Len()), opts)
+		assert.Nil(t, err)
+		assertSameAsFlat(t, tree, flat, queries)
+		assert.True(t, flat.pages.lru.Len() <= flat.pages.maxPages)
+		assert.Equal(t, len(flat.pages.pages), flat.pages.lru.Len())
+		assert.Equal(t, uint64(0), flat.pages.pageSize%4)
+		assert.Nil(t, flat.Close())
+	}
+
+	// Hot pages are cached
+	r := &countingReaderAt{r: bytes.NewReader(buf.Bytes())}
+	flat, err := NewPagedFlatTree(r, int64(buf.Len()), &PageCacheOptions{PageSize: 64})
+	assert.Nil(t, err)
+	flat.Get([]byte("table"))
+	reads := r.reads
+	flat.Get([]byte("table"))
+	assert.Equal(t, reads, r.reads)
+
+	// Reading errors are returned instead of crashing
+	diskFailure := errors.New("disk failure")
+	r.err = diskFailure
+	flat.pages.lru.Init()
+	flat.pages.pages = map[uint64]*list.Element{}
+	_, found, err := flat.TryGet([]byte("table"))
+	assert.True(t, errors.Is(err, diskFailure))
+	assert.False(t, found)
+	_, found = flat.Get([]byte("table"))
+	assert.False(t, found)
+	r.err = nil
+	_, found, err = flat.TryGet([]byte("table"))
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Nil(t, flat.Close())
+	_, _, err = flat.TryGet([]byte("table"))
+	assert.Equal(t, ErrClosed, err)
+
+	// So are the truncated files
+	flat, err = NewPagedFlatTree(bytes.NewReader(buf.Bytes()[:buf.Len()-8]), int64(buf.Len()),
+		nil)
+	assert.Nil(t, err)
+	err = flat.TryWalk(func(key []byte, value interface{}) bool {
+		return false
+	})
+	assert.True(t, errors.Is(err, ErrCorrupted))
+
+	_, err = NewPagedFlatTree(r, flatHeaderSize-1, nil)
+	assert.EqualError(t, err, "suffix: not a tree in flat layout")
+	r.err = diskFailure
+	_, err = NewPagedFlatTree(r, int64(buf.Len()), nil)
+	assert.EqualError(t, err, "disk failure")
+}
+
+func TestOpenPaged(t *testing.T) {
+	lists, tree := getFixtures()
+	dir, err := ioutil.TempDir("", "suffix_test_")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tree")
+	f, err := os.Create(path)
+	assert.Nil(t, err)
+	_, err = tree.WriteFlat(f)
+	assert.Nil(t, err)
+	f.Close()
+
+	flat, err := OpenPaged(path, &PageCacheOptions{PageSize: 32, CacheSize: 128})
+	assert.Nil(t, err)
+	assertSameAsFlat(t, tree, flat, lists)
+	assert.Nil(t, flat.Close())
+	assert.Nil(t, flat.Close())
+
+	_, err = OpenPaged(filepath.Join(dir, "nonexist"), nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, ioutil.WriteFile(path, []byte("SFXF"), 0644))
+	_, err = OpenPaged(path, nil)
+	assert.NotNil(t, err)
+}
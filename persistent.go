@@ -0,0 +1,77 @@
+package suffix
+
+// PersistentTree is a version of a fully persistent tree. A version is never modified:
+// Insert and Remove return a new version sharing the unchanged nodes with the old one, and
+// all versions stay queryable. It can keep the history of a rule set for debugging, or
+// publish each generation of the rules to the readers without locks, since a version can be
+// read by goroutines concurrently.
+//
+// Each change copies the nodes on the path of the key, like the first change after Snapshot.
+type PersistentTree struct {
+	// Frozen, so it can't be modified through Tree
+	tree    *Tree
+	version uint64
+}
+
+// NewPersistentTree returns the empty version 0 of a persistent tree with the options.
+func NewPersistentTree(opts ...Option) *PersistentTree {
+	tree := NewTree(opts...)
+	tree.Freeze()
+	return &PersistentTree{tree: tree}
+}
+
+// Version returns the number of changes from the empty tree to this version.
+func (tree *PersistentTree) Version() uint64 {
+	return tree.version
+}
+
+// Tree returns this version as a frozen Tree, for the queries not wrapped by PersistentTree.
+func (tree *PersistentTree) Tree() *Tree {
+	return tree.tree
+}
+
+// Insert returns a new version with the key and value inserted, and the value replaced in it.
+// The error is the reason why TryInsert rejects the key.
+func (tree *PersistentTree) Insert(key []byte, value interface{}) (next *PersistentTree,
+	oldValue interface{}, err error) {
+
+	forked := tree.tree.fork()
+	oldValue, err = forked.TryInsert(key, value)
+	if err != nil {
+		return nil, nil, err
+	}
+	forked.Freeze()
+	return &PersistentTree{tree: forked, version: tree.version + 1}, oldValue, nil
+}
+
+// Remove returns a new version without the key, and the value removed. If the key is not
+// found, this version itself is returned.
+func (tree *PersistentTree) Remove(key []byte) (next *PersistentTree, oldValue interface{},
+	found bool) {
+
+	prepared, err := tree.tree.prepareKey(key)
+	if err != nil || tree.tree.root.getLeaf(prepared) == nil {
+		return tree, nil, false
+	}
+	forked := tree.tree.fork()
+	oldValue, found = forked.Remove(key)
+	forked.Freeze()
+	return &PersistentTree{tree: forked, version: tree.version + 1}, oldValue, found
+}
+
+// Len returns the number of keys in this version.
+func (tree *PersistentTree) Len() int {
+	return tree.tree.Len()
+}
+
+// Get is like Tree.Get.
+func (tree *PersistentTree) Get(key []byte) (value interface{}, found bool) {
+	return tree.tree.Get(key)
+}
+
+// LongestSuffix is like Tree.LongestSuffix.
+func (tree *PersistentTree) LongestSuffix(key []byte) (matchedKey []byte, value interface{},
+	found bool) {
+
+	return tree.tree.LongestSuffix(key)
+}
@@ -0,0 +1,82 @@
+package suffix
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistentTree(t *testing.T) {
+	v0 := NewPersistentTree()
+	assert.Equal(t, uint64(0), v0.Version())
+	assert.True(t, v0.Tree().Frozen())
+
+	v1, _, err := v0.Insert([]byte("com"), 1)
+	assert.Nil(t, err)
+	v2, _, _ := v1.Insert([]byte("example.com"), 2)
+	v3, old, _ := v2.Insert([]byte("com"), 3)
+	assert.Equal(t, 1, old)
+	v4, old, found := v3.Remove([]byte("example.com"))
+	assert.True(t, found)
+	assert.Equal(t, 2, old)
+	v5, _, found := v4.Remove([]byte("org"))
+	assert.False(t, found)
+	assert.Same(t, v4, v5)
+	_, _, err = v4.Insert(nil, 0)
+	assert.Equal(t, ErrNilKey, err)
+
+	for _, c := range []struct {
+		version *PersistentTree
+		number  uint64
+		keys    map[string]interface{}
+	}{
+		{v0, 0, map[string]interface{}{}},
+		{v1, 1, map[string]interface{}{"com": 1}},
+		{v2, 2, map[string]interface{}{"com": 1, "example.com": 2}},
+		{v3, 3, map[string]interface{}{"com": 3, "example.com": 2}},
+		{v4, 4, map[string]interface{}{"com": 3}},
+	} {
+		assert.Equal(t, c.number, c.version.Version())
+		assert.Equal(t, len(c.keys), c.version.Len())
+		keys := map[string]interface{}{}
+		c.version.Tree().Walk(func(key []byte, value interface{}) bool {
+			keys[string(key)] = value
+			return false
+		})
+		assert.Equal(t, c.keys, keys, "version %d", c.number)
+		assert.Nil(t, c.version.Tree().Validate())
+	}
+	key, value, found := v2.LongestSuffix([]byte("www.example.com"))
+	assert.True(t, found)
+	assert.Equal(t, "example.com", string(key))
+	assert.Equal(t, 2, value)
+	_, found = v4.Get([]byte("example.com"))
+	assert.False(t, found)
+}
+
+func TestPersistentTree_Concurrent(t *testing.T) {
+	base := NewPersistentTree()
+	for _, key := range []string{"com", "example.com", "org"} {
+		base, _, _ = base.Insert([]byte(key), key)
+	}
+	var wg sync.WaitGroup
+	versions := make([]*PersistentTree, 8)
+	for i := range versions {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, _, _ := base.Insert([]byte{'a' + byte(i), '.', 'c', 'o', 'm'}, i)
+			v, _, _ = v.Remove([]byte("org"))
+			versions[i] = v
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 3, base.Len())
+	for i, v := range versions {
+		value, found := v.Get([]byte{'a' + byte(i), '.', 'c', 'o', 'm'})
+		assert.True(t, found)
+		assert.Equal(t, i, value)
+		assert.Equal(t, 3, v.Len())
+	}
+}
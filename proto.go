@@ -0,0 +1,260 @@
+package suffix
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// Field numbers of the messages in tree.proto
+const (
+	protoTreeEntries = 1
+
+	protoEntryKey   = 1
+	protoEntryValue = 2
+
+	protoValueBool   = 1
+	protoValueInt    = 2
+	protoValueUint   = 3
+	protoValueFloat  = 4
+	protoValueDouble = 5
+	protoValueString = 6
+	protoValueBytes  = 7
+)
+
+func appendProtoTag(buf []byte, field uint64, wireType uint64) []byte {
+	return appendUvarint(buf, field<<3|wireType)
+}
+
+func appendProtoBytes(buf []byte, field uint64, b []byte) []byte {
+	buf = appendProtoTag(buf, field, wireBytes)
+	buf = appendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func appendProtoValue(buf []byte, value interface{}) ([]byte, error) {
+	var tmp [8]byte
+	switch v := value.(type) {
+	case bool:
+		x := uint64(0)
+		if v {
+			x = 1
+		}
+		return appendUvarint(appendProtoTag(buf, protoValueBool, wireVarint), x), nil
+	case int, int8, int16, int32, int64:
+		x := toInt64(v)
+		// sint64 uses the same zigzag encoding as binary.PutVarint
+		return appendVarint(appendProtoTag(buf, protoValueInt, wireVarint), x), nil
+	case uint, uint8, uint16, uint32, uint64:
+		x := toUint64(v)
+		return appendUvarint(appendProtoTag(buf, protoValueUint, wireVarint), x), nil
+	case float32:
+		binary.LittleEndian.PutUint32(tmp[:4], math.Float32bits(v))
+		return append(appendProtoTag(buf, protoValueFloat, wireFixed32), tmp[:4]...), nil
+	case float64:
+		binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(v))
+		return append(appendProtoTag(buf, protoValueDouble, wireFixed64), tmp[:]...), nil
+	case string:
+		return appendProtoBytes(buf, protoValueString, []byte(v)), nil
+	case []byte:
+		return appendProtoBytes(buf, protoValueBytes, v), nil
+	}
+	return buf, fmt.Errorf("suffix: can't encode value of type %T", value)
+}
+
+func toInt64(v interface{}) int64 {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	}
+	return v.(int64)
+}
+
+func toUint64(v interface{}) uint64 {
+	switch v := v.(type) {
+	case uint:
+		return uint64(v)
+	case uint8:
+		return uint64(v)
+	case uint16:
+		return uint64(v)
+	case uint32:
+		return uint64(v)
+	}
+	return v.(uint64)
+}
+
+// ToProto encodes the tree as the Tree message defined in tree.proto, so it can be decoded
+// by the protobuf libraries of other languages. The values should be nil, booleans, numbers,
+// strings or []byte.
+func (tree *Tree) ToProto() ([]byte, error) {
+	var buf, entry, value []byte
+	var err error
+	tree.Walk(func(key []byte, v interface{}) bool {
+		entry = appendProtoBytes(entry[:0], protoEntryKey, key)
+		if v != nil {
+			value, err = appendProtoValue(value[:0], v)
+			if err != nil {
+				return true
+			}
+			entry = appendProtoBytes(entry, protoEntryValue, value)
+		}
+		buf = appendProtoBytes(buf, protoTreeEntries, entry)
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// protoField reads the next field of a message. For wireBytes, the payload is returned as
+// b, otherwise the number is returned as x.
+func (d *decodeBuffer) protoField() (field uint64, wireType uint64, x uint64, b []byte, err error) {
+	tag, err := d.uvarint()
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+	field, wireType = tag>>3, tag&7
+	switch wireType {
+	case wireVarint:
+		x, err = d.uvarint()
+	case wireFixed64:
+		b, err = d.bytes(8)
+		if err == nil {
+			x = binary.LittleEndian.Uint64(b)
+		}
+		b = nil
+	case wireBytes:
+		b, err = d.lenBytes()
+	case wireFixed32:
+		b, err = d.bytes(4)
+		if err == nil {
+			x = uint64(binary.LittleEndian.Uint32(b))
+		}
+		b = nil
+	default:
+		err = fmt.Errorf("suffix: unsupported protobuf wire type %d", wireType)
+	}
+	return field, wireType, x, b, err
+}
+
+// The wire types of the known fields in each message
+var (
+	protoTreeTypes = map[uint64]uint64{
+		protoTreeEntries: wireBytes,
+	}
+	protoEntryTypes = map[uint64]uint64{
+		protoEntryKey:   wireBytes,
+		protoEntryValue: wireBytes,
+	}
+	protoValueTypes = map[uint64]uint64{
+		protoValueBool:   wireVarint,
+		protoValueInt:    wireVarint,
+		protoValueUint:   wireVarint,
+		protoValueFloat:  wireFixed32,
+		protoValueDouble: wireFixed64,
+		protoValueString: wireBytes,
+		protoValueBytes:  wireBytes,
+	}
+)
+
+func checkProtoField(message string, types map[uint64]uint64, field, wireType uint64) error {
+	if expected, ok := types[field]; ok && expected != wireType {
+		return fmt.Errorf("suffix: field %d of %s has wire type %d, expected %d",
+			field, message, wireType, expected)
+	}
+	return nil
+}
+
+func decodeProtoValue(data []byte) (interface{}, error) {
+	d := decodeBuffer{data: data}
+	var value interface{}
+	for len(d.data) > 0 {
+		field, wireType, x, b, err := d.protoField()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkProtoField("Value", protoValueTypes, field, wireType); err != nil {
+			return nil, err
+		}
+		// As a oneof, the last field wins
+		switch field {
+		case protoValueBool:
+			value = x != 0
+		case protoValueInt:
+			value = int64(x>>1) ^ -int64(x&1)
+		case protoValueUint:
+			value = x
+		case protoValueFloat:
+			value = math.Float32frombits(uint32(x))
+		case protoValueDouble:
+			value = math.Float64frombits(x)
+		case protoValueString:
+			value = string(b)
+		case protoValueBytes:
+			value = b
+		}
+	}
+	return value, nil
+}
+
+// FromProto decodes a tree from the Tree message defined in tree.proto. Signed and unsigned
+// integers are decoded as int64 and uint64. Unknown fields are ignored.
+func FromProto(data []byte) (*Tree, error) {
+	// The keys are referred by the tree, so they can't share the memory with the caller
+	d := decodeBuffer{data: append([]byte(nil), data...)}
+	tree := NewTree()
+	for len(d.data) > 0 {
+		field, wireType, _, entry, err := d.protoField()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkProtoField("Tree", protoTreeTypes, field, wireType); err != nil {
+			return nil, err
+		}
+		if field != protoTreeEntries {
+			continue
+		}
+
+		ed := decodeBuffer{data: entry}
+		key := []byte{}
+		var value interface{}
+		for len(ed.data) > 0 {
+			field, wireType, _, b, err := ed.protoField()
+			if err != nil {
+				return nil, err
+			}
+			if err := checkProtoField("Entry", protoEntryTypes, field, wireType); err != nil {
+				return nil, err
+			}
+			switch field {
+			case protoEntryKey:
+				key = b
+			case protoEntryValue:
+				value, err = decodeProtoValue(b)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := tree.insertDecoded(key, value); err != nil {
+			return nil, err
+		}
+	}
+	return tree, nil
+}
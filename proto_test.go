@@ -0,0 +1,92 @@
+package suffix
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToProto(t *testing.T) {
+	_, tree := getFixtures()
+	tree.Insert([]byte{}, nil)
+	data, err := tree.ToProto()
+	assert.Nil(t, err)
+
+	newTree, err := FromProto(data)
+	assert.Nil(t, err)
+	assertSameContent(t, tree, newTree)
+
+	// The tree doesn't share memory with data
+	for i := range data {
+		data[i] = 0
+	}
+	assertSameContent(t, tree, newTree)
+}
+
+func TestToProto_Values(t *testing.T) {
+	values := []interface{}{
+		nil, true, false, -1, int8(-8), int16(-16), int32(-32), int64(-64),
+		uint(1), uint8(8), uint16(16), uint32(32), uint64(64),
+		float32(3.2), 6.4, "", "sth", []byte{}, []byte("sth"),
+	}
+	expectedValues := []interface{}{
+		nil, true, false, int64(-1), int64(-8), int64(-16), int64(-32), int64(-64),
+		uint64(1), uint64(8), uint64(16), uint64(32), uint64(64),
+		float32(3.2), 6.4, "", "sth", []byte{}, []byte("sth"),
+	}
+	tree := NewTree()
+	expected := NewTree()
+	for i, value := range values {
+		tree.Insert([]byte{byte(i)}, value)
+		expected.Insert([]byte{byte(i)}, expectedValues[i])
+	}
+	data, err := tree.ToProto()
+	assert.Nil(t, err)
+	newTree, err := FromProto(data)
+	assert.Nil(t, err)
+	assertSameContent(t, expected, newTree)
+
+	tree.Insert([]byte("struct"), struct{}{})
+	_, err = tree.ToProto()
+	assert.EqualError(t, err, "suffix: can't encode value of type struct {}")
+}
+
+func TestFromProto_Wire(t *testing.T) {
+	data := []byte{
+		// entries: {key: "a", value: {int_value: -1}}
+		0x0a, 0x07, 0x0a, 0x01, 'a', 0x12, 0x02, 0x10, 0x01,
+		// entries: {value: {string_value: "x"}}, with an unknown field 15 in Entry
+		0x0a, 0x07, 0x78, 0x01, 0x12, 0x03, 0x32, 0x01, 'x',
+		// unknown field 2 of Tree
+		0x10, 0x2a,
+	}
+	tree, err := FromProto(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, tree.Len())
+	value, found := tree.Get([]byte("a"))
+	assert.True(t, found)
+	assert.Equal(t, int64(-1), value)
+	value, found = tree.Get([]byte{})
+	assert.True(t, found)
+	assert.Equal(t, "x", value)
+
+	single := NewTree()
+	single.Insert([]byte("a"), -1)
+	encoded, err := single.ToProto()
+	assert.Nil(t, err)
+	assert.Equal(t, data[:9], encoded)
+}
+
+func TestFromProto_Invalid(t *testing.T) {
+	_, err := FromProto([]byte{0x0a, 0x07, 0x0a})
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	_, err = FromProto([]byte{0x08, 0x01})
+	assert.EqualError(t, err, "suffix: field 1 of Tree has wire type 0, expected 2")
+	_, err = FromProto([]byte{0x0a, 0x02, 0x08, 0x01})
+	assert.EqualError(t, err, "suffix: field 1 of Entry has wire type 0, expected 2")
+	_, err = FromProto([]byte{0x0a, 0x04, 0x12, 0x02, 0x0a, 0x00})
+	assert.EqualError(t, err, "suffix: field 1 of Value has wire type 2, expected 0")
+	_, err = FromProto([]byte{0x0b})
+	assert.EqualError(t, err, "suffix: unsupported protobuf wire type 3")
+}
@@ -0,0 +1,117 @@
+package suffix
+
+// A suffix tree over keys is a prefix tree over the reversed keys: "example.com" is a suffix
+// of "www.example.com" as "moc.elpmaxe" is a prefix of "moc.elpmaxe.www". The views below
+// reverse the keys on the way in and out, so the users thinking in prefixes can use Tree, and
+// a prefix tree can answer the suffix queries, without reversing the bytes by hand.
+
+// PrefixTree is the interface of the trees matching keys by prefixes.
+type PrefixTree interface {
+	Insert(key []byte, value interface{}) (oldValue interface{}, ok bool)
+	Get(key []byte) (value interface{}, found bool)
+	// LongestPrefix returns the longest key which is a prefix of key.
+	LongestPrefix(key []byte) (matchedKey []byte, value interface{}, found bool)
+	Remove(key []byte) (oldValue interface{}, found bool)
+}
+
+// reversed returns a reversed copy of b. It keeps nil as nil.
+func reversed(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	r := make([]byte, len(b))
+	for i, c := range b {
+		r[len(b)-1-i] = c
+	}
+	return r
+}
+
+// PrefixView is a prefix tree backed by a Tree, which stores the reversed keys.
+type PrefixView struct {
+	tree *Tree
+}
+
+// AsPrefixTree returns a view of the tree matching keys by prefixes. The keys inserted via the
+// view are stored reversed in the tree, and the keys of the tree are returned reversed.
+func (tree *Tree) AsPrefixTree() *PrefixView {
+	return &PrefixView{tree: tree}
+}
+
+// Insert inserts the key, which is copied in reverse.
+func (view *PrefixView) Insert(key []byte, value interface{}) (oldValue interface{}, ok bool) {
+	return view.tree.Insert(reversed(key), value)
+}
+
+// Get is like Tree.Get.
+func (view *PrefixView) Get(key []byte) (value interface{}, found bool) {
+	return view.tree.Get(reversed(key))
+}
+
+// LongestPrefix returns the longest key which is a prefix of key, like Tree.LongestSuffix.
+func (view *PrefixView) LongestPrefix(key []byte) (matchedKey []byte, value interface{},
+	found bool) {
+
+	matchedKey, value, found = view.tree.LongestSuffix(reversed(key))
+	return reversed(matchedKey), value, found
+}
+
+// Remove is like Tree.Remove.
+func (view *PrefixView) Remove(key []byte) (oldValue interface{}, found bool) {
+	return view.tree.Remove(reversed(key))
+}
+
+// Len returns the number of keys in the tree.
+func (view *PrefixView) Len() int {
+	return view.tree.Len()
+}
+
+// Walk is like Tree.Walk. The keys passed to f are copies.
+func (view *PrefixView) Walk(f func(key []byte, value interface{}) (stop bool)) {
+	view.tree.Walk(func(key []byte, value interface{}) bool {
+		return f(reversed(key), value)
+	})
+}
+
+// WalkPrefix travels through the keys which have the given prefix, like Tree.WalkSuffix.
+// The keys passed to f are copies.
+func (view *PrefixView) WalkPrefix(prefix []byte, f func(key []byte, value interface{}) (
+	stop bool)) {
+
+	view.tree.WalkSuffix(reversed(prefix), func(key []byte, value interface{}) bool {
+		return f(reversed(key), value)
+	})
+}
+
+// ReversedView matches keys by suffixes with a PrefixTree, which stores the reversed keys.
+type ReversedView struct {
+	tree PrefixTree
+}
+
+// WrapReversed returns a view of the prefix tree matching keys by suffixes. The keys inserted
+// via the view are stored reversed in the prefix tree.
+func WrapReversed(tree PrefixTree) *ReversedView {
+	return &ReversedView{tree: tree}
+}
+
+// Insert inserts the key, which is copied in reverse.
+func (view *ReversedView) Insert(key []byte, value interface{}) (oldValue interface{}, ok bool) {
+	return view.tree.Insert(reversed(key), value)
+}
+
+// Get returns the value of key.
+func (view *ReversedView) Get(key []byte) (value interface{}, found bool) {
+	return view.tree.Get(reversed(key))
+}
+
+// LongestSuffix returns the longest key which is a suffix of key, like Tree.LongestSuffix.
+func (view *ReversedView) LongestSuffix(key []byte) (matchedKey []byte, value interface{},
+	found bool) {
+
+	matchedKey, value, found = view.tree.LongestPrefix(reversed(key))
+	return reversed(matchedKey), value, found
+}
+
+// Remove removes key, and returns its value.
+func (view *ReversedView) Remove(key []byte) (oldValue interface{}, found bool) {
+	return view.tree.Remove(reversed(key))
+}
@@ -0,0 +1,74 @@
+package suffix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixView(t *testing.T) {
+	tree := NewTree()
+	view := tree.AsPrefixTree()
+	view.Insert([]byte("/api/"), 1)
+	view.Insert([]byte("/api/v1/"), 2)
+	view.Insert([]byte("/static/"), 3)
+	assert.Equal(t, 3, view.Len())
+
+	// Stored reversed
+	value, found := tree.Get([]byte("/ipa/"))
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+	value, found = view.Get([]byte("/api/"))
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+
+	key, value, found := view.LongestPrefix([]byte("/api/v1/users"))
+	assert.True(t, found)
+	assert.Equal(t, "/api/v1/", string(key))
+	assert.Equal(t, 2, value)
+	key, _, _ = view.LongestPrefix([]byte("/api/v2/users"))
+	assert.Equal(t, "/api/", string(key))
+	_, _, found = view.LongestPrefix([]byte("/favicon.ico"))
+	assert.False(t, found)
+	_, _, found = view.LongestPrefix(nil)
+	assert.False(t, found)
+
+	var keys []string
+	view.WalkPrefix([]byte("/api"), func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return false
+	})
+	assert.ElementsMatch(t, []string{"/api/", "/api/v1/"}, keys)
+	keys = nil
+	view.Walk(func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return false
+	})
+	assert.ElementsMatch(t, []string{"/api/", "/api/v1/", "/static/"}, keys)
+
+	oldValue, found := view.Remove([]byte("/api/v1/"))
+	assert.True(t, found)
+	assert.Equal(t, 2, oldValue)
+}
+
+func TestWrapReversed(t *testing.T) {
+	// A suffix tree over a prefix tree over a suffix tree
+	view := WrapReversed(NewTree().AsPrefixTree())
+	view.Insert([]byte("example.com"), 1)
+	view.Insert([]byte(".com"), 2)
+
+	key, value, found := view.LongestSuffix([]byte("www.example.com"))
+	assert.True(t, found)
+	assert.Equal(t, "example.com", string(key))
+	assert.Equal(t, 1, value)
+	key, _, _ = view.LongestSuffix([]byte("golang.com"))
+	assert.Equal(t, ".com", string(key))
+
+	value, found = view.Get([]byte(".com"))
+	assert.True(t, found)
+	assert.Equal(t, 2, value)
+	_, found = view.Remove([]byte(".com"))
+	assert.True(t, found)
+	_, _, found = view.LongestSuffix([]byte("golang.com"))
+	assert.False(t, found)
+}
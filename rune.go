@@ -0,0 +1,83 @@
+package suffix
+
+import (
+	"unicode/utf8"
+)
+
+// RuneTree is a suffix tree over text, which treats keys as sequences of runes instead of
+// bytes. Matches always start and end on character boundaries: a query like "\xa9", which is
+// the last byte of "é", never matches "café".
+//
+// Valid UTF-8 can only match valid UTF-8 at character boundaries, so RuneTree replaces each
+// byte of invalid UTF-8 in keys and queries with utf8.RuneError, and then uses the byte-level
+// matching of Tree. The keys walked are the replaced ones.
+type RuneTree struct {
+	tree *Tree
+}
+
+// NewRuneTree creates a RuneTree for future usage.
+func NewRuneTree() *RuneTree {
+	return &RuneTree{tree: NewTree()}
+}
+
+// runeKey converts s to valid UTF-8, replacing each invalid byte with utf8.RuneError.
+func runeKey(s string) []byte {
+	if utf8.ValidString(s) {
+		return []byte(s)
+	}
+	key := make([]byte, 0, len(s)+8)
+	var buf [utf8.UTFMax]byte
+	for _, r := range s {
+		n := utf8.EncodeRune(buf[:], r)
+		key = append(key, buf[:n]...)
+	}
+	return key
+}
+
+// Insert is like Tree.Insert.
+func (tree *RuneTree) Insert(key string, value interface{}) (oldValue interface{}, ok bool) {
+	return tree.tree.Insert(runeKey(key), value)
+}
+
+// Get is like Tree.Get.
+func (tree *RuneTree) Get(key string) (value interface{}, found bool) {
+	return tree.tree.Get(runeKey(key))
+}
+
+// LongestSuffix is like Tree.LongestSuffix. The matched key is always a suffix of key which
+// starts at a character boundary.
+func (tree *RuneTree) LongestSuffix(key string) (matchedKey string, value interface{},
+	found bool) {
+
+	k, value, found := tree.tree.LongestSuffix(runeKey(key))
+	return string(k), value, found
+}
+
+// Remove is like Tree.Remove.
+func (tree *RuneTree) Remove(key string) (oldValue interface{}, found bool) {
+	return tree.tree.Remove(runeKey(key))
+}
+
+// HasSequence reports whether seq occurs in any key of the tree as a sequence of runes.
+func (tree *RuneTree) HasSequence(seq string) bool {
+	return tree.tree.HasSequence(runeKey(seq))
+}
+
+// Len returns the number of keys in the tree.
+func (tree *RuneTree) Len() int {
+	return tree.tree.Len()
+}
+
+// Walk is like Tree.Walk.
+func (tree *RuneTree) Walk(f func(key string, value interface{}) (stop bool)) {
+	tree.tree.Walk(func(key []byte, value interface{}) bool {
+		return f(string(key), value)
+	})
+}
+
+// WalkSuffix is like Tree.WalkSuffix.
+func (tree *RuneTree) WalkSuffix(suffix string, f func(key string, value interface{}) (stop bool)) {
+	tree.tree.WalkSuffix(runeKey(suffix), func(key []byte, value interface{}) bool {
+		return f(string(key), value)
+	})
+}
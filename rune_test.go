@@ -0,0 +1,58 @@
+package suffix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuneTree(t *testing.T) {
+	tree := NewRuneTree()
+	tree.Insert("café", 1)
+	tree.Insert("é", 2)
+	tree.Insert("naïve", 3)
+	assert.Equal(t, 3, tree.Len())
+
+	value, found := tree.Get("café")
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+
+	// "\xa9" is the last byte of "é"
+	bytesTree := NewTree()
+	bytesTree.Insert([]byte("café"), 1)
+	assert.True(t, bytesTree.HasSequence([]byte("\xa9")))
+	assert.False(t, tree.HasSequence("\xa9"))
+	assert.True(t, tree.HasSequence("fé"))
+	assert.True(t, tree.HasSequence("ï"))
+
+	key, value, found := tree.LongestSuffix("cliché")
+	assert.True(t, found)
+	assert.Equal(t, "é", key)
+	assert.Equal(t, 2, value)
+	_, _, found = tree.LongestSuffix("\xa9")
+	assert.False(t, found)
+
+	// Invalid bytes are replaced one by one
+	tree.Insert("bad\xc3\xc3", 4)
+	value, found = tree.Get("bad��")
+	assert.True(t, found)
+	assert.Equal(t, 4, value)
+	assert.True(t, tree.HasSequence("\xc3"))
+	var keys []string
+	tree.WalkSuffix("\xff", func(key string, value interface{}) bool {
+		keys = append(keys, key)
+		return false
+	})
+	assert.Equal(t, []string{"bad��"}, keys)
+
+	oldValue, found := tree.Remove("bad\xc3\xc3")
+	assert.True(t, found)
+	assert.Equal(t, 4, oldValue)
+
+	keys = nil
+	tree.Walk(func(key string, value interface{}) bool {
+		keys = append(keys, key)
+		return false
+	})
+	assert.ElementsMatch(t, []string{"café", "é", "naïve"}, keys)
+}
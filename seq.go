@@ -0,0 +1,291 @@
+package suffix
+
+// SeqTree is a suffix tree whose keys are sequences of any comparable elements, like runes,
+// words, path segments or event IDs, instead of bytes. For example, with the events of a log
+// tokenized into a []string, LongestSuffix finds the longest known pattern which the latest
+// events end with.
+//
+// Tree is the specialization for bytes, which is faster and supports more features. Since
+// the elements are not ordered, the keys of a node are walked in the order of insertion, so
+// the walking order depends on the history of the tree.
+//
+// SeqTree is not safe for concurrent use.
+type SeqTree[E comparable] struct {
+	root      *_SeqNode[E]
+	leavesNum int
+}
+
+type _SeqLeaf[E comparable] struct {
+	key   []E
+	value interface{}
+}
+
+// _SeqNode is a node of SeqTree. Unlike _Node, the key ending at a node is kept in the node
+// itself, and the edges of a node never share the last element.
+type _SeqNode[E comparable] struct {
+	leaf  *_SeqLeaf[E]
+	edges []*_SeqEdge[E]
+}
+
+type _SeqEdge[E comparable] struct {
+	label []E
+	node  *_SeqNode[E]
+}
+
+// NewSeqTree creates a SeqTree for future usage.
+func NewSeqTree[E comparable]() *SeqTree[E] {
+	return &SeqTree[E]{root: &_SeqNode[E]{}}
+}
+
+// seqSuffixLen returns the length of the common suffix of a and b.
+func seqSuffixLen[E comparable](a, b []E) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	return n
+}
+
+func seqHasSuffix[E comparable](s, suffix []E) bool {
+	return len(s) >= len(suffix) && seqSuffixLen(s, suffix) == len(suffix)
+}
+
+// edge returns the index of the edge whose label ends with the last element of key, or -1.
+func (node *_SeqNode[E]) edge(key []E) int {
+	if len(key) == 0 {
+		return -1
+	}
+	last := key[len(key)-1]
+	for i, edge := range node.edges {
+		if edge.label[len(edge.label)-1] == last {
+			return i
+		}
+	}
+	return -1
+}
+
+// find returns the node where key ends, or nil.
+func (node *_SeqNode[E]) find(key []E) *_SeqNode[E] {
+	for len(key) > 0 {
+		i := node.edge(key)
+		if i < 0 || !seqHasSuffix(key, node.edges[i].label) {
+			return nil
+		}
+		key = key[:len(key)-len(node.edges[i].label)]
+		node = node.edges[i].node
+	}
+	return node
+}
+
+// Insert is like Tree.Insert. The tree keeps a reference to key, so don't modify it after
+// insertion.
+func (tree *SeqTree[E]) Insert(key []E, value interface{}) (oldValue interface{}, ok bool) {
+	if key == nil {
+		return nil, false
+	}
+	node, rest := tree.root, key
+	for len(rest) > 0 {
+		i := node.edge(rest)
+		if i < 0 {
+			node.edges = append(node.edges, &_SeqEdge[E]{
+				label: rest,
+				node:  &_SeqNode[E]{leaf: &_SeqLeaf[E]{key, value}},
+			})
+			tree.leavesNum++
+			return nil, true
+		}
+		edge := node.edges[i]
+		n := seqSuffixLen(rest, edge.label)
+		if n < len(edge.label) {
+			// Split the edge at the end of the common suffix
+			mid := &_SeqNode[E]{edges: []*_SeqEdge[E]{{
+				label: edge.label[:len(edge.label)-n],
+				node:  edge.node,
+			}}}
+			edge.label = edge.label[len(edge.label)-n:]
+			edge.node = mid
+		}
+		node, rest = edge.node, rest[:len(rest)-n]
+	}
+	if node.leaf != nil {
+		oldValue = node.leaf.value
+		node.leaf.value = value
+		return oldValue, true
+	}
+	node.leaf = &_SeqLeaf[E]{key, value}
+	tree.leavesNum++
+	return nil, true
+}
+
+// Get is like Tree.Get.
+func (tree *SeqTree[E]) Get(key []E) (value interface{}, found bool) {
+	if key == nil {
+		return nil, false
+	}
+	node := tree.root.find(key)
+	if node == nil || node.leaf == nil {
+		return nil, false
+	}
+	return node.leaf.value, true
+}
+
+// LongestSuffix is like Tree.LongestSuffix.
+func (tree *SeqTree[E]) LongestSuffix(key []E) (matchedKey []E, value interface{}, found bool) {
+	if key == nil {
+		return nil, nil, false
+	}
+	node := tree.root
+	for {
+		if node.leaf != nil {
+			matchedKey, value, found = node.leaf.key, node.leaf.value, true
+		}
+		i := node.edge(key)
+		if i < 0 || !seqHasSuffix(key, node.edges[i].label) {
+			return matchedKey, value, found
+		}
+		key = key[:len(key)-len(node.edges[i].label)]
+		node = node.edges[i].node
+	}
+}
+
+// Remove is like Tree.Remove.
+func (tree *SeqTree[E]) Remove(key []E) (oldValue interface{}, found bool) {
+	if key == nil {
+		return nil, false
+	}
+	// The nodes and the indexes of the edges from the root to the node of key
+	var parents []*_SeqNode[E]
+	var indexes []int
+	node := tree.root
+	for len(key) > 0 {
+		i := node.edge(key)
+		if i < 0 || !seqHasSuffix(key, node.edges[i].label) {
+			return nil, false
+		}
+		parents = append(parents, node)
+		indexes = append(indexes, i)
+		key = key[:len(key)-len(node.edges[i].label)]
+		node = node.edges[i].node
+	}
+	if node.leaf == nil {
+		return nil, false
+	}
+	oldValue = node.leaf.value
+	node.leaf = nil
+	tree.leavesNum--
+
+	// Drop the node if it is empty, and merge it into its edge if it has only one edge
+	if len(parents) > 0 {
+		parent, i := parents[len(parents)-1], indexes[len(indexes)-1]
+		edge := parent.edges[i]
+		switch len(node.edges) {
+		case 0:
+			parent.edges = append(parent.edges[:i], parent.edges[i+1:]...)
+			node = parent
+			if len(parents) > 1 && node.leaf == nil && len(node.edges) == 1 {
+				grand := parents[len(parents)-2]
+				grand.edges[indexes[len(indexes)-2]].merge()
+			}
+		case 1:
+			edge.merge()
+		}
+	}
+	return oldValue, true
+}
+
+// merge joins the only edge of the node below e into e.
+func (e *_SeqEdge[E]) merge() {
+	child := e.node.edges[0]
+	label := make([]E, 0, len(child.label)+len(e.label))
+	e.label = append(append(label, child.label...), e.label...)
+	e.node = child.node
+}
+
+// Len returns the number of keys in the tree.
+func (tree *SeqTree[E]) Len() int {
+	return tree.leavesNum
+}
+
+func (node *_SeqNode[E]) walk(f func(key []E, value interface{}) bool) (stop bool) {
+	if node.leaf != nil && f(node.leaf.key, node.leaf.value) {
+		return true
+	}
+	for _, edge := range node.edges {
+		if edge.node.walk(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk is like Tree.Walk, but the keys of a node are walked in the order of insertion.
+func (tree *SeqTree[E]) Walk(f func(key []E, value interface{}) (stop bool)) {
+	tree.root.walk(f)
+}
+
+// WalkSuffix is like Tree.WalkSuffix, but the keys of a node are walked in the order of
+// insertion.
+func (tree *SeqTree[E]) WalkSuffix(suffix []E, f func(key []E, value interface{}) (stop bool)) {
+	node := tree.root
+	for len(suffix) > 0 {
+		i := node.edge(suffix)
+		if i < 0 {
+			return
+		}
+		edge := node.edges[i]
+		if seqHasSuffix(edge.label, suffix) {
+			// The suffix ends inside this label, all keys below it match
+			break
+		}
+		if !seqHasSuffix(suffix, edge.label) {
+			return
+		}
+		suffix = suffix[:len(suffix)-len(edge.label)]
+		node = edge.node
+	}
+	if len(suffix) == 0 {
+		node.walk(f)
+		return
+	}
+	node.edges[node.edge(suffix)].node.walk(f)
+}
+
+// matchSeq reports whether seq matches the elements read from label backward, continuing
+// into the node below it if the label is used up.
+func matchSeq[E comparable](label []E, node *_SeqNode[E], seq []E) bool {
+	if len(seq) <= len(label) {
+		return seqHasSuffix(label, seq)
+	}
+	if !seqHasSuffix(seq, label) {
+		return false
+	}
+	seq = seq[:len(seq)-len(label)]
+	i := node.edge(seq)
+	return i >= 0 && matchSeq(node.edges[i].label, node.edges[i].node, seq)
+}
+
+func (node *_SeqNode[E]) hasSequence(seq []E) bool {
+	for _, edge := range node.edges {
+		// Try to match seq at each position of this label
+		for end := len(edge.label); end > 0; end-- {
+			if matchSeq(edge.label[:end], edge.node, seq) {
+				return true
+			}
+		}
+		if edge.node.hasSequence(seq) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSequence reports whether the given sequence occurs in any key of the tree.
+func (tree *SeqTree[E]) HasSequence(seq []E) bool {
+	if seq == nil || tree.leavesNum == 0 {
+		return false
+	}
+	if len(seq) == 0 {
+		return true
+	}
+	return tree.root.hasSequence(seq)
+}
@@ -0,0 +1,121 @@
+package suffix
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeqTree(t *testing.T) {
+	tree := NewSeqTree[string]()
+	tree.Insert(strings.Fields("login fail fail"), "brute force")
+	tree.Insert(strings.Fields("fail"), "failure")
+	tree.Insert(strings.Fields("login ok"), "login")
+	tree.Insert([]string{}, "any")
+	_, ok := tree.Insert(nil, "nil")
+	assert.False(t, ok)
+	assert.Equal(t, 4, tree.Len())
+
+	key, value, found := tree.LongestSuffix(strings.Fields("start login fail fail"))
+	assert.True(t, found)
+	assert.Equal(t, strings.Fields("login fail fail"), key)
+	assert.Equal(t, "brute force", value)
+	_, value, _ = tree.LongestSuffix(strings.Fields("login ok fail"))
+	assert.Equal(t, "failure", value)
+	_, value, _ = tree.LongestSuffix(strings.Fields("login"))
+	assert.Equal(t, "any", value)
+
+	value, found = tree.Get(strings.Fields("login ok"))
+	assert.True(t, found)
+	assert.Equal(t, "login", value)
+	_, found = tree.Get(strings.Fields("ok"))
+	assert.False(t, found)
+
+	assert.True(t, tree.HasSequence(strings.Fields("login fail")))
+	assert.True(t, tree.HasSequence([]string{"ok"}))
+	assert.False(t, tree.HasSequence(strings.Fields("ok fail")))
+
+	var keys []string
+	tree.WalkSuffix([]string{"fail"}, func(key []string, value interface{}) bool {
+		keys = append(keys, strings.Join(key, " "))
+		return false
+	})
+	assert.Equal(t, []string{"fail", "login fail fail"}, keys)
+
+	oldValue, found := tree.Remove([]string{"fail"})
+	assert.True(t, found)
+	assert.Equal(t, "failure", oldValue)
+	_, found = tree.Remove([]string{"fail"})
+	assert.False(t, found)
+	_, value, _ = tree.LongestSuffix(strings.Fields("login ok fail"))
+	assert.Equal(t, "any", value)
+	assert.Equal(t, 3, tree.Len())
+}
+
+func hasSeq(key, seq []int) bool {
+	for i := 0; i+len(seq) <= len(key); i++ {
+		if seqHasSuffix(key[:i+len(seq)], seq) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSeqTree_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randomSeq := func() []int {
+		seq := make([]int, r.Intn(6))
+		for i := range seq {
+			seq[i] = r.Intn(3)
+		}
+		return seq
+	}
+	tree := NewSeqTree[int]()
+	keys := map[string][]int{}
+	for round := 0; round < 2000; round++ {
+		key := randomSeq()
+		id := fmt.Sprint(key)
+		if r.Intn(3) == 0 {
+			_, found := tree.Remove(key)
+			_, expected := keys[id]
+			assert.Equal(t, expected, found)
+			delete(keys, id)
+		} else {
+			tree.Insert(key, id)
+			keys[id] = key
+		}
+		assert.Equal(t, len(keys), tree.Len())
+
+		query := randomSeq()
+		var longest []int
+		found := false
+		hasSequence := false
+		for _, k := range keys {
+			if seqHasSuffix(query, k) && (!found || len(k) > len(longest)) {
+				longest, found = k, true
+			}
+			hasSequence = hasSequence || hasSeq(k, query)
+		}
+		matched, _, ok := tree.LongestSuffix(query)
+		assert.Equal(t, found, ok)
+		assert.Equal(t, longest, matched)
+		assert.Equal(t, hasSequence, tree.HasSequence(query))
+
+		walked := 0
+		tree.WalkSuffix(query, func(key []int, value interface{}) bool {
+			assert.True(t, seqHasSuffix(key, query))
+			walked++
+			return false
+		})
+		expected := 0
+		for _, k := range keys {
+			if seqHasSuffix(k, query) {
+				expected++
+			}
+		}
+		assert.Equal(t, expected, walked)
+	}
+}
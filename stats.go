@@ -0,0 +1,83 @@
+package suffix
+
+import (
+	"unsafe"
+)
+
+// Stats describes the shape of a tree. The depth of a key is the number of edges from the
+// root to its leaf.
+type Stats struct {
+	// The internal nodes, including the root
+	Nodes int
+	Edges int
+	// The keys
+	Leaves   int
+	MaxDepth int
+	// The average depth of the keys, 0 for an empty tree
+	AvgDepth float64
+	// The total length of the edge labels
+	LabelBytes int
+	// The total length of the keys
+	KeyBytes int
+	// The number of nodes by their number of edges
+	Fanout map[int]int
+}
+
+// Stats walks through the tree and returns its structural metrics, to compare the shapes of
+// datasets or spot the pathological ones, like long chains of single-edge nodes.
+func (tree *Tree) Stats() Stats {
+	stats := Stats{Fanout: map[int]int{}}
+	depthSum := 0
+	var visit func(node *_Node, depth int)
+	visit = func(node *_Node, depth int) {
+		stats.Nodes++
+		stats.Fanout[len(node.edges)]++
+		for _, edge := range node.edges {
+			stats.Edges++
+			stats.LabelBytes += len(edge.label)
+			switch point := edge.point.(type) {
+			case *_Leaf:
+				stats.Leaves++
+				stats.KeyBytes += len(point.originKey)
+				depthSum += depth + 1
+				if depth+1 > stats.MaxDepth {
+					stats.MaxDepth = depth + 1
+				}
+			case *_Node:
+				visit(point, depth+1)
+			}
+		}
+	}
+	visit(tree.root, 0)
+	if stats.Leaves > 0 {
+		stats.AvgDepth = float64(depthSum) / float64(stats.Leaves)
+	}
+	return stats
+}
+
+// EstimateBytesPerKey returns the memory taken by the nodes, edges and leaves of the tree,
+// divided by the number of keys, so a dataset can be given a concrete cost over a plain map.
+// The keys and values are not counted, since a map needs them too, and the result doesn't
+// include the rounding of the allocator. It returns 0 for an empty tree.
+func (tree *Tree) EstimateBytesPerKey() float64 {
+	var total, leaves uintptr
+	var visit func(node *_Node)
+	visit = func(node *_Node) {
+		total += unsafe.Sizeof(*node) + uintptr(cap(node.edges))*unsafe.Sizeof(node.edges[0])
+		for _, edge := range node.edges {
+			total += unsafe.Sizeof(*edge)
+			switch point := edge.point.(type) {
+			case *_Leaf:
+				total += unsafe.Sizeof(*point)
+				leaves++
+			case *_Node:
+				visit(point)
+			}
+		}
+	}
+	visit(tree.root)
+	if leaves == 0 {
+		return 0
+	}
+	return float64(total) / float64(leaves)
+}
@@ -0,0 +1,52 @@
+package suffix
+
+import (
+	"fmt"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	assert.Equal(t, Stats{Nodes: 1, Fanout: map[int]int{0: 1}}, NewTree().Stats())
+
+	tree := NewTree()
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("example.com"), nil)
+	tree.Insert([]byte("a.example.com"), nil)
+	tree.Insert([]byte("org"), nil)
+	// root: "com" -> node1, "org" -> leaf
+	// node1: "" -> leaf, "example." -> node2
+	// node2: "" -> leaf, "a." -> leaf
+	stats := tree.Stats()
+	assert.Equal(t, 3, stats.Nodes)
+	assert.Equal(t, 6, stats.Edges)
+	assert.Equal(t, 4, stats.Leaves)
+	assert.Equal(t, 3, stats.MaxDepth)
+	assert.Equal(t, float64(1+2+3+3)/4, stats.AvgDepth)
+	assert.Equal(t, len("com")+len("org")+len("example.")+len("a."), stats.LabelBytes)
+	assert.Equal(t, 3+11+13+3, stats.KeyBytes)
+	assert.Equal(t, map[int]int{2: 3}, stats.Fanout)
+	assert.Equal(t, tree.Len(), stats.Leaves)
+}
+
+func TestEstimateBytesPerKey(t *testing.T) {
+	assert.Equal(t, float64(0), NewTree().EstimateBytesPerKey())
+
+	tree := NewTree()
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("org"), nil)
+	// root with 2 edges to leaves
+	root := unsafe.Sizeof(_Node{}) + uintptr(cap(tree.root.edges))*unsafe.Sizeof(&_Edge{})
+	edges := 2 * (unsafe.Sizeof(_Edge{}) + unsafe.Sizeof(_Leaf{}))
+	assert.Equal(t, float64(root+edges)/2, tree.EstimateBytesPerKey())
+
+	// The estimate is in the ballpark of the measured heap size
+	tree = NewTree()
+	for i := 0; i < 10000; i++ {
+		tree.Insert([]byte(fmt.Sprintf("host%d.example%d.com", i, i%100)), nil)
+	}
+	estimate := tree.EstimateBytesPerKey()
+	assert.True(t, estimate > 50 && estimate < 200, estimate)
+}
@@ -0,0 +1,140 @@
+package suffix
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The stream written by Encoder:
+//
+//	magic   "SFXS"
+//	version 1 byte, currently 1
+//	records, each of them is an uvarint length followed by the record: the key prefixed
+//		with its uvarint length, and the value encoded like MarshalBinary
+//	end     an uvarint 0
+const (
+	streamMagic   = "SFXS"
+	streamVersion = 1
+)
+
+// Encoder writes trees to an output stream. Unlike WriteTo, it writes the keys one by one, so
+// the memory used doesn't grow with the tree.
+type Encoder struct {
+	w   *bufio.Writer
+	buf []byte
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: bufio.NewWriter(w),
+	}
+}
+
+// Encode writes the keys and values of the tree. The values should be nil, booleans, numbers,
+// strings or []byte. Multiple trees can be written to the same stream.
+func (enc *Encoder) Encode(tree *Tree) error {
+	enc.w.WriteString(streamMagic)
+	enc.w.WriteByte(streamVersion)
+	var err error
+	tree.Walk(func(key []byte, value interface{}) bool {
+		record := appendUvarint(enc.buf[:0], uint64(len(key)))
+		record = append(record, key...)
+		record, err = appendValue(record, value)
+		if err != nil {
+			return true
+		}
+		enc.buf = record
+		err = enc.writeRecord(record)
+		return err != nil
+	})
+	if err != nil {
+		return err
+	}
+	return enc.writeRecord(nil)
+}
+
+func (enc *Encoder) writeRecord(record []byte) error {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], uint64(len(record)))
+	enc.w.Write(tmp[:n])
+	if _, err := enc.w.Write(record); err != nil {
+		return err
+	}
+	if len(record) == 0 {
+		return enc.w.Flush()
+	}
+	return nil
+}
+
+// Decoder reads trees written by Encoder from an input stream.
+type Decoder struct {
+	r *bufio.Reader
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r: bufio.NewReader(r),
+	}
+}
+
+// Decode reads the next tree from the stream, and inserts its keys into the given tree as
+// they arrive. It returns io.EOF if there is no more tree in the stream. If the stream is
+// broken or the tree rejects a key, like a frozen tree or a key longer than WithMaxKeyLen,
+// the keys read before are still in the tree.
+func (dec *Decoder) Decode(tree *Tree) error {
+	header := make([]byte, len(streamMagic)+1)
+	n, err := io.ReadFull(dec.r, header)
+	if err != nil {
+		if err == io.EOF && n == 0 {
+			return io.EOF
+		}
+		return io.ErrUnexpectedEOF
+	}
+	if !bytes.HasPrefix(header, []byte(streamMagic)) {
+		return fmt.Errorf("suffix: not a tree stream")
+	}
+	if header[len(streamMagic)] != streamVersion {
+		return fmt.Errorf("suffix: unsupported stream version %d", header[len(streamMagic)])
+	}
+
+	var buf bytes.Buffer
+	for {
+		size, err := binary.ReadUvarint(dec.r)
+		if err != nil {
+			return io.ErrUnexpectedEOF
+		}
+		if size == 0 {
+			return nil
+		}
+		if size > math.MaxInt64 {
+			return fmt.Errorf("%w: invalid record size %d", ErrCorrupted, size)
+		}
+		// Grow the buffer with the data actually read, instead of trusting the size
+		buf.Reset()
+		if _, err := io.CopyN(&buf, dec.r, int64(size)); err != nil {
+			return io.ErrUnexpectedEOF
+		}
+		// The key is referred by the tree, so each record needs its own memory
+		d := decodeBuffer{data: append([]byte{}, buf.Bytes()...)}
+		key, err := d.lenBytes()
+		if err != nil {
+			return err
+		}
+		value, err := d.value()
+		if err != nil {
+			return err
+		}
+		if len(d.data) != 0 {
+			return fmt.Errorf("%w: %d bytes of trailing data in record", ErrCorrupted, len(d.data))
+		}
+		if err := tree.insertDecoded(key, value); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package suffix
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoder(t *testing.T) {
+	_, tree := getFixtures()
+	tree.Insert([]byte{}, nil)
+	other := NewTree()
+	other.Insert([]byte("sth"), 1)
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	assert.Nil(t, enc.Encode(tree))
+	assert.Nil(t, enc.Encode(other))
+	assert.Nil(t, enc.Encode(NewTree()))
+
+	dec := NewDecoder(&buf)
+	newTree := NewTree()
+	assert.Nil(t, dec.Decode(newTree))
+	assertSameContent(t, tree, newTree)
+	newTree = NewTree()
+	assert.Nil(t, dec.Decode(newTree))
+	assertSameContent(t, other, newTree)
+	assert.Nil(t, dec.Decode(newTree))
+	assert.Equal(t, 1, newTree.Len())
+	assert.Equal(t, io.EOF, dec.Decode(newTree))
+}
+
+func TestDecoder_RejectedKey(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("sth"), 1)
+	tree.Insert([]byte("something"), 2)
+	var buf bytes.Buffer
+	assert.Nil(t, NewEncoder(&buf).Encode(tree))
+	data := buf.Bytes()
+
+	newTree := NewTree(WithMaxKeyLen(3))
+	err := NewDecoder(bytes.NewReader(data)).Decode(newTree)
+	assert.ErrorIs(t, err, ErrKeyTooLong)
+	assert.Equal(t, 1, newTree.Len())
+
+	newTree = NewTree()
+	newTree.Freeze()
+	err = NewDecoder(bytes.NewReader(data)).Decode(newTree)
+	assert.ErrorIs(t, err, ErrReadOnly)
+}
+
+func TestEncoder_UnsupportedValue(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("sth"), struct{}{})
+	assert.NotNil(t, NewEncoder(&bytes.Buffer{}).Encode(tree))
+}
+
+func TestDecoder_Invalid(t *testing.T) {
+	_, tree := getFixtures()
+	var buf bytes.Buffer
+	NewEncoder(&buf).Encode(tree)
+	data := buf.Bytes()
+
+	decode := func(data []byte) (*Tree, error) {
+		tree := NewTree()
+		return tree, NewDecoder(bytes.NewReader(data)).Decode(tree)
+	}
+	_, err := decode([]byte("SFX"))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	_, err = decode([]byte("SFXT\x01"))
+	assert.EqualError(t, err, "suffix: not a tree stream")
+	_, err = decode([]byte("SFXS\x02"))
+	assert.EqualError(t, err, "suffix: unsupported stream version 2")
+	for i := len(streamMagic) + 1; i < len(data); i++ {
+		_, err = decode(data[:i])
+		assert.NotNil(t, err, "truncated at %d", i)
+	}
+	// The keys before the broken record are kept
+	newTree, err := decode(data[:len(data)-2])
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.Equal(t, tree.Len()-1, newTree.Len())
+
+	// A record with trailing data
+	_, err = decode([]byte("SFXS\x01\x04\x01a\x00\x00\x00"))
+	assert.EqualError(t, err, "suffix: corrupted data: 1 bytes of trailing data in record")
+	// A huge size doesn't allocate the memory up front
+	_, err = decode([]byte("SFXS\x01\xff\xff\xff\xff\xff\xff\xff\xff\x7f\x01"))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
@@ -2,9 +2,19 @@ package suffix
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
 	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// ErrNilKey is returned by TryInsert and TryRemove for the nil keys, unless the tree is
+// created with NilKeyAsEmpty.
+var ErrNilKey = errors.New("suffix: nil key")
+
 // Return
 // the first index of the mismatch byte (from right to left, starts from 1)
 // len(left)+1 if left byte sequence is shorter than right one
@@ -40,16 +50,74 @@ type _Leaf struct {
 	// For LongestSuffix and so on. We choice to use more memory(24 bytes per node)
 	// over appending keys each time.
 	originKey []byte
+	value     interface{}
 }
 
 type _Node struct {
 	edges []*_Edge
+	// The tree which can modify this node. Nodes shared with a snapshot are copied first.
+	owner *cowOwner
+	// The cached hash of the subtree, set by WithMerkleHashes and cleared by writable
+	merkle atomic.Pointer[[sha256.Size]byte]
+	// Only used by ConcurrentTree, which locks the nodes hand over hand down the match path
+	mu sync.RWMutex
+}
+
+// cowOwner identifies a tree for copy-on-write. It isn't zero-sized, so each one has a
+// distinct address.
+type cowOwner struct {
+	_ byte
+}
+
+// writable returns node itself if it is owned by owner, otherwise a copy owned by owner.
+// Leaves are copied with the node, so the leaves of a writable node can be modified too.
+func (node *_Node) writable(owner *cowOwner) *_Node {
+	if node.owner == owner {
+		if node.merkle.Load() != nil {
+			node.merkle.Store(nil)
+		}
+		return node
+	}
+	edges := make([]*_Edge, len(node.edges))
+	for i, edge := range node.edges {
+		point := edge.point
+		if leaf, ok := point.(*_Leaf); ok {
+			point = &_Leaf{
+				originKey: leaf.originKey,
+				value:     leaf.value,
+			}
+		}
+		edges[i] = &_Edge{
+			label: edge.label,
+			point: point,
+		}
+	}
+	return &_Node{
+		edges: edges,
+		owner: owner,
+	}
+}
+
+// writableChild makes the child node of edge writable.
+func (node *_Node) writableChild(edge *_Edge, child *_Node) *_Node {
+	child = child.writable(node.owner)
+	edge.point = child
+	return child
+}
+
+// labelLess orders the edges of a node. Shorter labels come first, and the labels with the
+// same length are ordered by their bytes, so the shape of a tree only depends on its keys,
+// not the order they are inserted.
+func labelLess(a, b []byte) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return bytes.Compare(a, b) < 0
 }
 
 func (node *_Node) insertEdge(edge *_Edge) {
-	newEdgeLabelLen := len(edge.label)
 	idx := sort.Search(len(node.edges), func(i int) bool {
-		return newEdgeLabelLen < len(node.edges[i].label)
+		return labelLess(edge.label, node.edges[i].label)
 	})
 	node.edges = append(node.edges, nil)
 	copy(node.edges[idx+1:], node.edges[idx:])
@@ -65,15 +133,14 @@ func (node *_Node) removeEdge(idx int) {
 // Reorder edge which is not shorter than before
 func (node *_Node) backwardEdge(idx int) {
 	edge := node.edges[idx]
-	edgeLabelLen := len(edge.label)
 	edgesLen := len(node.edges)
 	if idx == edgesLen-1 {
 		// Still longest, no need to change
 		return
 	}
-	// Get the first edge which's label is longer than this edge...
+	// Get the first edge which should be after this edge...
 	i := sort.Search(edgesLen-idx-1, func(j int) bool {
-		return edgeLabelLen < len(node.edges[j+idx+1].label)
+		return labelLess(edge.label, node.edges[j+idx+1].label)
 	})
 	// ... and insert before it. (Note that we just add `idx` instead of `idx+1`)
 	i += idx
@@ -84,22 +151,37 @@ func (node *_Node) backwardEdge(idx int) {
 // Reorder edge which is shorter than before
 func (node *_Node) forwardEdge(idx int) {
 	edge := node.edges[idx]
-	edgeLabelLen := len(edge.label)
 	i := sort.Search(idx, func(j int) bool {
-		return edgeLabelLen < len(node.edges[j].label)
+		return labelLess(edge.label, node.edges[j].label)
 	})
 	copy(node.edges[i+1:idx+1], node.edges[i:idx])
 	node.edges[i] = edge
 }
 
-func (node *_Node) insert(key []byte) {
+func (node *_Node) insert(originKey []byte, key []byte, value interface{}) (
+	oldValue interface{}, replaced bool) {
+
+	for node != nil {
+		node, key, oldValue, replaced = node.insertStep(originKey, key, value)
+	}
+	return oldValue, replaced
+}
+
+// insertStep inserts the key into node, or returns the child node and the rest of the key to
+// insert into it. The child is made writable before returning, so node is the only one
+// modified in a step, which lets ConcurrentTree lock the nodes hand over hand.
+func (node *_Node) insertStep(originKey []byte, key []byte, value interface{}) (
+	next *_Node, nextKey []byte, oldValue interface{}, replaced bool) {
 
 	start := 0
 	if len(node.edges) > 0 && len(node.edges[0].label) == 0 {
 		// handle empty label as a special case, so the rest of labels don't share
 		// common suffix
 		if len(key) == 0 {
-			return
+			leaf := node.edges[0].point.(*_Leaf)
+			oldValue = leaf.value
+			leaf.value = value
+			return nil, nil, oldValue, true
 		}
 		start++
 	}
@@ -110,11 +192,12 @@ func (node *_Node) insert(key []byte) {
 			// CASE 1: key == label
 			switch point := edge.point.(type) {
 			case *_Leaf:
-				return
+				oldValue = point.value
+				point.value = value
+				return nil, nil, oldValue, true
 			case *_Node:
 				// Node hitted, insert a leaf under this Node
-				point.insert([]byte{})
-				return
+				return node.writableChild(edge, point), key[:0], nil, false
 			}
 		} else if gap < 0 {
 			// CASE 2: key > label
@@ -128,26 +211,29 @@ func (node *_Node) insert(key []byte) {
 				// Create new Node, move old Leaf under new Node, and then
 				//	insert a new Leaf
 				newNode := &_Node{
+					owner: node.owner,
 					edges: []*_Edge{
 						{
-							label: []byte{},
+							label: label[:0],
 							point: point,
 						},
 						{
 							label: label,
-							point: &_Leaf{},
+							point: &_Leaf{
+								originKey: originKey,
+								value:     value,
+							},
 						},
 					},
 				}
 				edge.point = newNode
-				return
+				return nil, nil, nil, false
 			case *_Node:
 				// Before: Node - "label" -> Node - "" -> Leaf(Value1)
 				// After: Node - "label" - Node - "" -> Leaf(Value1)
 				//							|- "s" -> Leaf(Value2)
 				// Insert a new Leaf with extra data as label
-				point.insert(label)
-				return
+				return node.writableChild(edge, point), label, nil, false
 			}
 		} else if gap > 1 {
 			// CASE 3: mismatch(key, label) after first letter or key < label
@@ -163,12 +249,16 @@ func (node *_Node) insert(key []byte) {
 			}
 			keyEdge := &_Edge{
 				label: key[:len(key)-gap+1],
-				point: &_Leaf{},
+				point: &_Leaf{
+					originKey: originKey,
+					value:     value,
+				},
 			}
 			newNode := &_Node{
 				edges: make([]*_Edge, 2),
+				owner: node.owner,
 			}
-			if len(newEdge.label) < len(keyEdge.label) {
+			if labelLess(newEdge.label, keyEdge.label) {
 				newNode.edges[0], newNode.edges[1] = newEdge, keyEdge
 			} else {
 				newNode.edges[0], newNode.edges[1] = keyEdge, newEdge
@@ -176,115 +266,636 @@ func (node *_Node) insert(key []byte) {
 			edge.point = newNode
 			edge.label = edge.label[len(edge.label)-gap+1:]
 			node.forwardEdge(i)
-			return
+			return nil, nil, nil, false
 		}
 		// CASE 4: totally mismatch
 	}
 
-	leaf := &_Leaf{}
+	leaf := &_Leaf{
+		originKey: originKey,
+		value:     value,
+	}
 	edge := &_Edge{
 		label: key,
 		point: leaf,
 	}
 	node.insertEdge(edge)
-	return
+	return nil, nil, nil, false
+}
+
+func (node *_Node) getLeaf(key []byte) *_Leaf {
+	for _, edge := range node.edges {
+		if !bytes.HasSuffix(key, edge.label) {
+			continue
+		}
+		subKey := key[:len(key)-len(edge.label)]
+		switch point := edge.point.(type) {
+		case *_Leaf:
+			if len(subKey) == 0 {
+				return point
+			}
+		case *_Node:
+			// Labels in the same Node don't share common suffix, so there is no
+			// other edge to try.
+			return point.getLeaf(subKey)
+		}
+	}
+	return nil
+}
+
+// longestSuffix finds the longest key which is a suffix of key. If sep is not negative, only
+// the keys starting at a label boundary (see WithSeparator) are matched.
+func (node *_Node) longestSuffix(key []byte, sep int) (matchedKey []byte, value interface{},
+	found bool) {
+
+	for _, edge := range node.edges {
+		if !bytes.HasSuffix(key, edge.label) {
+			continue
+		}
+		subKey := key[:len(key)-len(edge.label)]
+		switch point := edge.point.(type) {
+		case *_Leaf:
+			if !atBoundary(subKey, point.originKey, sep) {
+				if len(edge.label) == 0 {
+					continue
+				}
+				return matchedKey, value, found
+			}
+			if len(edge.label) == 0 {
+				// The key ends here. Remember it and look for a longer one.
+				matchedKey, value, found = point.originKey, point.value, true
+				continue
+			}
+			return point.originKey, point.value, true
+		case *_Node:
+			childKey, childValue, childFound := point.longestSuffix(subKey, sep)
+			if childFound {
+				return childKey, childValue, true
+			}
+			return matchedKey, value, found
+		}
+	}
+	return matchedKey, value, found
+}
+
+// suffixesOf calls f with each key which is a suffix of key, from the longest to the
+// shortest. It returns true once f returns true.
+func (node *_Node) suffixesOf(key []byte, sep int, f func(key []byte, value interface{}) bool) (
+	stop bool) {
+
+	var ended *_Leaf
+	for _, edge := range node.edges {
+		if !bytes.HasSuffix(key, edge.label) {
+			continue
+		}
+		subKey := key[:len(key)-len(edge.label)]
+		switch point := edge.point.(type) {
+		case *_Leaf:
+			if !atBoundary(subKey, point.originKey, sep) {
+				continue
+			}
+			if len(edge.label) == 0 {
+				// The key ends here, and is shorter than the keys below other edges
+				ended = point
+				continue
+			}
+			if f(point.originKey, point.value) {
+				return true
+			}
+		case *_Node:
+			if point.suffixesOf(subKey, sep, f) {
+				return true
+			}
+		}
+	}
+	if ended != nil {
+		return f(ended.originKey, ended.value)
+	}
+	return false
 }
 
 func (node *_Node) mergeChildNode(idx int, child *_Node) {
 	if len(child.edges) == 1 {
 		edge := node.edges[idx]
-		edge.point = child.edges[0].point
-		edge.label = append(child.edges[0].label, edge.label...)
+		childEdge := child.edges[0]
+		label := make([]byte, 0, len(childEdge.label)+len(edge.label))
+		label = append(label, childEdge.label...)
+		edge.label = append(label, edge.label...)
+		edge.point = childEdge.point
 		node.backwardEdge(idx)
 	}
 	// When child has only one edge, we will remove the child and merge its label,
 	// So there is no case that child has no edge.
 }
 
+func (node *_Node) remove(key []byte) (oldValue interface{}, found bool) {
+	for i, edge := range node.edges {
+		if !bytes.HasSuffix(key, edge.label) {
+			continue
+		}
+		subKey := key[:len(key)-len(edge.label)]
+		switch point := edge.point.(type) {
+		case *_Leaf:
+			if len(subKey) == 0 {
+				node.removeEdge(i)
+				return point.value, true
+			}
+		case *_Node:
+			point = node.writableChild(edge, point)
+			oldValue, found = point.remove(subKey)
+			if found {
+				node.mergeChildNode(i, point)
+			}
+			return oldValue, found
+		}
+	}
+	return nil, false
+}
+
+func (node *_Node) walk(f func(key []byte, value interface{}) bool) (stop bool) {
+	for _, edge := range node.edges {
+		switch point := edge.point.(type) {
+		case *_Leaf:
+			if f(point.originKey, point.value) {
+				return true
+			}
+		case *_Node:
+			if point.walk(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (node *_Node) walkSuffix(suffix []byte, f func(key []byte, value interface{}) bool) (
+	stop bool) {
+
+	if len(suffix) == 0 {
+		return node.walk(f)
+	}
+	for _, edge := range node.edges {
+		if bytes.HasSuffix(edge.label, suffix) {
+			// The suffix ends inside this label, all keys below it match
+			switch point := edge.point.(type) {
+			case *_Leaf:
+				return f(point.originKey, point.value)
+			case *_Node:
+				return point.walk(f)
+			}
+		}
+		if len(edge.label) > 0 && bytes.HasSuffix(suffix, edge.label) {
+			if point, ok := edge.point.(*_Node); ok {
+				return point.walkSuffix(suffix[:len(suffix)-len(edge.label)], f)
+			}
+		}
+	}
+	return false
+}
+
+// walkNode calls f with the labels from each edge up to the root, and the value if the edge
+// points to a Leaf. All edges of a Node are visited before its child Nodes, and f is called
+// with a nil label each time it enters a child Node.
+func (node *_Node) walkNode(labels [][]byte, f func(labels [][]byte, value interface{})) {
+	for _, edge := range node.edges {
+		edgeLabels := append([][]byte{edge.label}, labels...)
+		switch point := edge.point.(type) {
+		case *_Leaf:
+			f(edgeLabels, point.value)
+		case *_Node:
+			f(edgeLabels, nil)
+		}
+	}
+	for _, edge := range node.edges {
+		if point, ok := edge.point.(*_Node); ok {
+			f([][]byte{nil}, nil)
+			point.walkNode(append([][]byte{edge.label}, labels...), f)
+		}
+	}
+}
+
+// matchSequence reports whether key matches the bytes read from label[:end] of the given
+// edge backward, continuing into the Node below it if the label is used up.
+func matchSequence(edge *_Edge, end int, key []byte) bool {
+	label := edge.label[:end]
+	if len(key) <= len(label) {
+		return bytes.HasSuffix(label, key)
+	}
+	if !bytes.HasSuffix(key, label) {
+		return false
+	}
+	point, ok := edge.point.(*_Node)
+	if !ok {
+		return false
+	}
+	subKey := key[:len(key)-len(label)]
+	for _, childEdge := range point.edges {
+		if matchSequence(childEdge, len(childEdge.label), subKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func (node *_Node) hasSequence(key []byte) bool {
+	if len(key) == 0 {
+		return true
+	}
+	for _, edge := range node.edges {
+		// Try to match the key at each position of this label
+		for end := len(edge.label); end > 0; end-- {
+			if matchSequence(edge, end, key) {
+				return true
+			}
+		}
+		if point, ok := edge.point.(*_Node); ok {
+			if point.hasSequence(key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Tree represents a suffix tree.
+//
+// The shape of a tree only depends on its keys, so trees with the same keys and values are
+// walked in the same order and encoded into the same bytes by MarshalBinary, WriteTo and the
+// other encodings, no matter in which order the keys were inserted.
 type Tree struct {
-	root *_Node
+	root      *_Node
+	leavesNum int
+	// nil until the first Snapshot, so a tree without snapshots never copies nodes
+	owner *cowOwner
+	// Applied to keys and queries in order, see Option
+	transforms []keyStep
+	// Set by WithSeparator
+	tokenMode bool
+	sep       byte
+	// Converts the stored keys before returning them, set by WithIDNA
+	outputKey func([]byte) []byte
+	// 0 means no limit, set by WithMaxKeyLen
+	maxKeyLen int
+	nilKeys   NilKeyPolicy
+	// Set by WithMetrics
+	metrics MetricsSink
+	// Set by WithTracer
+	tracer func(step TraceStep)
+	// Set by WithEdgeCounts
+	edgeCounts *edgeCounter
+	// Set by WithLogger
+	logger eventLogger
+	// Set by WithMerkleHashes
+	merkleHashes bool
+	// Set by Freeze
+	frozen bool
+	guard  writerGuard
 }
 
 // NewTree create a suffix tree for future usage.
-func NewTree() *Tree {
-	return &Tree{
+func NewTree(opts ...Option) *Tree {
+	tree := &Tree{
 		root: &_Node{
 			edges: []*_Edge{},
 		},
 	}
+	for _, opt := range opts {
+		opt(tree)
+	}
+	return tree
 }
 
-func (tree *Tree) Insert(key []byte) bool {
-	if key == nil {
-		return false
+// Insert suffix tree with given key and value. Return the previous value and a boolean to
+// indicate whether the insertion is successful.
+// The tree keeps a reference to key, so don't modify it after insertion.
+func (tree *Tree) Insert(key []byte, value interface{}) (oldValue interface{}, ok bool) {
+	oldValue, err := tree.TryInsert(key, value)
+	return oldValue, err == nil
+}
+
+// TryInsert is like Insert, but returns the reason why the key is rejected: ErrNilKey,
+// ErrEmptyKey, ErrKeyTooLong or ErrReadOnly, possibly wrapped, or the error of an option
+// checking the keys.
+func (tree *Tree) TryInsert(key []byte, value interface{}) (oldValue interface{}, err error) {
+	if tree.metrics != nil {
+		start := time.Now()
+		defer func() {
+			tree.metrics.Observe(OpInsert, time.Since(start), err == nil)
+		}()
 	}
-	tree.root.insert(key)
-	return true
+	logging := tree.logger != nil && tree.logger.enabled()
+	if logging {
+		rawKey := key
+		defer func() {
+			if err != nil {
+				tree.logger.event("rejected key", "key", quoteKey(rawKey), "err", err)
+			}
+		}()
+	}
+	if tree.frozen {
+		return nil, ErrReadOnly
+	}
+	key, err = tree.prepareKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err = tree.checkKeyLen(key); err != nil {
+		return nil, err
+	}
+	tree.guard.acquire()
+	defer tree.guard.release(tree)
+	tree.root = tree.root.writable(tree.owner)
+	oldValue, replaced := tree.root.insert(key, key, value)
+	if !replaced {
+		tree.leavesNum++
+		if logging {
+			tree.logInserted(key)
+		}
+	}
+	return oldValue, nil
 }
 
-func (node *_Node) hasSequence(key []byte) bool {
-	edges := node.edges
-	start := 0
-	if len(key) == 0 {
-		return true
+// Get returns the value of given key and a boolean to indicate whether the value is found.
+func (tree *Tree) Get(key []byte) (value interface{}, found bool) {
+	if tree.metrics != nil {
+		defer tree.observe(OpGet, time.Now(), &found)
+	}
+	key, err := tree.prepareKey(key)
+	if err != nil {
+		return nil, false
+	}
+	var leaf *_Leaf
+	if trace := tree.lookupTracer(key); trace != nil {
+		leaf = tree.root.traceLookup(OpGet, key, -1, true, 0, trace)
+	} else {
+		leaf = tree.root.getLeaf(key)
+	}
+	if leaf == nil {
+		return nil, false
 	}
+	return leaf.value, true
+}
 
-	if len(edges[0].label) == 0 {
-		// handle empty label as a special case, so the rest of labels don't share
-		// common suffix
-		if len(key) == 0 {
-			return true
+// LongestSuffix is mostly like Get. It returns the key which is the longest suffix of the given
+// key, and the value referred by this key. Plus a boolean to indicate whether the key/value is
+// found.
+func (tree *Tree) LongestSuffix(key []byte) (matchedKey []byte, value interface{}, found bool) {
+	if tree.metrics != nil {
+		defer tree.observe(OpLongestSuffix, time.Now(), &found)
+	}
+	key, err := tree.prepareKey(key)
+	if err != nil {
+		return nil, nil, false
+	}
+	if trace := tree.lookupTracer(key); trace != nil {
+		leaf := tree.root.traceLookup(OpLongestSuffix, key, tree.separator(), false, 0, trace)
+		if leaf != nil {
+			matchedKey, value, found = leaf.originKey, leaf.value, true
 		}
-		start++
+	} else {
+		matchedKey, value, found = tree.root.longestSuffix(key, tree.separator())
+	}
+	if found && tree.outputKey != nil {
+		matchedKey = tree.outputKey(matchedKey)
 	}
+	return matchedKey, value, found
+}
 
-	keyLen := len(key)
-	for i := start; i < len(edges); i++ {
-		edge := edges[i]
-		edgeLabelLen := len(edge.label)
-		if keyLen > edgeLabelLen {
-			if bytes.Equal(key[keyLen-edgeLabelLen:], edge.label) {
-				subKey := key[:keyLen-edgeLabelLen]
-				switch point := edge.point.(type) {
-				case *_Leaf:
-					return true
-				case *_Node:
-					found := point.hasSequence(subKey)
-					if found {
-						return true
-					}
-				}
-			}
-		} else if keyLen == edgeLabelLen {
-			if bytes.Equal(key, edge.label) {
-				switch point := edge.point.(type) {
-				case *_Leaf:
-					return true
-				case *_Node:
-					found := point.hasSequence([]byte{})
-					if found {
-						return true
-					}
-				}
-			}
-		} else if keyLen < edgeLabelLen {
-			if bytes.Equal(key, edge.label[edgeLabelLen-keyLen:]) {
-				return true
-			}
+// AllSuffixesOf calls f with each key which is a suffix of the given key, from the longest to
+// the shortest, so the first one is the key returned by LongestSuffix. Once f returns true, it
+// will stop.
+func (tree *Tree) AllSuffixesOf(key []byte, f func(key []byte, value interface{}) (stop bool)) {
+	key, err := tree.prepareKey(key)
+	if err != nil {
+		return
+	}
+	tree.root.suffixesOf(key, tree.separator(), tree.outputFunc(f))
+}
+
+// Remove returns the value of given key and a boolean to indicate whether the value is found.
+// Then the value will be removed.
+func (tree *Tree) Remove(key []byte) (oldValue interface{}, found bool) {
+	oldValue, found, _ = tree.TryRemove(key)
+	return oldValue, found
+}
+
+// TryRemove is like Remove, but returns the reason why the key can't be removed: ErrReadOnly,
+// or the error of TryInsert for a rejected key. A missing key is not an error.
+func (tree *Tree) TryRemove(key []byte) (oldValue interface{}, found bool, err error) {
+	if tree.metrics != nil {
+		defer tree.observe(OpRemove, time.Now(), &found)
+	}
+	if tree.frozen {
+		return nil, false, ErrReadOnly
+	}
+	key, err = tree.prepareKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	tree.guard.acquire()
+	defer tree.guard.release(tree)
+	merges := tree.logger != nil && tree.logger.enabled() && tree.removalMerges(key)
+	tree.root = tree.root.writable(tree.owner)
+	oldValue, found = tree.root.remove(key)
+	if found {
+		tree.leavesNum--
+		if merges {
+			tree.logger.event("merged edge", "key", quoteKey(key))
 		}
 	}
+	return oldValue, found, nil
+}
 
-	if start == 1 {
-		return true
+// CompareAndSwap swaps the value of key to newValue if the current value equals to oldValue.
+// It panics if the current value is not comparable, and returns whether the value is swapped.
+func (tree *Tree) CompareAndSwap(key []byte, oldValue, newValue interface{}) (swapped bool) {
+	key, err := tree.prepareKey(key)
+	if err != nil || tree.frozen {
+		return false
+	}
+	tree.guard.acquire()
+	defer tree.guard.release(tree)
+	leaf := tree.root.getLeaf(key)
+	if leaf != nil && leaf.value == oldValue {
+		if tree.owner == nil && !tree.merkleHashes {
+			leaf.value = newValue
+		} else {
+			// The leaf may be shared with a snapshot, or the hashes of its path may be
+			// cached, so replace it through the path
+			tree.root = tree.root.writable(tree.owner)
+			tree.root.insert(key, key, newValue)
+		}
+		swapped = true
 	}
+	return swapped
+}
 
-	return false
+// CompareAndDelete removes key if its value equals to oldValue.
+// It panics if the current value is not comparable, and returns whether the key is removed.
+func (tree *Tree) CompareAndDelete(key []byte, oldValue interface{}) (deleted bool) {
+	key, err := tree.prepareKey(key)
+	if err != nil || tree.frozen {
+		return false
+	}
+	tree.guard.acquire()
+	defer tree.guard.release(tree)
+	leaf := tree.root.getLeaf(key)
+	if leaf != nil && leaf.value == oldValue {
+		tree.root = tree.root.writable(tree.owner)
+		tree.root.remove(key)
+		tree.leavesNum--
+		deleted = true
+	}
+	return deleted
+}
+
+// Snapshot returns a point-in-time copy of the tree in O(1) time. The copy shares all nodes
+// with the tree, and both of them copy a shared node before modifying it, so the changes made
+// to one are invisible to the other. The cost of copying is paid by the following mutations,
+// and each node is copied at most once.
+//
+// Like other mutations, Snapshot can't run concurrently with the writes to the tree. But once
+// it returns, the snapshot can be read while the tree is being modified, which is how
+// ConcurrentTree.SnapshotTo writes a consistent image without holding the locks.
+func (tree *Tree) Snapshot() *Tree {
+	if tree.frozen {
+		// The nodes of a frozen tree are never modified, so they can be shared without
+		// changing its owner, and the goroutines sharing it can take snapshots concurrently
+		return tree.fork()
+	}
+	tree.guard.acquire()
+	defer tree.guard.release(tree)
+	tree.owner = &cowOwner{}
+	snapshot := tree.fork()
+	return snapshot
+}
+
+// fork returns a tree sharing the nodes and the options of tree, which copies the nodes before
+// modifying them. Unlike Snapshot, tree itself is not changed, so it must not be modified
+// afterward, unless it is also made to copy the shared nodes.
+func (tree *Tree) fork() *Tree {
+	return &Tree{
+		root:         tree.root,
+		leavesNum:    tree.leavesNum,
+		owner:        &cowOwner{},
+		transforms:   tree.transforms,
+		tokenMode:    tree.tokenMode,
+		sep:          tree.sep,
+		outputKey:    tree.outputKey,
+		maxKeyLen:    tree.maxKeyLen,
+		nilKeys:      tree.nilKeys,
+		metrics:      tree.metrics,
+		tracer:       tree.tracer,
+		edgeCounts:   tree.edgeCounts,
+		logger:       tree.logger,
+		merkleHashes: tree.merkleHashes,
+	}
+}
+
+// emptyLike returns an empty tree with the options of tree which change or reject keys, so
+// the keys decoded for tree can be inserted like tree does.
+func (tree *Tree) emptyLike() *Tree {
+	return &Tree{
+		root:       &_Node{edges: []*_Edge{}},
+		transforms: tree.transforms,
+		tokenMode:  tree.tokenMode,
+		sep:        tree.sep,
+		outputKey:  tree.outputKey,
+		maxKeyLen:  tree.maxKeyLen,
+		nilKeys:    tree.nilKeys,
+	}
+}
+
+// rewritesKeys reports whether the options of tree change or reject keys, so a tree decoded
+// without them must be inserted again.
+func (tree *Tree) rewritesKeys() bool {
+	return len(tree.transforms) > 0 || tree.maxKeyLen > 0 || tree.nilKeys == RejectEmptyKey
+}
+
+// insertDecoded inserts a decoded key into a tree made by emptyLike.
+func (tree *Tree) insertDecoded(key []byte, value interface{}) error {
+	if _, err := tree.TryInsert(key, value); err != nil {
+		return fmt.Errorf("suffix: decoded key %s is rejected: %w", quoteKey(key), err)
+	}
+	return nil
+}
+
+// Len returns the number of keys in the tree.
+func (tree *Tree) Len() int {
+	return tree.leavesNum
+}
+
+// Walk through the tree, call function with key and value.
+// Once the function returns true, it will stop walking.
+// The travelling order is DFS, in the same suffix level the shortest key comes first, and the
+// ties are broken by bytes, so the order doesn't depend on the insertion order.
+func (tree *Tree) Walk(f func(key []byte, value interface{}) (stop bool)) {
+	tree.root.walk(tree.outputFunc(f))
+}
+
+// outputFunc wraps f to convert the keys passed to it with outputKey.
+func (tree *Tree) outputFunc(f func(key []byte, value interface{}) bool) func(
+	key []byte, value interface{}) bool {
+
+	if tree.outputKey == nil {
+		return f
+	}
+	return func(key []byte, value interface{}) bool {
+		return f(tree.outputKey(key), value)
+	}
+}
+
+// WalkSuffix travels through nodes which have given suffix in the same order as Walk.
+// Once the function returns true, it will stop walking.
+func (tree *Tree) WalkSuffix(suffix []byte, f func(key []byte, value interface{}) (stop bool)) {
+	if suffix != nil {
+		var err error
+		if suffix, err = tree.transformKey(suffix); err != nil {
+			return
+		}
+	}
+	f = tree.outputFunc(f)
+	if tree.tokenMode && len(suffix) > 0 {
+		g := f
+		f = func(key []byte, value interface{}) bool {
+			if !atBoundary(key[:len(key)-len(suffix)], suffix, int(tree.sep)) {
+				return false
+			}
+			return g(key, value)
+		}
+	}
+	tree.root.walkSuffix(suffix, f)
 }
 
-func (tree *Tree) HasSequence(key []byte) bool {
-	if key == nil || len(tree.root.edges) == 0 {
+func (tree *Tree) walkNode(f func(labels [][]byte, value interface{})) {
+	tree.root.walkNode([][]byte{}, f)
+}
+
+// HasSequence reports whether the given byte sequence occurs in any key of the tree.
+func (tree *Tree) HasSequence(key []byte) (found bool) {
+	if tree.metrics != nil {
+		defer tree.observe(OpHasSequence, time.Now(), &found)
+	}
+	if len(tree.root.edges) == 0 {
 		return false
 	}
-	return tree.root.hasSequence(key)
+	key, err := tree.prepareKey(key)
+	if err != nil || !tree.root.hasSequence(key) {
+		return false
+	}
+	if !tree.tokenMode || len(key) == 0 {
+		return true
+	}
+	// The sequence surrounded by separators is in the middle of a key. Otherwise it may be at
+	// the start or the end, which can't be told from the shape, so check the keys one by one.
+	wrapped := make([]byte, 0, len(key)+2)
+	wrapped = append(append(append(wrapped, tree.sep), key...), tree.sep)
+	if tree.root.hasSequence(wrapped) {
+		return true
+	}
+	tree.root.walk(func(k []byte, _ interface{}) bool {
+		found = hasLabels(k, key, tree.sep)
+		return found
+	})
+	return found
 }
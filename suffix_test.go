@@ -10,9 +10,12 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"testing/quick"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/spacewander/go-suffix-tree/suffixtest"
 )
 
 var (
@@ -129,6 +132,45 @@ func TestLongestSuffix_Base(t *testing.T) {
 	assertLongestSuffix(t, tree, "fourth", false)
 }
 
+func TestAllSuffixesOf(t *testing.T) {
+	tree := NewTree()
+	for _, key := range []string{"", "h", "th", "sth", "else sth", "any sth", "s"} {
+		tree.Insert([]byte(key), key)
+	}
+	collect := func(key string) []string {
+		keys := []string{}
+		tree.AllSuffixesOf([]byte(key), func(key []byte, value interface{}) bool {
+			keys = append(keys, string(key))
+			assert.Equal(t, string(key), value)
+			return false
+		})
+		return keys
+	}
+	assert.Equal(t, []string{"else sth", "sth", "th", "h", ""}, collect("else sth"))
+	assert.Equal(t, []string{"sth", "th", "h", ""}, collect("lse sth"))
+	assert.Equal(t, []string{""}, collect("x"))
+
+	n := 0
+	tree.AllSuffixesOf([]byte("sth"), func(key []byte, value interface{}) bool {
+		n++
+		return n == 2
+	})
+	assert.Equal(t, 2, n)
+}
+
+func TestAllSuffixesOf_Separator(t *testing.T) {
+	tree := NewTree(WithSeparator('.'))
+	for _, key := range []string{"com", "example.com", "ample.com", "www.example.com"} {
+		tree.Insert([]byte(key), key)
+	}
+	keys := []string{}
+	tree.AllSuffixesOf([]byte("www.example.com"), func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return false
+	})
+	assert.Equal(t, []string{"www.example.com", "example.com", "com"}, keys)
+}
+
 func TestRemove_EmptyTree(t *testing.T) {
 	tree := NewTree()
 	_, found := tree.Remove([]byte("anything"))
@@ -162,6 +204,34 @@ func TestRemove_Base(t *testing.T) {
 	assertGet(t, tree, "sth else", true)
 }
 
+func TestCompareAndSwap(t *testing.T) {
+	tree := NewTree()
+	assert.False(t, tree.CompareAndSwap([]byte("sth"), nil, "sth"))
+	assert.False(t, tree.CompareAndSwap(nil, nil, "sth"))
+
+	tree.Insert([]byte("sth"), "sth")
+	assert.False(t, tree.CompareAndSwap([]byte("sth"), "else", "any"))
+	assert.True(t, tree.CompareAndSwap([]byte("sth"), "sth", "else"))
+	value, _ := tree.Get([]byte("sth"))
+	assert.Equal(t, "else", value.(string))
+
+	tree.Insert([]byte("any"), []int{})
+	assert.Panics(t, func() {
+		tree.CompareAndSwap([]byte("any"), []int{}, nil)
+	})
+}
+
+func TestCompareAndDelete(t *testing.T) {
+	lists, tree := getFixtures()
+	assert.False(t, tree.CompareAndDelete([]byte("sth"), nil))
+	assert.False(t, tree.CompareAndDelete(nil, nil))
+	assert.False(t, tree.CompareAndDelete([]byte("table"), "chair"))
+	assert.True(t, tree.CompareAndDelete([]byte("table"), "table"))
+	assertGet(t, tree, "table", false)
+	assertGet(t, tree, "presentable", true)
+	assert.Equal(t, len(lists)-1, tree.Len())
+}
+
 func TestWalk(t *testing.T) {
 	lists, tree := getFixtures()
 
@@ -268,6 +338,8 @@ func TestInvalidInput(t *testing.T) {
 
 	_, ok := tree.Insert(nil, "any")
 	assert.False(t, ok)
+	_, err := tree.TryInsert(nil, "any")
+	assert.EqualError(t, err, "suffix: nil key")
 
 	_, found := tree.Get(nil)
 	assert.False(t, found)
@@ -573,3 +645,177 @@ func TestHasSequence(t *testing.T) {
 		t.Fatal("Failed to find existing subsequence")
 	}
 }
+
+func copyContent(tree *Tree) map[string]interface{} {
+	content := map[string]interface{}{}
+	tree.Walk(func(key []byte, value interface{}) bool {
+		content[string(key)] = value
+		return false
+	})
+	return content
+}
+
+func TestSnapshot(t *testing.T) {
+	lists, tree := getFixtures()
+	expected := copyContent(tree)
+	snapshot := tree.Snapshot()
+
+	tree.Insert([]byte("vegetable"), "vegetable")
+	tree.Insert([]byte("table"), 1)
+	tree.Insert([]byte{}, nil)
+	tree.Remove([]byte("credible"))
+	tree.Remove([]byte("sense"))
+	assert.True(t, tree.CompareAndSwap([]byte("word"), "word", 2))
+	assert.True(t, tree.CompareAndDelete([]byte("nothing"), "nothing"))
+	assert.Equal(t, len(lists)-1, tree.Len())
+	assert.Equal(t, expected, copyContent(snapshot))
+	assert.Equal(t, len(lists), snapshot.Len())
+	msg, inOrder := checkLabelOrder(tree)
+	assert.True(t, inOrder, msg)
+
+	changed := copyContent(tree)
+	snapshot.Insert([]byte("comfortable"), "comfortable")
+	snapshot.Remove([]byte("table"))
+	assert.Equal(t, changed, copyContent(tree))
+	value, found := snapshot.Get([]byte("comfortable"))
+	assert.True(t, found)
+	assert.Equal(t, "comfortable", value)
+
+	// Nodes are copied once
+	root := tree.root
+	tree.Insert([]byte("able"), nil)
+	assert.True(t, root == tree.root)
+}
+
+func TestSnapshot_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	randomKey := func() []byte {
+		b := make([]byte, r.Intn(6))
+		for i := range b {
+			b[i] = "abc"[r.Intn(3)]
+		}
+		return b
+	}
+	tree := NewTree()
+	model := map[string]interface{}{}
+	var snapshots []*Tree
+	var models []map[string]interface{}
+	for i := 0; i < 2000; i++ {
+		key := randomKey()
+		switch r.Intn(4) {
+		case 0, 1:
+			tree.Insert(key, i)
+			model[string(key)] = i
+		case 2:
+			tree.Remove(key)
+			delete(model, string(key))
+		case 3:
+			if tree.CompareAndSwap(key, model[string(key)], -i) {
+				model[string(key)] = -i
+			}
+		}
+		if i%100 == 0 {
+			snapshots = append(snapshots, tree.Snapshot())
+			saved := map[string]interface{}{}
+			for k, v := range model {
+				saved[k] = v
+			}
+			models = append(models, saved)
+		}
+	}
+	assert.Equal(t, model, copyContent(tree))
+	for i, snapshot := range snapshots {
+		assert.Equal(t, models[i], copyContent(snapshot))
+		assert.Equal(t, len(models[i]), snapshot.Len())
+	}
+}
+
+func TestCanonicalShape(t *testing.T) {
+	lists, tree := getFixtures()
+	lists = append(lists, "", "able", "bble", "cble", "thins", "thint")
+	encode := func(tree *Tree) []string {
+		binary, err := tree.MarshalBinary()
+		assert.Nil(t, err)
+		var format, flat bytes.Buffer
+		_, err = tree.WriteTo(&format)
+		assert.Nil(t, err)
+		_, err = tree.WriteFlat(&flat)
+		assert.Nil(t, err)
+		json, err := tree.MarshalJSON()
+		assert.Nil(t, err)
+		return []string{string(binary), format.String(), flat.String(), string(json)}
+	}
+
+	tree = NewTree()
+	for _, s := range lists {
+		tree.Insert([]byte(s), s)
+	}
+	expected := encode(tree)
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 20; i++ {
+		tree := NewTree()
+		for _, j := range r.Perm(len(lists)) {
+			tree.Insert([]byte(lists[j]), lists[j])
+		}
+		// Removing keys merges nodes, which shouldn't change the shape either
+		tree.Insert([]byte("ble"), nil)
+		tree.Insert([]byte("thin"), nil)
+		tree.Remove([]byte("ble"))
+		tree.Remove([]byte("thin"))
+		assert.Equal(t, expected, encode(tree))
+	}
+}
+
+func TestTree_AgainstModel(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	keys := make([][]byte, 50)
+	for i := range keys {
+		key := make([]byte, r.Intn(8))
+		for j := range key {
+			key[j] = "ab."[r.Intn(3)]
+		}
+		keys[i] = key
+	}
+	for seed := int64(0); seed < 10; seed++ {
+		assert.Nil(t, suffixtest.CheckAgainstModel(NewTree(), keys, 1000, seed))
+	}
+}
+
+func TestTree_Quick(t *testing.T) {
+	// The tree and the model agree on the lookups of each key and the queries around them
+	agree := func(keys [][]byte) bool {
+		tree, model := NewTree(), suffixtest.NewModel()
+		for i, key := range keys {
+			tree.Insert(key, i)
+			model.Insert(key, i)
+		}
+		for i, key := range keys {
+			if i%2 == 0 {
+				tree.Remove(key)
+				model.Remove(key)
+			}
+		}
+		if tree.Len() != model.Len() || tree.Validate() != nil {
+			return false
+		}
+		for _, key := range keys {
+			for _, query := range [][]byte{key, append([]byte("a"), key...), key[len(key)/2:]} {
+				matched, value, found := tree.LongestSuffix(query)
+				expectedMatched, expectedValue, expectedFound := model.LongestSuffix(query)
+				if found != expectedFound || value != expectedValue ||
+					!bytes.Equal(matched, expectedMatched) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	err := quick.Check(func(keys suffixtest.Keys) bool { return agree(keys) }, nil)
+	if checkErr, ok := err.(*quick.CheckError); ok {
+		keys := checkErr.In[0].(suffixtest.Keys)
+		t.Fatalf("failed on keys %q", suffixtest.ShrinkKeys(keys, func(keys [][]byte) bool {
+			return !agree(keys)
+		}))
+	}
+	assert.Nil(t, err)
+}
@@ -0,0 +1,66 @@
+package suffixbench
+
+import (
+	"runtime"
+	"testing"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// Build builds a tree of the keys with the options, and returns it.
+func Build(keys [][]byte, opts ...suffix.Option) *suffix.Tree {
+	tree := suffix.NewTree(opts...)
+	for _, key := range keys {
+		tree.Insert(key, nil)
+	}
+	return tree
+}
+
+// BenchmarkBuild measures building a tree of the keys. It reports the time per key as
+// "ns/key".
+func BenchmarkBuild(b *testing.B, keys [][]byte, opts ...suffix.Option) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Build(keys, opts...)
+	}
+	if len(keys) > 0 {
+		b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(keys)), "ns/key")
+	}
+}
+
+// BenchmarkGet measures Get on a tree of the keys, looking up each query in turn.
+func BenchmarkGet(b *testing.B, keys, queries [][]byte, opts ...suffix.Option) {
+	tree := Build(keys, opts...)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Get(queries[i%len(queries)])
+	}
+}
+
+// BenchmarkLongestSuffix measures LongestSuffix on a tree of the keys, looking up each query in
+// turn.
+func BenchmarkLongestSuffix(b *testing.B, keys, queries [][]byte, opts ...suffix.Option) {
+	tree := Build(keys, opts...)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.LongestSuffix(queries[i%len(queries)])
+	}
+}
+
+// BytesPerKey returns the heap memory per key taken by a tree of the keys, not counting the
+// keys themselves, which the tree shares with the caller.
+func BytesPerKey(keys [][]byte, opts ...suffix.Option) float64 {
+	if len(keys) == 0 {
+		return 0
+	}
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	tree := Build(keys, opts...)
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(tree)
+	return float64(int64(after.HeapAlloc)-int64(before.HeapAlloc)) / float64(len(keys))
+}
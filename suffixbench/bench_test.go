@@ -0,0 +1,96 @@
+package suffixbench
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDomains(t *testing.T) {
+	list := "# top sites\n1,Google.com\n2, example.org\n\nexample.net\n"
+	keys, err := LoadDomains(strings.NewReader(list))
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("google.com"), []byte("example.org"), []byte("example.net")},
+		keys)
+}
+
+func TestLoadWords(t *testing.T) {
+	keys, err := LoadWords(strings.NewReader("able\n\n table \n"))
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("able"), []byte("table")}, keys)
+
+	_, err = LoadFile("testdata/missing.txt", LoadWords)
+	assert.NotNil(t, err)
+}
+
+func TestSynthetic(t *testing.T) {
+	domains := SyntheticDomains(100, 1)
+	assert.Equal(t, 100, len(domains))
+	assert.Equal(t, domains, SyntheticDomains(100, 1))
+	for _, d := range domains {
+		assert.True(t, bytes.Contains(d, []byte(".")), string(d))
+	}
+	keys := RandomKeys(100, 8, 1)
+	assert.Equal(t, keys, RandomKeys(100, 8, 1))
+	for _, k := range keys {
+		assert.True(t, len(k) <= 8)
+	}
+	assert.True(t, BytesPerKey(domains) > 0)
+	assert.Equal(t, float64(0), BytesPerKey(nil))
+	assert.Equal(t, 100, Build(domains).Len()+countDuplicates(domains))
+}
+
+func countDuplicates(keys [][]byte) int {
+	seen := map[string]bool{}
+	n := 0
+	for _, k := range keys {
+		if seen[string(k)] {
+			n++
+		}
+		seen[string(k)] = true
+	}
+	return n
+}
+
+// datasets returns the datasets to benchmark: the domains, from SUFFIX_BENCH_DOMAINS if set,
+// the random binaries, and the words from SUFFIX_BENCH_WORDS if set.
+func datasets(b *testing.B) map[string][][]byte {
+	sets := map[string][][]byte{
+		"domains": SyntheticDomains(100000, 1),
+		"binary":  RandomKeys(100000, 12, 1),
+	}
+	if path := os.Getenv("SUFFIX_BENCH_DOMAINS"); path != "" {
+		keys, err := LoadFile(path, LoadDomains)
+		if err != nil {
+			b.Fatal(err)
+		}
+		sets["domains"] = keys
+	}
+	if path := os.Getenv("SUFFIX_BENCH_WORDS"); path != "" {
+		keys, err := LoadFile(path, LoadWords)
+		if err != nil {
+			b.Fatal(err)
+		}
+		sets["words"] = keys
+	}
+	return sets
+}
+
+func BenchmarkDatasets(b *testing.B) {
+	for name, keys := range datasets(b) {
+		queries := SyntheticDomains(1000, 2)
+		b.Run(name+"/Build", func(b *testing.B) {
+			BenchmarkBuild(b, keys)
+			b.ReportMetric(BytesPerKey(keys), "B/key")
+		})
+		b.Run(name+"/Get", func(b *testing.B) {
+			BenchmarkGet(b, keys, keys)
+		})
+		b.Run(name+"/LongestSuffix", func(b *testing.B) {
+			BenchmarkLongestSuffix(b, keys, queries)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package suffixbench provides the datasets and standard benchmarks of the suffix tree, so the
+// performance of different versions and options can be measured the same way.
+//
+// The benchmarks of this package run on the synthetic datasets by default. Set
+// SUFFIX_BENCH_DOMAINS to a domain list, like the Tranco list, or SUFFIX_BENCH_WORDS to a
+// dictionary like /usr/share/dict/words, to run them on real data:
+//
+//	SUFFIX_BENCH_DOMAINS=top-1m.csv go test -bench . ./suffixbench
+package suffixbench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+// LoadDomains reads a domain list, with one domain per line, either alone or as the last
+// field of a CSV record like "1,example.com" in the Tranco and Alexa lists. The domains are
+// lowercased, and the empty lines and the lines starting with "#" are skipped.
+func LoadDomains(r io.Reader) ([][]byte, error) {
+	var keys [][]byte
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if i := strings.LastIndexByte(line, ','); i >= 0 {
+			line = strings.TrimSpace(line[i+1:])
+		}
+		keys = append(keys, []byte(strings.ToLower(line)))
+	}
+	return keys, scanner.Err()
+}
+
+// LoadWords reads a dictionary, with one word per line. The empty lines are skipped.
+func LoadWords(r io.Reader) ([][]byte, error) {
+	var keys [][]byte
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" {
+			keys = append(keys, []byte(word))
+		}
+	}
+	return keys, scanner.Err()
+}
+
+// LoadFile reads the file at path with load, like LoadDomains or LoadWords.
+func LoadFile(path string, load func(r io.Reader) ([][]byte, error)) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	keys, err := load(f)
+	if err != nil {
+		return nil, fmt.Errorf("suffixbench: read %s: %v", path, err)
+	}
+	return keys, nil
+}
+
+var (
+	syntheticTLDs   = []string{"com", "net", "org", "io", "de", "co.uk", "com.cn", "jp"}
+	syntheticLabels = []string{"www", "api", "mail", "cdn", "static", "m", "blog", "shop"}
+)
+
+// randomLabel returns a lowercase label of 3 to 12 letters.
+func randomLabel(r *rand.Rand) string {
+	b := make([]byte, 3+r.Intn(10))
+	for i := range b {
+		b[i] = byte('a' + r.Intn(26))
+	}
+	return string(b)
+}
+
+// SyntheticDomains generates n domains shaped like a popular domain list: a random name under
+// a few common TLDs, with some common subdomains. The same seed generates the same domains.
+func SyntheticDomains(n int, seed int64) [][]byte {
+	r := rand.New(rand.NewSource(seed))
+	keys := make([][]byte, n)
+	for i := range keys {
+		domain := randomLabel(r) + "." + syntheticTLDs[r.Intn(len(syntheticTLDs))]
+		if r.Intn(4) == 0 {
+			domain = syntheticLabels[r.Intn(len(syntheticLabels))] + "." + domain
+		}
+		keys[i] = []byte(domain)
+	}
+	return keys
+}
+
+// RandomKeys generates n random binary keys of up to maxLen bytes. The same seed generates the
+// same keys.
+func RandomKeys(n, maxLen int, seed int64) [][]byte {
+	r := rand.New(rand.NewSource(seed))
+	keys := make([][]byte, n)
+	for i := range keys {
+		keys[i] = make([]byte, r.Intn(maxLen+1))
+		r.Read(keys[i])
+	}
+	return keys
+}
@@ -0,0 +1,61 @@
+package suffixdns
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// Audience targets the users of features or experiments by the domains of their mail
+// addresses. Each rule maps a domain suffix, like "example.com", to a rule ID, and matches the
+// addresses at the domain and its subdomains, like "a@example.com" and "b@eu.example.com". The
+// rule of the longest matched domain wins.
+//
+// The rule set can be swapped while serving: Eligible never blocks, and sees either the old or
+// the new rule set as a whole. Audience is safe for concurrent use.
+type Audience struct {
+	rules atomic.Value // *suffix.Tree
+}
+
+// NewAudience creates an Audience with the rules, which map domain suffixes to rule IDs.
+func NewAudience(rules map[string]string) (*Audience, error) {
+	a := &Audience{}
+	if err := a.Swap(rules); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Swap replaces all rules with the given ones. The current rules are kept if any domain is
+// invalid.
+func (a *Audience) Swap(rules map[string]string) error {
+	tree := newDomainTree()
+	for domain, ruleID := range rules {
+		if _, err := tree.TryInsert([]byte(domain), ruleID); err != nil {
+			return fmt.Errorf("suffixdns: invalid domain %q: %v", domain, err)
+		}
+	}
+	a.rules.Store(tree)
+	return nil
+}
+
+// Eligible returns the ID of the rule matching the domain of the mail address. ok is false if
+// no rule matches, or the address has no domain.
+func (a *Audience) Eligible(email string) (ruleID string, ok bool) {
+	domain, ok := emailDomain(email)
+	if !ok {
+		return "", false
+	}
+	tree := a.rules.Load().(*suffix.Tree)
+	_, v, found := tree.LongestSuffix([]byte(domain))
+	if !found {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// Len returns the number of rules.
+func (a *Audience) Len() int {
+	return a.rules.Load().(*suffix.Tree).Len()
+}
@@ -0,0 +1,62 @@
+package suffixdns
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertEligible(t *testing.T, a *Audience, email, ruleID string) {
+	id, ok := a.Eligible(email)
+	assert.Equal(t, ruleID != "", ok, email)
+	assert.Equal(t, ruleID, id, email)
+}
+
+func TestAudience(t *testing.T) {
+	a, err := NewAudience(map[string]string{
+		"example.com":    "staff",
+		"eu.example.com": "staff-eu",
+		"edu":            "education",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, a.Len())
+
+	assertEligible(t, a, "alice@example.com", "staff")
+	assertEligible(t, a, "Bob <bob@EU.Example.com>", "staff-eu")
+	assertEligible(t, a, "carol@mit.edu", "education")
+	assertEligible(t, a, "dave@badexample.com", "")
+	assertEligible(t, a, "no-domain", "")
+	assertEligible(t, a, "trailing@", "")
+
+	assert.NotNil(t, a.Swap(map[string]string{"a..b": "bad"}))
+	assertEligible(t, a, "alice@example.com", "staff")
+
+	assert.Nil(t, a.Swap(map[string]string{"example.org": "beta"}))
+	assertEligible(t, a, "alice@example.com", "")
+	assertEligible(t, a, "alice@example.org", "beta")
+
+	_, err = NewAudience(map[string]string{"": "all", "a..b": "bad"})
+	assert.NotNil(t, err)
+}
+
+func TestAudience_ConcurrentSwap(t *testing.T) {
+	a, _ := NewAudience(map[string]string{"example.com": "v1"})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			a.Swap(map[string]string{"example.com": "v2"})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			id, ok := a.Eligible("a@example.com")
+			assert.True(t, ok)
+			assert.Contains(t, []string{"v1", "v2"}, id)
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,141 @@
+package suffixdns
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// blocklistEntry is a domain listed in or excluded from a Blocklist.
+type blocklistEntry struct {
+	value    string
+	excluded bool
+	// Only the domain itself is listed or excluded, not its subdomains
+	exact bool
+}
+
+// Blocklist answers whether a domain or any of its parent domains is listed, like the
+// domain based DNS blocklists (RHSBL). Listing "example.com" lists all its subdomains too,
+// unless a subdomain is excluded, and the deepest listed or excluded domain decides.
+//
+// The domains are case-insensitive, and a trailing dot is ignored. Blocklist is safe for
+// concurrent reads, but not for writes.
+type Blocklist struct {
+	tree *suffix.Tree
+}
+
+// NewBlocklist creates an empty Blocklist.
+func NewBlocklist() *Blocklist {
+	return &Blocklist{tree: newDomainTree()}
+}
+
+func (b *Blocklist) add(domain string, e *blocklistEntry) error {
+	if _, err := b.tree.TryInsert([]byte(domain), e); err != nil {
+		return fmt.Errorf("suffixdns: invalid domain %q: %v", domain, err)
+	}
+	return nil
+}
+
+// List lists the domain and its subdomains with the value, like "127.0.0.2:spam source",
+// replacing the listing or exclusion of the same domain.
+func (b *Blocklist) List(domain string, value string) error {
+	return b.add(domain, &blocklistEntry{value: value})
+}
+
+// Exclude excludes the domain and its subdomains from the listing of their parent domains.
+func (b *Blocklist) Exclude(domain string) error {
+	return b.add(domain, &blocklistEntry{excluded: true})
+}
+
+// Remove removes the listing or exclusion of the domain, and reports whether it existed.
+func (b *Blocklist) Remove(domain string) bool {
+	_, found := b.tree.Remove([]byte(domain))
+	return found
+}
+
+// Lookup returns the listed domain which is the domain itself or its deepest parent, and the
+// value it is listed with. found is false if no parent is listed, or the deepest one is
+// excluded. The entries of ReadZone listing only a domain itself are skipped for its
+// subdomains.
+func (b *Blocklist) Lookup(domain string) (listed string, value string, found bool) {
+	labels := countLabels(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	b.tree.AllSuffixesOf([]byte(domain), func(key []byte, v interface{}) bool {
+		e := v.(*blocklistEntry)
+		if e.exact && countLabels(string(key)) != labels {
+			return false
+		}
+		if !e.excluded {
+			listed, value, found = string(key), e.value, true
+		}
+		return true
+	})
+	return listed, value, found
+}
+
+// Len returns the number of listed and excluded domains.
+func (b *Blocklist) Len() int {
+	return b.tree.Len()
+}
+
+// ReadZone reads the listings from r in the format of the rbldnsd dnset zone files:
+//
+//	# comment, also started with ";"
+//	:127.0.0.2:listed as spam source   the value of the entries below without their own
+//	example.com                        only the domain itself is listed with the value above
+//	.example.net :127.0.0.3:phishing   listed with its subdomains and its own value
+//	*.example.org                      listed with its subdomains
+//	!good.example.com                  excluded, only the domain itself
+//	!.good.example.net                 excluded with its subdomains
+//
+// Like in rbldnsd, a plain domain only lists or excludes the domain itself, and a leading "."
+// or "*." extends it to the subdomains, like List and Exclude. The "$" directives are skipped.
+// It stops once ctx is done or r fails, and returns the number of entries read.
+func (b *Blocklist) ReadZone(ctx context.Context, r io.Reader) (n int, err error) {
+	done := ctx.Done()
+	scanner := bufio.NewScanner(r)
+	value := ""
+	lineNo := 0
+	for scanner.Scan() {
+		select {
+		case <-done:
+			return n, ctx.Err()
+		default:
+		}
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';' || line[0] == '$':
+			continue
+		case line[0] == ':':
+			value = line[1:]
+			continue
+		}
+		domain, own := line, value
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			domain = line[:i]
+			own = strings.TrimSpace(line[i:])
+			own = strings.TrimPrefix(own, ":")
+		}
+		e := &blocklistEntry{exact: true}
+		if strings.HasPrefix(domain, "!") {
+			domain, e.excluded = domain[1:], true
+		}
+		if strings.HasPrefix(domain, "*.") {
+			domain, e.exact = domain[2:], false
+		} else if strings.HasPrefix(domain, ".") {
+			domain, e.exact = domain[1:], false
+		}
+		if !e.excluded {
+			e.value = own
+		}
+		if err = b.add(domain, e); err != nil {
+			return n, fmt.Errorf("%v at line %d", err, lineNo)
+		}
+		n++
+	}
+	return n, scanner.Err()
+}
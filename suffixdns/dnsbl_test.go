@@ -0,0 +1,81 @@
+package suffixdns
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertListed(t *testing.T, b *Blocklist, domain, listed, value string) {
+	l, v, found := b.Lookup(domain)
+	if listed == "" {
+		assert.False(t, found, domain)
+		return
+	}
+	assert.True(t, found, domain)
+	assert.Equal(t, listed, l, domain)
+	assert.Equal(t, value, v, domain)
+}
+
+func TestBlocklist(t *testing.T) {
+	b := NewBlocklist()
+	assert.Nil(t, b.List("example.com", "spam"))
+	assert.Nil(t, b.List("bad.example.com", "phishing"))
+	assert.Nil(t, b.Exclude("good.example.com"))
+	assert.NotNil(t, b.List("a..b", ""))
+
+	assertListed(t, b, "example.com", "example.com", "spam")
+	assertListed(t, b, "WWW.Example.COM.", "example.com", "spam")
+	assertListed(t, b, "x.bad.example.com", "bad.example.com", "phishing")
+	assertListed(t, b, "mail.good.example.com", "", "")
+	assertListed(t, b, "badexample.com", "", "")
+	assertListed(t, b, "com", "", "")
+
+	assert.True(t, b.Remove("good.example.com"))
+	assert.False(t, b.Remove("good.example.com"))
+	assertListed(t, b, "mail.good.example.com", "example.com", "spam")
+}
+
+func TestBlocklist_ReadZone(t *testing.T) {
+	zone := `# test zone
+$TTL 3600
+:127.0.0.2:spam source
+example.com
+.example.net :127.0.0.3:phishing
+*.example.org
+mail.example.org :127.0.0.4
+; exclusions
+!ok.example.org
+!.good.example.org
+`
+	b := NewBlocklist()
+	n, err := b.ReadZone(context.Background(), strings.NewReader(zone))
+	assert.Nil(t, err)
+	assert.Equal(t, 6, n)
+	assert.Equal(t, 6, b.Len())
+	// A plain entry only lists the domain itself
+	assertListed(t, b, "Example.COM.", "example.com", "127.0.0.2:spam source")
+	assertListed(t, b, "www.example.com", "", "")
+	assertListed(t, b, "example.net", "example.net", "127.0.0.3:phishing")
+	assertListed(t, b, "www.example.net", "example.net", "127.0.0.3:phishing")
+	assertListed(t, b, "a.example.org", "example.org", "127.0.0.2:spam source")
+	// A plain exclusion only excludes the domain itself
+	assertListed(t, b, "ok.example.org", "", "")
+	assertListed(t, b, "www.ok.example.org", "example.org", "127.0.0.2:spam source")
+	assertListed(t, b, "x.good.example.org", "", "")
+	// The deeper plain entry is skipped for the subdomains
+	assertListed(t, b, "mail.example.org", "mail.example.org", "127.0.0.4")
+	assertListed(t, b, "x.mail.example.org", "example.org", "127.0.0.2:spam source")
+
+	_, err = b.ReadZone(context.Background(), strings.NewReader("ok.com\nbad..com\n"))
+	assert.Equal(t, `suffixdns: invalid domain "bad..com": suffix: host "bad..com" has an empty label at line 2`,
+		err.Error())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n, err = b.ReadZone(ctx, strings.NewReader(zone))
+	assert.Equal(t, 0, n)
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,102 @@
+package suffixdns
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// domainFlags tells how a domain in DomainSet matches.
+type domainFlags struct {
+	exact      bool
+	subdomains bool
+}
+
+// DomainSet is a set of domains for allow or block lists, like the mail domains accepted by
+// a filter. The domains are case-insensitive, and a trailing dot is ignored.
+//
+// DomainSet is safe for concurrent reads, but not for writes.
+type DomainSet struct {
+	tree *suffix.Tree
+}
+
+// NewDomainSet creates an empty DomainSet.
+func NewDomainSet() *DomainSet {
+	return &DomainSet{tree: newDomainTree()}
+}
+
+func (s *DomainSet) add(domain string, exact bool) error {
+	flags := &domainFlags{}
+	if v, found := s.tree.Get([]byte(domain)); found {
+		flags = v.(*domainFlags)
+	}
+	if exact {
+		flags.exact = true
+	} else {
+		flags.subdomains = true
+	}
+	if _, err := s.tree.TryInsert([]byte(domain), flags); err != nil {
+		return fmt.Errorf("suffixdns: invalid domain %q: %v", domain, err)
+	}
+	return nil
+}
+
+// Add adds the domain itself, so "example.com" matches "example.com" only.
+func (s *DomainSet) Add(domain string) error {
+	return s.add(domain, true)
+}
+
+// AddSubdomains adds all subdomains of the domain, so "corp.example" matches
+// "mail.corp.example" and "a.mail.corp.example", but not "corp.example" itself. Call Add too
+// to match it.
+func (s *DomainSet) AddSubdomains(domain string) error {
+	return s.add(domain, false)
+}
+
+// Match reports whether the domain is in the set.
+func (s *DomainSet) Match(domain string) bool {
+	domain = strings.TrimSuffix(strings.TrimSpace(domain), ".")
+	if v, found := s.tree.Get([]byte(domain)); found && v.(*domainFlags).exact {
+		return true
+	}
+	labels := countLabels(domain)
+	for query := domain; ; {
+		key, v, found := s.tree.LongestSuffix([]byte(query))
+		if !found {
+			return false
+		}
+		keyLabels := 0
+		if len(key) > 0 {
+			keyLabels = countLabels(string(key))
+		}
+		if keyLabels < labels && v.(*domainFlags).subdomains {
+			return true
+		}
+		if keyLabels == 0 {
+			return false
+		}
+		query, _ = parentDomain(string(key))
+	}
+}
+
+// emailDomain returns the domain of a mail address, which may be in the form of
+// "Name <user@example.com>".
+func emailDomain(addr string) (string, bool) {
+	if a, err := mail.ParseAddress(addr); err == nil {
+		addr = a.Address
+	}
+	i := strings.LastIndexByte(addr, '@')
+	if i < 0 || i == len(addr)-1 {
+		return "", false
+	}
+	return addr[i+1:], true
+}
+
+// MatchEmail reports whether the domain of the mail address is in the set. It returns false
+// if the address has no domain.
+func (s *DomainSet) MatchEmail(addr string) bool {
+	domain, ok := emailDomain(addr)
+	return ok && s.Match(domain)
+}
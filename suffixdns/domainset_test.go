@@ -0,0 +1,41 @@
+package suffixdns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainSet(t *testing.T) {
+	s := NewDomainSet()
+	assert.Nil(t, s.Add("example.com"))
+	assert.Nil(t, s.AddSubdomains("corp.example"))
+	assert.Nil(t, s.Add("Partner.ORG."))
+	assert.NotNil(t, s.Add("a..example.com"))
+
+	for domain, expected := range map[string]bool{
+		"example.com":         true,
+		"EXAMPLE.com.":        true,
+		"www.example.com":     false,
+		"corp.example":        false,
+		"mail.corp.example":   true,
+		"a.mail.corp.example": true,
+		"badcorp.example":     false,
+		"partner.org":         true,
+		"":                    false,
+		"a..corp.example":     false,
+	} {
+		assert.Equal(t, expected, s.Match(domain), domain)
+	}
+
+	for addr, expected := range map[string]bool{
+		"user@example.com":              true,
+		"User <user@MAIL.corp.example>": true,
+		`"odd@name"@partner.org`:        true,
+		"user@evil.com":                 false,
+		"example.com":                   false,
+		"user@":                         false,
+	} {
+		assert.Equal(t, expected, s.MatchEmail(addr), addr)
+	}
+}
@@ -0,0 +1,186 @@
+// Package suffixdns provides the domain name matching built on the suffix tree, like the
+// Public Suffix List.
+package suffixdns
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// pslRule holds the rules of the Public Suffix List for a domain.
+type pslRule struct {
+	// "com"
+	normal bool
+	// "*.ck", stored at "ck"
+	wildcard bool
+	// "!www.ck", stored at "www.ck"
+	exception bool
+	// From the ICANN section, not the private one
+	icann bool
+}
+
+// List is a Public Suffix List, which tells under which suffixes the Internet users can
+// directly register names, like "com" and "co.uk". See https://publicsuffix.org.
+//
+// The internationalized rules match both U-labels and A-labels. A List is safe for
+// concurrent use once it is parsed.
+type List struct {
+	tree *suffix.Tree
+}
+
+// ParseList parses a list in the format of public_suffix_list.dat, including the wildcard
+// "*." and exception "!" rules, and the ICANN and private sections.
+func ParseList(r io.Reader) (*List, error) {
+	list := &List{tree: suffix.NewTree(suffix.WithSeparator('.'), suffix.WithIDNA())}
+	scanner := bufio.NewScanner(r)
+	icann := false
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.Contains(line, "===BEGIN ICANN DOMAINS==="):
+			icann = true
+			continue
+		case strings.Contains(line, "===END ICANN DOMAINS==="):
+			icann = false
+			continue
+		case line == "" || strings.HasPrefix(line, "//"):
+			continue
+		}
+		// Only the first field is the rule
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			line = line[:i]
+		}
+		rule := strings.ToLower(line)
+		var r pslRule
+		switch {
+		case strings.HasPrefix(rule, "!"):
+			rule = rule[1:]
+			r.exception = true
+		case strings.HasPrefix(rule, "*."):
+			rule = rule[2:]
+			r.wildcard = true
+		default:
+			r.normal = true
+		}
+		if rule == "" || strings.Contains(rule, "*") || strings.HasPrefix(rule, ".") ||
+			strings.HasSuffix(rule, ".") || strings.Contains(rule, "..") {
+			return nil, fmt.Errorf("suffixdns: invalid rule %q at line %d", line, lineNo)
+		}
+		r.icann = icann
+		list.add(rule, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// LoadList reads the list from the file at path, see ParseList.
+func LoadList(path string) (*List, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseList(f)
+}
+
+func (list *List) add(domain string, r pslRule) {
+	if v, found := list.tree.Get([]byte(domain)); found {
+		old := v.(*pslRule)
+		r.normal = r.normal || old.normal
+		r.wildcard = r.wildcard || old.wildcard
+		r.exception = r.exception || old.exception
+		r.icann = r.icann || old.icann
+	}
+	list.tree.Insert([]byte(domain), &r)
+}
+
+// countLabels returns the number of labels in a non-empty domain.
+func countLabels(domain string) int {
+	return strings.Count(domain, ".") + 1
+}
+
+// parentDomain removes the first label of domain. It returns false for a single label.
+func parentDomain(domain string) (string, bool) {
+	i := strings.IndexByte(domain, '.')
+	if i < 0 {
+		return "", false
+	}
+	return domain[i+1:], true
+}
+
+// lastLabels returns the last n labels of domain.
+func lastLabels(domain string, n int) string {
+	for i := len(domain) - 1; i >= 0; i-- {
+		if domain[i] == '.' {
+			n--
+			if n == 0 {
+				return domain[i+1:]
+			}
+		}
+	}
+	return domain
+}
+
+// PublicSuffix returns the public suffix of the domain, and whether it is managed by ICANN
+// instead of privately. It follows the algorithm of publicsuffix.org: an exception rule wins,
+// otherwise the matched rule with the most labels wins, and "*" is used if no rule matches, so
+// the public suffix of an unknown domain is its last label.
+func (list *List) PublicSuffix(domain string) (publicSuffix string, icann bool) {
+	domain = strings.ToLower(domain)
+	labels := countLabels(domain)
+	// The number of labels in the public suffix by the prevailing rule, 0 if no rule matches
+	n := 0
+	for query := domain; ; {
+		key, v, found := list.tree.LongestSuffix([]byte(query))
+		if !found {
+			break
+		}
+		r := v.(*pslRule)
+		keyLabels := countLabels(string(key))
+		if r.exception {
+			// The longest exception rule comes first, and the public suffix is the rule
+			// without its first label
+			return lastLabels(domain, keyLabels-1), r.icann
+		}
+		if r.wildcard && labels > keyLabels && keyLabels+1 > n {
+			n, icann = keyLabels+1, r.icann
+		}
+		if r.normal && keyLabels > n {
+			n, icann = keyLabels, r.icann
+		}
+		parent, ok := parentDomain(string(key))
+		if !ok {
+			break
+		}
+		query = parent
+	}
+	if n == 0 {
+		return lastLabels(domain, 1), false
+	}
+	return lastLabels(domain, n), icann
+}
+
+// EffectiveTLDPlusOne returns the public suffix of the domain plus one more label, which is
+// the part registered by the owner, like "example.co.uk" for "www.example.co.uk". It returns
+// an error if the domain is a public suffix itself, or has empty labels.
+func (list *List) EffectiveTLDPlusOne(domain string) (string, error) {
+	if domain == "" || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") ||
+		strings.Contains(domain, "..") {
+		return "", fmt.Errorf("suffixdns: empty label in domain %q", domain)
+	}
+	publicSuffix, _ := list.PublicSuffix(domain)
+	labels := countLabels(publicSuffix)
+	if countLabels(domain) <= labels {
+		return "", fmt.Errorf("suffixdns: cannot derive eTLD+1 for domain %q", domain)
+	}
+	return lastLabels(domain, labels+1), nil
+}
@@ -0,0 +1,86 @@
+package suffixdns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testList = `// A part of public_suffix_list.dat
+// ===BEGIN ICANN DOMAINS===
+com
+uk
+co.uk
+*.ck
+!www.ck
+jp
+*.kawasaki.jp
+!city.kawasaki.jp
+cn
+// Chinese "company"
+公司.cn
+// ===END ICANN DOMAINS===
+
+// ===BEGIN PRIVATE DOMAINS===
+blogspot.com	// text after the rule is ignored
+github.io
+// ===END PRIVATE DOMAINS===
+`
+
+func getTestList(t *testing.T) *List {
+	list, err := ParseList(strings.NewReader(testList))
+	assert.Nil(t, err)
+	return list
+}
+
+func TestPublicSuffix(t *testing.T) {
+	list := getTestList(t)
+	for _, c := range []struct {
+		domain, publicSuffix string
+		icann                bool
+		plusOne              string
+	}{
+		{"example.com", "com", true, "example.com"},
+		{"WWW.Example.COM", "com", true, "example.com"},
+		{"com", "com", true, ""},
+		{"www.example.co.uk", "co.uk", true, "example.co.uk"},
+		{"co.uk", "co.uk", true, ""},
+		{"a.b.ck", "b.ck", true, "a.b.ck"},
+		{"ck", "ck", false, ""},
+		{"www.ck", "ck", true, "www.ck"},
+		{"x.www.ck", "ck", true, "www.ck"},
+		{"foo.kawasaki.jp", "foo.kawasaki.jp", true, ""},
+		{"a.foo.kawasaki.jp", "foo.kawasaki.jp", true, "a.foo.kawasaki.jp"},
+		{"city.kawasaki.jp", "kawasaki.jp", true, "city.kawasaki.jp"},
+		{"example.blogspot.com", "blogspot.com", false, "example.blogspot.com"},
+		{"blogspot.com", "blogspot.com", false, ""},
+		{"example.unknown", "unknown", false, "example.unknown"},
+		{"www.example.公司.cn", "公司.cn", true, "example.公司.cn"},
+		{"www.example.xn--55qx5d.cn", "xn--55qx5d.cn", true, "example.xn--55qx5d.cn"},
+	} {
+		publicSuffix, icann := list.PublicSuffix(c.domain)
+		assert.Equal(t, c.publicSuffix, publicSuffix, c.domain)
+		assert.Equal(t, c.icann, icann, c.domain)
+
+		plusOne, err := list.EffectiveTLDPlusOne(strings.ToLower(c.domain))
+		if c.plusOne == "" {
+			assert.NotNil(t, err, c.domain)
+		} else {
+			assert.Nil(t, err, c.domain)
+			assert.Equal(t, c.plusOne, plusOne, c.domain)
+		}
+	}
+
+	for _, invalid := range []string{"", ".com", "example.com.", "example..com"} {
+		_, err := list.EffectiveTLDPlusOne(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestParseList_Invalid(t *testing.T) {
+	for _, invalid := range []string{"*", "!", "a.*.com", ".com", "com.", "a..com"} {
+		_, err := ParseList(strings.NewReader("com\n" + invalid + "\n"))
+		assert.EqualError(t, err, `suffixdns: invalid rule "`+invalid+`" at line 2`)
+	}
+}
@@ -0,0 +1,98 @@
+package suffixdns
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// ReverseName returns the name of the address under in-addr.arpa or ip6.arpa, which is used
+// by the PTR records, like "4.3.2.1.in-addr.arpa" for 1.2.3.4. The IPv4-mapped IPv6
+// addresses are treated as IPv4.
+func ReverseName(addr netip.Addr) string {
+	addr = addr.Unmap()
+	var b strings.Builder
+	if addr.Is4() {
+		ip := addr.As4()
+		for i := len(ip) - 1; i >= 0; i-- {
+			b.WriteString(strconv.Itoa(int(ip[i])))
+			b.WriteByte('.')
+		}
+		b.WriteString("in-addr.arpa")
+		return b.String()
+	}
+	ip := addr.As16()
+	for i := len(ip) - 1; i >= 0; i-- {
+		b.WriteByte(hexDigits[ip[i]&0xf])
+		b.WriteByte('.')
+		b.WriteByte(hexDigits[ip[i]>>4])
+		b.WriteByte('.')
+	}
+	b.WriteString("ip6.arpa")
+	return b.String()
+}
+
+// ReverseZone returns the name of the reverse zone delegated for the prefix, like
+// "2.0.192.in-addr.arpa" for 192.0.2.0/24. The delegations happen on the label boundaries, so
+// the prefix length must be a multiple of 8 for IPv4, or 4 for IPv6.
+func ReverseZone(prefix netip.Prefix) (string, error) {
+	if !prefix.IsValid() {
+		return "", fmt.Errorf("suffixdns: invalid prefix %s", prefix)
+	}
+	addr, bits := prefix.Addr().Unmap(), prefix.Bits()
+	if addr.Is4() && prefix.Addr().Is4In6() {
+		bits -= 96
+	}
+	labelBits := 4
+	if addr.Is4() {
+		labelBits = 8
+	}
+	if bits%labelBits != 0 {
+		return "", fmt.Errorf("suffixdns: prefix %s is not on a label boundary", prefix)
+	}
+	name := ReverseName(addr)
+	// Drop the labels for the host part
+	labels := addr.BitLen()/labelBits - bits/labelBits
+	for i := 0; i < labels; i++ {
+		name = name[strings.IndexByte(name, '.')+1:]
+	}
+	return name, nil
+}
+
+// ReverseMatcher finds which delegated reverse zone owns the PTR record of an address, by the
+// longest matched prefix.
+type ReverseMatcher struct {
+	tree *suffix.Tree
+}
+
+// NewReverseMatcher creates an empty ReverseMatcher.
+func NewReverseMatcher() *ReverseMatcher {
+	return &ReverseMatcher{tree: suffix.NewTree(suffix.WithSeparator('.'))}
+}
+
+// Add adds the reverse zone of the prefix with a value, see ReverseZone.
+func (m *ReverseMatcher) Add(prefix netip.Prefix, value interface{}) error {
+	zone, err := ReverseZone(prefix)
+	if err != nil {
+		return err
+	}
+	m.tree.Insert([]byte(zone), value)
+	return nil
+}
+
+// Match returns the most specific reverse zone containing the address, and its value.
+func (m *ReverseMatcher) Match(addr netip.Addr) (zone string, value interface{}, found bool) {
+	if !addr.IsValid() {
+		return "", nil, false
+	}
+	key, value, found := m.tree.LongestSuffix([]byte(ReverseName(addr)))
+	if !found {
+		return "", nil, false
+	}
+	return string(key), value, true
+}
@@ -0,0 +1,61 @@
+package suffixdns
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverseName(t *testing.T) {
+	assert.Equal(t, "4.3.2.1.in-addr.arpa", ReverseName(netip.MustParseAddr("1.2.3.4")))
+	assert.Equal(t, "4.3.2.1.in-addr.arpa", ReverseName(netip.MustParseAddr("::ffff:1.2.3.4")))
+	assert.Equal(t,
+		"b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+		ReverseName(netip.MustParseAddr("2001:db8::567:89ab")))
+}
+
+func TestReverseZone(t *testing.T) {
+	for prefix, expected := range map[string]string{
+		"192.0.2.0/24":        "2.0.192.in-addr.arpa",
+		"10.0.0.0/8":          "10.in-addr.arpa",
+		"0.0.0.0/0":           "in-addr.arpa",
+		"1.2.3.4/32":          "4.3.2.1.in-addr.arpa",
+		"2001:db8::/32":       "8.b.d.0.1.0.0.2.ip6.arpa",
+		"2001:db8:1::/52":     "0.1.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+		"::ffff:10.0.0.0/104": "10.in-addr.arpa",
+	} {
+		zone, err := ReverseZone(netip.MustParsePrefix(prefix))
+		assert.Nil(t, err, prefix)
+		assert.Equal(t, expected, zone, prefix)
+	}
+	_, err := ReverseZone(netip.MustParsePrefix("192.0.2.0/25"))
+	assert.EqualError(t, err, "suffixdns: prefix 192.0.2.0/25 is not on a label boundary")
+	_, err = ReverseZone(netip.Prefix{})
+	assert.NotNil(t, err)
+}
+
+func TestReverseMatcher(t *testing.T) {
+	m := NewReverseMatcher()
+	assert.Nil(t, m.Add(netip.MustParsePrefix("10.0.0.0/8"), "corp"))
+	assert.Nil(t, m.Add(netip.MustParsePrefix("10.1.0.0/16"), "lab"))
+	assert.Nil(t, m.Add(netip.MustParsePrefix("2001:db8::/32"), "v6"))
+	assert.NotNil(t, m.Add(netip.MustParsePrefix("10.2.0.0/15"), "bad"))
+
+	for addr, expected := range map[string]interface{}{
+		"10.0.0.1":    "corp",
+		"10.1.2.3":    "lab",
+		"10.11.2.3":   "corp",
+		"192.0.2.1":   nil,
+		"2001:db8::1": "v6",
+		"2001:db9::1": nil,
+	} {
+		_, value, found := m.Match(netip.MustParseAddr(addr))
+		assert.Equal(t, expected != nil, found, addr)
+		assert.Equal(t, expected, value, addr)
+	}
+	zone, _, _ := m.Match(netip.MustParseAddr("10.1.2.3"))
+	assert.Equal(t, "1.10.in-addr.arpa", zone)
+	_, _, found := m.Match(netip.Addr{})
+	assert.False(t, found)
+}
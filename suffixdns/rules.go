@@ -0,0 +1,128 @@
+package suffixdns
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// Decision is the result of evaluating a domain with a RuleSet.
+type Decision int
+
+const (
+	// NoMatch means no rule matches the domain.
+	NoMatch Decision = iota
+	// Block means the most specific matched rule blocks the domain.
+	Block
+	// Allow means the most specific matched rule is an exception.
+	Allow
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Block:
+		return "block"
+	case Allow:
+		return "allow"
+	default:
+		return "no match"
+	}
+}
+
+// domainRules holds the rules of a domain in RuleSet.
+type domainRules struct {
+	block     string
+	exception string
+}
+
+// RuleSet is a set of domain rules, each of which blocks a domain and its subdomains, or is
+// an exception allowing them, like the "||example.com^" and "@@||example.com^" rules of the
+// ad blocking filters. The rule of the deepest matched domain decides, and an exception wins
+// over a block rule of the same domain.
+//
+// The domains are case-insensitive, and a trailing dot is ignored. RuleSet is safe for
+// concurrent reads, but not for writes.
+type RuleSet struct {
+	tree *suffix.Tree
+}
+
+// NewRuleSet creates an empty RuleSet.
+func NewRuleSet() *RuleSet {
+	return &RuleSet{tree: newDomainTree()}
+}
+
+// parseRule returns the domain of a rule, and whether it is an exception.
+func parseRule(rule string) (domain string, exception bool, err error) {
+	domain = strings.TrimSpace(rule)
+	if strings.HasPrefix(domain, "@@") {
+		domain, exception = domain[2:], true
+	}
+	if strings.HasPrefix(domain, "||") {
+		domain = strings.TrimSuffix(domain[2:], "^")
+	}
+	if domain == "" || strings.ContainsAny(domain, "/|^$*#@! \t") {
+		return "", false, fmt.Errorf("suffixdns: unsupported rule %q", rule)
+	}
+	return domain, exception, nil
+}
+
+// AddRule adds a rule, which is one of:
+//
+//	example.com, ||example.com^          block example.com and its subdomains
+//	@@example.com, @@||example.com^      the exception of them
+//
+// It returns an error for the other forms, like the rules with paths or options.
+func (s *RuleSet) AddRule(rule string) error {
+	domain, exception, err := parseRule(rule)
+	if err != nil {
+		return err
+	}
+	r := &domainRules{}
+	if v, found := s.tree.Get([]byte(domain)); found {
+		r = v.(*domainRules)
+	}
+	if exception {
+		r.exception = rule
+	} else {
+		r.block = rule
+	}
+	if _, err := s.tree.TryInsert([]byte(domain), r); err != nil {
+		return fmt.Errorf("suffixdns: invalid rule %q: %v", rule, err)
+	}
+	return nil
+}
+
+// ReadRules adds the rules from r, one per line. The empty lines, the comments started with
+// "!" or "#", the "[Adblock Plus 2.0]" like headers, and the rules not supported by AddRule
+// are skipped. It returns the number of rules added.
+func (s *RuleSet) ReadRules(r io.Reader) (added int, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '!' || line[0] == '#' || line[0] == '[' {
+			continue
+		}
+		if s.AddRule(line) == nil {
+			added++
+		}
+	}
+	return added, scanner.Err()
+}
+
+// Evaluate returns the decision of the most specific rule matching the domain, and the rule
+// as it was added.
+func (s *RuleSet) Evaluate(domain string) (decision Decision, rule string) {
+	s.tree.AllSuffixesOf([]byte(domain), func(key []byte, v interface{}) bool {
+		r := v.(*domainRules)
+		if r.exception != "" {
+			decision, rule = Allow, r.exception
+		} else {
+			decision, rule = Block, r.block
+		}
+		return true
+	})
+	return decision, rule
+}
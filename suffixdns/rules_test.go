@@ -0,0 +1,54 @@
+package suffixdns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertDecision(t *testing.T, s *RuleSet, domain string, decision Decision, rule string) {
+	d, r := s.Evaluate(domain)
+	assert.Equal(t, decision, d, domain)
+	assert.Equal(t, rule, r, domain)
+}
+
+func TestRuleSet(t *testing.T) {
+	s := NewRuleSet()
+	assert.Nil(t, s.AddRule("||ads.example^"))
+	assert.Nil(t, s.AddRule("@@||ok.ads.example^"))
+	assert.Nil(t, s.AddRule("bad.ok.ads.example"))
+	assert.Nil(t, s.AddRule("tracker.test"))
+	assert.Nil(t, s.AddRule("@@tracker.test"))
+	for _, rule := range []string{"", "@@", "||ads.example/banner^", "ads.example$third-party",
+		"example.com##.ad", "a..b"} {
+
+		assert.NotNil(t, s.AddRule(rule), rule)
+	}
+
+	assertDecision(t, s, "ads.example", Block, "||ads.example^")
+	assertDecision(t, s, "X.Ads.Example.", Block, "||ads.example^")
+	assertDecision(t, s, "ok.ads.example", Allow, "@@||ok.ads.example^")
+	assertDecision(t, s, "cdn.ok.ads.example", Allow, "@@||ok.ads.example^")
+	assertDecision(t, s, "x.bad.ok.ads.example", Block, "bad.ok.ads.example")
+	assertDecision(t, s, "tracker.test", Allow, "@@tracker.test")
+	assertDecision(t, s, "myads.example", NoMatch, "")
+	assert.Equal(t, "no match", NoMatch.String())
+	assert.Equal(t, "block", Block.String())
+	assert.Equal(t, "allow", Allow.String())
+}
+
+func TestRuleSet_ReadRules(t *testing.T) {
+	list := `[Adblock Plus 2.0]
+! Title: test
+||ads.example^
+@@||ok.ads.example^
+||ads.example/banner.png
+example.com##.ad
+`
+	s := NewRuleSet()
+	added, err := s.ReadRules(strings.NewReader(list))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, added)
+	assertDecision(t, s, "a.ok.ads.example", Allow, "@@||ok.ads.example^")
+}
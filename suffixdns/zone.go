@@ -0,0 +1,129 @@
+package suffixdns
+
+import (
+	"fmt"
+	"strings"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// zoneEntry holds the zones added for a domain.
+type zoneEntry struct {
+	zone     interface{}
+	hasZone  bool
+	wildcard interface{}
+	// For "*.example.com", stored at "example.com"
+	hasWildcard bool
+}
+
+// ZoneMatcher finds the zone of a domain name among the added zones. A zone like
+// "example.com" contains itself and all names below it, and the longest matched zone wins.
+// A wildcard zone like "*.example.com" matches the names exactly one label below
+// "example.com", but not "example.com" itself or the names deeper than one label, and it
+// wins over the zone "example.com".
+//
+// The names are case-insensitive, matched label by label, and a trailing dot is ignored.
+// The root zone "." contains every name. ZoneMatcher is not safe for concurrent writes.
+type ZoneMatcher struct {
+	tree *suffix.Tree
+}
+
+// NewZoneMatcher creates an empty ZoneMatcher.
+func NewZoneMatcher() *ZoneMatcher {
+	return &ZoneMatcher{tree: newDomainTree()}
+}
+
+// newDomainTree creates a tree matching case-insensitive domain names label by label.
+func newDomainTree() *suffix.Tree {
+	return suffix.NewTree(suffix.WithHostMode(), suffix.WithCaseFolding(),
+		suffix.WithSeparator('.'))
+}
+
+// splitWildcard removes the leading "*." from a zone.
+func splitWildcard(zone string) (apex string, wildcard bool) {
+	if strings.HasPrefix(zone, "*.") {
+		return zone[2:], true
+	}
+	return zone, false
+}
+
+// Add adds the zone with a value, replacing the value of the same zone. It returns an error
+// if the zone has empty labels.
+func (m *ZoneMatcher) Add(zone string, value interface{}) error {
+	apex, wildcard := splitWildcard(zone)
+	if wildcard && strings.Trim(apex, ".") == "" {
+		return fmt.Errorf("suffixdns: invalid zone %q", zone)
+	}
+	e := &zoneEntry{}
+	if v, found := m.tree.Get([]byte(apex)); found {
+		e = v.(*zoneEntry)
+	}
+	if wildcard {
+		e.wildcard, e.hasWildcard = value, true
+	} else {
+		e.zone, e.hasZone = value, true
+	}
+	if _, err := m.tree.TryInsert([]byte(apex), e); err != nil {
+		return fmt.Errorf("suffixdns: invalid zone %q: %v", zone, err)
+	}
+	return nil
+}
+
+// Remove removes the zone, and reports whether it was added.
+func (m *ZoneMatcher) Remove(zone string) bool {
+	apex, wildcard := splitWildcard(zone)
+	v, found := m.tree.Get([]byte(apex))
+	if !found {
+		return false
+	}
+	e := v.(*zoneEntry)
+	if wildcard {
+		found, e.wildcard, e.hasWildcard = e.hasWildcard, nil, false
+	} else {
+		found, e.zone, e.hasZone = e.hasZone, nil, false
+	}
+	if !e.hasZone && !e.hasWildcard {
+		m.tree.Remove([]byte(apex))
+	}
+	return found
+}
+
+// Match returns the zone of the name and its value. The rest are the labels of the name in
+// front of the zone, so for a wildcard zone it is the label matched by "*". found is false if
+// no zone contains the name.
+func (m *ZoneMatcher) Match(name string) (zone string, rest []string, value interface{},
+	found bool) {
+
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	if strings.HasPrefix(name, ".") || strings.Contains(name, "..") {
+		return "", nil, nil, false
+	}
+	var labels []string
+	if name != "" {
+		labels = strings.Split(name, ".")
+	}
+	for query := name; ; {
+		key, v, ok := m.tree.LongestSuffix([]byte(query))
+		if !ok {
+			return "", nil, nil, false
+		}
+		e := v.(*zoneEntry)
+		zone = "."
+		keyLabels := 0
+		if len(key) > 0 {
+			zone = string(key)
+			keyLabels = countLabels(zone)
+		}
+		rest = labels[:len(labels)-keyLabels]
+		if e.hasWildcard && len(rest) == 1 {
+			return "*." + zone, rest, e.wildcard, true
+		}
+		if e.hasZone {
+			return zone, rest, e.zone, true
+		}
+		if keyLabels == 0 {
+			return "", nil, nil, false
+		}
+		query, _ = parentDomain(zone)
+	}
+}
@@ -0,0 +1,63 @@
+package suffixdns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZoneMatcher(t *testing.T) {
+	m := NewZoneMatcher()
+	assert.Nil(t, m.Add("example.com", 1))
+	assert.Nil(t, m.Add("*.example.com", 2))
+	assert.Nil(t, m.Add("*.dev.example.com.", 3))
+	assert.Nil(t, m.Add("Sub.Example.com", 4))
+	assert.NotNil(t, m.Add("a..example.com", 5))
+	assert.NotNil(t, m.Add("*.", 5))
+
+	for _, c := range []struct {
+		name  string
+		zone  string
+		rest  []string
+		value interface{}
+	}{
+		{"example.com", "example.com", []string{}, 1},
+		{"www.example.com", "*.example.com", []string{"www"}, 2},
+		{"a.www.example.com", "example.com", []string{"a", "www"}, 1},
+		{"dev.example.com", "*.example.com", []string{"dev"}, 2},
+		{"x.dev.example.com", "*.dev.example.com", []string{"x"}, 3},
+		{"y.x.dev.example.com", "example.com", []string{"y", "x", "dev"}, 1},
+		{"sub.example.com.", "sub.example.com", []string{}, 4},
+		{"A.SUB.example.com", "sub.example.com", []string{"a"}, 4},
+		{"badexample.com", "", nil, nil},
+		{"com", "", nil, nil},
+		{"a..example.com", "", nil, nil},
+	} {
+		zone, rest, value, found := m.Match(c.name)
+		assert.Equal(t, c.value != nil, found, c.name)
+		assert.Equal(t, c.zone, zone, c.name)
+		if c.rest != nil {
+			assert.Equal(t, c.rest, rest, c.name)
+		}
+		assert.Equal(t, c.value, value, c.name)
+	}
+
+	assert.True(t, m.Remove("*.example.com"))
+	assert.False(t, m.Remove("*.example.com"))
+	zone, _, _, _ := m.Match("www.example.com")
+	assert.Equal(t, "example.com", zone)
+	assert.True(t, m.Remove("*.dev.example.com"))
+	zone, _, _, _ = m.Match("x.dev.example.com")
+	assert.Equal(t, "example.com", zone)
+	assert.False(t, m.Remove("org"))
+
+	assert.Nil(t, m.Add(".", 0))
+	zone, rest, value, found := m.Match("example.org")
+	assert.True(t, found)
+	assert.Equal(t, ".", zone)
+	assert.Equal(t, []string{"example", "org"}, rest)
+	assert.Equal(t, 0, value)
+	zone, _, _, found = m.Match(".")
+	assert.True(t, found)
+	assert.Equal(t, ".", zone)
+}
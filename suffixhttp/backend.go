@@ -0,0 +1,89 @@
+package suffixhttp
+
+import (
+	"net/url"
+	"sync"
+	"sync/atomic"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// BackendTable maps host suffixes to the upstream targets of a reverse proxy. The suffixes
+// are matched label by label, so "example.com" matches "example.com" and "www.example.com",
+// but not "badexample.com", and the longest matched suffix wins. The empty suffix matches
+// every host.
+//
+// Pick never blocks: the writers build a new version of the table, which shares the unchanged
+// part with the current one, and publish it atomically. So the table can be updated or
+// reloaded while serving.
+type BackendTable struct {
+	// Serializes the writers
+	lock    sync.Mutex
+	current atomic.Value // *suffix.Tree
+}
+
+func newBackendTree() *suffix.Tree {
+	return suffix.NewTree(suffix.WithSeparator('.'))
+}
+
+// NewBackendTable creates an empty BackendTable.
+func NewBackendTable() *BackendTable {
+	t := &BackendTable{}
+	t.current.Store(newBackendTree())
+	return t
+}
+
+func (t *BackendTable) tree() *suffix.Tree {
+	return t.current.Load().(*suffix.Tree)
+}
+
+// update applies f to a new version of the table, and publishes it.
+func (t *BackendTable) update(f func(tree *suffix.Tree)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	next := t.tree().Snapshot()
+	f(next)
+	t.current.Store(next)
+}
+
+// Insert maps the host suffix, normalized by NormalizeHost, to the target.
+func (t *BackendTable) Insert(hostSuffix string, target *url.URL) {
+	t.update(func(tree *suffix.Tree) {
+		tree.Insert([]byte(NormalizeHost(hostSuffix)), target)
+	})
+}
+
+// Remove removes the host suffix, and reports whether it was mapped.
+func (t *BackendTable) Remove(hostSuffix string) (found bool) {
+	t.update(func(tree *suffix.Tree) {
+		_, found = tree.Remove([]byte(NormalizeHost(hostSuffix)))
+	})
+	return found
+}
+
+// Swap replaces all mappings with the given ones at once, for reloading the configuration.
+// The requests never see a half-loaded table.
+func (t *BackendTable) Swap(targets map[string]*url.URL) {
+	next := newBackendTree()
+	for hostSuffix, target := range targets {
+		next.Insert([]byte(NormalizeHost(hostSuffix)), target)
+	}
+	t.lock.Lock()
+	t.current.Store(next)
+	t.lock.Unlock()
+}
+
+// Pick returns the target of the most specific suffix matching the host, which may have a
+// port. It returns false if no suffix matches.
+func (t *BackendTable) Pick(host string) (target *url.URL, found bool) {
+	_, v, found := t.tree().LongestSuffix([]byte(NormalizeHost(host)))
+	if !found {
+		return nil, false
+	}
+	return v.(*url.URL), true
+}
+
+// Len returns the number of suffixes.
+func (t *BackendTable) Len() int {
+	return t.tree().Len()
+}
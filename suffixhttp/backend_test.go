@@ -0,0 +1,78 @@
+package suffixhttp
+
+import (
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParse(t *testing.T, s string) *url.URL {
+	u, err := url.Parse(s)
+	assert.Nil(t, err)
+	return u
+}
+
+func TestBackendTable(t *testing.T) {
+	table := NewBackendTable()
+	web := mustParse(t, "http://10.0.0.1:8080")
+	api := mustParse(t, "http://10.0.0.2:8080")
+	table.Insert("example.com", web)
+	table.Insert("API.example.com.", api)
+	assert.Equal(t, 2, table.Len())
+
+	for host, expected := range map[string]*url.URL{
+		"example.com":         web,
+		"www.example.com:443": web,
+		"api.example.com":     api,
+		"v1.api.example.com":  api,
+		"badexample.com":      nil,
+		"example.org":         nil,
+	} {
+		target, found := table.Pick(host)
+		assert.Equal(t, expected != nil, found, host)
+		assert.True(t, expected == target, host)
+	}
+
+	assert.True(t, table.Remove("api.example.com"))
+	assert.False(t, table.Remove("api.example.com"))
+	target, _ := table.Pick("v1.api.example.com")
+	assert.True(t, web == target)
+
+	fallback := mustParse(t, "http://10.0.0.3")
+	table.Swap(map[string]*url.URL{"": fallback, "example.org": api})
+	assert.Equal(t, 2, table.Len())
+	target, _ = table.Pick("www.example.com")
+	assert.True(t, fallback == target)
+	target, _ = table.Pick("example.org")
+	assert.True(t, api == target)
+}
+
+func TestBackendTable_Concurrent(t *testing.T) {
+	table := NewBackendTable()
+	target := mustParse(t, "http://10.0.0.1")
+	table.Insert("example.com", target)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			table.Insert(strconv.Itoa(i)+".example.org", target)
+			if i%100 == 0 {
+				table.Swap(map[string]*url.URL{"example.com": target})
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			got, found := table.Pick("www.example.com")
+			assert.True(t, found)
+			assert.True(t, target == got)
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,131 @@
+package suffixhttp
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// debugLimit is the default number of keys listed by DebugHandler.
+const debugLimit = 100
+
+// debugKey is a key listed by DebugHandler.
+type debugKey struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// debugLookup is the result of looking up the query of DebugHandler.
+type debugLookup struct {
+	Query       string `json:"query"`
+	Found       bool   `json:"found"`
+	Value       string `json:"value,omitempty"`
+	SuffixFound bool   `json:"suffixFound"`
+	SuffixKey   string `json:"suffixKey,omitempty"`
+	SuffixValue string `json:"suffixValue,omitempty"`
+}
+
+// debugPage is the content served by DebugHandler.
+type debugPage struct {
+	Stats  suffix.Stats `json:"stats"`
+	Lookup *debugLookup `json:"lookup,omitempty"`
+	Suffix string       `json:"suffix"`
+	Keys   []debugKey   `json:"keys"`
+	// Whether there are more keys than listed
+	More bool `json:"more"`
+}
+
+var debugTemplate = template.Must(template.New("debug").Funcs(template.FuncMap{
+	"quote": func(key string) string { return suffix.QuoteKey([]byte(key)) },
+}).Parse(`<!DOCTYPE html>
+<html><head><title>suffix tree</title></head><body>
+<h2>Stats</h2>
+<table>
+<tr><td>keys</td><td>{{.Stats.Leaves}}</td></tr>
+<tr><td>nodes</td><td>{{.Stats.Nodes}}</td></tr>
+<tr><td>edges</td><td>{{.Stats.Edges}}</td></tr>
+<tr><td>max depth</td><td>{{.Stats.MaxDepth}}</td></tr>
+<tr><td>average depth</td><td>{{printf "%.2f" .Stats.AvgDepth}}</td></tr>
+<tr><td>label bytes</td><td>{{.Stats.LabelBytes}}</td></tr>
+<tr><td>key bytes</td><td>{{.Stats.KeyBytes}}</td></tr>
+</table>
+<h2>Lookup</h2>
+<form><input name="q"{{with .Lookup}} value="{{.Query}}"{{end}}> <input type="submit" value="Lookup"></form>
+{{with .Lookup}}<p>Get: {{if .Found}}{{.Value}}{{else}}not found{{end}}</p>
+<p>LongestSuffix: {{if .SuffixFound}}{{quote .SuffixKey}} = {{.SuffixValue}}{{else}}not found{{end}}</p>{{end}}
+<h2>Keys</h2>
+<form><input name="suffix" value="{{.Suffix}}"> <input type="submit" value="List"></form>
+<pre>{{range .Keys}}{{quote .Key}} = {{.Value}}
+{{end}}{{if .More}}...
+{{end}}</pre>
+</body></html>
+`))
+
+// DebugHandler returns a handler for troubleshooting a tree, like a table of rules, which is
+// usually mounted under /debug/suffixtree. It serves an HTML page with the stats of the tree,
+// a form looking up a key with Get and LongestSuffix, and the keys ending with a suffix. The
+// parameters are:
+//
+//	q       the key to look up
+//	suffix  list the keys ending with it, all keys by default
+//	limit   the number of keys listed, 100 by default
+//	format  "json" to serve the same content as JSON
+//
+// The keys on the HTML page and the values are formatted with suffix.QuoteKey and
+// suffix.QuoteValue like Tree.Dump, so binary data is escaped.
+//
+// The tree is read without locking, so tree should return a tree which isn't being written,
+// like a Snapshot or a tree which is replaced instead of modified.
+func DebugHandler(tree func() *suffix.Tree) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t := tree()
+		query := req.URL.Query()
+		limit := debugLimit
+		if s := query.Get("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				http.Error(w, "invalid limit", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		page := debugPage{Stats: t.Stats(), Suffix: query.Get("suffix"), Keys: []debugKey{}}
+		if query.Has("q") {
+			q := query.Get("q")
+			lookup := &debugLookup{Query: q}
+			var value interface{}
+			if value, lookup.Found = t.Get([]byte(q)); lookup.Found {
+				lookup.Value = suffix.QuoteValue(value)
+			}
+			key, value, found := t.LongestSuffix([]byte(q))
+			if found {
+				lookup.SuffixFound = true
+				lookup.SuffixKey, lookup.SuffixValue = string(key), suffix.QuoteValue(value)
+			}
+			page.Lookup = lookup
+		}
+		t.WalkSuffix([]byte(page.Suffix), func(key []byte, value interface{}) bool {
+			if len(page.Keys) == limit {
+				page.More = true
+				return true
+			}
+			page.Keys = append(page.Keys, debugKey{
+				Key:   string(key),
+				Value: suffix.QuoteValue(value),
+			})
+			return false
+		})
+
+		if query.Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(page)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		debugTemplate.Execute(w, page)
+	})
+}
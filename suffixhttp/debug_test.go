@@ -0,0 +1,56 @@
+package suffixhttp
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+func serveDebug(t *testing.T, target string) *httptest.ResponseRecorder {
+	tree := suffix.NewTree()
+	tree.Insert([]byte("com"), 1)
+	tree.Insert([]byte("example.com"), "<b>")
+	tree.Insert([]byte("org"), nil)
+	tree.Insert([]byte("\x00.net"), []byte{0xff})
+	handler := DebugHandler(func() *suffix.Tree { return tree })
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+	return w
+}
+
+func TestDebugHandler_JSON(t *testing.T) {
+	w := serveDebug(t, "/debug/suffixtree?format=json&q=www.example.com&suffix=com")
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var page debugPage
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, 4, page.Stats.Leaves)
+	assert.Equal(t, &debugLookup{Query: "www.example.com", SuffixFound: true,
+		SuffixKey: "example.com", SuffixValue: `"<b>"`}, page.Lookup)
+	assert.Equal(t, []debugKey{{"com", "1"}, {"example.com", `"<b>"`}}, page.Keys)
+	assert.False(t, page.More)
+
+	w = serveDebug(t, "/?format=json&limit=1")
+	page = debugPage{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Nil(t, page.Lookup)
+	assert.Equal(t, 1, len(page.Keys))
+	assert.True(t, page.More)
+}
+
+func TestDebugHandler_HTML(t *testing.T) {
+	w := serveDebug(t, "/?q=example.com")
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.True(t, strings.Contains(body, "<td>keys</td><td>4</td>"), body)
+	assert.True(t, strings.Contains(body, `&#34;\x00.net&#34; = &#34;\xff&#34;`), body)
+	assert.True(t, strings.Contains(body, "Get: &#34;&lt;b&gt;&#34;"), body)
+	assert.True(t, strings.Contains(body, `&#34;org&#34; = &lt;nil&gt;`), body)
+
+	w = serveDebug(t, "/?limit=x")
+	assert.Equal(t, 400, w.Code)
+}
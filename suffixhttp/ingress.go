@@ -0,0 +1,157 @@
+package suffixhttp
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// ingressHost holds the values of the Ingress hosts of a domain.
+type ingressHost struct {
+	exact    interface{}
+	hasExact bool
+	// For "*.example.com", stored at "example.com"
+	wildcard    interface{}
+	hasWildcard bool
+}
+
+// IngressMatcher matches request hosts against the host rules of Kubernetes Ingress
+// resources, and maps them to route values. Following the Ingress spec, a host is either
+// precise, like "foo.bar.com", or a wildcard with "*" as its first label, like "*.foo.com",
+// which matches exactly one more label: "bar.foo.com" matches it, but "baz.bar.foo.com" and
+// "foo.com" don't. A precise host wins over a wildcard, and the empty host matches the hosts
+// matching no other rule.
+//
+// The hosts are case-insensitive. IngressMatcher is safe for concurrent use.
+type IngressMatcher struct {
+	lock     sync.RWMutex
+	tree     *suffix.Tree
+	any      interface{}
+	hasAny   bool
+	patterns int
+}
+
+// NewIngressMatcher creates an empty IngressMatcher.
+func NewIngressMatcher() *IngressMatcher {
+	return &IngressMatcher{tree: suffix.NewTree(suffix.WithSeparator('.'))}
+}
+
+// parseIngressHost validates the host of an Ingress rule, and returns the domain it is
+// stored at.
+func parseIngressHost(host string) (domain string, wildcard bool, err error) {
+	domain = strings.ToLower(host)
+	if strings.HasPrefix(domain, "*.") {
+		domain, wildcard = domain[2:], true
+	}
+	if domain == "" || strings.ContainsAny(domain, "*:/ ") || strings.Contains(domain, "..") ||
+		strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") ||
+		net.ParseIP(domain) != nil {
+
+		return "", false, fmt.Errorf("suffixhttp: invalid Ingress host %q", host)
+	}
+	return domain, wildcard, nil
+}
+
+// Add maps the Ingress host to the value, replacing the value of the same host. It returns an
+// error if the host is an IP address, has a port, or has "*" in other places than the first
+// label.
+func (m *IngressMatcher) Add(host string, value interface{}) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if host == "" {
+		if !m.hasAny {
+			m.patterns++
+		}
+		m.any, m.hasAny = value, true
+		return nil
+	}
+	domain, wildcard, err := parseIngressHost(host)
+	if err != nil {
+		return err
+	}
+	h := &ingressHost{}
+	if v, found := m.tree.Get([]byte(domain)); found {
+		h = v.(*ingressHost)
+	}
+	if wildcard {
+		if !h.hasWildcard {
+			m.patterns++
+		}
+		h.wildcard, h.hasWildcard = value, true
+	} else {
+		if !h.hasExact {
+			m.patterns++
+		}
+		h.exact, h.hasExact = value, true
+	}
+	m.tree.Insert([]byte(domain), h)
+	return nil
+}
+
+// Remove removes the Ingress host, and reports whether it was added.
+func (m *IngressMatcher) Remove(host string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if host == "" {
+		found := m.hasAny
+		m.any, m.hasAny = nil, false
+		if found {
+			m.patterns--
+		}
+		return found
+	}
+	domain, wildcard, err := parseIngressHost(host)
+	if err != nil {
+		return false
+	}
+	v, found := m.tree.Get([]byte(domain))
+	if !found {
+		return false
+	}
+	h := v.(*ingressHost)
+	if wildcard {
+		found, h.wildcard, h.hasWildcard = h.hasWildcard, nil, false
+	} else {
+		found, h.exact, h.hasExact = h.hasExact, nil, false
+	}
+	if !h.hasExact && !h.hasWildcard {
+		m.tree.Remove([]byte(domain))
+	}
+	if found {
+		m.patterns--
+	}
+	return found
+}
+
+// Match returns the Ingress host matching the request host, which is normalized by
+// NormalizeHost, and its value.
+func (m *IngressMatcher) Match(host string) (rule string, value interface{}, found bool) {
+	host = NormalizeHost(host)
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if host != "" {
+		if v, ok := m.tree.Get([]byte(host)); ok && v.(*ingressHost).hasExact {
+			return host, v.(*ingressHost).exact, true
+		}
+		if i := strings.IndexByte(host, '.'); i > 0 {
+			parent := host[i+1:]
+			if v, ok := m.tree.Get([]byte(parent)); ok && v.(*ingressHost).hasWildcard {
+				return "*." + parent, v.(*ingressHost).wildcard, true
+			}
+		}
+	}
+	if m.hasAny {
+		return "", m.any, true
+	}
+	return "", nil, false
+}
+
+// Len returns the number of Ingress hosts.
+func (m *IngressMatcher) Len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.patterns
+}
@@ -0,0 +1,44 @@
+package suffixhttp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertIngress(t *testing.T, m *IngressMatcher, host, rule string, found bool) {
+	r, v, ok := m.Match(host)
+	assert.Equal(t, found, ok, host)
+	assert.Equal(t, rule, r, host)
+	if found {
+		assert.Equal(t, rule, v, host)
+	}
+}
+
+func TestIngressMatcher(t *testing.T) {
+	m := NewIngressMatcher()
+	assertIngress(t, m, "foo.bar.com", "", false)
+	for _, host := range []string{"foo.bar.com", "*.foo.com", "*.bar.com", ""} {
+		assert.Nil(t, m.Add(host, host))
+	}
+	for _, host := range []string{"*", "*.", "foo.*.com", "f*.com", "foo.com:80",
+		"10.0.0.1", "a..com", ".foo.com"} {
+
+		assert.NotNil(t, m.Add(host, nil), host)
+	}
+	assert.Equal(t, 4, m.Len())
+
+	assertIngress(t, m, "Foo.Bar.com:8080", "foo.bar.com", true)
+	assertIngress(t, m, "baz.bar.com", "*.bar.com", true)
+	assertIngress(t, m, "bar.foo.com", "*.foo.com", true)
+	assertIngress(t, m, "baz.bar.foo.com", "", true)
+	assertIngress(t, m, "foo.com", "", true)
+
+	assert.True(t, m.Remove(""))
+	assert.False(t, m.Remove(""))
+	assert.True(t, m.Remove("*.foo.com"))
+	assert.False(t, m.Remove("foo.com"))
+	assert.Equal(t, 2, m.Len())
+	assertIngress(t, m, "bar.foo.com", "", false)
+	assertIngress(t, m, "foo.bar.com", "foo.bar.com", true)
+}
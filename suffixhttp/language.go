@@ -0,0 +1,100 @@
+package suffixhttp
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spacewander/go-suffix-tree/suffixrule"
+)
+
+// LanguageRange is a language range of the Accept-Language header with its quality.
+type LanguageRange struct {
+	Tag     string
+	Quality float64
+}
+
+// ParseAcceptLanguage parses the Accept-Language header, like "fr-CH, fr;q=0.9, en;q=0.8",
+// into the language ranges ordered by quality, keeping the order of the header for the same
+// quality. The ranges with a malformed or zero quality are dropped.
+func ParseAcceptLanguage(header string) []LanguageRange {
+	var ranges []LanguageRange
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if params = strings.TrimSpace(params); params != "" {
+			name, value, ok := strings.Cut(params, "=")
+			if !ok || strings.TrimSpace(name) != "q" {
+				continue
+			}
+			var err error
+			q, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		if q == 0 {
+			continue
+		}
+		ranges = append(ranges, LanguageRange{Tag: tag, Quality: q})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].Quality > ranges[j].Quality
+	})
+	return ranges
+}
+
+// LanguageMatcher resolves the Accept-Language header of requests to the best supported
+// language tag. Each requested range falls back by removing its subtags from the end, so
+// "de-CH-1996" matches a supported "de-CH" or "de", but "de" doesn't match a supported "de-CH".
+// The tags are case-insensitive.
+//
+// LanguageMatcher is safe for concurrent use once it is created.
+type LanguageMatcher struct {
+	resolver *suffixrule.LocaleResolver
+}
+
+// NewLanguageMatcher creates a LanguageMatcher of the supported tags.
+func NewLanguageMatcher(supported ...string) *LanguageMatcher {
+	m := &LanguageMatcher{resolver: suffixrule.NewLocaleResolver()}
+	for _, tag := range supported {
+		if tag != "" {
+			m.resolver.Add(tag, nil)
+		}
+	}
+	return m
+}
+
+// Match returns the best supported tag for the Accept-Language header. The candidates are the
+// supported tags matched by the requested ranges, ordered by quality and without duplicates,
+// so tag is the first of them. The "*" range is ignored. found is false if no range matches.
+func (m *LanguageMatcher) Match(header string) (tag string, candidates []string, found bool) {
+	seen := map[string]bool{}
+	for _, r := range ParseAcceptLanguage(header) {
+		if r.Tag == "*" {
+			continue
+		}
+		matched, _, _, ok := m.resolver.Resolve(r.Tag)
+		if !ok || seen[matched] {
+			continue
+		}
+		seen[matched] = true
+		candidates = append(candidates, matched)
+	}
+	if len(candidates) == 0 {
+		return "", nil, false
+	}
+	return candidates[0], candidates, true
+}
+
+// MatchRequest is like Match, with the Accept-Language header of the request.
+func (m *LanguageMatcher) MatchRequest(req *http.Request) (tag string, candidates []string,
+	found bool) {
+
+	return m.Match(strings.Join(req.Header.Values("Accept-Language"), ","))
+}
@@ -0,0 +1,43 @@
+package suffixhttp
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	assert.Equal(t, []LanguageRange{
+		{Tag: "fr-CH", Quality: 1},
+		{Tag: "*", Quality: 1},
+		{Tag: "fr", Quality: 0.9},
+		{Tag: "de", Quality: 0.9},
+		{Tag: "en", Quality: 0.5},
+	}, ParseAcceptLanguage("en;q=0.5, fr-CH, fr;q=0.9,de; q=0.9, *, it;q=0, es;q=x, pt;v=1,"))
+	assert.Nil(t, ParseAcceptLanguage(""))
+}
+
+func TestLanguageMatcher(t *testing.T) {
+	m := NewLanguageMatcher("en", "en-GB", "fr", "zh-Hant", "")
+
+	tag, candidates, found := m.Match("fr-CH, fr;q=0.9, en-US;q=0.8, en;q=0.7, *;q=0.1")
+	assert.True(t, found)
+	assert.Equal(t, "fr", tag)
+	assert.Equal(t, []string{"fr", "en"}, candidates)
+
+	tag, _, _ = m.Match("zh-hant-tw;q=0.5, en-gb-oxendict;q=0.8")
+	assert.Equal(t, "en-GB", tag)
+
+	_, _, found = m.Match("de, ja;q=0.5, *")
+	assert.False(t, found)
+	_, _, found = m.Match("")
+	assert.False(t, found)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Add("Accept-Language", "de;q=0.9")
+	req.Header.Add("Accept-Language", "zh-Hant-HK")
+	tag, candidates, _ = m.MatchRequest(req)
+	assert.Equal(t, "zh-Hant", tag)
+	assert.Equal(t, []string{"zh-Hant"}, candidates)
+}
@@ -0,0 +1,150 @@
+// Package suffixhttp provides the HTTP helpers built on the suffix tree: routing requests by
+// their host names, with the longest registered host suffix winning, matching Ingress hosts
+// and languages, and a debug handler for inspecting trees.
+package suffixhttp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// route holds the handlers registered for a domain.
+type route struct {
+	// Registered as "example.com", for the domain and its subdomains
+	domain        http.Handler
+	domainPattern string
+	// Registered as ".example.com", for the subdomains only
+	subdomains        http.Handler
+	subdomainsPattern string
+}
+
+// Router is an http.Handler which dispatches requests by the host names. A pattern like
+// "example.org" matches the host "example.org" and all its subdomains, and a pattern with a
+// leading dot like ".api.example.com" matches the subdomains of "api.example.com" only. The
+// patterns are matched label by label, so "example.org" doesn't match "badexample.org", and
+// the longest matched pattern wins. The empty pattern matches every host.
+//
+// Router is safe for concurrent use.
+type Router struct {
+	// NotFound handles the requests matching no pattern. http.NotFound is used if it is nil.
+	NotFound http.Handler
+
+	lock sync.RWMutex
+	tree *suffix.Tree
+}
+
+// NewRouter creates a Router without any routes.
+func NewRouter() *Router {
+	return &Router{tree: suffix.NewTree(suffix.WithSeparator('.'))}
+}
+
+// NormalizeHost converts the host of a request, which may come from the Host header, into the
+// form matched by Router: the port is stripped, ASCII letters are lowercased, and a single
+// trailing dot is removed. The brackets around IPv6 addresses are removed too.
+func NormalizeHost(host string) string {
+	host = strings.TrimSpace(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	} else if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	host = strings.TrimSuffix(host, ".")
+	return strings.ToLower(host)
+}
+
+// Handle registers the handler for the given host pattern. It panics if the pattern is
+// already registered or the handler is nil.
+func (r *Router) Handle(pattern string, handler http.Handler) {
+	if handler == nil {
+		panic("suffixhttp: nil handler")
+	}
+	subdomains := strings.HasPrefix(pattern, ".")
+	key := NormalizeHost(strings.TrimPrefix(pattern, "."))
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	rt := &route{}
+	if v, found := r.tree.Get([]byte(key)); found {
+		rt = v.(*route)
+	}
+	if subdomains {
+		if rt.subdomains != nil {
+			panic(fmt.Sprintf("suffixhttp: multiple registrations for %s", pattern))
+		}
+		rt.subdomains, rt.subdomainsPattern = handler, pattern
+	} else {
+		if rt.domain != nil {
+			panic(fmt.Sprintf("suffixhttp: multiple registrations for %s", pattern))
+		}
+		rt.domain, rt.domainPattern = handler, pattern
+	}
+	r.tree.Insert([]byte(key), rt)
+}
+
+// HandleFunc registers the handler function for the given host pattern.
+func (r *Router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	if handler == nil {
+		panic("suffixhttp: nil handler")
+	}
+	r.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// Match returns the handler and the pattern matching the host, which is normalized by
+// NormalizeHost. The returned handler is nil if no pattern matches.
+func (r *Router) Match(host string) (handler http.Handler, pattern string) {
+	host = NormalizeHost(host)
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	query := host
+	for {
+		key, v, found := r.tree.LongestSuffix([]byte(query))
+		if !found {
+			return nil, ""
+		}
+		rt := v.(*route)
+		if len(key) < len(host) && rt.subdomains != nil {
+			return rt.subdomains, rt.subdomainsPattern
+		}
+		if rt.domain != nil {
+			return rt.domain, rt.domainPattern
+		}
+		// Only the subdomains of the key are routed, look for a shorter pattern
+		if len(key) == 0 {
+			return nil, ""
+		}
+		i := strings.IndexByte(string(key), '.')
+		if i < 0 {
+			query = ""
+		} else {
+			query = string(key[i+1:])
+		}
+	}
+}
+
+// Handler returns the handler for the request, like http.ServeMux.Handler. The pattern is
+// empty if the request is handled by NotFound.
+func (r *Router) Handler(req *http.Request) (handler http.Handler, pattern string) {
+	host := req.Host
+	if host == "" && req.URL != nil {
+		host = req.URL.Host
+	}
+	handler, pattern = r.Match(host)
+	if handler != nil {
+		return handler, pattern
+	}
+	if r.NotFound != nil {
+		return r.NotFound, ""
+	}
+	return http.NotFoundHandler(), ""
+}
+
+// ServeHTTP dispatches the request to the handler whose pattern matches its host.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	handler, _ := r.Handler(req)
+	handler.ServeHTTP(w, req)
+}
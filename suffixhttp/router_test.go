@@ -0,0 +1,92 @@
+package suffixhttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func text(s string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, s)
+	})
+}
+
+func TestNormalizeHost(t *testing.T) {
+	for host, expected := range map[string]string{
+		"Example.COM":        "example.com",
+		"example.com:8080":   "example.com",
+		"example.com.":       "example.com",
+		"example.com.:443":   "example.com",
+		"[::1]:80":           "::1",
+		"[::1]":              "::1",
+		"::1":                "::1",
+		" www.example.com\t": "www.example.com",
+		"":                   "",
+	} {
+		assert.Equal(t, expected, NormalizeHost(host), host)
+	}
+}
+
+func TestRouter(t *testing.T) {
+	r := NewRouter()
+	r.Handle("example.org", text("org"))
+	r.Handle(".api.example.com", text("api"))
+	r.Handle("example.com", text("com"))
+	r.HandleFunc("v2.api.example.com", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "v2")
+	})
+
+	for host, expected := range map[string]string{
+		"example.org":          "org",
+		"www.example.org":      "org",
+		"badexample.org":       "404 page not found\n",
+		"x.api.example.com":    "api",
+		"API.example.com:8443": "com",
+		"v2.api.example.com":   "v2",
+		"x.v2.api.example.com": "v2",
+		"www.example.com.":     "com",
+		"example.net":          "404 page not found\n",
+	} {
+		req := httptest.NewRequest("GET", "http://"+host+"/", nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, expected, w.Body.String(), host)
+	}
+
+	_, pattern := r.Match("x.api.example.com")
+	assert.Equal(t, ".api.example.com", pattern)
+	r.NotFound = text("default")
+	handler, pattern := r.Handler(httptest.NewRequest("GET", "http://example.net/", nil))
+	assert.Equal(t, "", pattern)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, nil)
+	assert.Equal(t, "default", w.Body.String())
+
+	assert.Panics(t, func() { r.Handle("EXAMPLE.com", text("dup")) })
+	assert.Panics(t, func() { r.Handle(".api.example.com", text("dup")) })
+	assert.Panics(t, func() { r.Handle("example.net", nil) })
+}
+
+func TestRouter_SubdomainsOnly(t *testing.T) {
+	r := NewRouter()
+	r.Handle("", text("any"))
+	r.Handle(".example.com", text("sub"))
+
+	handler, pattern := r.Match("example.com")
+	assert.NotNil(t, handler)
+	assert.Equal(t, "", pattern)
+	_, pattern = r.Match("a.b.example.com")
+	assert.Equal(t, ".example.com", pattern)
+
+	r = NewRouter()
+	r.Handle(".com", text("com"))
+	handler, _ = r.Match("com")
+	assert.Nil(t, handler)
+	_, pattern = r.Match("example.com")
+	assert.Equal(t, ".com", pattern)
+}
@@ -0,0 +1,50 @@
+// Package suffixpath matches file names and paths by their suffixes, like extensions.
+package suffixpath
+
+import (
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// ExtensionMux maps file name suffixes, like ".tar.gz", ".service.ts" or "_test.go", to
+// values, and finds the value of a file name by the longest suffix it ends with. So
+// "a.tar.gz" matches ".tar.gz" instead of ".gz", without ordering a chain of
+// strings.HasSuffix by hand.
+//
+// The suffixes are matched byte by byte against the whole name given to Match, so a suffix
+// like "/Makefile" can match a path too. ExtensionMux is safe for concurrent reads, but not for
+// writes.
+type ExtensionMux struct {
+	tree *suffix.Tree
+}
+
+// NewExtensionMux creates an empty ExtensionMux. The options apply to the suffixes and names,
+// for example, suffix.WithCaseFolding() matches them case-insensitively.
+func NewExtensionMux(opts ...suffix.Option) *ExtensionMux {
+	return &ExtensionMux{tree: suffix.NewTree(opts...)}
+}
+
+// Handle maps the suffix pattern to the value, replacing the value of the same pattern. The
+// empty pattern matches every name, as the fallback.
+func (mux *ExtensionMux) Handle(pattern string, value interface{}) {
+	mux.tree.Insert([]byte(pattern), value)
+}
+
+// Remove removes the suffix pattern, and reports whether it was mapped.
+func (mux *ExtensionMux) Remove(pattern string) bool {
+	_, found := mux.tree.Remove([]byte(pattern))
+	return found
+}
+
+// Match returns the longest suffix pattern which name ends with, and its value.
+func (mux *ExtensionMux) Match(name string) (pattern string, value interface{}, found bool) {
+	key, value, found := mux.tree.LongestSuffix([]byte(name))
+	if !found {
+		return "", nil, false
+	}
+	return string(key), value, true
+}
+
+// Len returns the number of suffixes.
+func (mux *ExtensionMux) Len() int {
+	return mux.tree.Len()
+}
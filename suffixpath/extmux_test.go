@@ -0,0 +1,59 @@
+package suffixpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+func TestExtensionMux(t *testing.T) {
+	mux := NewExtensionMux()
+	mux.Handle(".gz", "gzip")
+	mux.Handle(".tar.gz", "tarball")
+	mux.Handle(".ts", "typescript")
+	mux.Handle(".service.ts", "service")
+	mux.Handle(".go", "go")
+	mux.Handle("_test.go", "test")
+	mux.Handle("/Makefile", "make")
+	assert.Equal(t, 7, mux.Len())
+
+	for name, expected := range map[string]interface{}{
+		"a.gz":            "gzip",
+		"src/a.tar.gz":    "tarball",
+		"user.service.ts": "service",
+		"user.ts":         "typescript",
+		"service.ts":      "typescript",
+		"suffix_test.go":  "test",
+		"suffix.go":       "go",
+		"build/Makefile":  "make",
+		"GNUmakefile":     nil,
+		"README.md":       nil,
+	} {
+		_, value, found := mux.Match(name)
+		assert.Equal(t, expected != nil, found, name)
+		assert.Equal(t, expected, value, name)
+	}
+	matched, _, _ := mux.Match("a.tar.gz")
+	assert.Equal(t, ".tar.gz", matched)
+
+	assert.True(t, mux.Remove(".tar.gz"))
+	assert.False(t, mux.Remove(".tar.gz"))
+	_, value, _ := mux.Match("a.tar.gz")
+	assert.Equal(t, "gzip", value)
+
+	mux.Handle("", "other")
+	_, value, _ = mux.Match("README.md")
+	assert.Equal(t, "other", value)
+}
+
+func TestExtensionMux_CaseFolding(t *testing.T) {
+	mux := NewExtensionMux(suffix.WithCaseFolding())
+	mux.Handle(".JPG", "jpeg")
+	_, value, found := mux.Match("photo.jpg")
+	assert.True(t, found)
+	assert.Equal(t, "jpeg", value)
+	_, value, _ = mux.Match("PHOTO.Jpg")
+	assert.Equal(t, "jpeg", value)
+}
@@ -0,0 +1,145 @@
+package suffixpath
+
+import (
+	"strings"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// standardTypes are the MIME types of the common extensions in static file servers.
+var standardTypes = map[string]string{
+	".7z":          "application/x-7z-compressed",
+	".aac":         "audio/aac",
+	".avif":        "image/avif",
+	".bmp":         "image/bmp",
+	".bz2":         "application/x-bzip2",
+	".css":         "text/css; charset=utf-8",
+	".csv":         "text/csv; charset=utf-8",
+	".doc":         "application/msword",
+	".docx":        "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".eot":         "application/vnd.ms-fontobject",
+	".epub":        "application/epub+zip",
+	".flac":        "audio/flac",
+	".gif":         "image/gif",
+	".gz":          "application/gzip",
+	".htm":         "text/html; charset=utf-8",
+	".html":        "text/html; charset=utf-8",
+	".ico":         "image/vnd.microsoft.icon",
+	".ics":         "text/calendar; charset=utf-8",
+	".jar":         "application/java-archive",
+	".jpeg":        "image/jpeg",
+	".jpg":         "image/jpeg",
+	".js":          "text/javascript; charset=utf-8",
+	".json":        "application/json",
+	".jsonld":      "application/ld+json",
+	".m4a":         "audio/mp4",
+	".map":         "application/json",
+	".md":          "text/markdown; charset=utf-8",
+	".mjs":         "text/javascript; charset=utf-8",
+	".mp3":         "audio/mpeg",
+	".mp4":         "video/mp4",
+	".mpeg":        "video/mpeg",
+	".oga":         "audio/ogg",
+	".ogg":         "audio/ogg",
+	".ogv":         "video/ogg",
+	".otf":         "font/otf",
+	".pdf":         "application/pdf",
+	".png":         "image/png",
+	".ppt":         "application/vnd.ms-powerpoint",
+	".pptx":        "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".rar":         "application/vnd.rar",
+	".rss":         "application/rss+xml",
+	".rtf":         "application/rtf",
+	".svg":         "image/svg+xml",
+	".tar":         "application/x-tar",
+	".tar.gz":      "application/gzip",
+	".tgz":         "application/gzip",
+	".tif":         "image/tiff",
+	".tiff":        "image/tiff",
+	".ts":          "video/mp2t",
+	".ttf":         "font/ttf",
+	".txt":         "text/plain; charset=utf-8",
+	".wasm":        "application/wasm",
+	".wav":         "audio/wav",
+	".weba":        "audio/webm",
+	".webm":        "video/webm",
+	".webmanifest": "application/manifest+json",
+	".webp":        "image/webp",
+	".woff":        "font/woff",
+	".woff2":       "font/woff2",
+	".xhtml":       "application/xhtml+xml",
+	".xls":         "application/vnd.ms-excel",
+	".xlsx":        "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".xml":         "text/xml; charset=utf-8",
+	".yaml":        "application/yaml",
+	".yml":         "application/yaml",
+	".zip":         "application/zip",
+	".zst":         "application/zstd",
+}
+
+// MIMETable finds the MIME types of file paths by their extensions. Unlike
+// mime.TypeByExtension, it takes a path instead of an extension, doesn't read the system MIME
+// files, and doesn't allocate for the lowercase extensions, which suits hot static file
+// servers.
+//
+// A MIMETable can't be changed once it is created, so it is safe for concurrent use.
+type MIMETable struct {
+	tree *suffix.KeyTree[string]
+}
+
+// NewMIMETable creates a MIMETable of the standard extensions, like ".html", ".css", ".js",
+// ".png" and ".woff2".
+func NewMIMETable() *MIMETable {
+	return NewMIMETableOf(standardTypes)
+}
+
+// NewMIMETableOf creates a MIMETable of the given extensions, which start with "." and map
+// to MIME types. The table keeps a copy of types.
+func NewMIMETableOf(types map[string]string) *MIMETable {
+	table := &MIMETable{tree: suffix.NewKeyTree[string]()}
+	for ext, typ := range types {
+		table.tree.Insert(strings.ToLower(ext), typ)
+	}
+	return table
+}
+
+// Lookup returns the MIME type of the extension, like ".html", or "" if it is unknown. The
+// extension is matched case-insensitively.
+func (table *MIMETable) Lookup(ext string) string {
+	if v, found := table.tree.Get(ext); found {
+		return v.(string)
+	}
+	if lower := strings.ToLower(ext); lower != ext {
+		if v, found := table.tree.Get(lower); found {
+			return v.(string)
+		}
+	}
+	return ""
+}
+
+// LookupPath returns the MIME type of the file path by the longest extension it ends with,
+// so "a.tar.gz" is typed by ".tar.gz" before ".gz". It returns "" if no extension matches.
+func (table *MIMETable) LookupPath(p string) string {
+	if _, v, found := table.tree.LongestSuffix(p); found {
+		return v.(string)
+	}
+	// Only the extensions in uppercase or mixed case reach here
+	base := p[strings.LastIndexByte(p, '/')+1:]
+	i := strings.IndexByte(base, '.')
+	if i < 0 {
+		return ""
+	}
+	lower := strings.ToLower(base[i:])
+	if lower == base[i:] {
+		return ""
+	}
+	if _, v, found := table.tree.LongestSuffix(lower); found {
+		return v.(string)
+	}
+	return ""
+}
+
+// Len returns the number of extensions.
+func (table *MIMETable) Len() int {
+	return table.tree.Len()
+}
@@ -0,0 +1,40 @@
+package suffixpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMIMETable(t *testing.T) {
+	table := NewMIMETable()
+	assert.Equal(t, "text/html; charset=utf-8", table.Lookup(".html"))
+	assert.Equal(t, "text/html; charset=utf-8", table.Lookup(".HTML"))
+	assert.Equal(t, "", table.Lookup(".unknown"))
+	assert.Equal(t, "", table.Lookup("html"))
+
+	assert.Equal(t, "text/css; charset=utf-8", table.LookupPath("/static/site.min.css"))
+	assert.Equal(t, "application/xhtml+xml", table.LookupPath("page.xhtml"))
+	assert.Equal(t, "image/png", table.LookupPath("/IMG/Photo.PNG"))
+	assert.Equal(t, "application/gzip", table.LookupPath("dist/app.TAR.GZ"))
+	assert.Equal(t, "", table.LookupPath("/static/README"))
+	assert.Equal(t, "", table.LookupPath("/static.css/README"))
+	assert.Equal(t, "", table.LookupPath(""))
+}
+
+func TestMIMETableOf(t *testing.T) {
+	table := NewMIMETableOf(map[string]string{".Go": "text/x-go", ".mod": "text/plain"})
+	assert.Equal(t, 2, table.Len())
+	assert.Equal(t, "text/x-go", table.LookupPath("main.go"))
+	assert.Equal(t, "text/plain", table.LookupPath("go.MOD"))
+	assert.Equal(t, "", table.LookupPath("main.html"))
+}
+
+func TestMIMETable_NoAllocation(t *testing.T) {
+	table := NewMIMETable()
+	allocs := testing.AllocsPerRun(100, func() {
+		table.LookupPath("/static/js/app.min.js")
+		table.Lookup(".png")
+	})
+	assert.Equal(t, float64(0), allocs)
+}
@@ -0,0 +1,91 @@
+package suffixpath
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// ObjectRule is a rule filtering the object keys in a bucket by prefix and suffix, like an S3
+// lifecycle or event notification rule. The empty prefix or suffix matches every key.
+type ObjectRule struct {
+	ID     string
+	Prefix string
+	Suffix string
+}
+
+// ObjectRuleMatcher finds all rules applying to an object key. The rules are indexed by their
+// suffixes, so a key is checked against the rules whose suffix it ends with only, in one pass
+// over the index, which keeps the matching cheap with thousands of rules.
+//
+// ObjectRuleMatcher is safe for concurrent reads, but not for writes.
+type ObjectRuleMatcher struct {
+	tree  *suffix.Tree
+	rules map[string]ObjectRule
+}
+
+// NewObjectRuleMatcher creates an ObjectRuleMatcher without rules.
+func NewObjectRuleMatcher() *ObjectRuleMatcher {
+	return &ObjectRuleMatcher{tree: suffix.NewTree(), rules: map[string]ObjectRule{}}
+}
+
+// Add adds the rule. It returns an error if a rule with the same ID is added.
+func (m *ObjectRuleMatcher) Add(rule ObjectRule) error {
+	if _, ok := m.rules[rule.ID]; ok {
+		return fmt.Errorf("suffixpath: duplicate rule ID %q", rule.ID)
+	}
+	var rules []ObjectRule
+	if v, found := m.tree.Get([]byte(rule.Suffix)); found {
+		rules = v.([]ObjectRule)
+	}
+	m.tree.Insert([]byte(rule.Suffix), append(rules, rule))
+	m.rules[rule.ID] = rule
+	return nil
+}
+
+// Remove removes the rule of the ID, and reports whether it was added.
+func (m *ObjectRuleMatcher) Remove(id string) bool {
+	rule, ok := m.rules[id]
+	if !ok {
+		return false
+	}
+	delete(m.rules, id)
+	v, _ := m.tree.Get([]byte(rule.Suffix))
+	rules := v.([]ObjectRule)
+	if len(rules) == 1 {
+		m.tree.Remove([]byte(rule.Suffix))
+		return true
+	}
+	rest := make([]ObjectRule, 0, len(rules)-1)
+	for _, r := range rules {
+		if r.ID != id {
+			rest = append(rest, r)
+		}
+	}
+	m.tree.Insert([]byte(rule.Suffix), rest)
+	return true
+}
+
+// Match returns the sorted IDs of the rules applying to the object key.
+func (m *ObjectRuleMatcher) Match(key string) []string {
+	var ids []string
+	m.tree.AllSuffixesOf([]byte(key), func(_ []byte, v interface{}) bool {
+		for _, rule := range v.([]ObjectRule) {
+			if len(rule.Prefix)+len(rule.Suffix) <= len(key) &&
+				strings.HasPrefix(key, rule.Prefix) {
+
+				ids = append(ids, rule.ID)
+			}
+		}
+		return false
+	})
+	sort.Strings(ids)
+	return ids
+}
+
+// Len returns the number of rules.
+func (m *ObjectRuleMatcher) Len() int {
+	return len(m.rules)
+}
@@ -0,0 +1,36 @@
+package suffixpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjectRuleMatcher(t *testing.T) {
+	m := NewObjectRuleMatcher()
+	for _, rule := range []ObjectRule{
+		{ID: "logs", Prefix: "logs/"},
+		{ID: "gz", Suffix: ".gz"},
+		{ID: "old-logs", Prefix: "logs/2020/", Suffix: ".log.gz"},
+		{ID: "images", Prefix: "img/", Suffix: ".jpg"},
+		{ID: "overlap", Prefix: "a.j", Suffix: ".jpg"},
+	} {
+		assert.Nil(t, m.Add(rule))
+	}
+	assert.NotNil(t, m.Add(ObjectRule{ID: "gz"}))
+	assert.Equal(t, 5, m.Len())
+
+	assert.Equal(t, []string{"gz", "logs", "old-logs"}, m.Match("logs/2020/app.log.gz"))
+	assert.Equal(t, []string{"gz", "logs"}, m.Match("logs/2021/app.log.gz"))
+	assert.Equal(t, []string{"images"}, m.Match("img/cat.jpg"))
+	assert.Nil(t, m.Match("img/cat.png"))
+	// The prefix and suffix can't overlap
+	assert.Nil(t, m.Match("a.jpg"))
+	assert.Equal(t, []string{"overlap"}, m.Match("a.j.jpg"))
+
+	assert.True(t, m.Remove("old-logs"))
+	assert.False(t, m.Remove("old-logs"))
+	assert.True(t, m.Remove("gz"))
+	assert.Equal(t, []string{"logs"}, m.Match("logs/2020/app.log.gz"))
+	assert.Equal(t, 3, m.Len())
+}
@@ -0,0 +1,140 @@
+package suffixpath
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// WatchFilter filters the paths of file system events, like those from fsnotify, by glob
+// patterns such as "**/*.go" or "**/testdata/*.golden". A "*" matches any characters except
+// "/", "**" as a whole segment matches any number of directories, and "?" and "[...]" work
+// as in path.Match.
+//
+// The patterns are indexed by their literal tails, the part after the last wildcard like
+// ".go", so a path is only compared with the patterns whose tail it ends with, which keeps
+// the filter cheap with many patterns and events. Windows paths are converted to slashes.
+//
+// WatchFilter is safe for concurrent use.
+type WatchFilter struct {
+	lock sync.RWMutex
+	tree *suffix.Tree
+	n    int
+}
+
+// NewWatchFilter creates a WatchFilter without patterns, which matches no path.
+func NewWatchFilter() *WatchFilter {
+	return &WatchFilter{tree: suffix.NewTree()}
+}
+
+// globTail returns the literal tail of the pattern.
+func globTail(pattern string) string {
+	return pattern[strings.LastIndexAny(pattern, "*?[]\\")+1:]
+}
+
+// Add adds the glob pattern. It returns path.ErrBadPattern if the pattern is malformed.
+func (f *WatchFilter) Add(pattern string) error {
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return err
+		}
+	}
+	tail := globTail(pattern)
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var patterns []string
+	if v, found := f.tree.Get([]byte(tail)); found {
+		patterns = v.([]string)
+		for _, p := range patterns {
+			if p == pattern {
+				return nil
+			}
+		}
+	}
+	// Copy on append, so the slices seen by Match are never modified
+	patterns = append(patterns[:len(patterns):len(patterns)], pattern)
+	f.tree.Insert([]byte(tail), patterns)
+	f.n++
+	return nil
+}
+
+// Remove removes the pattern, and reports whether it was added.
+func (f *WatchFilter) Remove(pattern string) bool {
+	tail := globTail(pattern)
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	v, found := f.tree.Get([]byte(tail))
+	if !found {
+		return false
+	}
+	patterns := v.([]string)
+	for i, p := range patterns {
+		if p != pattern {
+			continue
+		}
+		if len(patterns) == 1 {
+			f.tree.Remove([]byte(tail))
+		} else {
+			rest := make([]string, 0, len(patterns)-1)
+			rest = append(append(rest, patterns[:i]...), patterns[i+1:]...)
+			f.tree.Insert([]byte(tail), rest)
+		}
+		f.n--
+		return true
+	}
+	return false
+}
+
+// Match returns the first added pattern with the longest tail which matches the path.
+func (f *WatchFilter) Match(name string) (pattern string, ok bool) {
+	name = filepath.ToSlash(name)
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	f.tree.AllSuffixesOf([]byte(name), func(key []byte, v interface{}) bool {
+		for _, p := range v.([]string) {
+			if matchGlob(p, name) {
+				pattern, ok = p, true
+				return true
+			}
+		}
+		return false
+	})
+	return pattern, ok
+}
+
+// Len returns the number of patterns.
+func (f *WatchFilter) Len() int {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.n
+}
+
+// matchGlob reports whether name matches the pattern, segment by segment.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Try to match the rest from each segment
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,43 @@
+package suffixpath
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertWatch(t *testing.T, f *WatchFilter, name, pattern string) {
+	p, ok := f.Match(name)
+	assert.Equal(t, pattern != "", ok, name)
+	assert.Equal(t, pattern, p, name)
+}
+
+func TestWatchFilter(t *testing.T) {
+	f := NewWatchFilter()
+	for _, pattern := range []string{"**/*.go", "**/testdata/*.golden", "docs/*.md",
+		"**/.git/**", "**/*_test.go", "**/*.go"} {
+
+		assert.Nil(t, f.Add(pattern))
+	}
+	assert.Equal(t, path.ErrBadPattern, f.Add("**/[a.go"))
+	assert.Equal(t, 5, f.Len())
+
+	assertWatch(t, f, "/src/pkg/main.go", "**/*.go")
+	assertWatch(t, f, "main.go", "**/*.go")
+	assertWatch(t, f, "/src/pkg/main_test.go", "**/*_test.go")
+	assertWatch(t, f, "/src/pkg/main.go~", "")
+	assertWatch(t, f, "/src/testdata/out.golden", "**/testdata/*.golden")
+	assertWatch(t, f, "/src/testdata/sub/out.golden", "")
+	assertWatch(t, f, "docs/index.md", "docs/*.md")
+	assertWatch(t, f, "/repo/docs/index.md", "")
+	assertWatch(t, f, "/repo/.git/objects/ab", "**/.git/**")
+
+	assert.True(t, f.Remove("**/*_test.go"))
+	assert.False(t, f.Remove("**/*_test.go"))
+	assert.False(t, f.Remove("**/*.txt"))
+	assertWatch(t, f, "/src/pkg/main_test.go", "**/*.go")
+	assert.True(t, f.Remove("**/*.go"))
+	assertWatch(t, f, "/src/pkg/main.go", "")
+	assert.Equal(t, 3, f.Len())
+}
@@ -0,0 +1,98 @@
+package suffixrule
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// classRule is a rule of Classifier with its hit count.
+type classRule struct {
+	label string
+	hits  atomic.Uint64
+}
+
+// Classifier classifies lines, like the lines of logs, by the rule suffixes they end with. The
+// longest matched suffix wins, and its label is returned. Each rule counts the lines it
+// classifies, and the counts can be read at any time with Counts.
+//
+// Classifier is safe for concurrent use.
+type Classifier struct {
+	lock   sync.RWMutex
+	tree   *suffix.Tree
+	misses atomic.Uint64
+}
+
+// NewClassifier creates a Classifier without rules.
+func NewClassifier() *Classifier {
+	return &Classifier{tree: suffix.NewTree()}
+}
+
+// AddRule adds a rule which labels the lines ending with the suffix. It returns an error if
+// the suffix already has a rule.
+func (c *Classifier) AddRule(ruleSuffix, label string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, found := c.tree.Get([]byte(ruleSuffix)); found {
+		return fmt.Errorf("suffixrule: duplicate rule %q", ruleSuffix)
+	}
+	c.tree.Insert([]byte(ruleSuffix), &classRule{label: label})
+	return nil
+}
+
+// RemoveRule removes the rule of the suffix with its count, and reports whether it existed.
+func (c *Classifier) RemoveRule(ruleSuffix string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, found := c.tree.Remove([]byte(ruleSuffix))
+	return found
+}
+
+// Classify returns the label of the longest rule suffix the line ends with, and counts a hit
+// for the rule. The trailing line break of the line is ignored. ok is false if no rule
+// matches, which is counted by Misses.
+func (c *Classifier) Classify(line string) (label string, ok bool) {
+	line = strings.TrimRight(line, "\r\n")
+	c.lock.RLock()
+	_, v, found := c.tree.LongestSuffix([]byte(line))
+	c.lock.RUnlock()
+	if !found {
+		c.misses.Add(1)
+		return "", false
+	}
+	rule := v.(*classRule)
+	rule.hits.Add(1)
+	return rule.label, true
+}
+
+// Counts returns the hit count of each rule, keyed by the rule suffix. The counts are read
+// one by one, so the lines classified meanwhile may be partly counted.
+func (c *Classifier) Counts() map[string]uint64 {
+	counts := map[string]uint64{}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	c.tree.Walk(func(key []byte, value interface{}) bool {
+		counts[string(key)] = value.(*classRule).hits.Load()
+		return false
+	})
+	return counts
+}
+
+// Misses returns the number of lines matching no rule.
+func (c *Classifier) Misses() uint64 {
+	return c.misses.Load()
+}
+
+// ResetCounts sets all the counts to zero.
+func (c *Classifier) ResetCounts() {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	c.tree.Walk(func(key []byte, value interface{}) bool {
+		value.(*classRule).hits.Store(0)
+		return false
+	})
+	c.misses.Store(0)
+}
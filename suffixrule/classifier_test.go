@@ -0,0 +1,55 @@
+package suffixrule
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifier(t *testing.T) {
+	c := NewClassifier()
+	assert.Nil(t, c.AddRule("timeout", "network"))
+	assert.Nil(t, c.AddRule("read timeout", "disk"))
+	assert.Nil(t, c.AddRule("out of memory", "oom"))
+	assert.NotNil(t, c.AddRule("timeout", "other"))
+
+	label, ok := c.Classify("dial tcp 10.0.0.1:80: i/o timeout\n")
+	assert.True(t, ok)
+	assert.Equal(t, "network", label)
+	label, ok = c.Classify("disk read timeout\r\n")
+	assert.True(t, ok)
+	assert.Equal(t, "disk", label)
+	_, ok = c.Classify("all good")
+	assert.False(t, ok)
+
+	assert.Equal(t, map[string]uint64{"timeout": 1, "read timeout": 1, "out of memory": 0},
+		c.Counts())
+	assert.Equal(t, uint64(1), c.Misses())
+
+	c.ResetCounts()
+	assert.Equal(t, uint64(0), c.Counts()["timeout"])
+	assert.Equal(t, uint64(0), c.Misses())
+
+	assert.True(t, c.RemoveRule("read timeout"))
+	assert.False(t, c.RemoveRule("read timeout"))
+	label, _ = c.Classify("disk read timeout")
+	assert.Equal(t, "network", label)
+}
+
+func TestClassifier_Concurrent(t *testing.T) {
+	c := NewClassifier()
+	c.AddRule("error", "error")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Classify("fatal error")
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, uint64(800), c.Counts()["error"])
+}
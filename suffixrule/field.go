@@ -0,0 +1,55 @@
+package suffixrule
+
+import (
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// FieldMapper maps the suffixes of field names in structured logs, like "_at", "_ms" or
+// "_id", to values, like the types of the fields or the handlers parsing them. A field is
+// mapped by the longest suffix it ends with, so "duration_seconds" matches "_seconds" before
+// "_s".
+//
+// FieldMapper is safe for concurrent reads, but not for writes.
+type FieldMapper struct {
+	tree *suffix.KeyTree[string]
+}
+
+// NewFieldMapper creates an empty FieldMapper. The options apply to the suffixes and field
+// names, for example, suffix.WithCaseFolding() matches "createdAt" with "at" too.
+func NewFieldMapper(opts ...suffix.Option) *FieldMapper {
+	return &FieldMapper{tree: suffix.NewKeyTree[string](opts...)}
+}
+
+// Handle maps the field name suffix to the value, replacing the value of the same suffix.
+// The empty suffix matches every field, as the fallback.
+func (m *FieldMapper) Handle(fieldSuffix string, value interface{}) {
+	m.tree.Insert(fieldSuffix, value)
+}
+
+// Remove removes the suffix, and reports whether it was mapped.
+func (m *FieldMapper) Remove(fieldSuffix string) bool {
+	_, found := m.tree.Remove(fieldSuffix)
+	return found
+}
+
+// Lookup returns the value of the longest suffix the field name ends with.
+func (m *FieldMapper) Lookup(field string) (value interface{}, found bool) {
+	_, value, found = m.tree.LongestSuffix(field)
+	return value, found
+}
+
+// Map returns the values of the fields of a record, in the order of fields. The value of a
+// field matching no suffix is nil.
+func (m *FieldMapper) Map(fields []string) []interface{} {
+	return m.AppendMap(make([]interface{}, 0, len(fields)), fields)
+}
+
+// AppendMap is like Map, but appends the values to dst and returns the extended slice, so a
+// log processor can reuse the slice across records.
+func (m *FieldMapper) AppendMap(dst []interface{}, fields []string) []interface{} {
+	for _, field := range fields {
+		_, value, _ := m.tree.LongestSuffix(field)
+		dst = append(dst, value)
+	}
+	return dst
+}
@@ -0,0 +1,42 @@
+package suffixrule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+func TestFieldMapper(t *testing.T) {
+	m := NewFieldMapper()
+	m.Handle("_at", "time")
+	m.Handle("_ms", "duration")
+	m.Handle("_id", "id")
+	m.Handle("_trace_id", "trace")
+
+	value, found := m.Lookup("created_at")
+	assert.True(t, found)
+	assert.Equal(t, "time", value)
+	_, found = m.Lookup("paid")
+	assert.False(t, found)
+
+	fields := []string{"user_id", "span_trace_id", "latency_ms", "message"}
+	assert.Equal(t, []interface{}{"id", "trace", "duration", nil}, m.Map(fields))
+
+	buf := m.AppendMap(nil, fields[:1])
+	buf = m.AppendMap(buf[:0], fields[2:])
+	assert.Equal(t, []interface{}{"duration", nil}, buf)
+
+	m.Handle("", "string")
+	assert.True(t, m.Remove("_trace_id"))
+	assert.False(t, m.Remove("_trace_id"))
+	assert.Equal(t, []interface{}{"id", "id", "duration", "string"}, m.Map(fields))
+}
+
+func TestFieldMapper_CaseFolding(t *testing.T) {
+	m := NewFieldMapper(suffix.WithCaseFolding())
+	m.Handle("at", "time")
+	value, _ := m.Lookup("createdAt")
+	assert.Equal(t, "time", value)
+}
@@ -0,0 +1,74 @@
+package suffixrule
+
+import (
+	"strings"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// localeEntry is a resource of LocaleResolver with its tag as added.
+type localeEntry struct {
+	tag      string
+	resource interface{}
+}
+
+// LocaleResolver stores resources, like message catalogs, by locale tags, and resolves a
+// requested tag to the best available resource by removing its subtags from the end: for
+// "pt-BR-sao", it tries "pt-BR-sao", then "pt-BR", and then "pt".
+//
+// The tags are case-insensitive, and "_" is the same as "-", so "pt_br" matches "pt-BR".
+// LocaleResolver is safe for concurrent reads, but not for writes.
+type LocaleResolver struct {
+	// The tags are stored reversed, so the longest matched prefix is found as a suffix
+	tags *suffix.PrefixView
+}
+
+// NewLocaleResolver creates an empty LocaleResolver.
+func NewLocaleResolver() *LocaleResolver {
+	return &LocaleResolver{tags: suffix.NewTree(suffix.WithSeparator('-')).AsPrefixTree()}
+}
+
+func normalizeLocale(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+// Add adds the resource of the tag, replacing the resource of the same tag.
+func (r *LocaleResolver) Add(tag string, resource interface{}) {
+	r.tags.Insert([]byte(normalizeLocale(tag)), &localeEntry{tag: tag, resource: resource})
+}
+
+// Remove removes the resource of the tag, and reports whether it was added.
+func (r *LocaleResolver) Remove(tag string) bool {
+	_, found := r.tags.Remove([]byte(normalizeLocale(tag)))
+	return found
+}
+
+// Resolve returns the resource for the requested tag, and the tag it was added with. The chain
+// is the forms of the requested tag tried in order, ending with the matched one, or all forms
+// if no resource is found.
+func (r *LocaleResolver) Resolve(tag string) (matched string, resource interface{},
+	chain []string, found bool) {
+
+	tag = normalizeLocale(tag)
+	if tag == "" {
+		return "", nil, nil, false
+	}
+	key, v, found := r.tags.LongestPrefix([]byte(tag))
+	// The empty tag may be added as the last resort, it isn't a form of the requested tag
+	for form := tag; ; {
+		if found && len(form) < len(key) {
+			break
+		}
+		chain = append(chain, form)
+		i := strings.LastIndexByte(form, '-')
+		if i < 0 {
+			break
+		}
+		form = form[:i]
+	}
+	if !found {
+		return "", nil, chain, false
+	}
+	e := v.(*localeEntry)
+	return e.tag, e.resource, chain, true
+}
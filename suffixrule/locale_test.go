@@ -0,0 +1,50 @@
+package suffixrule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocaleResolver(t *testing.T) {
+	r := NewLocaleResolver()
+	r.Add("pt", "pt")
+	r.Add("pt-BR", "pt-BR")
+	r.Add("zh-Hant", "zh-Hant")
+
+	matched, resource, chain, found := r.Resolve("pt-BR-sao")
+	assert.True(t, found)
+	assert.Equal(t, "pt-BR", matched)
+	assert.Equal(t, "pt-BR", resource)
+	assert.Equal(t, []string{"pt-br-sao", "pt-br"}, chain)
+
+	matched, _, chain, found = r.Resolve("pt_PT")
+	assert.True(t, found)
+	assert.Equal(t, "pt", matched)
+	assert.Equal(t, []string{"pt-pt", "pt"}, chain)
+
+	matched, _, _, found = r.Resolve("ZH-hant-TW")
+	assert.True(t, found)
+	assert.Equal(t, "zh-Hant", matched)
+
+	// "pt" isn't a subtag of "ptx"
+	_, _, chain, found = r.Resolve("ptx-A")
+	assert.False(t, found)
+	assert.Equal(t, []string{"ptx-a", "ptx"}, chain)
+
+	_, _, chain, found = r.Resolve("")
+	assert.False(t, found)
+	assert.Nil(t, chain)
+
+	r.Add("", "default")
+	matched, resource, chain, found = r.Resolve("en-US")
+	assert.True(t, found)
+	assert.Equal(t, "", matched)
+	assert.Equal(t, "default", resource)
+	assert.Equal(t, []string{"en-us", "en"}, chain)
+
+	assert.True(t, r.Remove("PT-br"))
+	assert.False(t, r.Remove("pt-BR"))
+	matched, _, _, _ = r.Resolve("pt-BR")
+	assert.Equal(t, "pt", matched)
+}
@@ -0,0 +1,124 @@
+package suffixrule
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// methodRule is a pattern of MethodMatcher with its value.
+type methodRule struct {
+	pattern string
+	value   interface{}
+}
+
+// MethodMatcher matches gRPC full method names, like "/pkg.v1.Service/Method", against
+// patterns, so the interceptors can apply policies like auth and rate limits by the
+// info.FullMethod of each call. The patterns are:
+//
+//	/pkg.v1.Service/Method  the method itself
+//	Service/Method          a suffix of the full method name, starting after a "." or "/",
+//	/Method                 like the method Method of the services named Service
+//	/pkg.v1.Service/*       all methods of the service
+//	Service/*               all methods of the services whose names end with the suffix,
+//	                        starting after a "."
+//
+// For a method, the longest matched method pattern wins, and then the longest matched service
+// pattern. MethodMatcher is safe for concurrent use.
+type MethodMatcher struct {
+	lock     sync.RWMutex
+	methods  *suffix.Tree
+	services *suffix.Tree
+}
+
+// NewMethodMatcher creates an empty MethodMatcher.
+func NewMethodMatcher() *MethodMatcher {
+	return &MethodMatcher{methods: suffix.NewTree(), services: suffix.NewTree()}
+}
+
+// parseMethodPattern returns the tree and the key of the pattern.
+func (m *MethodMatcher) parseMethodPattern(pattern string) (*suffix.Tree, string, error) {
+	i := strings.LastIndexByte(pattern, '/')
+	if i < 0 || i == len(pattern)-1 || strings.HasPrefix(pattern, ".") {
+		return nil, "", fmt.Errorf("suffixrule: invalid method pattern %q", pattern)
+	}
+	service, method := pattern[:i], pattern[i+1:]
+	if strings.Contains(service, "/") && !strings.HasPrefix(service, "/") ||
+		strings.Count(service, "/") > 1 || strings.Contains(service, "*") {
+
+		return nil, "", fmt.Errorf("suffixrule: invalid method pattern %q", pattern)
+	}
+	if method == "*" {
+		if service == "" || service == "/" {
+			return nil, "", fmt.Errorf("suffixrule: invalid method pattern %q", pattern)
+		}
+		return m.services, service, nil
+	}
+	if strings.Contains(method, "*") {
+		return nil, "", fmt.Errorf("suffixrule: invalid method pattern %q", pattern)
+	}
+	return m.methods, pattern, nil
+}
+
+// Handle maps the pattern to the value, replacing the value of the same pattern.
+func (m *MethodMatcher) Handle(pattern string, value interface{}) error {
+	tree, key, err := m.parseMethodPattern(pattern)
+	if err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	tree.Insert([]byte(key), &methodRule{pattern: pattern, value: value})
+	return nil
+}
+
+// Remove removes the pattern, and reports whether it was mapped.
+func (m *MethodMatcher) Remove(pattern string) bool {
+	tree, key, err := m.parseMethodPattern(pattern)
+	if err != nil {
+		return false
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	_, found := tree.Remove([]byte(key))
+	return found
+}
+
+// longestAtBoundary returns the rule of the longest key which is a suffix of name, and starts
+// at the beginning of name, with a "/", or after a "." or "/".
+func longestAtBoundary(tree *suffix.Tree, name string) (rule *methodRule) {
+	tree.AllSuffixesOf([]byte(name), func(key []byte, value interface{}) bool {
+		rest := name[:len(name)-len(key)]
+		if rest == "" || key[0] == '/' || rest[len(rest)-1] == '.' ||
+			rest[len(rest)-1] == '/' {
+
+			rule = value.(*methodRule)
+			return true
+		}
+		return false
+	})
+	return rule
+}
+
+// Match returns the pattern matching the full method name, and its value. found is false if
+// no pattern matches, or the name isn't like "/service/method".
+func (m *MethodMatcher) Match(fullMethod string) (pattern string, value interface{},
+	found bool) {
+
+	i := strings.LastIndexByte(fullMethod, '/')
+	if i <= 0 || i == len(fullMethod)-1 || fullMethod[0] != '/' {
+		return "", nil, false
+	}
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	rule := longestAtBoundary(m.methods, fullMethod)
+	if rule == nil {
+		rule = longestAtBoundary(m.services, fullMethod[:i])
+	}
+	if rule == nil {
+		return "", nil, false
+	}
+	return rule.pattern, rule.value, true
+}
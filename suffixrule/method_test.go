@@ -0,0 +1,55 @@
+package suffixrule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertMethodMatch(t *testing.T, m *MethodMatcher, fullMethod, pattern string) {
+	p, v, found := m.Match(fullMethod)
+	if pattern == "" {
+		assert.False(t, found, fullMethod)
+		return
+	}
+	assert.True(t, found, fullMethod)
+	assert.Equal(t, pattern, p, fullMethod)
+	assert.Equal(t, pattern, v, fullMethod)
+}
+
+func TestMethodMatcher(t *testing.T) {
+	m := NewMethodMatcher()
+	for _, pattern := range []string{
+		"/pkg.v1.Orders/Create", "Orders/Get", "/Health", "/pkg.v1.Orders/*", "v1.Admin/*",
+		"Admin/*",
+	} {
+		assert.Nil(t, m.Handle(pattern, pattern))
+	}
+
+	assertMethodMatch(t, m, "/pkg.v1.Orders/Create", "/pkg.v1.Orders/Create")
+	assertMethodMatch(t, m, "/pkg.v1.Orders/Get", "Orders/Get")
+	assertMethodMatch(t, m, "/pkg.v2.Orders/Get", "Orders/Get")
+	assertMethodMatch(t, m, "/PreOrders/Get", "")
+	assertMethodMatch(t, m, "/pkg.v1.Orders/Delete", "/pkg.v1.Orders/*")
+	assertMethodMatch(t, m, "/other.pkg.v1.Orders/Delete", "")
+	assertMethodMatch(t, m, "/pkg.Users/Health", "/Health")
+	assertMethodMatch(t, m, "/pkg.Users/CheckHealth", "")
+	assertMethodMatch(t, m, "/pkg.v1.Admin/Reset", "v1.Admin/*")
+	assertMethodMatch(t, m, "/pkg.v2.Admin/Reset", "Admin/*")
+	assertMethodMatch(t, m, "/SuperAdmin/Reset", "")
+	assertMethodMatch(t, m, "Orders/Get", "")
+	assertMethodMatch(t, m, "/Orders/", "")
+
+	assert.True(t, m.Remove("Orders/Get"))
+	assert.False(t, m.Remove("Orders/Get"))
+	assertMethodMatch(t, m, "/pkg.v1.Orders/Get", "/pkg.v1.Orders/*")
+}
+
+func TestMethodMatcher_InvalidPattern(t *testing.T) {
+	m := NewMethodMatcher()
+	for _, pattern := range []string{"", "Get", "Orders/", "/*", "a/b/c", "/Or*/Get",
+		"/Orders/G*", ".Orders/Get"} {
+
+		assert.NotNil(t, m.Handle(pattern, nil), pattern)
+	}
+}
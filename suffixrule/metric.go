@@ -0,0 +1,48 @@
+package suffixrule
+
+import (
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// MetricMatcher maps the suffixes of metric names, like "_total", "_bucket" or
+// "_seconds_sum" in Prometheus-style pipelines, to the actions relabeling or transforming
+// them. A metric is matched by the longest suffix it ends with.
+//
+// Match doesn't allocate, so it can be called for every sample of every scrape. MetricMatcher
+// is safe for concurrent reads, but not for writes.
+type MetricMatcher struct {
+	tree *suffix.KeyTree[string]
+}
+
+// NewMetricMatcher creates an empty MetricMatcher.
+func NewMetricMatcher() *MetricMatcher {
+	return &MetricMatcher{tree: suffix.NewKeyTree[string]()}
+}
+
+// Handle maps the metric name suffix to the action, replacing the action of the same suffix.
+func (m *MetricMatcher) Handle(metricSuffix string, action interface{}) {
+	m.tree.Insert(metricSuffix, action)
+}
+
+// Remove removes the suffix, and reports whether it was mapped.
+func (m *MetricMatcher) Remove(metricSuffix string) bool {
+	_, found := m.tree.Remove(metricSuffix)
+	return found
+}
+
+// Match returns the longest suffix the metric name ends with and its action. The base is the
+// name without the suffix, like "http_requests" for "http_requests_total".
+func (m *MetricMatcher) Match(name string) (base, metricSuffix string, action interface{},
+	found bool) {
+
+	metricSuffix, action, found = m.tree.LongestSuffix(name)
+	if !found {
+		return "", "", nil, false
+	}
+	return name[:len(name)-len(metricSuffix)], metricSuffix, action, true
+}
+
+// Len returns the number of suffixes.
+func (m *MetricMatcher) Len() int {
+	return m.tree.Len()
+}
@@ -0,0 +1,57 @@
+package suffixrule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricMatcher(t *testing.T) {
+	m := NewMetricMatcher()
+	m.Handle("_total", "counter")
+	m.Handle("_bucket", "histogram")
+	m.Handle("_sum", "summary")
+	m.Handle("_seconds_sum", "duration")
+	assert.Equal(t, 4, m.Len())
+
+	base, s, action, found := m.Match("http_requests_total")
+	assert.True(t, found)
+	assert.Equal(t, "http_requests", base)
+	assert.Equal(t, "_total", s)
+	assert.Equal(t, "counter", action)
+
+	base, s, action, _ = m.Match("rpc_duration_seconds_sum")
+	assert.Equal(t, "rpc_duration", base)
+	assert.Equal(t, "_seconds_sum", s)
+	assert.Equal(t, "duration", action)
+
+	_, _, _, found = m.Match("up")
+	assert.False(t, found)
+
+	assert.True(t, m.Remove("_seconds_sum"))
+	assert.False(t, m.Remove("_seconds_sum"))
+	_, s, _, _ = m.Match("rpc_duration_seconds_sum")
+	assert.Equal(t, "_sum", s)
+}
+
+func TestMetricMatcher_NoAllocation(t *testing.T) {
+	m := NewMetricMatcher()
+	m.Handle("_total", "counter")
+	m.Handle("_bucket", "histogram")
+	allocs := testing.AllocsPerRun(100, func() {
+		m.Match("http_request_duration_seconds_bucket")
+		m.Match("up")
+	})
+	assert.Equal(t, float64(0), allocs)
+}
+
+func BenchmarkMetricMatcher(b *testing.B) {
+	m := NewMetricMatcher()
+	for _, s := range []string{"_total", "_bucket", "_sum", "_count", "_seconds_sum", "_bytes"} {
+		m.Handle(s, s)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m.Match("http_request_duration_seconds_bucket")
+	}
+}
@@ -0,0 +1,179 @@
+// Package suffixrule matches names against the rules stored by their suffixes, like the topic
+// subscriptions of message brokers.
+package suffixrule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// subscription holds the subscribers of a pattern.
+type subscription struct {
+	segments []string
+	ids      map[string]struct{}
+}
+
+// SubscriptionSet matches message topics against the subscribed patterns. The topics and the
+// patterns are split into segments by ".", and a pattern matches the topics ending with its
+// segments, so ".orders.created" and "orders.created" both match "orders.created" and
+// "eu.orders.created", but not "preorders.created". A "*" segment in a pattern matches any
+// single segment, like "*.created" or "orders.*".
+//
+// The patterns are stored in a suffix tree by the segments after their last "*", so a topic
+// is only compared with the patterns sharing its suffix. SubscriptionSet is safe for
+// concurrent use.
+type SubscriptionSet struct {
+	lock sync.RWMutex
+	tree *suffix.Tree
+}
+
+// NewSubscriptionSet creates an empty SubscriptionSet.
+func NewSubscriptionSet() *SubscriptionSet {
+	return &SubscriptionSet{tree: suffix.NewTree(suffix.WithSeparator('.'))}
+}
+
+// splitTopic splits a topic or pattern into segments. A leading "." is ignored.
+func splitTopic(topic string) ([]string, bool) {
+	topic = strings.TrimPrefix(topic, ".")
+	if topic == "" {
+		return nil, false
+	}
+	segments := strings.Split(topic, ".")
+	for _, s := range segments {
+		if s == "" {
+			return nil, false
+		}
+	}
+	return segments, true
+}
+
+// parsePattern returns the segments of pattern, and the literal segments after its last "*"
+// joined as the key in the tree.
+func parsePattern(pattern string) (segments []string, key string, err error) {
+	segments, ok := splitTopic(pattern)
+	if !ok {
+		return nil, "", fmt.Errorf("suffixrule: invalid pattern %q", pattern)
+	}
+	last := -1
+	for i, s := range segments {
+		if s == "*" {
+			last = i
+		} else if strings.Contains(s, "*") {
+			return nil, "", fmt.Errorf("suffixrule: invalid wildcard in pattern %q", pattern)
+		}
+	}
+	return segments, strings.Join(segments[last+1:], "."), nil
+}
+
+// matchSegments reports whether the topic ends with the pattern segments.
+func matchSegments(pattern, topic []string) bool {
+	if len(pattern) > len(topic) {
+		return false
+	}
+	topic = topic[len(topic)-len(pattern):]
+	for i, s := range pattern {
+		if s != "*" && s != topic[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Subscribe adds the subscriber id to the pattern. It returns an error if the pattern has
+// empty segments, or a "*" mixed with other characters in a segment.
+func (s *SubscriptionSet) Subscribe(pattern, id string) error {
+	segments, key, err := parsePattern(pattern)
+	if err != nil {
+		return err
+	}
+	// "orders.created" and ".orders.created" are the same pattern
+	name := strings.Join(segments, ".")
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	subs := map[string]*subscription{}
+	if v, found := s.tree.Get([]byte(key)); found {
+		subs = v.(map[string]*subscription)
+	} else {
+		s.tree.Insert([]byte(key), subs)
+	}
+	sub, ok := subs[name]
+	if !ok {
+		sub = &subscription{segments: segments, ids: map[string]struct{}{}}
+		subs[name] = sub
+	}
+	sub.ids[id] = struct{}{}
+	return nil
+}
+
+// Unsubscribe removes the subscriber id from the pattern, and reports whether it was
+// subscribed.
+func (s *SubscriptionSet) Unsubscribe(pattern, id string) bool {
+	segments, key, err := parsePattern(pattern)
+	if err != nil {
+		return false
+	}
+	name := strings.Join(segments, ".")
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	v, found := s.tree.Get([]byte(key))
+	if !found {
+		return false
+	}
+	subs := v.(map[string]*subscription)
+	sub, ok := subs[name]
+	if !ok {
+		return false
+	}
+	if _, ok = sub.ids[id]; !ok {
+		return false
+	}
+	delete(sub.ids, id)
+	if len(sub.ids) == 0 {
+		delete(subs, name)
+		if len(subs) == 0 {
+			s.tree.Remove([]byte(key))
+		}
+	}
+	return true
+}
+
+// Match returns the sorted IDs of the subscribers whose patterns match the topic. Each ID is
+// returned once, even if it subscribes to several matched patterns.
+func (s *SubscriptionSet) Match(topic string) []string {
+	segments, ok := splitTopic(topic)
+	if !ok {
+		return nil
+	}
+	topic = strings.Join(segments, ".")
+	matched := map[string]struct{}{}
+
+	s.lock.RLock()
+	s.tree.AllSuffixesOf([]byte(topic), func(key []byte, value interface{}) bool {
+		for _, sub := range value.(map[string]*subscription) {
+			if !matchSegments(sub.segments, segments) {
+				continue
+			}
+			for id := range sub.ids {
+				matched[id] = struct{}{}
+			}
+		}
+		return false
+	})
+	s.lock.RUnlock()
+
+	if len(matched) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(matched))
+	for id := range matched {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,39 @@
+package suffixrule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionSet(t *testing.T) {
+	s := NewSubscriptionSet()
+	assert.Nil(t, s.Subscribe(".orders.created", "audit"))
+	assert.Nil(t, s.Subscribe("orders.created", "billing"))
+	assert.Nil(t, s.Subscribe("*.created", "stats"))
+	assert.Nil(t, s.Subscribe("eu.orders.*", "eu"))
+	assert.Nil(t, s.Subscribe("eu.*.created", "eu"))
+	assert.Nil(t, s.Subscribe("*", "all"))
+
+	assert.Equal(t, []string{"all", "audit", "billing", "stats"}, s.Match("orders.created"))
+	assert.Equal(t, []string{"all", "audit", "billing", "eu", "stats"},
+		s.Match("eu.orders.created"))
+	assert.Equal(t, []string{"all", "stats"}, s.Match("preorders.created"))
+	assert.Equal(t, []string{"all", "eu"}, s.Match("eu.orders.paid"))
+	assert.Equal(t, []string{"all"}, s.Match("created"))
+	assert.Nil(t, s.Match("a..b"))
+	assert.Nil(t, s.Match(""))
+
+	assert.True(t, s.Unsubscribe("orders.created", "audit"))
+	assert.False(t, s.Unsubscribe("orders.created", "audit"))
+	assert.True(t, s.Unsubscribe("*", "all"))
+	assert.False(t, s.Unsubscribe("*.paid", "stats"))
+	assert.Equal(t, []string{"billing", "stats"}, s.Match("orders.created"))
+}
+
+func TestSubscriptionSet_InvalidPattern(t *testing.T) {
+	s := NewSubscriptionSet()
+	for _, pattern := range []string{"", ".", "a..b", "a.", "ord*.created"} {
+		assert.NotNil(t, s.Subscribe(pattern, "id"), pattern)
+	}
+}
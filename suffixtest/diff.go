@@ -0,0 +1,80 @@
+package suffixtest
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+)
+
+// Subject is the API checked against Model by CheckAgainstModel. *suffix.Tree implements it,
+// and so should the wrappers of it.
+type Subject interface {
+	Target
+	LongestSuffix(key []byte) (matchedKey []byte, value interface{}, found bool)
+	HasSequence(key []byte) bool
+	Len() int
+	Walk(f func(key []byte, value interface{}) (stop bool))
+}
+
+// CheckAgainstModel performs n random operations on the keys against both the empty subject
+// and a Model, and returns an error describing the first difference. Besides comparing the
+// result of each operation, it queries LongestSuffix and HasSequence with random queries
+// derived from the keys, and compares the contents of both at the end.
+func CheckAgainstModel(subject Subject, keys [][]byte, n int, seed int64) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("suffixtest: no keys")
+	}
+	model := NewModel()
+	gen := NewGenerator(0, seed, keys)
+	r := rand.New(rand.NewSource(seed))
+	for i := 0; i < n; i++ {
+		op := gen.Next()
+		if got, want := Apply(subject, op), Apply(model, op); got != want {
+			return fmt.Errorf("suffixtest: op %d %v %q: got %v, want %v", i, op.Kind, op.Key,
+				got, want)
+		}
+
+		key := keys[r.Intn(len(keys))]
+		// A key with some bytes before it, so the keys it ends with may match
+		query := append(append([]byte{}, keys[r.Intn(len(keys))]...), key...)
+		gotKey, gotValue, gotFound := subject.LongestSuffix(query)
+		wantKey, wantValue, wantFound := model.LongestSuffix(query)
+		if gotFound != wantFound || !bytes.Equal(gotKey, wantKey) || gotValue != wantValue {
+			return fmt.Errorf("suffixtest: op %d LongestSuffix(%q): got %q %v %v, "+
+				"want %q %v %v", i, query, gotKey, gotValue, gotFound, wantKey, wantValue,
+				wantFound)
+		}
+
+		// A random part of a key
+		start := r.Intn(len(key) + 1)
+		seq := key[start : start+r.Intn(len(key)-start+1)]
+		if got, want := subject.HasSequence(seq), model.HasSequence(seq); got != want {
+			return fmt.Errorf("suffixtest: op %d HasSequence(%q): got %v, want %v", i, seq,
+				got, want)
+		}
+	}
+	return compareContents(subject, model)
+}
+
+// compareContents compares the keys and values of subject and model, regardless of the order.
+func compareContents(subject Subject, model *Model) error {
+	if got, want := subject.Len(), model.Len(); got != want {
+		return fmt.Errorf("suffixtest: Len: got %d, want %d", got, want)
+	}
+	var err error
+	walked := 0
+	subject.Walk(func(key []byte, value interface{}) bool {
+		walked++
+		want, found := model.Get(key)
+		if !found || want != value {
+			err = fmt.Errorf("suffixtest: Walk: got %q = %v, want %v (found %v)", key, value,
+				want, found)
+			return true
+		}
+		return false
+	})
+	if err == nil && walked != model.Len() {
+		err = fmt.Errorf("suffixtest: Walk: walked %d keys, want %d", walked, model.Len())
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package suffixtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// brokenTree forgets the keys ending with "x".
+type brokenTree struct {
+	*suffix.Tree
+}
+
+func (t brokenTree) Insert(key []byte, value interface{}) (interface{}, bool) {
+	if len(key) > 0 && key[len(key)-1] == 'x' {
+		return nil, true
+	}
+	return t.Tree.Insert(key, value)
+}
+
+func TestCheckAgainstModel(t *testing.T) {
+	for seed := int64(0); seed < 5; seed++ {
+		assert.Nil(t, CheckAgainstModel(suffix.NewTree(), testKeys, 500, seed))
+	}
+	assert.NotNil(t, CheckAgainstModel(brokenTree{suffix.NewTree()},
+		[][]byte{[]byte("ax"), []byte("x")}, 100, 1))
+	assert.NotNil(t, CheckAgainstModel(suffix.NewTree(), nil, 100, 1))
+}
+
+func TestModel_Walk(t *testing.T) {
+	model := NewModel()
+	for _, s := range []string{"table", "able", "cable", "edible"} {
+		model.Insert([]byte(s), s)
+	}
+	keys := []string{}
+	model.WalkSuffix([]byte("able"), func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return false
+	})
+	assert.Equal(t, []string{"able", "cable", "table"}, keys)
+
+	keys = keys[:0]
+	model.Walk(func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return len(keys) == 2
+	})
+	assert.Equal(t, []string{"able", "cable"}, keys)
+}
@@ -0,0 +1,104 @@
+package suffixtest
+
+import (
+	"bufio"
+	"os"
+	"testing"
+)
+
+// DecodeOps decodes the input of a fuzz target into operations, so any input is a valid
+// sequence of operations. Each operation is encoded as:
+//
+//	kind byte: the OpKind is kind % 5, and the OldValue of CompareAndSwap and
+//	           CompareAndDelete is int(kind / 5)
+//	len byte:  the length of the key, truncated to the rest of the input
+//	key bytes
+//
+// The Value of the i-th operation is i, so the OldValue can refer to the value inserted by an
+// earlier operation. A trailing byte without the length is ignored.
+func DecodeOps(data []byte) []Op {
+	var ops []Op
+	for len(data) >= 2 {
+		kind, n := data[0], int(data[1])
+		data = data[2:]
+		if n > len(data) {
+			n = len(data)
+		}
+		op := Op{
+			Kind: OpKind(kind % 5),
+			Key:  append([]byte{}, data[:n]...),
+		}
+		data = data[n:]
+		switch op.Kind {
+		case OpInsert:
+			op.Value = len(ops)
+		case OpCompareAndSwap:
+			op.Value = len(ops)
+			op.OldValue = int(kind / 5)
+		case OpCompareAndDelete:
+			op.OldValue = int(kind / 5)
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// EncodeOps encodes the kinds and keys of operations in the format of DecodeOps, to build the
+// seed inputs. The values are not encoded, and the keys longer than 255 bytes are truncated.
+func EncodeOps(ops []Op) []byte {
+	var data []byte
+	for _, op := range ops {
+		key := op.Key
+		if len(key) > 255 {
+			key = key[:255]
+		}
+		data = append(data, byte(op.Kind), byte(len(key)))
+		data = append(data, key...)
+	}
+	return data
+}
+
+// ReadKeys reads the keys from the file at path, one key per line.
+func ReadKeys(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var keys [][]byte
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		keys = append(keys, append([]byte{}, scanner.Bytes()...))
+	}
+	return keys, scanner.Err()
+}
+
+// SeedOps returns the seed inputs built from the keys for the fuzz targets taking the input
+// of DecodeOps: one inserting all keys, one inserting all keys then removing them in reverse,
+// and one looking each key up and inserting it.
+func SeedOps(keys [][]byte) [][]byte {
+	var insertAll, removeAll, lookups []Op
+	for _, key := range keys {
+		insertAll = append(insertAll, Op{Kind: OpInsert, Key: key})
+		lookups = append(lookups, Op{Kind: OpGet, Key: key}, Op{Kind: OpInsert, Key: key})
+	}
+	removeAll = append(removeAll, insertAll...)
+	for i := len(keys) - 1; i >= 0; i-- {
+		removeAll = append(removeAll, Op{Kind: OpRemove, Key: keys[i]})
+	}
+	return [][]byte{EncodeOps(insertAll), EncodeOps(removeAll), EncodeOps(lookups)}
+}
+
+// SeedCorpus adds the seed inputs built by SeedOps from the key file at path to the fuzz
+// target. The extra arguments are appended to each input, for the targets taking more
+// arguments.
+func SeedCorpus(f *testing.F, path string, extra ...interface{}) error {
+	keys, err := ReadKeys(path)
+	if err != nil {
+		return err
+	}
+	for _, seed := range SeedOps(keys) {
+		f.Add(append([]interface{}{seed}, extra...)...)
+	}
+	return nil
+}
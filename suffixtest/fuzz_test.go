@@ -0,0 +1,51 @@
+package suffixtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeOps(t *testing.T) {
+	ops := DecodeOps([]byte{0, 2, 'a', 'b', 8, 1, 'b', 9, 1, 'c', 7})
+	assert.Equal(t, []Op{
+		{Kind: OpInsert, Key: []byte("ab"), Value: 0},
+		{Kind: OpCompareAndSwap, Key: []byte("b"), Value: 1, OldValue: 1},
+		{Kind: OpCompareAndDelete, Key: []byte("c"), OldValue: 1},
+	}, ops)
+	assert.Nil(t, DecodeOps([]byte{1}))
+	assert.Equal(t, []Op{{Kind: OpGet, Key: []byte{}}}, DecodeOps([]byte{1, 0}))
+}
+
+func TestEncodeOps(t *testing.T) {
+	ops := []Op{
+		{Kind: OpInsert, Key: []byte("abc")},
+		{Kind: OpRemove, Key: []byte("")},
+	}
+	data := EncodeOps(ops)
+	assert.Equal(t, []byte{0, 3, 'a', 'b', 'c', 2, 0}, data)
+	decoded := DecodeOps(data)
+	assert.Equal(t, OpInsert, decoded[0].Kind)
+	assert.Equal(t, []byte("abc"), decoded[0].Key)
+	assert.Equal(t, OpRemove, decoded[1].Kind)
+}
+
+func TestSeedOps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("com\nexample.com\n"), 0o644))
+	keys, err := ReadKeys(path)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("com"), []byte("example.com")}, keys)
+
+	seeds := SeedOps(keys)
+	assert.Equal(t, 3, len(seeds))
+	assert.Equal(t, 2, len(DecodeOps(seeds[0])))
+	assert.Equal(t, 4, len(DecodeOps(seeds[1])))
+	assert.Equal(t, OpRemove, DecodeOps(seeds[1])[2].Kind)
+	assert.Equal(t, []byte("example.com"), DecodeOps(seeds[1])[2].Key)
+
+	_, err = ReadKeys(filepath.Join(t.TempDir(), "missing"))
+	assert.NotNil(t, err)
+}
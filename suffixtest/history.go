@@ -0,0 +1,294 @@
+package suffixtest
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Target is the part of the tree API which can be checked for linearizability.
+// *suffix.ConcurrentTree implements it.
+type Target interface {
+	Insert(key []byte, value interface{}) (oldValue interface{}, ok bool)
+	Get(key []byte) (value interface{}, found bool)
+	Remove(key []byte) (oldValue interface{}, found bool)
+	CompareAndSwap(key []byte, oldValue, newValue interface{}) (swapped bool)
+	CompareAndDelete(key []byte, oldValue interface{}) (deleted bool)
+}
+
+// OpKind is the kind of an Op.
+type OpKind int
+
+// Kinds of Op
+const (
+	OpInsert OpKind = iota
+	OpGet
+	OpRemove
+	OpCompareAndSwap
+	OpCompareAndDelete
+)
+
+var opKindNames = []string{"Insert", "Get", "Remove", "CompareAndSwap", "CompareAndDelete"}
+
+func (kind OpKind) String() string {
+	if int(kind) < len(opKindNames) {
+		return opKindNames[kind]
+	}
+	return fmt.Sprintf("OpKind(%d)", int(kind))
+}
+
+// Op is an operation on a single key.
+type Op struct {
+	Kind OpKind
+	Key  []byte
+	// The value to insert or swap in
+	Value interface{}
+	// The value to compare with
+	OldValue interface{}
+}
+
+// Result is the result of an Op. Value is the returned value, OK is the returned boolean.
+type Result struct {
+	Value interface{}
+	OK    bool
+}
+
+// Apply performs op on target and returns its result.
+func Apply(target Target, op Op) Result {
+	switch op.Kind {
+	case OpInsert:
+		value, ok := target.Insert(op.Key, op.Value)
+		return Result{value, ok}
+	case OpGet:
+		value, found := target.Get(op.Key)
+		return Result{value, found}
+	case OpRemove:
+		value, found := target.Remove(op.Key)
+		return Result{value, found}
+	case OpCompareAndSwap:
+		return Result{OK: target.CompareAndSwap(op.Key, op.OldValue, op.Value)}
+	case OpCompareAndDelete:
+		return Result{OK: target.CompareAndDelete(op.Key, op.OldValue)}
+	}
+	panic(fmt.Sprintf("suffixtest: unknown op %v", op.Kind))
+}
+
+// Value is the type of values generated by Generator. Each generated value is unique, so
+// the checker can tell which Insert a read observes.
+type Value struct {
+	Generator int
+	Seq       int
+}
+
+// Generator generates random operations on a fixed set of keys.
+type Generator struct {
+	id     int
+	keys   [][]byte
+	rand   *rand.Rand
+	seq    int
+	values []interface{}
+}
+
+// NewGenerator creates a Generator. Generators with different id generate different values.
+func NewGenerator(id int, seed int64, keys [][]byte) *Generator {
+	return &Generator{
+		id:   id,
+		keys: keys,
+		rand: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Next returns a random operation.
+func (g *Generator) Next() Op {
+	op := Op{
+		Kind: OpKind(g.rand.Intn(len(opKindNames))),
+		Key:  g.keys[g.rand.Intn(len(g.keys))],
+	}
+	switch op.Kind {
+	case OpInsert, OpCompareAndSwap:
+		g.seq++
+		op.Value = Value{g.id, g.seq}
+	}
+	switch op.Kind {
+	case OpCompareAndSwap, OpCompareAndDelete:
+		// Compare with a value we have generated, which may or may not be the current one
+		if len(g.values) > 0 {
+			op.OldValue = g.values[g.rand.Intn(len(g.values))]
+		}
+	}
+	if op.Value != nil {
+		g.values = append(g.values, op.Value)
+	}
+	return op
+}
+
+// Event is an Op performed by a client, with its Result and the logical time it was called
+// and returned.
+type Event struct {
+	Op
+	Result
+	Client int
+	Call   int64
+	Return int64
+}
+
+func (event Event) String() string {
+	return fmt.Sprintf("client %d [%d, %d] %v(%q, %v, %v) = (%v, %v)", event.Client,
+		event.Call, event.Return, event.Kind, event.Key, event.OldValue, event.Op.Value,
+		event.Result.Value, event.OK)
+}
+
+// History is the record of concurrent operations.
+type History []Event
+
+// Run performs opsPerClient random operations from each of clients goroutines on target
+// concurrently, and returns the recorded history.
+func Run(target Target, keys [][]byte, clients, opsPerClient int, seed int64) History {
+	var clock int64
+	histories := make([]History, clients)
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(client int) {
+			defer wg.Done()
+			g := NewGenerator(client, seed+int64(client), keys)
+			for j := 0; j < opsPerClient; j++ {
+				op := g.Next()
+				call := atomic.AddInt64(&clock, 1)
+				result := Apply(target, op)
+				ret := atomic.AddInt64(&clock, 1)
+				histories[client] = append(histories[client], Event{
+					Op:     op,
+					Result: result,
+					Client: client,
+					Call:   call,
+					Return: ret,
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var history History
+	for _, h := range histories {
+		history = append(history, h...)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Call < history[j].Call
+	})
+	return history
+}
+
+// CheckLinearizable returns an error if the history can't be explained by performing its
+// operations one at a time on a Model, in an order which respects the real time order of
+// non-overlapping operations. As every Op touches a single key, each key is checked
+// independently.
+func CheckLinearizable(history History) error {
+	byKey := map[string]History{}
+	for _, event := range history {
+		byKey[string(event.Key)] = append(byKey[string(event.Key)], event)
+	}
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		events := byKey[key]
+		c := &checker{
+			events:  events,
+			done:    make([]bool, len(events)),
+			visited: map[string]bool{},
+		}
+		if !c.search(keyState{}, len(events)) {
+			lines := make([]string, len(events))
+			for i, event := range events {
+				lines[i] = event.String()
+			}
+			return fmt.Errorf("suffixtest: history of key %q is not linearizable:\n%s",
+				key, strings.Join(lines, "\n"))
+		}
+	}
+	return nil
+}
+
+type keyState struct {
+	present bool
+	value   interface{}
+}
+
+func (state keyState) step(op Op) (keyState, Result) {
+	switch op.Kind {
+	case OpInsert:
+		return keyState{true, op.Value}, Result{state.value, true}
+	case OpGet:
+		return state, Result{state.value, state.present}
+	case OpRemove:
+		return keyState{}, Result{state.value, state.present}
+	case OpCompareAndSwap:
+		if state.present && state.value == op.OldValue {
+			return keyState{true, op.Value}, Result{OK: true}
+		}
+		return state, Result{OK: false}
+	case OpCompareAndDelete:
+		if state.present && state.value == op.OldValue {
+			return keyState{}, Result{OK: true}
+		}
+		return state, Result{OK: false}
+	}
+	panic(fmt.Sprintf("suffixtest: unknown op %v", op.Kind))
+}
+
+// checker searches the linearization of a single key's history, as described in
+// "Testing for Linearizability" by Gavin Lowe.
+type checker struct {
+	events History
+	done   []bool
+	// Combinations of linearized events and state which are known to fail
+	visited map[string]bool
+}
+
+func (c *checker) search(state keyState, remaining int) bool {
+	if remaining == 0 {
+		return true
+	}
+	var sig strings.Builder
+	for _, done := range c.done {
+		if done {
+			sig.WriteByte('1')
+		} else {
+			sig.WriteByte('0')
+		}
+	}
+	fmt.Fprintf(&sig, "%v:%#v", state.present, state.value)
+	if c.visited[sig.String()] {
+		return false
+	}
+
+	// Only the events called before the earliest return can be linearized first
+	var minReturn int64 = -1
+	for i, event := range c.events {
+		if !c.done[i] && (minReturn < 0 || event.Return < minReturn) {
+			minReturn = event.Return
+		}
+	}
+	for i, event := range c.events {
+		if c.done[i] || event.Call > minReturn {
+			continue
+		}
+		next, result := state.step(event.Op)
+		if result != event.Result {
+			continue
+		}
+		c.done[i] = true
+		if c.search(next, remaining-1) {
+			return true
+		}
+		c.done[i] = false
+	}
+	c.visited[sig.String()] = true
+	return false
+}
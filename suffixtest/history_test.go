@@ -0,0 +1,119 @@
+package suffixtest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+var testKeys = [][]byte{
+	[]byte(""), []byte("able"), []byte("table"), []byte("presentable"), []byte("word"),
+}
+
+// lockedTree makes a Tree safe for concurrent use with a single lock.
+type lockedTree struct {
+	mu   sync.Mutex
+	tree *suffix.Tree
+}
+
+func (t *lockedTree) Insert(key []byte, value interface{}) (interface{}, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tree.Insert(key, value)
+}
+
+func (t *lockedTree) Get(key []byte) (interface{}, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tree.Get(key)
+}
+
+func (t *lockedTree) Remove(key []byte) (interface{}, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tree.Remove(key)
+}
+
+func (t *lockedTree) CompareAndSwap(key []byte, oldValue, newValue interface{}) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tree.CompareAndSwap(key, oldValue, newValue)
+}
+
+func (t *lockedTree) CompareAndDelete(key []byte, oldValue interface{}) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tree.CompareAndDelete(key, oldValue)
+}
+
+func TestRun_ConcurrentTree(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		history := Run(suffix.NewConcurrentTree(), testKeys, 4, 100, seed)
+		assert.Equal(t, 400, len(history))
+		assert.Nil(t, CheckLinearizable(history))
+	}
+}
+
+func TestRun_LockedTree(t *testing.T) {
+	history := Run(&lockedTree{tree: suffix.NewTree()}, testKeys, 4, 100, 0)
+	assert.Nil(t, CheckLinearizable(history))
+}
+
+func TestCheckLinearizable(t *testing.T) {
+	key := []byte("sth")
+	v1 := Value{0, 1}
+	v2 := Value{1, 1}
+	insert := Event{Op: Op{Kind: OpInsert, Key: key, Value: v1},
+		Result: Result{nil, true}, Client: 0, Call: 1, Return: 2}
+
+	// Get returns after the insertion, but can't see it
+	history := History{insert, {Op: Op{Kind: OpGet, Key: key},
+		Result: Result{nil, false}, Client: 1, Call: 3, Return: 4}}
+	assert.NotNil(t, CheckLinearizable(history))
+
+	// Overlapped with the insertion, so both results are fine
+	insert.Return = 4
+	history = History{insert, {Op: Op{Kind: OpGet, Key: key},
+		Result: Result{nil, false}, Client: 1, Call: 2, Return: 3}}
+	assert.Nil(t, CheckLinearizable(history))
+	history[1].Result = Result{v1, true}
+	assert.Nil(t, CheckLinearizable(history))
+
+	// Both swaps can't succeed
+	history = History{insert,
+		{Op: Op{Kind: OpCompareAndSwap, Key: key, OldValue: v1, Value: v2},
+			Result: Result{OK: true}, Client: 1, Call: 5, Return: 8},
+		{Op: Op{Kind: OpCompareAndDelete, Key: key, OldValue: v1},
+			Result: Result{OK: true}, Client: 2, Call: 6, Return: 7},
+	}
+	assert.NotNil(t, CheckLinearizable(history))
+	history[2].OldValue = v2
+	assert.Nil(t, CheckLinearizable(history))
+
+	// Keys are checked independently
+	history = append(history, Event{Op: Op{Kind: OpRemove, Key: []byte("else")},
+		Result: Result{nil, false}, Client: 3, Call: 1, Return: 9})
+	assert.Nil(t, CheckLinearizable(history))
+}
+
+func TestGenerator(t *testing.T) {
+	g1 := NewGenerator(1, 42, testKeys)
+	g2 := NewGenerator(1, 42, testKeys)
+	kinds := map[OpKind]bool{}
+	values := map[interface{}]bool{}
+	for i := 0; i < 100; i++ {
+		op := g1.Next()
+		assert.Equal(t, op, g2.Next())
+		kinds[op.Kind] = true
+		if op.Kind == OpInsert || op.Kind == OpCompareAndSwap {
+			assert.False(t, values[op.Value])
+			values[op.Value] = true
+		}
+	}
+	assert.Equal(t, len(opKindNames), len(kinds))
+	assert.Equal(t, "CompareAndDelete", OpCompareAndDelete.String())
+	assert.Equal(t, "OpKind(9)", OpKind(9).String())
+}
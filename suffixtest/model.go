@@ -0,0 +1,125 @@
+// Package suffixtest provides utilities for testing the suffix package and the code built on
+// it: a map-based reference model with a randomized differential checker against it, the
+// helpers of fuzz targets, testing/quick generators of keys with a shrinker, a concurrent
+// operation generator and a linearizability checker for the histories it records.
+package suffixtest
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Model is a naive implementation of the suffix tree API, built on a map. It is slow but
+// obviously correct, so it can be used as the expected behavior of a tree.
+type Model struct {
+	entries map[string]interface{}
+}
+
+// NewModel creates an empty Model.
+func NewModel() *Model {
+	return &Model{
+		entries: map[string]interface{}{},
+	}
+}
+
+// Insert is like suffix.Tree.Insert.
+func (m *Model) Insert(key []byte, value interface{}) (oldValue interface{}, ok bool) {
+	if key == nil {
+		return nil, false
+	}
+	oldValue = m.entries[string(key)]
+	m.entries[string(key)] = value
+	return oldValue, true
+}
+
+// Get is like suffix.Tree.Get.
+func (m *Model) Get(key []byte) (value interface{}, found bool) {
+	if key == nil {
+		return nil, false
+	}
+	value, found = m.entries[string(key)]
+	return value, found
+}
+
+// LongestSuffix is like suffix.Tree.LongestSuffix.
+func (m *Model) LongestSuffix(key []byte) (matchedKey []byte, value interface{}, found bool) {
+	if key == nil {
+		return nil, nil, false
+	}
+	for k, v := range m.entries {
+		if bytes.HasSuffix(key, []byte(k)) && (!found || len(k) > len(matchedKey)) {
+			matchedKey, value, found = []byte(k), v, true
+		}
+	}
+	return matchedKey, value, found
+}
+
+// Remove is like suffix.Tree.Remove.
+func (m *Model) Remove(key []byte) (oldValue interface{}, found bool) {
+	if key == nil {
+		return nil, false
+	}
+	oldValue, found = m.entries[string(key)]
+	delete(m.entries, string(key))
+	return oldValue, found
+}
+
+// CompareAndSwap is like suffix.Tree.CompareAndSwap.
+func (m *Model) CompareAndSwap(key []byte, oldValue, newValue interface{}) (swapped bool) {
+	value, found := m.Get(key)
+	if !found || value != oldValue {
+		return false
+	}
+	m.entries[string(key)] = newValue
+	return true
+}
+
+// CompareAndDelete is like suffix.Tree.CompareAndDelete.
+func (m *Model) CompareAndDelete(key []byte, oldValue interface{}) (deleted bool) {
+	value, found := m.Get(key)
+	if !found || value != oldValue {
+		return false
+	}
+	delete(m.entries, string(key))
+	return true
+}
+
+// Len is like suffix.Tree.Len.
+func (m *Model) Len() int {
+	return len(m.entries)
+}
+
+// HasSequence is like suffix.Tree.HasSequence.
+func (m *Model) HasSequence(key []byte) bool {
+	if key == nil {
+		return false
+	}
+	for k := range m.entries {
+		if bytes.Contains([]byte(k), key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk is like suffix.Tree.Walk, but the keys are walked in the order of their bytes.
+func (m *Model) Walk(f func(key []byte, value interface{}) (stop bool)) {
+	m.WalkSuffix(nil, f)
+}
+
+// WalkSuffix is like suffix.Tree.WalkSuffix, but the keys are walked in the order of their
+// bytes.
+func (m *Model) WalkSuffix(suffix []byte, f func(key []byte, value interface{}) (stop bool)) {
+	keys := make([]string, 0, len(m.entries))
+	for k := range m.entries {
+		if bytes.HasSuffix([]byte(k), suffix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if f([]byte(k), m.entries[k]) {
+			return
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package suffixtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+func TestModel(t *testing.T) {
+	words := []string{
+		"edible", "presentable", "abominable", "credible", "table", "", "able",
+	}
+	model := NewModel()
+	tree := suffix.NewTree()
+	for _, s := range words {
+		model.Insert([]byte(s), s)
+		tree.Insert([]byte(s), s)
+	}
+	assert.Equal(t, tree.Len(), model.Len())
+
+	for _, s := range []string{"vegetable", "edible", "unable", "credible", "xyz", ""} {
+		expectedKey, expectedValue, expectedFound := tree.LongestSuffix([]byte(s))
+		key, value, found := model.LongestSuffix([]byte(s))
+		assert.Equal(t, string(expectedKey), string(key))
+		assert.Equal(t, expectedValue, value)
+		assert.Equal(t, expectedFound, found)
+	}
+	for _, s := range []string{"bl", "xyz", "ed", ""} {
+		assert.Equal(t, tree.HasSequence([]byte(s)), model.HasSequence([]byte(s)), s)
+	}
+
+	_, ok := model.Insert(nil, nil)
+	assert.False(t, ok)
+	_, found := model.Get(nil)
+	assert.False(t, found)
+	_, _, found = model.LongestSuffix(nil)
+	assert.False(t, found)
+	_, found = model.Remove(nil)
+	assert.False(t, found)
+	assert.False(t, model.HasSequence(nil))
+
+	assert.False(t, model.CompareAndSwap([]byte("table"), "chair", "desk"))
+	assert.True(t, model.CompareAndSwap([]byte("table"), "table", "chair"))
+	assert.False(t, model.CompareAndDelete([]byte("table"), "table"))
+	assert.True(t, model.CompareAndDelete([]byte("table"), "chair"))
+	oldValue, found := model.Remove([]byte("able"))
+	assert.True(t, found)
+	assert.Equal(t, "able", oldValue)
+	assert.Equal(t, len(words)-2, model.Len())
+}
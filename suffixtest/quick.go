@@ -0,0 +1,134 @@
+package suffixtest
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+)
+
+// The tails shared by the generated keys, so they often share suffixes with each other
+var quickTails = [][]byte{
+	[]byte("com"), []byte(".com"), []byte("example.com"), []byte("/index.html"),
+	[]byte("\x00"), []byte("\xff\xfe"),
+}
+
+// RandomKey returns a key chosen to hit the corner cases of a suffix tree: the empty key,
+// binary data, keys from a small alphabet which share bytes by chance, keys ending with a
+// few common tails, and long runs of a byte which make deep chains of nodes. size bounds the
+// length of the random parts, like the size of testing/quick.
+func RandomKey(r *rand.Rand, size int) []byte {
+	switch r.Intn(6) {
+	case 0:
+		return []byte{}
+	case 1:
+		key := make([]byte, r.Intn(size+1))
+		r.Read(key)
+		return key
+	case 2:
+		return append(smallAlphabet(r, r.Intn(4)), quickTails[r.Intn(len(quickTails))]...)
+	case 3:
+		key := smallAlphabet(r, 1)
+		return append(key, bytes.Repeat([]byte{'x'}, 4*size+r.Intn(size+1))...)
+	case 4:
+		return smallAlphabet(r, r.Intn(size+1))
+	default:
+		return append([]byte{}, quickTails[r.Intn(len(quickTails))]...)
+	}
+}
+
+func smallAlphabet(r *rand.Rand, n int) []byte {
+	const alphabet = "ab."
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return b
+}
+
+// RandomKeys returns up to size distinct keys, to be inserted into a tree. Besides the keys of
+// RandomKey, many keys are a previous key with bytes added before it, or one of its suffixes,
+// so the keys are suffixes of each other and the edges of the tree get split and merged.
+func RandomKeys(r *rand.Rand, size int) [][]byte {
+	seen := map[string]bool{}
+	var keys [][]byte
+	for n := r.Intn(size + 1); n > 0; n-- {
+		var key []byte
+		switch {
+		case len(keys) == 0 || r.Intn(3) == 0:
+			key = RandomKey(r, size)
+		case r.Intn(2) == 0:
+			prev := keys[r.Intn(len(keys))]
+			key = append(smallAlphabet(r, 1+r.Intn(3)), prev...)
+		default:
+			prev := keys[r.Intn(len(keys))]
+			key = append([]byte{}, prev[r.Intn(len(prev)+1):]...)
+		}
+		if !seen[string(key)] {
+			seen[string(key)] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Key is a key implementing testing/quick.Generator with RandomKey.
+type Key []byte
+
+// Generate implements testing/quick.Generator.
+func (Key) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(Key(RandomKey(r, size)))
+}
+
+// Keys is a set of keys implementing testing/quick.Generator with RandomKeys, to generate
+// whole trees.
+type Keys [][]byte
+
+// Generate implements testing/quick.Generator.
+func (Keys) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(Keys(RandomKeys(r, size)))
+}
+
+// ShrinkKeys returns a smaller set of keys for which fails still returns true, to turn the
+// keys failing a property into a readable counterexample. It drops the keys, then the bytes
+// of the remaining keys, first from the start since the tree compares keys from the end. fails
+// must return true for keys, and must not keep or modify the slices it gets.
+func ShrinkKeys(keys [][]byte, fails func(keys [][]byte) bool) [][]byte {
+	keys = append([][]byte{}, keys...)
+	for shrunk := true; shrunk; {
+		shrunk = false
+		// Drop chunks of keys, from the halves to the single keys
+		for chunk := (len(keys) + 1) / 2; chunk > 0; chunk /= 2 {
+			for i := 0; i+chunk <= len(keys); {
+				candidate := append(append([][]byte{}, keys[:i]...), keys[i+chunk:]...)
+				if fails(candidate) {
+					keys, shrunk = candidate, true
+				} else {
+					i += chunk
+				}
+			}
+		}
+		for i := range keys {
+			for _, shorter := range shorterKeys(keys[i]) {
+				candidate := append([][]byte{}, keys...)
+				candidate[i] = shorter
+				if fails(candidate) {
+					keys, shrunk = candidate, true
+					break
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// shorterKeys returns the keys made by removing one or more bytes from key.
+func shorterKeys(key []byte) [][]byte {
+	var keys [][]byte
+	if len(key) > 1 {
+		keys = append(keys, key[len(key)/2:], key[:len(key)/2])
+	}
+	for i := range key {
+		keys = append(keys, append(append([]byte{}, key[:i]...), key[i+1:]...))
+	}
+	return keys
+}
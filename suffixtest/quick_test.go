@@ -0,0 +1,79 @@
+package suffixtest
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomKey(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var empty, long, binary, shared int
+	for i := 0; i < 1000; i++ {
+		key := RandomKey(r, 10)
+		switch {
+		case len(key) == 0:
+			empty++
+		case len(key) >= 40:
+			long++
+		case bytes.HasSuffix(key, []byte("com")):
+			shared++
+		case bytes.IndexFunc(key, func(c rune) bool { return c > 0x7f }) >= 0:
+			binary++
+		}
+	}
+	for _, n := range []int{empty, long, binary, shared} {
+		assert.True(t, n > 50, n)
+	}
+}
+
+func TestRandomKeys(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		keys := RandomKeys(r, 20)
+		assert.True(t, len(keys) <= 20)
+		seen := map[string]bool{}
+		for _, key := range keys {
+			assert.False(t, seen[string(key)])
+			seen[string(key)] = true
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	assert.Nil(t, quick.Check(func(keys Keys, key Key) bool {
+		model := NewModel()
+		for _, k := range keys {
+			model.Insert(k, nil)
+		}
+		model.Insert(key, nil)
+		_, found := model.Get(key)
+		return found && model.Len() <= len(keys)+1
+	}, nil))
+}
+
+func TestShrinkKeys(t *testing.T) {
+	// Fails if a key ends with "z" and another key ends with it
+	fails := func(keys [][]byte) bool {
+		for _, a := range keys {
+			for _, b := range keys {
+				if len(a) > len(b) && bytes.HasSuffix(b, []byte("z")) && bytes.HasSuffix(a, b) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	keys := [][]byte{
+		[]byte("abc"), []byte("xyz"), []byte("com"), []byte("foo.xyz"), []byte(""),
+		[]byte("bar"),
+	}
+	assert.True(t, fails(keys))
+	shrunk := ShrinkKeys(keys, fails)
+	assert.Equal(t, [][]byte{[]byte("z"), []byte("yz")}, shrunk)
+	// The input is not modified
+	assert.Equal(t, []byte("foo.xyz"), keys[3])
+}
@@ -0,0 +1,169 @@
+// Package suffixtls selects TLS certificates by the server names requested by clients.
+package suffixtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// entry holds the certificates registered for a domain.
+type entry struct {
+	// For "example.com"
+	exact *tls.Certificate
+	// For "*.example.com"
+	wildcard *tls.Certificate
+	// For ".example.com"
+	subdomains *tls.Certificate
+}
+
+// Selector stores certificates by host name patterns, and selects one for each TLS handshake
+// by the server name of the client. Set its GetCertificate method as tls.Config.GetCertificate.
+//
+// The patterns are:
+//
+//	example.com    the host itself
+//	*.example.com  the hosts one label below example.com, like www.example.com, as wildcard
+//	               certificates match
+//	.example.com   all hosts below example.com, at any depth
+//
+// For a server name, the exact pattern is tried first, then the wildcard, and then the
+// longest ".suffix" pattern. The matching is case-insensitive and label by label.
+//
+// Selector is safe for concurrent use.
+type Selector struct {
+	// Default is returned when no pattern matches, including the clients which send no
+	// server name. If it is nil, GetCertificate returns an error instead.
+	Default *tls.Certificate
+
+	lock sync.RWMutex
+	tree *suffix.Tree
+}
+
+// NewSelector creates an empty Selector.
+func NewSelector() *Selector {
+	return &Selector{tree: suffix.NewTree(suffix.WithSeparator('.'))}
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// Add registers the certificate for the pattern, replacing the certificate registered for the
+// same pattern.
+func (s *Selector) Add(pattern string, cert *tls.Certificate) error {
+	if cert == nil {
+		return errors.New("suffixtls: nil certificate")
+	}
+	name := normalizeName(pattern)
+	kind := &entry{}
+	switch {
+	case strings.HasPrefix(name, "*."):
+		name = name[2:]
+		kind.wildcard = cert
+	case strings.HasPrefix(name, "."):
+		name = name[1:]
+		kind.subdomains = cert
+	default:
+		kind.exact = cert
+	}
+	if name == "" || strings.Contains(name, "*") || strings.Contains(name, "..") ||
+		strings.HasPrefix(name, ".") {
+		return fmt.Errorf("suffixtls: invalid pattern %q", pattern)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	e := &entry{}
+	if v, found := s.tree.Get([]byte(name)); found {
+		e = v.(*entry)
+	}
+	if kind.exact != nil {
+		e.exact = kind.exact
+	}
+	if kind.wildcard != nil {
+		e.wildcard = kind.wildcard
+	}
+	if kind.subdomains != nil {
+		e.subdomains = kind.subdomains
+	}
+	s.tree.Insert([]byte(name), e)
+	return nil
+}
+
+// AddCertificate registers the certificate for each DNS name in it, which may be an exact name
+// or a wildcard. The leaf is parsed from cert.Certificate if cert.Leaf is nil.
+func (s *Selector) AddCertificate(cert *tls.Certificate) error {
+	leaf := cert.Leaf
+	if leaf == nil {
+		if len(cert.Certificate) == 0 {
+			return errors.New("suffixtls: empty certificate chain")
+		}
+		var err error
+		leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return err
+		}
+	}
+	if len(leaf.DNSNames) == 0 {
+		return errors.New("suffixtls: certificate has no DNS names")
+	}
+	for _, name := range leaf.DNSNames {
+		if err := s.Add(name, cert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Select returns the certificate for the server name, or nil if no pattern matches.
+func (s *Selector) Select(serverName string) *tls.Certificate {
+	name := normalizeName(serverName)
+	if name == "" {
+		return nil
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if v, found := s.tree.Get([]byte(name)); found && v.(*entry).exact != nil {
+		return v.(*entry).exact
+	}
+	i := strings.IndexByte(name, '.')
+	if i < 0 {
+		return nil
+	}
+	parent := name[i+1:]
+	if v, found := s.tree.Get([]byte(parent)); found && v.(*entry).wildcard != nil {
+		return v.(*entry).wildcard
+	}
+	for query := parent; ; {
+		key, v, found := s.tree.LongestSuffix([]byte(query))
+		if !found {
+			return nil
+		}
+		if cert := v.(*entry).subdomains; cert != nil {
+			return cert
+		}
+		i := strings.IndexByte(string(key), '.')
+		if i < 0 {
+			return nil
+		}
+		query = string(key[i+1:])
+	}
+}
+
+// GetCertificate returns the certificate for the server name of the handshake, or Default.
+// It fits tls.Config.GetCertificate.
+func (s *Selector) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if cert := s.Select(hello.ServerName); cert != nil {
+		return cert, nil
+	}
+	if s.Default != nil {
+		return s.Default, nil
+	}
+	return nil, fmt.Errorf("suffixtls: no certificate for %q", hello.ServerName)
+}
@@ -0,0 +1,100 @@
+package suffixtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCert(t *testing.T, names ...string) *tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestSelector(t *testing.T) {
+	apex := &tls.Certificate{}
+	wildcard := &tls.Certificate{}
+	internal := &tls.Certificate{}
+	s := NewSelector()
+	assert.Nil(t, s.Add("example.com", apex))
+	assert.Nil(t, s.Add("*.Example.com", wildcard))
+	assert.Nil(t, s.Add(".internal.example.com", internal))
+
+	for name, expected := range map[string]*tls.Certificate{
+		"example.com":               apex,
+		"EXAMPLE.COM.":              apex,
+		"www.example.com":           wildcard,
+		"a.b.example.com":           nil,
+		"internal.example.com":      wildcard,
+		"db.internal.example.com":   internal,
+		"a.db.internal.example.com": internal,
+		"badexample.com":            nil,
+		"com":                       nil,
+		"":                          nil,
+	} {
+		assert.True(t, expected == s.Select(name), name)
+	}
+
+	_, err := s.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.org"})
+	assert.EqualError(t, err, `suffixtls: no certificate for "example.org"`)
+	s.Default = &tls.Certificate{}
+	cert, err := s.GetCertificate(&tls.ClientHelloInfo{})
+	assert.Nil(t, err)
+	assert.True(t, s.Default == cert)
+
+	for _, invalid := range []string{"", "*.", "a.*.com", "..com", "*.*.com"} {
+		assert.NotNil(t, s.Add(invalid, apex), invalid)
+	}
+	assert.NotNil(t, s.Add("example.net", nil))
+}
+
+func TestSelector_AddCertificate(t *testing.T) {
+	s := NewSelector()
+	cert := newCert(t, "example.com", "*.example.com")
+	assert.Nil(t, s.AddCertificate(cert))
+	assert.True(t, cert == s.Select("example.com"))
+	assert.True(t, cert == s.Select("www.example.com"))
+	assert.Nil(t, s.Select("example.org"))
+
+	assert.NotNil(t, s.AddCertificate(&tls.Certificate{}))
+	assert.NotNil(t, s.AddCertificate(&tls.Certificate{Certificate: [][]byte{{1, 2, 3}}}))
+}
+
+func TestSelector_Handshake(t *testing.T) {
+	s := NewSelector()
+	assert.Nil(t, s.AddCertificate(newCert(t, "*.example.com")))
+	assert.Nil(t, s.AddCertificate(newCert(t, "example.org")))
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go func() {
+		server := tls.Server(serverConn, &tls.Config{GetCertificate: s.GetCertificate})
+		server.Handshake()
+		server.Close()
+	}()
+	client := tls.Client(clientConn, &tls.Config{
+		ServerName:         "www.example.com",
+		InsecureSkipVerify: true,
+	})
+	assert.Nil(t, client.Handshake())
+	assert.Equal(t, []string{"*.example.com"}, client.ConnectionState().PeerCertificates[0].DNSNames)
+}
@@ -0,0 +1,89 @@
+package suffixtls
+
+import (
+	"net"
+	"strings"
+
+	suffix "github.com/spacewander/go-suffix-tree"
+)
+
+// nameFlags tells how a DNS name of a certificate matches.
+type nameFlags struct {
+	exact bool
+	// For "*.example.com", stored at "example.com"
+	wildcard bool
+}
+
+// NameMatcher matches host names against the DNS names of a certificate, the subject
+// alternative names, with the wildcard rules of RFC 6125. Build it once per certificate to
+// cache the names for the custom TLS verifiers.
+//
+// A wildcard is only allowed as the whole leftmost label, like "*.example.com", and matches
+// exactly one label, so "www.example.com" matches it but "example.com" and
+// "a.www.example.com" don't. The wildcards with partial labels like "w*.example.com", or
+// right above a top-level domain like "*.com", never match. The names are case-insensitive,
+// a trailing dot is ignored, and IP addresses never match.
+//
+// NameMatcher is safe for concurrent use.
+type NameMatcher struct {
+	tree *suffix.Tree
+}
+
+// NewNameMatcher creates a NameMatcher of the DNS names. The invalid names are ignored.
+func NewNameMatcher(dnsNames []string) *NameMatcher {
+	m := &NameMatcher{tree: suffix.NewTree(suffix.WithSeparator('.'))}
+	for _, name := range dnsNames {
+		name = normalizeName(name)
+		wildcard := strings.HasPrefix(name, "*.")
+		if wildcard {
+			name = name[2:]
+			if !strings.Contains(name, ".") {
+				continue
+			}
+		}
+		if !validName(name) {
+			continue
+		}
+		flags := &nameFlags{}
+		if v, found := m.tree.Get([]byte(name)); found {
+			flags = v.(*nameFlags)
+		}
+		if wildcard {
+			flags.wildcard = true
+		} else {
+			flags.exact = true
+		}
+		m.tree.Insert([]byte(name), flags)
+	}
+	return m
+}
+
+// validName reports whether name is a non-empty name without empty labels or wildcards.
+func validName(name string) bool {
+	return name != "" && !strings.Contains(name, "*") && !strings.Contains(name, "..") &&
+		!strings.HasPrefix(name, ".") && !strings.HasSuffix(name, ".")
+}
+
+// Matches reports whether the host matches any DNS name.
+func (m *NameMatcher) Matches(host string) bool {
+	host = normalizeName(host)
+	if !validName(host) || net.ParseIP(host) != nil {
+		return false
+	}
+	if v, found := m.tree.Get([]byte(host)); found && v.(*nameFlags).exact {
+		return true
+	}
+	i := strings.IndexByte(host, '.')
+	if i < 0 {
+		return false
+	}
+	v, found := m.tree.Get([]byte(host[i+1:]))
+	return found && v.(*nameFlags).wildcard
+}
+
+// MatchesCertificate reports whether the host matches any of the DNS names of a certificate,
+// like x509.Certificate.DNSNames. See NameMatcher for the rules. Use NameMatcher to check
+// many hosts against the same certificate.
+func MatchesCertificate(host string, dnsNames []string) bool {
+	return NewNameMatcher(dnsNames).Matches(host)
+}
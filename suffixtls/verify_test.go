@@ -0,0 +1,30 @@
+package suffixtls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNameMatcher(t *testing.T) {
+	m := NewNameMatcher([]string{"Example.com", "*.example.com", "*.api.example.org.",
+		"w*.example.net", "*.com", "bad..name", "10.0.0.1"})
+
+	for _, host := range []string{"example.com", "EXAMPLE.com.", "www.example.com",
+		"a.api.example.org"} {
+
+		assert.True(t, m.Matches(host), host)
+	}
+	for _, host := range []string{"", "a.www.example.com", "api.example.org",
+		"www.example.net", "example.com.cn", "other.com", "10.0.0.1", "a..example.com",
+		"*.example.com"} {
+
+		assert.False(t, m.Matches(host), host)
+	}
+}
+
+func TestMatchesCertificate(t *testing.T) {
+	assert.True(t, MatchesCertificate("www.example.com", []string{"*.example.com"}))
+	assert.False(t, MatchesCertificate("example.com", []string{"*.example.com"}))
+	assert.False(t, MatchesCertificate("example.com", nil))
+}
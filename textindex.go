@@ -0,0 +1,469 @@
+package suffix
+
+import (
+	"bytes"
+	"fmt"
+	"math/bits"
+	"sort"
+	"sync"
+)
+
+// TextIndex is a suffix tree over all suffixes of a single text, which is the classic use
+// of suffix tree in text indexing. While Tree looks up independent keys by their suffixes,
+// TextIndex answers questions about the substrings of one text.
+//
+// The end of the text is treated as a terminator which is smaller than any byte, so each
+// suffix of the text ends at its own leaf.
+type TextIndex struct {
+	text []byte
+	root *_IndexNode
+
+	lceOnce sync.Once
+	lce     *lceTable
+}
+
+type _IndexNode struct {
+	// The label of the edge leading to this node is text[start:end]
+	start, end int
+	// Children are sorted by the first byte of their labels. A leaf whose label is empty
+	// terminates the suffix which ends at this node, it is always the first child.
+	children []*_IndexNode
+	// The start of the suffix ended at this leaf, or -1 if this is not a leaf
+	suffix int
+	// The suffix link of an internal node: the node whose path is the path of this node
+	// without its first byte. nil for the root and the leaves.
+	link *_IndexNode
+}
+
+func (node *_IndexNode) isLeaf() bool {
+	return node.suffix >= 0
+}
+
+// child returns the position of the child whose label starts with c, and whether it exists.
+// If not, the position is where such a child should be inserted.
+func (node *_IndexNode) child(text []byte, c byte) (int, bool) {
+	i := sort.Search(len(node.children), func(i int) bool {
+		child := node.children[i]
+		return child.start < child.end && text[child.start] >= c
+	})
+	if i < len(node.children) {
+		child := node.children[i]
+		return i, child.start < child.end && text[child.start] == c
+	}
+	return i, false
+}
+
+func (node *_IndexNode) insertChild(i int, child *_IndexNode) {
+	node.children = append(node.children, nil)
+	copy(node.children[i+1:], node.children[i:])
+	node.children[i] = child
+}
+
+// NewTextIndex builds the suffix tree of text. The text is referenced by the index and
+// should not be modified afterward. It takes O(n²) time in the worst case, like indexing
+// a text full of the same byte.
+func NewTextIndex(text []byte) *TextIndex {
+	idx := &TextIndex{
+		text: text,
+		root: &_IndexNode{suffix: -1},
+	}
+	for i := range text {
+		idx.insertSuffix(i)
+	}
+	idx.linkNodes()
+	return idx
+}
+
+func (idx *TextIndex) insertSuffix(suffix int) {
+	text := idx.text
+	n := len(text)
+	node := idx.root
+	pos := suffix
+	for {
+		if pos == n {
+			node.insertChild(0, &_IndexNode{start: n, end: n, suffix: suffix})
+			return
+		}
+		i, found := node.child(text, text[pos])
+		if !found {
+			node.insertChild(i, &_IndexNode{start: pos, end: n, suffix: suffix})
+			return
+		}
+		child := node.children[i]
+		k := child.start
+		for k < child.end && pos < n && text[k] == text[pos] {
+			k++
+			pos++
+		}
+		if k < child.end {
+			// split the edge at the first mismatch
+			mid := &_IndexNode{
+				start:    child.start,
+				end:      k,
+				children: []*_IndexNode{child},
+				suffix:   -1,
+			}
+			child.start = k
+			node.children[i] = mid
+			child = mid
+		}
+		node = child
+	}
+}
+
+// Text returns the indexed text.
+func (idx *TextIndex) Text() []byte {
+	return idx.text
+}
+
+// Contains returns whether pattern is a substring of the text.
+func (idx *TextIndex) Contains(pattern []byte) bool {
+	return idx.locus(pattern) != nil
+}
+
+// locus returns the node at or below the end of pattern, or nil if pattern is not a substring
+// of the text. The leaves under it are the suffixes starting with pattern.
+func (idx *TextIndex) locus(pattern []byte) *_IndexNode {
+	text := idx.text
+	node := idx.root
+	for len(pattern) > 0 {
+		i, found := node.child(text, pattern[0])
+		if !found {
+			return nil
+		}
+		node = node.children[i]
+		label := text[node.start:node.end]
+		if len(pattern) <= len(label) {
+			if !bytes.HasPrefix(label, pattern) {
+				return nil
+			}
+			return node
+		}
+		if !bytes.HasPrefix(pattern, label) {
+			return nil
+		}
+		pattern = pattern[len(label):]
+	}
+	return node
+}
+
+// eachLeaf calls f with the start of each suffix under node.
+func (node *_IndexNode) eachLeaf(f func(suffix int)) {
+	if node.isLeaf() {
+		f(node.suffix)
+		return
+	}
+	for _, child := range node.children {
+		child.eachLeaf(f)
+	}
+}
+
+// DistinctSubstrings returns the number of distinct non-empty substrings of the text. Each of
+// them ends inside or at the end of exactly one edge, so it is the total length of the labels.
+func (idx *TextIndex) DistinctSubstrings() uint64 {
+	var n uint64
+	var visit func(node *_IndexNode)
+	visit = func(node *_IndexNode) {
+		for _, child := range node.children {
+			n += uint64(child.end - child.start)
+			visit(child)
+		}
+	}
+	visit(idx.root)
+	return n
+}
+
+// ToSuffixArray returns the suffix array of the text, and its LCP array. sa[i] is the start
+// of the i-th smallest suffix, and lcp[i] is the length of the longest common prefix of
+// the suffixes at sa[i-1] and sa[i]. lcp[0] is always 0. The suffix array is the same as
+// the one index/suffixarray works with.
+func (idx *TextIndex) ToSuffixArray() (sa []int, lcp []int) {
+	sa = make([]int, 0, len(idx.text))
+	lcp = make([]int, 0, len(idx.text))
+	// the depth of the lowest common ancestor of the last leaf and the next one
+	lcaDepth := 0
+	var visit func(node *_IndexNode, depth int)
+	visit = func(node *_IndexNode, depth int) {
+		if node.isLeaf() {
+			sa = append(sa, node.suffix)
+			lcp = append(lcp, lcaDepth)
+			return
+		}
+		for i, child := range node.children {
+			if i > 0 {
+				lcaDepth = depth
+			}
+			visit(child, depth+child.end-child.start)
+		}
+	}
+	visit(idx.root, 0)
+	return sa, lcp
+}
+
+// FromSuffixArray builds the suffix tree of text from its suffix array, in which sa[i] is
+// the start of the i-th smallest suffix. It takes O(n) time, much faster than NewTextIndex
+// for large texts. The text is referenced by the index and should not be modified
+// afterward. An error is returned if sa is not the suffix array of text.
+func FromSuffixArray(text []byte, sa []int) (*TextIndex, error) {
+	n := len(text)
+	if len(sa) != n {
+		return nil, fmt.Errorf("suffix: suffix array has %d entries, expected %d", len(sa), n)
+	}
+	rank := make([]int, n)
+	for i := range rank {
+		rank[i] = -1
+	}
+	for i, s := range sa {
+		if s < 0 || s >= n || rank[s] >= 0 {
+			return nil, fmt.Errorf("suffix: invalid suffix %d at %d", s, i)
+		}
+		rank[s] = i
+	}
+
+	// The suffixes are sorted if each adjacent pair is ordered by its first bytes, or by the
+	// ranks of the suffixes following them if the first bytes are equal. The empty suffix
+	// following the last byte comes first.
+	rankAfter := func(s int) int {
+		if s+1 == n {
+			return -1
+		}
+		return rank[s+1]
+	}
+	for i := 1; i < n; i++ {
+		prev, s := sa[i-1], sa[i]
+		if text[prev] > text[s] || text[prev] == text[s] && rankAfter(prev) > rankAfter(s) {
+			// The ranks may be wrong too, so find the first pair out of order by
+			// comparing the suffixes themselves
+			for j := 1; j < n; j++ {
+				if bytes.Compare(text[sa[j-1]:], text[sa[j]:]) > 0 {
+					i = j
+					break
+				}
+			}
+			return nil, fmt.Errorf("suffix: suffixes at %d and %d are not sorted", i-1, i)
+		}
+	}
+
+	// Kasai's algorithm, which relies on sa being valid
+	lcp := make([]int, n)
+	h := 0
+	for i := 0; i < n; i++ {
+		if rank[i] == 0 {
+			h = 0
+			continue
+		}
+		j := sa[rank[i]-1]
+		for i+h < n && j+h < n && text[i+h] == text[j+h] {
+			h++
+		}
+		lcp[rank[i]] = h
+		if h > 0 {
+			h--
+		}
+	}
+
+	type entry struct {
+		node  *_IndexNode
+		depth int
+	}
+	root := &_IndexNode{suffix: -1}
+	stack := []entry{{root, 0}}
+	for i, s := range sa {
+		l := lcp[i]
+		var last *_IndexNode
+		for stack[len(stack)-1].depth > l {
+			last = stack[len(stack)-1].node
+			stack = stack[:len(stack)-1]
+		}
+		top := stack[len(stack)-1]
+		if top.depth < l {
+			// split the edge to the last child at the common prefix
+			mid := &_IndexNode{
+				start:    last.start,
+				end:      last.start + l - top.depth,
+				children: []*_IndexNode{last},
+				suffix:   -1,
+			}
+			last.start = mid.end
+			top.node.children[len(top.node.children)-1] = mid
+			top = entry{mid, l}
+			stack = append(stack, top)
+		} else if top.node.isLeaf() {
+			// the previous suffix is a prefix of this one
+			top.node.children = []*_IndexNode{{start: n, end: n, suffix: top.node.suffix}}
+			top.node.suffix = -1
+		}
+		leaf := &_IndexNode{start: s + l, end: n, suffix: s}
+		top.node.children = append(top.node.children, leaf)
+		stack = append(stack, entry{leaf, n - s})
+	}
+	idx := &TextIndex{text: text, root: root}
+	idx.linkNodes()
+	return idx, nil
+}
+
+// descend walks down from node along text[start:end], which must end at a node, comparing
+// only the first byte of each edge.
+func (idx *TextIndex) descend(node *_IndexNode, start, end int) *_IndexNode {
+	for start < end {
+		i, _ := node.child(idx.text, idx.text[start])
+		node = node.children[i]
+		start += node.end - node.start
+	}
+	return node
+}
+
+// linkNodes sets the suffix links of the internal nodes. The link of a node is found from the
+// link of its parent, and the node it points to always exists since each suffix ends at a
+// leaf.
+func (idx *TextIndex) linkNodes() {
+	var visit func(node *_IndexNode)
+	visit = func(node *_IndexNode) {
+		for _, child := range node.children {
+			if child.isLeaf() {
+				continue
+			}
+			if node == idx.root {
+				child.link = idx.descend(idx.root, child.start+1, child.end)
+			} else {
+				child.link = idx.descend(node.link, child.start, child.end)
+			}
+			visit(child)
+		}
+	}
+	visit(idx.root)
+}
+
+// MatchingStatistics returns ms, in which ms[i] is the length of the longest prefix of
+// query[i:] occurring in the text. It takes O(len(query)) time: when the match of query[i:]
+// stops, the match of query[i+1:] continues from the suffix link of the node reached, instead
+// of starting again from the root.
+func (idx *TextIndex) MatchingStatistics(query []byte) []int {
+	text := idx.text
+	ms := make([]int, len(query))
+	// query[i:i+m] is matched, and node is the deepest node on its path, at depth
+	node, depth, m := idx.root, 0, 0
+	for i := range query {
+		// Walk down the part known to match, comparing only the first byte of each edge
+		for m > depth {
+			j, _ := node.child(text, query[i+depth])
+			child := node.children[j]
+			if child.isLeaf() || child.end-child.start > m-depth {
+				break
+			}
+			node, depth = child, depth+child.end-child.start
+		}
+		for i+m < len(query) {
+			j, found := node.child(text, query[i+depth])
+			if !found {
+				break
+			}
+			child := node.children[j]
+			pos := child.start + m - depth
+			if pos >= child.end || text[pos] != query[i+m] {
+				break
+			}
+			m++
+			if pos+1 == child.end && !child.isLeaf() {
+				node, depth = child, m
+			}
+		}
+		ms[i] = m
+		if m == 0 {
+			continue
+		}
+		m--
+		if node != idx.root {
+			node, depth = node.link, depth-1
+		}
+	}
+	return ms
+}
+
+// LongestCommonSubstring returns the longest substring of query occurring in the text, as
+// its start in query and one of its starts in the text. length is 0 if query and the text
+// share no byte.
+func (idx *TextIndex) LongestCommonSubstring(query []byte) (queryStart, textStart, length int) {
+	for i, m := range idx.MatchingStatistics(query) {
+		if m > length {
+			queryStart, length = i, m
+		}
+	}
+	if length == 0 {
+		return 0, 0, 0
+	}
+	return queryStart, idx.find(query[queryStart : queryStart+length]), length
+}
+
+// find returns a start of pattern in the text, which must contain it.
+func (idx *TextIndex) find(pattern []byte) int {
+	node, depth := idx.root, 0
+	for depth < len(pattern) {
+		i, _ := node.child(idx.text, pattern[depth])
+		node = node.children[i]
+		depth += node.end - node.start
+	}
+	for !node.isLeaf() {
+		node = node.children[0]
+	}
+	return node.suffix
+}
+
+// lceTable answers the depth of the lowest common ancestor of two leaves in O(1), as the
+// minimum of the LCP array between their ranks, with a sparse table of the minimums.
+type lceTable struct {
+	// rank[i] is the position of the suffix at i in the suffix array
+	rank []int
+	// mins[k][r] is the minimum of lcp[r:r+2^k]
+	mins [][]int
+}
+
+func newLCETable(sa, lcp []int) *lceTable {
+	t := &lceTable{rank: make([]int, len(sa))}
+	for r, s := range sa {
+		t.rank[s] = r
+	}
+	t.mins = [][]int{lcp}
+	for k := 1; 1<<k <= len(lcp); k++ {
+		prev, half := t.mins[k-1], 1<<(k-1)
+		mins := make([]int, len(lcp)-1<<k+1)
+		for r := range mins {
+			mins[r] = prev[r]
+			if prev[r+half] < mins[r] {
+				mins[r] = prev[r+half]
+			}
+		}
+		t.mins = append(t.mins, mins)
+	}
+	return t
+}
+
+// LCE returns the length of the longest common prefix of the suffixes of the text starting at
+// i and j, the longest common extension. The first call builds a table in O(n log n) time,
+// then each call takes O(1). It panics if i or j is not in [0, len(text)].
+func (idx *TextIndex) LCE(i, j int) int {
+	n := len(idx.text)
+	if i < 0 || i > n || j < 0 || j > n {
+		panic(fmt.Sprintf("suffix: LCE position out of range [0, %d]: %d, %d", n, i, j))
+	}
+	if i == j {
+		return n - i
+	}
+	if i == n || j == n {
+		return 0
+	}
+	idx.lceOnce.Do(func() {
+		idx.lce = newLCETable(idx.ToSuffixArray())
+	})
+	a, b := idx.lce.rank[i], idx.lce.rank[j]
+	if a > b {
+		a, b = b, a
+	}
+	// The minimum of lcp[a+1:b+1], from two overlapping ranges of 2^k entries
+	k := bits.Len(uint(b-a)) - 1
+	x, y := idx.lce.mins[k][a+1], idx.lce.mins[k][b+1-1<<k]
+	if y < x {
+		return y
+	}
+	return x
+}
@@ -0,0 +1,256 @@
+package suffix
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func naiveSuffixArray(text []byte) (sa []int, lcp []int) {
+	sa = make([]int, len(text))
+	for i := range sa {
+		sa[i] = i
+	}
+	sort.Slice(sa, func(i, j int) bool {
+		return bytes.Compare(text[sa[i]:], text[sa[j]:]) < 0
+	})
+	lcp = make([]int, len(text))
+	for i := 1; i < len(sa); i++ {
+		a, b := text[sa[i-1]:], text[sa[i]:]
+		for lcp[i] < len(a) && lcp[i] < len(b) && a[lcp[i]] == b[lcp[i]] {
+			lcp[i]++
+		}
+	}
+	return sa, lcp
+}
+
+func randomText(letters string, n int) []byte {
+	text := make([]byte, n)
+	for i := range text {
+		text[i] = letters[rand.Intn(len(letters))]
+	}
+	return text
+}
+
+func TestTextIndex_Contains(t *testing.T) {
+	idx := NewTextIndex([]byte("mississippi"))
+	assert.Equal(t, "mississippi", string(idx.Text()))
+	for _, pattern := range []string{"", "m", "issi", "ssippi", "mississippi", "pi", "i"} {
+		assert.True(t, idx.Contains([]byte(pattern)), pattern)
+	}
+	for _, pattern := range []string{"x", "mississippis", "ssm", "pp i", "ipi"} {
+		assert.False(t, idx.Contains([]byte(pattern)), pattern)
+	}
+
+	idx = NewTextIndex(nil)
+	assert.True(t, idx.Contains(nil))
+	assert.False(t, idx.Contains([]byte("a")))
+}
+
+func TestTextIndex_ToSuffixArray(t *testing.T) {
+	sa, lcp := NewTextIndex([]byte("banana")).ToSuffixArray()
+	assert.Equal(t, []int{5, 3, 1, 0, 4, 2}, sa)
+	assert.Equal(t, []int{0, 1, 3, 0, 0, 2}, lcp)
+
+	sa, lcp = NewTextIndex(nil).ToSuffixArray()
+	assert.Empty(t, sa)
+	assert.Empty(t, lcp)
+
+	for _, letters := range []string{"a", "ab", "acgt", "abcdefghijklmnopqrstuvwxyz"} {
+		for i := 0; i < 50; i++ {
+			text := randomText(letters, rand.Intn(64))
+			expectedSA, expectedLCP := naiveSuffixArray(text)
+			sa, lcp := NewTextIndex(text).ToSuffixArray()
+			assert.Equal(t, expectedSA, sa, string(text))
+			assert.Equal(t, expectedLCP, lcp, string(text))
+		}
+	}
+}
+
+func assertSameTextIndex(t *testing.T, expected, actual *TextIndex) {
+	var walk func(node *_IndexNode, depth int, out *[]string)
+	walk = func(node *_IndexNode, depth int, out *[]string) {
+		*out = append(*out, fmt.Sprintf("%d %q %d", depth, expected.text[node.start:node.end], node.suffix))
+		for _, child := range node.children {
+			walk(child, depth+1, out)
+		}
+	}
+	var expectedNodes, actualNodes []string
+	walk(expected.root, 0, &expectedNodes)
+	walk(actual.root, 0, &actualNodes)
+	assert.Equal(t, expectedNodes, actualNodes)
+}
+
+func TestFromSuffixArray(t *testing.T) {
+	for _, letters := range []string{"a", "ab", "acgt", "abcdefghijklmnopqrstuvwxyz"} {
+		for i := 0; i < 50; i++ {
+			text := randomText(letters, rand.Intn(64))
+			sa, _ := naiveSuffixArray(text)
+			idx, err := FromSuffixArray(text, sa)
+			assert.Nil(t, err)
+			assertSameTextIndex(t, NewTextIndex(text), idx)
+		}
+	}
+}
+
+func TestFromSuffixArray_Invalid(t *testing.T) {
+	text := []byte("banana")
+	_, err := FromSuffixArray(text, []int{5, 3, 1, 0, 4})
+	assert.EqualError(t, err, "suffix: suffix array has 5 entries, expected 6")
+	_, err = FromSuffixArray(text, []int{5, 3, 1, 0, 4, 6})
+	assert.EqualError(t, err, "suffix: invalid suffix 6 at 5")
+	_, err = FromSuffixArray(text, []int{5, 3, 1, 0, 4, 4})
+	assert.EqualError(t, err, "suffix: invalid suffix 4 at 5")
+	_, err = FromSuffixArray(text, []int{3, 5, 1, 0, 4, 2})
+	assert.EqualError(t, err, "suffix: suffixes at 0 and 1 are not sorted")
+	_, err = FromSuffixArray(text, []int{5, 1, 3, 0, 4, 2})
+	assert.EqualError(t, err, "suffix: suffixes at 1 and 2 are not sorted")
+	_, err = FromSuffixArray(text, []int{5, 3, 1, 2, 4, 0})
+	assert.EqualError(t, err, "suffix: suffixes at 3 and 4 are not sorted")
+	_, err = FromSuffixArray([]byte("aaaa"), []int{3, 1, 0, 2})
+	assert.EqualError(t, err, "suffix: suffixes at 2 and 3 are not sorted")
+	_, err = FromSuffixArray([]byte("aabaa"), []int{4, 3, 0, 2, 1})
+	assert.EqualError(t, err, "suffix: suffixes at 3 and 4 are not sorted")
+
+	// Only the suffix array itself is accepted among the permutations
+	for i := 0; i < 500; i++ {
+		text := randomText("ab", 1+rand.Intn(6))
+		expected, _ := NewTextIndex(text).ToSuffixArray()
+		sa := rand.Perm(len(text))
+		_, err := FromSuffixArray(text, sa)
+		assert.Equal(t, fmt.Sprint(sa) == fmt.Sprint(expected), err == nil, "%q %v", text, sa)
+	}
+}
+
+// assertSuffixLinks checks that the link of each internal node is the node of its path
+// without the first byte.
+func assertSuffixLinks(t *testing.T, idx *TextIndex) {
+	paths := map[*_IndexNode]string{}
+	var walk func(node *_IndexNode, path string)
+	walk = func(node *_IndexNode, path string) {
+		paths[node] = path
+		for _, child := range node.children {
+			walk(child, path+string(idx.text[child.start:child.end]))
+		}
+	}
+	walk(idx.root, "")
+	for node, path := range paths {
+		if node == idx.root || node.isLeaf() {
+			assert.Nil(t, node.link)
+			continue
+		}
+		if assert.NotNil(t, node.link, path) {
+			assert.Equal(t, path[1:], paths[node.link], path)
+		}
+	}
+}
+
+func naiveMatchingStatistics(text, query []byte) []int {
+	ms := make([]int, len(query))
+	for i := range query {
+		for ms[i] < len(query)-i && bytes.Contains(text, query[i:i+ms[i]+1]) {
+			ms[i]++
+		}
+	}
+	return ms
+}
+
+func TestTextIndex_MatchingStatistics(t *testing.T) {
+	idx := NewTextIndex([]byte("mississippi"))
+	assertSuffixLinks(t, idx)
+	assert.Equal(t, []int{5, 4, 3, 2, 1, 0, 4, 3, 2, 1},
+		idx.MatchingStatistics([]byte("issipxssip")))
+	assert.Equal(t, []int{}, idx.MatchingStatistics(nil))
+	assert.Equal(t, []int{0}, NewTextIndex(nil).MatchingStatistics([]byte("a")))
+
+	for _, letters := range []string{"a", "ab", "acgt"} {
+		for i := 0; i < 50; i++ {
+			text := randomText(letters, rand.Intn(64))
+			query := randomText(letters, rand.Intn(64))
+			idx := NewTextIndex(text)
+			assertSuffixLinks(t, idx)
+			expected := naiveMatchingStatistics(text, query)
+			assert.Equal(t, expected, idx.MatchingStatistics(query), "%s %s", text, query)
+
+			sa, _ := naiveSuffixArray(text)
+			idx, err := FromSuffixArray(text, sa)
+			assert.Nil(t, err)
+			assertSuffixLinks(t, idx)
+			assert.Equal(t, expected, idx.MatchingStatistics(query), "%s %s", text, query)
+		}
+	}
+}
+
+func TestTextIndex_LongestCommonSubstring(t *testing.T) {
+	idx := NewTextIndex([]byte("the quick brown fox"))
+	queryStart, textStart, length := idx.LongestCommonSubstring([]byte("a brown dog"))
+	assert.Equal(t, 1, queryStart)
+	assert.Equal(t, 9, textStart)
+	assert.Equal(t, len(" brown "), length)
+
+	_, _, length = idx.LongestCommonSubstring([]byte("XYZ"))
+	assert.Equal(t, 0, length)
+
+	for i := 0; i < 50; i++ {
+		text := randomText("ab", 1+rand.Intn(64))
+		query := randomText("ab", 1+rand.Intn(64))
+		queryStart, textStart, length := NewTextIndex(text).LongestCommonSubstring(query)
+		assert.Equal(t, query[queryStart:queryStart+length], text[textStart:textStart+length])
+		ms := naiveMatchingStatistics(text, query)
+		sort.Ints(ms)
+		assert.Equal(t, ms[len(ms)-1], length)
+	}
+}
+
+func TestTextIndex_LCE(t *testing.T) {
+	idx := NewTextIndex([]byte("mississippi"))
+	assert.Equal(t, 4, idx.LCE(1, 4))
+	assert.Equal(t, 0, idx.LCE(0, 1))
+	assert.Equal(t, 3, idx.LCE(2, 5))
+	assert.Equal(t, 11, idx.LCE(0, 0))
+	assert.Equal(t, 0, idx.LCE(3, 11))
+	assert.Equal(t, 0, idx.LCE(11, 11))
+	assert.Panics(t, func() { idx.LCE(-1, 0) })
+	assert.Panics(t, func() { idx.LCE(0, 12) })
+	assert.Equal(t, 0, NewTextIndex(nil).LCE(0, 0))
+
+	for _, letters := range []string{"a", "ab", "acgt"} {
+		for i := 0; i < 20; i++ {
+			text := randomText(letters, 1+rand.Intn(64))
+			idx := NewTextIndex(text)
+			for a := 0; a <= len(text); a++ {
+				for b := 0; b <= len(text); b++ {
+					expected := 0
+					for a+expected < len(text) && b+expected < len(text) &&
+						text[a+expected] == text[b+expected] {
+						expected++
+					}
+					assert.Equal(t, expected, idx.LCE(a, b), "%s %d %d", text, a, b)
+				}
+			}
+		}
+	}
+}
+
+func TestTextIndex_DistinctSubstrings(t *testing.T) {
+	assert.Equal(t, uint64(0), NewTextIndex(nil).DistinctSubstrings())
+	assert.Equal(t, uint64(3), NewTextIndex([]byte("aaa")).DistinctSubstrings())
+	assert.Equal(t, uint64(15), NewTextIndex([]byte("banana")).DistinctSubstrings())
+
+	for _, letters := range []string{"a", "ab", "acgt"} {
+		for i := 0; i < 20; i++ {
+			text := randomText(letters, rand.Intn(64))
+			substrings := map[string]bool{}
+			for a := range text {
+				for b := a + 1; b <= len(text); b++ {
+					substrings[string(text[a:b])] = true
+				}
+			}
+			assert.Equal(t, uint64(len(substrings)), NewTextIndex(text).DistinctSubstrings())
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package suffix
+
+import (
+	"bytes"
+)
+
+// TraceAction is what a lookup does with an edge, see TraceStep.
+type TraceAction int
+
+const (
+	// TraceSkip means the label isn't a suffix of the rest of the query.
+	TraceSkip TraceAction = iota
+	// TraceDescend means the label matches, and the lookup goes into the node below it.
+	TraceDescend
+	// TraceMatch means the label matches, and the key of the leaf below it is a candidate:
+	// the result of Get, or a suffix of the query for LongestSuffix, which may be replaced
+	// by a longer one found later.
+	TraceMatch
+	// TraceReject means the label matches, but the key of the leaf below it doesn't, like a
+	// key shorter than the query for Get, or a key not starting at a label boundary.
+	TraceReject
+)
+
+var traceActionNames = []string{"skip", "descend", "match", "reject"}
+
+func (action TraceAction) String() string {
+	if int(action) < len(traceActionNames) {
+		return traceActionNames[action]
+	}
+	return "unknown"
+}
+
+// TraceStep is an edge considered by a lookup.
+type TraceStep struct {
+	// OpGet or OpLongestSuffix
+	Op string
+	// The number of edges above the edge
+	Depth int
+	Label []byte
+	// The part of the query left before the edge. The label is compared with its end.
+	Rest   []byte
+	Action TraceAction
+	// The key of the leaf for TraceMatch and TraceReject
+	Key []byte
+}
+
+// WithTracer calls tracer for every edge considered by Get and LongestSuffix, to answer
+// questions like "why doesn't this key match?". The steps are in the order of the lookup.
+// The traced lookups are slower, so the option is meant for debugging.
+func WithTracer(tracer func(step TraceStep)) Option {
+	return func(tree *Tree) {
+		tree.tracer = tracer
+	}
+}
+
+// traceLookup is getLeaf if exact is true, otherwise longestSuffix, which reports each edge it
+// considers.
+func (node *_Node) traceLookup(op string, key []byte, sep int, exact bool, depth int,
+	trace func(step TraceStep)) (leaf *_Leaf) {
+
+	for _, edge := range node.edges {
+		step := TraceStep{Op: op, Depth: depth, Label: edge.label, Rest: key}
+		if !bytes.HasSuffix(key, edge.label) {
+			step.Action = TraceSkip
+			trace(step)
+			continue
+		}
+		subKey := key[:len(key)-len(edge.label)]
+		switch point := edge.point.(type) {
+		case *_Leaf:
+			step.Key = point.originKey
+			matched := len(subKey) == 0
+			if !exact {
+				matched = atBoundary(subKey, point.originKey, sep)
+			}
+			if !matched {
+				step.Action = TraceReject
+				trace(step)
+				if len(edge.label) == 0 {
+					continue
+				}
+				// Labels in the same Node don't share common suffix, so there is no other
+				// edge to try
+				return leaf
+			}
+			step.Action = TraceMatch
+			trace(step)
+			if exact || len(edge.label) > 0 {
+				return point
+			}
+			// The key ends here. Remember it and look for a longer one.
+			leaf = point
+		case *_Node:
+			step.Action = TraceDescend
+			trace(step)
+			if child := point.traceLookup(op, subKey, sep, exact, depth+1, trace); child != nil {
+				return child
+			}
+			return leaf
+		}
+	}
+	return leaf
+}
@@ -0,0 +1,74 @@
+package suffix
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTracer(t *testing.T) {
+	var steps []TraceStep
+	tree := NewTree(WithTracer(func(step TraceStep) {
+		steps = append(steps, step)
+	}))
+	tree.Insert([]byte("com"), 1)
+	tree.Insert([]byte("example.com"), 2)
+	tree.Insert([]byte("org"), 3)
+
+	_, found := tree.Get([]byte("ample.com"))
+	assert.False(t, found)
+	assert.Equal(t, []TraceStep{
+		{Op: OpGet, Depth: 0, Label: []byte("com"), Rest: []byte("ample.com"),
+			Action: TraceDescend},
+		{Op: OpGet, Depth: 1, Label: []byte(""), Rest: []byte("ample."),
+			Action: TraceReject, Key: []byte("com")},
+		{Op: OpGet, Depth: 1, Label: []byte("example."), Rest: []byte("ample."),
+			Action: TraceSkip},
+	}, steps)
+
+	steps = nil
+	key, value, found := tree.LongestSuffix([]byte("www.example.com"))
+	assert.True(t, found)
+	assert.Equal(t, []byte("example.com"), key)
+	assert.Equal(t, 2, value)
+	actions := []TraceAction{}
+	for _, step := range steps {
+		actions = append(actions, step.Action)
+	}
+	assert.Equal(t, []TraceAction{TraceDescend, TraceMatch, TraceMatch}, actions)
+	assert.Equal(t, "match", TraceMatch.String())
+	assert.Equal(t, "unknown", TraceAction(10).String())
+}
+
+func TestWithTracer_SameResults(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	randKey := func() []byte {
+		key := make([]byte, r.Intn(7))
+		for i := range key {
+			key[i] = "ab."[r.Intn(3)]
+		}
+		return key
+	}
+	for _, opts := range [][]Option{nil, {WithSeparator('.')}} {
+		tree := NewTree(opts...)
+		traced := NewTree(append(opts, WithTracer(func(TraceStep) {}))...)
+		for i := 0; i < 200; i++ {
+			key := randKey()
+			tree.Insert(key, i)
+			traced.Insert(key, i)
+		}
+		for i := 0; i < 1000; i++ {
+			query := randKey()
+			value, found := tree.Get(query)
+			tracedValue, tracedFound := traced.Get(query)
+			assert.Equal(t, found, tracedFound, "%q", query)
+			assert.Equal(t, value, tracedValue, "%q", query)
+			key, value, found := tree.LongestSuffix(query)
+			tracedKey, tracedValue, tracedFound := traced.LongestSuffix(query)
+			assert.Equal(t, found, tracedFound, "%q", query)
+			assert.Equal(t, string(key), string(tracedKey), "%q", query)
+			assert.Equal(t, value, tracedValue, "%q", query)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package suffix
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Validate checks the invariants of the tree's structure, and returns an error describing the
+// first broken one, or nil. It is meant for the tests and fuzzing of the code embedding the
+// tree, since a valid tree never breaks them:
+//
+//   - the edges of a node are sorted by the label length and then the bytes, so there is at
+//     most one empty label, and it comes first
+//   - the non-empty labels of a node don't end with the same byte, as they would share a
+//     common suffix which should have been split out
+//   - each node other than the root has at least two edges, as a single edge should have
+//     been merged into its parent
+//   - only the edges to the leaves have empty labels
+//   - the key of each leaf is the labels on its path, and Len is the number of leaves
+func (tree *Tree) Validate() error {
+	_, err := tree.validate()
+	return err
+}
+
+// validate is Validate, which also returns the node where the invariant is broken.
+func (tree *Tree) validate() (invalid *_Node, err error) {
+	leaves := 0
+	var check func(node *_Node, path []byte, isRoot bool) error
+	check = func(node *_Node, path []byte, isRoot bool) (err error) {
+		defer func() {
+			if err != nil && invalid == nil {
+				invalid = node
+			}
+		}()
+		if !isRoot && len(node.edges) < 2 {
+			return fmt.Errorf("suffix: invalid tree: node at %s has %d edges",
+				quoteKey(path), len(node.edges))
+		}
+		lastBytes := map[byte]bool{}
+		for i, edge := range node.edges {
+			if i > 0 && !labelLess(node.edges[i-1].label, edge.label) {
+				return fmt.Errorf("suffix: invalid tree: edges %s and %s at %s are not sorted",
+					quoteKey(node.edges[i-1].label), quoteKey(edge.label), quoteKey(path))
+			}
+			if len(edge.label) > 0 {
+				c := edge.label[len(edge.label)-1]
+				if lastBytes[c] {
+					return fmt.Errorf(
+						"suffix: invalid tree: edges at %s share the common suffix %s",
+						quoteKey(path), quoteKey([]byte{c}))
+				}
+				lastBytes[c] = true
+			}
+			childPath := make([]byte, 0, len(edge.label)+len(path))
+			childPath = append(append(childPath, edge.label...), path...)
+			switch point := edge.point.(type) {
+			case *_Leaf:
+				leaves++
+				if !bytes.Equal(point.originKey, childPath) {
+					return fmt.Errorf("suffix: invalid tree: leaf at %s has the key %s",
+						quoteKey(childPath), quoteKey(point.originKey))
+				}
+			case *_Node:
+				if len(edge.label) == 0 {
+					return fmt.Errorf("suffix: invalid tree: empty label to a node at %s",
+						quoteKey(path))
+				}
+				if err := check(point, childPath, false); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("suffix: invalid tree: edge %s at %s points to %T",
+					quoteKey(edge.label), quoteKey(path), edge.point)
+			}
+		}
+		return nil
+	}
+	if err := check(tree.root, nil, true); err != nil {
+		return invalid, err
+	}
+	if leaves != tree.leavesNum {
+		return tree.root, fmt.Errorf("suffix: invalid tree: Len is %d, but there are %d leaves",
+			tree.leavesNum, leaves)
+	}
+	return nil, nil
+}
@@ -0,0 +1,69 @@
+package suffix
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newValidateTestTree() *Tree {
+	tree := NewTree()
+	tree.Insert([]byte("com"), nil)
+	tree.Insert([]byte("example.com"), nil)
+	tree.Insert([]byte("a.example.com"), nil)
+	tree.Insert([]byte("org"), nil)
+	return tree
+}
+
+func TestValidate(t *testing.T) {
+	assert.Nil(t, NewTree().Validate())
+	assert.Nil(t, newValidateTestTree().Validate())
+
+	tree := NewTree()
+	r := rand.New(rand.NewSource(1))
+	keys := [][]byte{}
+	for i := 0; i < 1000; i++ {
+		key := make([]byte, r.Intn(6))
+		for j := range key {
+			key[j] = "abc"[r.Intn(3)]
+		}
+		if r.Intn(3) == 0 && len(keys) > 0 {
+			tree.Remove(keys[r.Intn(len(keys))])
+		} else {
+			tree.Insert(key, nil)
+			keys = append(keys, key)
+		}
+		if !assert.Nil(t, tree.Validate()) {
+			return
+		}
+	}
+}
+
+func TestValidate_Broken(t *testing.T) {
+	tree := newValidateTestTree()
+	tree.root.edges[0], tree.root.edges[1] = tree.root.edges[1], tree.root.edges[0]
+	assert.Equal(t, `suffix: invalid tree: edges "org" and "com" at "" are not sorted`,
+		tree.Validate().Error())
+
+	tree = newValidateTestTree()
+	tree.root.edges[1].label = []byte("ocm")
+	assert.Equal(t, `suffix: invalid tree: edges at "" share the common suffix "m"`,
+		tree.Validate().Error())
+
+	tree = newValidateTestTree()
+	node := tree.root.edges[0].point.(*_Node)
+	node.edges = node.edges[1:]
+	assert.Equal(t, `suffix: invalid tree: node at "com" has 1 edges`, tree.Validate().Error())
+
+	tree = newValidateTestTree()
+	node = tree.root.edges[0].point.(*_Node)
+	node.edges[0].point.(*_Leaf).originKey = []byte("org")
+	assert.Equal(t, `suffix: invalid tree: leaf at "com" has the key "org"`,
+		tree.Validate().Error())
+
+	tree = newValidateTestTree()
+	tree.leavesNum++
+	assert.Equal(t, "suffix: invalid tree: Len is 5, but there are 4 leaves",
+		tree.Validate().Error())
+}
@@ -0,0 +1,188 @@
+package suffix
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Tags of the values supported by the binary encodings
+const (
+	valueNil byte = iota
+	valueBool
+	valueInt
+	valueInt8
+	valueInt16
+	valueInt32
+	valueInt64
+	valueUint
+	valueUint8
+	valueUint16
+	valueUint32
+	valueUint64
+	valueFloat32
+	valueFloat64
+	valueString
+	valueBytes
+)
+
+func appendUvarint(buf []byte, x uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], x)
+	return append(buf, tmp[:n]...)
+}
+
+func appendVarint(buf []byte, x int64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(tmp[:], x)
+	return append(buf, tmp[:n]...)
+}
+
+// appendValue encodes value with a leading type tag. Only nil, booleans, numbers, strings
+// and []byte are supported, since they can be decoded without knowing the type in advance.
+func appendValue(buf []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, valueNil), nil
+	case bool:
+		if v {
+			return append(buf, valueBool, 1), nil
+		}
+		return append(buf, valueBool, 0), nil
+	case int:
+		return appendVarint(append(buf, valueInt), int64(v)), nil
+	case int8:
+		return appendVarint(append(buf, valueInt8), int64(v)), nil
+	case int16:
+		return appendVarint(append(buf, valueInt16), int64(v)), nil
+	case int32:
+		return appendVarint(append(buf, valueInt32), int64(v)), nil
+	case int64:
+		return appendVarint(append(buf, valueInt64), v), nil
+	case uint:
+		return appendUvarint(append(buf, valueUint), uint64(v)), nil
+	case uint8:
+		return appendUvarint(append(buf, valueUint8), uint64(v)), nil
+	case uint16:
+		return appendUvarint(append(buf, valueUint16), uint64(v)), nil
+	case uint32:
+		return appendUvarint(append(buf, valueUint32), uint64(v)), nil
+	case uint64:
+		return appendUvarint(append(buf, valueUint64), v), nil
+	case float32:
+		return appendUvarint(append(buf, valueFloat32), uint64(math.Float32bits(v))), nil
+	case float64:
+		return appendUvarint(append(buf, valueFloat64), math.Float64bits(v)), nil
+	case string:
+		buf = appendUvarint(append(buf, valueString), uint64(len(v)))
+		return append(buf, v...), nil
+	case []byte:
+		buf = appendUvarint(append(buf, valueBytes), uint64(len(v)))
+		return append(buf, v...), nil
+	}
+	return buf, fmt.Errorf("suffix: can't encode value of type %T", value)
+}
+
+// decodeBuffer reads the encoded data from the front.
+type decodeBuffer struct {
+	data []byte
+}
+
+func (d *decodeBuffer) byte() (byte, error) {
+	if len(d.data) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b, nil
+}
+
+func (d *decodeBuffer) uvarint() (uint64, error) {
+	x, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	d.data = d.data[n:]
+	return x, nil
+}
+
+func (d *decodeBuffer) varint() (int64, error) {
+	x, n := binary.Varint(d.data)
+	if n <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	d.data = d.data[n:]
+	return x, nil
+}
+
+// bytes returns the next n bytes, which share the memory with the buffer.
+func (d *decodeBuffer) bytes(n uint64) ([]byte, error) {
+	if uint64(len(d.data)) < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := d.data[:n:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+// lenBytes reads the length prefixed bytes.
+func (d *decodeBuffer) lenBytes() ([]byte, error) {
+	n, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	return d.bytes(n)
+}
+
+func (d *decodeBuffer) value() (interface{}, error) {
+	tag, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	switch tag {
+	case valueNil:
+		return nil, nil
+	case valueBool:
+		b, err := d.byte()
+		return b != 0, err
+	case valueInt, valueInt8, valueInt16, valueInt32, valueInt64:
+		x, err := d.varint()
+		switch tag {
+		case valueInt:
+			return int(x), err
+		case valueInt8:
+			return int8(x), err
+		case valueInt16:
+			return int16(x), err
+		case valueInt32:
+			return int32(x), err
+		}
+		return x, err
+	case valueUint, valueUint8, valueUint16, valueUint32, valueUint64:
+		x, err := d.uvarint()
+		switch tag {
+		case valueUint:
+			return uint(x), err
+		case valueUint8:
+			return uint8(x), err
+		case valueUint16:
+			return uint16(x), err
+		case valueUint32:
+			return uint32(x), err
+		}
+		return x, err
+	case valueFloat32:
+		x, err := d.uvarint()
+		return math.Float32frombits(uint32(x)), err
+	case valueFloat64:
+		x, err := d.uvarint()
+		return math.Float64frombits(x), err
+	case valueString:
+		b, err := d.lenBytes()
+		return string(b), err
+	case valueBytes:
+		return d.lenBytes()
+	}
+	return nil, fmt.Errorf("%w: unknown value tag %d", ErrCorrupted, tag)
+}
@@ -0,0 +1,164 @@
+package suffix
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// Each WAL record is an uvarint payload length, the little-endian CRC32 (IEEE) of the
+// payload, and the payload: the op, the key prefixed with its uvarint length, and for
+// insertion the value encoded like MarshalBinary.
+const (
+	walOpInsert byte = 1
+	walOpRemove byte = 2
+)
+
+// WAL wraps a Tree, and appends a record to the log before each mutation is applied.
+// After a crash, the tree can be rebuilt by replaying the log with RecoverWAL.
+type WAL struct {
+	tree *Tree
+	w    io.Writer
+	buf  []byte
+}
+
+// NewWAL returns a WAL appending records to w. The tree can be read directly, but should
+// only be mutated through the WAL.
+func NewWAL(tree *Tree, w io.Writer) *WAL {
+	return &WAL{
+		tree: tree,
+		w:    w,
+	}
+}
+
+// Tree returns the wrapped tree.
+func (wal *WAL) Tree() *Tree {
+	return wal.tree
+}
+
+func (wal *WAL) append(payload []byte) error {
+	var header [binary.MaxVarintLen64 + 4]byte
+	n := binary.PutUvarint(header[:], uint64(len(payload)))
+	binary.LittleEndian.PutUint32(header[n:], crc32.ChecksumIEEE(payload))
+	record := append(header[:n+4], payload...)
+	_, err := wal.w.Write(record)
+	return err
+}
+
+// Insert logs the insertion, and then inserts the key and value into the tree like
+// Tree.TryInsert. The value should be nil, boolean, number, string or []byte.
+// If the tree rejects the key, like a frozen tree, or the record can't be written, nothing is
+// logged and the tree is not changed.
+func (wal *WAL) Insert(key []byte, value interface{}) (oldValue interface{}, err error) {
+	// Don't log the keys which the tree rejects
+	if err := wal.tree.checkKey(key); err != nil {
+		return nil, err
+	}
+	payload := appendUvarint(append(wal.buf[:0], walOpInsert), uint64(len(key)))
+	payload = append(payload, key...)
+	payload, err = appendValue(payload, value)
+	if err != nil {
+		return nil, err
+	}
+	wal.buf = payload
+	if err = wal.append(payload); err != nil {
+		return nil, err
+	}
+	return wal.tree.TryInsert(key, value)
+}
+
+// Remove logs the removal, and then removes the key from the tree like Tree.TryRemove.
+// The keys which can't be inserted are rejected with the error of Insert, instead of being
+// reported as not found. If the record can't be written, the tree is not changed.
+func (wal *WAL) Remove(key []byte) (oldValue interface{}, found bool, err error) {
+	// Don't log the keys which the tree rejects
+	if err := wal.tree.checkKey(key); err != nil {
+		return nil, false, err
+	}
+	payload := appendUvarint(append(wal.buf[:0], walOpRemove), uint64(len(key)))
+	payload = append(payload, key...)
+	wal.buf = payload
+	if err = wal.append(payload); err != nil {
+		return nil, false, err
+	}
+	return wal.tree.TryRemove(key)
+}
+
+// Sync commits the log to stable storage, if the writer supports Sync like *os.File.
+func (wal *WAL) Sync() error {
+	if s, ok := wal.w.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// RecoverWAL replays the records written by WAL into the tree, and returns the number of
+// records replayed. If the log ends in the middle of a record, which happens when the process
+// crashed while writing it, the records before are replayed and io.ErrUnexpectedEOF is
+// returned. Other errors mean the log is corrupted.
+func RecoverWAL(r io.Reader, tree *Tree) (n int, err error) {
+	br := bufio.NewReader(r)
+	var buf bytes.Buffer
+	for {
+		size, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, io.ErrUnexpectedEOF
+		}
+		if size > math.MaxInt32 {
+			return n, fmt.Errorf("%w: WAL record %d has invalid size %d", ErrCorrupted, n, size)
+		}
+		var checksum [4]byte
+		if _, err := io.ReadFull(br, checksum[:]); err != nil {
+			return n, io.ErrUnexpectedEOF
+		}
+		// Grow the buffer with the data actually read, instead of trusting the size
+		buf.Reset()
+		if _, err := io.CopyN(&buf, br, int64(size)); err != nil {
+			return n, io.ErrUnexpectedEOF
+		}
+		// The key is referred by the tree, so each record needs its own memory
+		payload := append([]byte{}, buf.Bytes()...)
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(checksum[:]) {
+			return n, fmt.Errorf("%w: checksum mismatch in WAL record %d", ErrCorrupted, n)
+		}
+		if err := replayWALRecord(payload, tree); err != nil {
+			return n, fmt.Errorf("%w: WAL record %d is invalid: %v", ErrCorrupted, n, err)
+		}
+		n++
+	}
+}
+
+func replayWALRecord(payload []byte, tree *Tree) error {
+	d := decodeBuffer{data: payload}
+	op, err := d.byte()
+	if err != nil {
+		return err
+	}
+	key, err := d.lenBytes()
+	if err != nil {
+		return err
+	}
+	switch op {
+	case walOpInsert:
+		value, err := d.value()
+		if err != nil {
+			return err
+		}
+		tree.Insert(key, value)
+	case walOpRemove:
+		tree.Remove(key)
+	default:
+		return fmt.Errorf("unknown op %d", op)
+	}
+	if len(d.data) != 0 {
+		return fmt.Errorf("%d bytes of trailing data", len(d.data))
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package suffix
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failedWriter struct{}
+
+func (failedWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWAL(t *testing.T) {
+	var log bytes.Buffer
+	wal := NewWAL(NewTree(), &log)
+	lists, _ := getFixtures()
+	for _, s := range lists {
+		_, err := wal.Insert([]byte(s), s)
+		assert.Nil(t, err)
+	}
+	oldValue, err := wal.Insert([]byte("table"), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "table", oldValue)
+	oldValue, found, err := wal.Remove([]byte("edible"))
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "edible", oldValue)
+	_, found, err = wal.Remove([]byte("nonexist"))
+	assert.Nil(t, err)
+	assert.False(t, found)
+	_, err = wal.Insert([]byte{}, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, wal.Sync())
+
+	tree := NewTree()
+	n, err := RecoverWAL(bytes.NewReader(log.Bytes()), tree)
+	assert.Nil(t, err)
+	assert.Equal(t, len(lists)+4, n)
+	assertSameContent(t, wal.Tree(), tree)
+}
+
+func TestWAL_Invalid(t *testing.T) {
+	wal := NewWAL(NewTree(), &bytes.Buffer{})
+	_, err := wal.Insert(nil, nil)
+	assert.NotNil(t, err)
+	_, found, err := wal.Remove(nil)
+	assert.Equal(t, ErrNilKey, err)
+	assert.False(t, found)
+	_, err = wal.Insert([]byte("sth"), struct{}{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, wal.Tree().Len())
+
+	wal = NewWAL(NewTree(), failedWriter{})
+	_, err = wal.Insert([]byte("sth"), "sth")
+	assert.EqualError(t, err, "disk full")
+	assert.Equal(t, 0, wal.Tree().Len())
+	wal.tree.Insert([]byte("sth"), "sth")
+	_, _, err = wal.Remove([]byte("sth"))
+	assert.EqualError(t, err, "disk full")
+	assert.Equal(t, 1, wal.Tree().Len())
+
+	// The keys rejected by the tree are not logged
+	var log bytes.Buffer
+	wal = NewWAL(NewTree(WithValidateUTF8()), &log)
+	_, err = wal.Insert([]byte("\xff"), nil)
+	assert.EqualError(t, err, `suffix: key "\xff" is not valid UTF-8`)
+	_, _, err = wal.Remove([]byte("\xff"))
+	assert.EqualError(t, err, `suffix: key "\xff" is not valid UTF-8`)
+	assert.Equal(t, 0, log.Len())
+
+	// Nor are the mutations of a frozen tree
+	tree := NewTree()
+	tree.Insert([]byte("sth"), "sth")
+	tree.Freeze()
+	wal = NewWAL(tree, &log)
+	_, err = wal.Insert([]byte("else"), "else")
+	assert.Equal(t, ErrReadOnly, err)
+	_, _, err = wal.Remove([]byte("sth"))
+	assert.Equal(t, ErrReadOnly, err)
+	assert.Equal(t, 0, log.Len())
+	assert.Equal(t, 1, tree.Len())
+}
+
+func TestRecoverWAL_Broken(t *testing.T) {
+	var log bytes.Buffer
+	wal := NewWAL(NewTree(), &log)
+	wal.Insert([]byte("sth"), "sth")
+	size := log.Len()
+	wal.Insert([]byte("else"), "else")
+	data := log.Bytes()
+
+	// Crashed in the middle of the second record
+	for i := size + 1; i < len(data); i++ {
+		tree := NewTree()
+		n, err := RecoverWAL(bytes.NewReader(data[:i]), tree)
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, 1, tree.Len())
+	}
+
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)-1]++
+	n, err := RecoverWAL(bytes.NewReader(corrupted), NewTree())
+	assert.EqualError(t, err, "suffix: corrupted data: checksum mismatch in WAL record 1")
+	assert.Equal(t, 1, n)
+
+	// Valid checksum with an unknown op
+	var invalid bytes.Buffer
+	wal = NewWAL(NewTree(), &invalid)
+	wal.append([]byte{3, 0})
+	_, err = RecoverWAL(&invalid, NewTree())
+	assert.EqualError(t, err, "suffix: corrupted data: WAL record 0 is invalid: unknown op 3")
+}
@@ -0,0 +1,161 @@
+package suffix
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Aggregation tells how WeightedTree.Score combines the weights of the matched keys.
+type Aggregation int
+
+const (
+	// SumWeights adds up the weights.
+	SumWeights Aggregation = iota
+	// MaxWeight takes the highest weight.
+	MaxWeight
+)
+
+// weighted is the value kept by WeightedTree.
+type weighted struct {
+	value  interface{}
+	weight float64
+}
+
+// WeightedTree is a suffix tree whose keys carry numeric weights besides their values, so a
+// query can be scored by all the keys it ends with in one pass. For example, a reputation
+// system can weight "com", "example.com" and "www.example.com" separately, and score a host
+// name by combining them.
+type WeightedTree struct {
+	tree *Tree
+}
+
+// NewWeightedTree creates a WeightedTree for future usage.
+func NewWeightedTree(opts ...Option) *WeightedTree {
+	return &WeightedTree{tree: NewTree(opts...)}
+}
+
+// Insert inserts the key with its value and weight, replacing those of the same key.
+func (tree *WeightedTree) Insert(key []byte, value interface{}, weight float64) (
+	oldValue interface{}, ok bool) {
+
+	old, ok := tree.tree.Insert(key, &weighted{value: value, weight: weight})
+	if !ok {
+		return nil, false
+	}
+	if old != nil {
+		return old.(*weighted).value, true
+	}
+	return nil, true
+}
+
+// Get returns the value and weight of key.
+func (tree *WeightedTree) Get(key []byte) (value interface{}, weight float64, found bool) {
+	v, found := tree.tree.Get(key)
+	if !found {
+		return nil, 0, false
+	}
+	w := v.(*weighted)
+	return w.value, w.weight, true
+}
+
+// Remove removes key, and returns its value.
+func (tree *WeightedTree) Remove(key []byte) (oldValue interface{}, found bool) {
+	v, found := tree.tree.Remove(key)
+	if !found {
+		return nil, false
+	}
+	return v.(*weighted).value, true
+}
+
+// Len returns the number of keys in the tree.
+func (tree *WeightedTree) Len() int {
+	return tree.tree.Len()
+}
+
+// Walk is like Tree.Walk, with the weight of each key.
+func (tree *WeightedTree) Walk(f func(key []byte, value interface{}, weight float64) (
+	stop bool)) {
+
+	tree.tree.Walk(func(key []byte, v interface{}) bool {
+		w := v.(*weighted)
+		return f(key, w.value, w.weight)
+	})
+}
+
+// Score combines the weights of all keys which are suffixes of the query with agg. matched is
+// the number of such keys, and the score is 0 if there is none.
+func (tree *WeightedTree) Score(query []byte, agg Aggregation) (score float64, matched int) {
+	tree.tree.AllSuffixesOf(query, func(key []byte, v interface{}) bool {
+		weight := v.(*weighted).weight
+		switch {
+		case agg == MaxWeight && matched > 0:
+			if weight > score {
+				score = weight
+			}
+		case agg == MaxWeight:
+			score = weight
+		default:
+			score += weight
+		}
+		matched++
+		return false
+	})
+	return score, matched
+}
+
+// BestSuffix returns the key with the highest weight among the keys which are suffixes of the
+// query, which may not be the longest one returned by LongestSuffix, like the rule with the
+// highest priority. The longer key wins if two of them have the same weight.
+func (tree *WeightedTree) BestSuffix(query []byte) (key []byte, value interface{},
+	weight float64, found bool) {
+
+	tree.tree.AllSuffixesOf(query, func(k []byte, v interface{}) bool {
+		w := v.(*weighted)
+		if !found || w.weight > weight {
+			key, value, weight, found = k, w.value, w.weight, true
+		}
+		return false
+	})
+	return key, value, weight, found
+}
+
+// Suggest returns the keys ending with tail, from the highest weight to the lowest, for
+// completing the end of a host name as it is typed. Keys with the same weight are sorted by
+// their bytes. At most limit keys are returned, or all of them if limit is not positive. Like
+// WalkSuffix, the tail must start at a separator in the separator mode.
+func (tree *WeightedTree) Suggest(tail []byte, limit int) [][]byte {
+	var keys [][]byte
+	var weights []float64
+	tree.tree.WalkSuffix(tail, func(key []byte, v interface{}) bool {
+		keys = append(keys, key)
+		weights = append(weights, v.(*weighted).weight)
+		return false
+	})
+	sort.Sort(byWeight{keys, weights})
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
+
+// byWeight sorts keys by their weights in descending order.
+type byWeight struct {
+	keys    [][]byte
+	weights []float64
+}
+
+func (s byWeight) Len() int {
+	return len(s.keys)
+}
+
+func (s byWeight) Less(i, j int) bool {
+	if s.weights[i] != s.weights[j] {
+		return s.weights[i] > s.weights[j]
+	}
+	return bytes.Compare(s.keys[i], s.keys[j]) < 0
+}
+
+func (s byWeight) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.weights[i], s.weights[j] = s.weights[j], s.weights[i]
+}
@@ -0,0 +1,99 @@
+package suffix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightedTree(t *testing.T) {
+	tree := NewWeightedTree(WithSeparator('.'))
+	_, ok := tree.Insert([]byte("com"), "tld", 1)
+	assert.True(t, ok)
+	tree.Insert([]byte("example.com"), "domain", -3)
+	tree.Insert([]byte("www.example.com"), "host", 0.5)
+	old, ok := tree.Insert([]byte("example.com"), "domain", -2)
+	assert.True(t, ok)
+	assert.Equal(t, "domain", old)
+	_, ok = tree.Insert(nil, "nil", 1)
+	assert.False(t, ok)
+
+	value, weight, found := tree.Get([]byte("example.com"))
+	assert.True(t, found)
+	assert.Equal(t, "domain", value)
+	assert.Equal(t, -2.0, weight)
+	_, _, found = tree.Get([]byte("ample.com"))
+	assert.False(t, found)
+	assert.Equal(t, 3, tree.Len())
+
+	score, matched := tree.Score([]byte("www.example.com"), SumWeights)
+	assert.Equal(t, -0.5, score)
+	assert.Equal(t, 3, matched)
+	score, matched = tree.Score([]byte("api.example.com"), MaxWeight)
+	assert.Equal(t, 1.0, score)
+	assert.Equal(t, 2, matched)
+	score, matched = tree.Score([]byte("example.org"), MaxWeight)
+	assert.Equal(t, 0.0, score)
+	assert.Equal(t, 0, matched)
+
+	tree.Remove([]byte("com"))
+	score, _ = tree.Score([]byte("api.example.com"), MaxWeight)
+	assert.Equal(t, -2.0, score)
+
+	n := 0
+	tree.Walk(func(key []byte, value interface{}, weight float64) bool {
+		n++
+		return false
+	})
+	assert.Equal(t, 2, n)
+}
+
+func TestWeightedTree_Suggest(t *testing.T) {
+	tree := NewWeightedTree()
+	tree.Insert([]byte("mail.example.com"), nil, 2)
+	tree.Insert([]byte("www.example.com"), nil, 5)
+	tree.Insert([]byte("api.example.com"), nil, 2)
+	tree.Insert([]byte("sample.com"), nil, 9)
+	tree.Insert([]byte("example.org"), nil, 7)
+
+	toStrings := func(keys [][]byte) []string {
+		var s []string
+		for _, key := range keys {
+			s = append(s, string(key))
+		}
+		return s
+	}
+	assert.Equal(t, []string{"sample.com", "www.example.com", "api.example.com",
+		"mail.example.com"}, toStrings(tree.Suggest([]byte("ample.com"), 0)))
+	assert.Equal(t, []string{"www.example.com", "api.example.com"},
+		toStrings(tree.Suggest([]byte("example.com"), 2)))
+	assert.Equal(t, 5, len(tree.Suggest(nil, -1)))
+	assert.Empty(t, tree.Suggest([]byte("net"), 3))
+
+	tree = NewWeightedTree(WithSeparator('.'))
+	tree.Insert([]byte("www.example.com"), nil, 1)
+	tree.Insert([]byte("sample.com"), nil, 2)
+	assert.Equal(t, []string{"www.example.com"},
+		toStrings(tree.Suggest([]byte("example.com"), 10)))
+}
+
+func TestWeightedTree_BestSuffix(t *testing.T) {
+	tree := NewWeightedTree(WithSeparator('.'))
+	tree.Insert([]byte("com"), "tld", 1)
+	tree.Insert([]byte("example.com"), "domain", 3)
+	tree.Insert([]byte("www.example.com"), "host", 2)
+	tree.Insert([]byte("api.example.com"), "api", 3)
+
+	key, value, weight, found := tree.BestSuffix([]byte("www.example.com"))
+	assert.True(t, found)
+	assert.Equal(t, "example.com", string(key))
+	assert.Equal(t, "domain", value)
+	assert.Equal(t, 3.0, weight)
+	// The same weight as "example.com", but longer
+	key, _, _, _ = tree.BestSuffix([]byte("api.example.com"))
+	assert.Equal(t, "api.example.com", string(key))
+	key, _, _, _ = tree.BestSuffix([]byte("ample.com"))
+	assert.Equal(t, "com", string(key))
+	_, _, _, found = tree.BestSuffix([]byte("example.org"))
+	assert.False(t, found)
+}
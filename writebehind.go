@@ -0,0 +1,212 @@
+package suffix
+
+import (
+	"io"
+	"sync"
+)
+
+// Apply up to this number of queued mutations each time the write lock is held, so readers
+// are not starved by a busy writer.
+const writeBehindBatchSize = 128
+
+type writeOpKind int
+
+const (
+	writeOpInsert writeOpKind = iota
+	writeOpRemove
+	writeOpCompareAndSwap
+	writeOpCompareAndDelete
+	writeOpFlush
+)
+
+type writeOp struct {
+	kind     writeOpKind
+	key      []byte
+	value    interface{}
+	oldValue interface{}
+	// Set for the operations waiting for the result
+	result chan writeResult
+}
+
+// writeResult is the result of a queued operation. A panic of the operation is passed to the
+// goroutine waiting for it, instead of crashing the background goroutine.
+type writeResult struct {
+	ok       bool
+	panicked interface{}
+}
+
+// wait returns the result of op, or panics like op in the calling goroutine.
+func (op writeOp) wait() bool {
+	res := <-op.result
+	if res.panicked != nil {
+		panic(res.panicked)
+	}
+	return res.ok
+}
+
+// WriteBehind wraps a Tree so that Insert and Remove are queued and applied by a background
+// goroutine. The mutations are visible to the readers after Flush returns.
+// It is safe for concurrent use.
+type WriteBehind struct {
+	mu   sync.RWMutex
+	tree *Tree
+	ops  chan writeOp
+	done chan struct{}
+}
+
+// NewWriteBehind starts a background goroutine applying mutations to tree. Insert and Remove
+// block once queueSize mutations are waiting. The tree should not be used directly
+// until the WriteBehind is closed.
+func NewWriteBehind(tree *Tree, queueSize int) *WriteBehind {
+	wb := &WriteBehind{
+		tree: tree,
+		ops:  make(chan writeOp, queueSize),
+		done: make(chan struct{}),
+	}
+	go wb.apply()
+	return wb
+}
+
+func (wb *WriteBehind) apply() {
+	defer close(wb.done)
+	for op := range wb.ops {
+		wb.mu.Lock()
+		wb.applyOp(op)
+		// Drain the queue while we are holding the lock
+	batch:
+		for i := 1; i < writeBehindBatchSize; i++ {
+			select {
+			case op, ok := <-wb.ops:
+				if !ok {
+					break batch
+				}
+				wb.applyOp(op)
+			default:
+				break batch
+			}
+		}
+		wb.mu.Unlock()
+	}
+}
+
+func (wb *WriteBehind) applyOp(op writeOp) {
+	switch op.kind {
+	case writeOpInsert:
+		wb.tree.Insert(op.key, op.value)
+	case writeOpRemove:
+		wb.tree.Remove(op.key)
+	case writeOpCompareAndSwap:
+		op.reply(func() bool { return wb.tree.CompareAndSwap(op.key, op.oldValue, op.value) })
+	case writeOpCompareAndDelete:
+		op.reply(func() bool { return wb.tree.CompareAndDelete(op.key, op.oldValue) })
+	case writeOpFlush:
+		op.result <- writeResult{ok: true}
+	}
+}
+
+// reply sends the result of f to the goroutine waiting for op. The comparisons panic on the
+// values which are not comparable, so the panic is recovered, and raised in that goroutine.
+func (op writeOp) reply(f func() bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			op.result <- writeResult{panicked: r}
+		}
+	}()
+	op.result <- writeResult{ok: f()}
+}
+
+// Insert queues the insertion of key and value. It returns false if the tree rejects the key,
+// or is frozen.
+// The tree keeps a reference to key, so don't modify it after insertion.
+func (wb *WriteBehind) Insert(key []byte, value interface{}) bool {
+	if wb.tree.checkKey(key) != nil {
+		return false
+	}
+	wb.ops <- writeOp{kind: writeOpInsert, key: key, value: value}
+	return true
+}
+
+// Remove queues the removal of key.
+func (wb *WriteBehind) Remove(key []byte) {
+	wb.ops <- writeOp{kind: writeOpRemove, key: key}
+}
+
+// CompareAndSwap is like Tree.CompareAndSwap. It is applied after the mutations queued before
+// it, and waits for the result. It panics in the calling goroutine if the current value is not
+// comparable.
+func (wb *WriteBehind) CompareAndSwap(key []byte, oldValue, newValue interface{}) (swapped bool) {
+	op := writeOp{kind: writeOpCompareAndSwap, key: key, value: newValue,
+		oldValue: oldValue, result: make(chan writeResult, 1)}
+	wb.ops <- op
+	return op.wait()
+}
+
+// CompareAndDelete is like Tree.CompareAndDelete. It is applied after the mutations queued
+// before it, and waits for the result. It panics in the calling goroutine if the current value
+// is not comparable.
+func (wb *WriteBehind) CompareAndDelete(key []byte, oldValue interface{}) (deleted bool) {
+	op := writeOp{kind: writeOpCompareAndDelete, key: key, oldValue: oldValue,
+		result: make(chan writeResult, 1)}
+	wb.ops <- op
+	return op.wait()
+}
+
+// Flush waits until all mutations queued before it are applied.
+func (wb *WriteBehind) Flush() {
+	op := writeOp{kind: writeOpFlush, result: make(chan writeResult, 1)}
+	wb.ops <- op
+	op.wait()
+}
+
+// Close applies all queued mutations and stops the background goroutine. The WriteBehind
+// can still be read after Close, but Insert, Remove, Flush and
+// the compare-and-swap operations will panic.
+func (wb *WriteBehind) Close() {
+	close(wb.ops)
+	<-wb.done
+}
+
+// Get is like Tree.Get.
+func (wb *WriteBehind) Get(key []byte) (value interface{}, found bool) {
+	wb.mu.RLock()
+	defer wb.mu.RUnlock()
+	return wb.tree.Get(key)
+}
+
+// LongestSuffix is like Tree.LongestSuffix.
+func (wb *WriteBehind) LongestSuffix(key []byte) (matchedKey []byte, value interface{}, found bool) {
+	wb.mu.RLock()
+	defer wb.mu.RUnlock()
+	return wb.tree.LongestSuffix(key)
+}
+
+// HasSequence is like Tree.HasSequence.
+func (wb *WriteBehind) HasSequence(key []byte) bool {
+	wb.mu.RLock()
+	defer wb.mu.RUnlock()
+	return wb.tree.HasSequence(key)
+}
+
+// Len is like Tree.Len.
+func (wb *WriteBehind) Len() int {
+	wb.mu.RLock()
+	defer wb.mu.RUnlock()
+	return wb.tree.Len()
+}
+
+// Walk is like Tree.Walk. Mutations are not applied during walking, so don't call Flush in f.
+func (wb *WriteBehind) Walk(f func(key []byte, value interface{}) (stop bool)) {
+	wb.mu.RLock()
+	defer wb.mu.RUnlock()
+	wb.tree.Walk(f)
+}
+
+// SnapshotTo writes a point-in-time image of the tree in the format of Tree.WriteTo. It
+// contains the mutations applied before it, so call Flush first to include the queued ones.
+// Mutations are held back only while the snapshot is taken, not while the image is written.
+func (wb *WriteBehind) SnapshotTo(w io.Writer) (n int64, err error) {
+	wb.mu.Lock()
+	snapshot := wb.tree.Snapshot()
+	wb.mu.Unlock()
+	return snapshot.WriteTo(w)
+}
@@ -0,0 +1,142 @@
+package suffix
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteBehind(t *testing.T) {
+	wb := NewWriteBehind(NewTree(), 4)
+	assert.True(t, wb.Insert([]byte("sth"), "sth"))
+	assert.True(t, wb.Insert([]byte("else"), "else"))
+	assert.False(t, wb.Insert(nil, "any"))
+	wb.Remove([]byte("else"))
+	wb.Flush()
+
+	value, found := wb.Get([]byte("sth"))
+	assert.True(t, found)
+	assert.Equal(t, "sth", value.(string))
+	_, found = wb.Get([]byte("else"))
+	assert.False(t, found)
+	key, _, found := wb.LongestSuffix([]byte("any sth"))
+	assert.True(t, found)
+	assert.Equal(t, "sth", string(key))
+	assert.True(t, wb.HasSequence([]byte("t")))
+	assert.Equal(t, 1, wb.Len())
+
+	wb.Insert([]byte("else"), "else")
+	wb.Close()
+	count := 0
+	wb.Walk(func(key []byte, value interface{}) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, 2, count)
+}
+
+func TestWriteBehind_CompareAndSwap(t *testing.T) {
+	wb := NewWriteBehind(NewTree(), 4)
+	defer wb.Close()
+	wb.Insert([]byte("sth"), "sth")
+	// Applied after the queued insertion
+	assert.True(t, wb.CompareAndSwap([]byte("sth"), "sth", "else"))
+	assert.False(t, wb.CompareAndSwap([]byte("sth"), "sth", "any"))
+	assert.False(t, wb.CompareAndSwap(nil, nil, "any"))
+	value, _ := wb.Get([]byte("sth"))
+	assert.Equal(t, "else", value.(string))
+
+	assert.False(t, wb.CompareAndDelete([]byte("sth"), "sth"))
+	assert.False(t, wb.CompareAndDelete(nil, nil))
+	assert.True(t, wb.CompareAndDelete([]byte("sth"), "else"))
+	assert.Equal(t, 0, wb.Len())
+}
+
+func TestWriteBehind_Frozen(t *testing.T) {
+	tree := NewTree()
+	tree.Insert([]byte("sth"), "sth")
+	tree.Freeze()
+	wb := NewWriteBehind(tree, 4)
+	defer wb.Close()
+	assert.False(t, wb.Insert([]byte("else"), "else"))
+	wb.Flush()
+	assert.Equal(t, 1, wb.Len())
+}
+
+func TestWriteBehind_CompareAndSwapPanics(t *testing.T) {
+	wb := NewWriteBehind(NewTree(), 4)
+	defer wb.Close()
+	wb.Insert([]byte("sth"), []int{1})
+	// The values are not comparable, the panic is raised in the caller
+	assert.Panics(t, func() {
+		wb.CompareAndSwap([]byte("sth"), []int{1}, []int{2})
+	})
+	assert.Panics(t, func() {
+		wb.CompareAndDelete([]byte("sth"), []int{1})
+	})
+
+	// The background goroutine keeps working
+	wb.Insert([]byte("else"), "else")
+	wb.Flush()
+	assert.Equal(t, 2, wb.Len())
+}
+
+func TestWriteBehind_Concurrent(t *testing.T) {
+	wb := NewWriteBehind(NewTree(), 16)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				wb.Insert([]byte(strconv.Itoa(i*100+j)), j)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				wb.Get([]byte(strconv.Itoa(j)))
+				wb.HasSequence([]byte("9"))
+			}
+		}()
+	}
+	wg.Wait()
+	wb.Flush()
+	assert.Equal(t, 400, wb.Len())
+	wb.Close()
+}
+
+func TestWriteBehind_SnapshotTo(t *testing.T) {
+	wb := NewWriteBehind(NewTree(), 4)
+	defer wb.Close()
+	for i := 0; i < 100; i++ {
+		wb.Insert([]byte(strconv.Itoa(i)), i)
+	}
+	wb.Flush()
+
+	var buf bytes.Buffer
+	// Keep mutating the tree while the image is written
+	for i := 0; i < 100; i++ {
+		wb.Remove([]byte(strconv.Itoa(i)))
+		if i == 0 {
+			wb.Flush()
+			_, err := wb.SnapshotTo(&buf)
+			assert.Nil(t, err)
+		}
+	}
+	wb.Flush()
+	assert.Equal(t, 0, wb.Len())
+
+	image := NewTree()
+	_, err := image.ReadFrom(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 99, image.Len())
+	_, found := image.Get([]byte("0"))
+	assert.False(t, found)
+	value, found := image.Get([]byte("99"))
+	assert.True(t, found)
+	assert.Equal(t, 99, value)
+}